@@ -0,0 +1,106 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+)
+
+// AccessibilityOptions add the document language and title to the generated PDF, so screen readers read it in the right
+// language and show the title instead of the file name. Only /Lang, the title and DisplayDocTitle are added.
+// wkhtmltopdf does not write structure tags and none are added, there is no /MarkInfo or /StructTreeRoot, so the PDF
+// is not a tagged PDF and does not pass PDF/UA or WCAG checks which need tags. Tag the PDF with another tool when that
+// is needed. The outline which wkhtmltopdf creates from the headings of the pages is kept for navigation.
+// Information which can not be added is reported in the warnings of the PDFGenerator instead of failing the PDF.
+type AccessibilityOptions struct {
+	Enable bool   // Add the language and title to the PDF
+	Lang   string // Language of the document, e.g. en-US. Default the lang attribute of the html element of the first PageReader
+	Title  string // Title of the document. Default the title wkhtmltopdf sets from the Title option or the first page
+}
+
+// htmlLangRegexp finds the lang attribute of the html element
+var htmlLangRegexp = regexp.MustCompile(`(?is)<html\b[^>]*?\slang\s*=\s*["']?([A-Za-z0-9-]+)`)
+
+// langRegexp matches a language tag which can be written in a PDF
+var langRegexp = regexp.MustCompile(`^[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// documentLang returns the language of the accessibility options, or of the html element of the first PageReader
+// which is read into memory to find it
+func (pdfg *PDFGenerator) documentLang() (string, error) {
+	if pdfg.Accessibility.Lang != "" {
+		return pdfg.Accessibility.Lang, nil
+	}
+	for _, p := range pdfg.pages {
+		p, ok := p.(*PageReader)
+		if !ok || p.Input == nil {
+			continue
+		}
+		html, err := ioutil.ReadAll(p.Input)
+		if err != nil {
+			return "", err
+		}
+		p.Input = bytes.NewReader(html)
+		if m := htmlLangRegexp.FindSubmatch(html); m != nil {
+			return string(m[1]), nil
+		}
+		break
+	}
+	return "", nil
+}
+
+// addAccessibility adds the language, the title and the setting to display the title to the PDF with an incremental update,
+// the warnings are the information which could not be added
+func addAccessibility(pdf []byte, lang, title string) ([]byte, []string) {
+	var warnings []string
+	if lang == "" {
+		warnings = append(warnings, "accessibility: the document has no language, set AccessibilityOptions.Lang or the lang attribute of the html element")
+	} else if !langRegexp.MatchString(lang) {
+		warnings = append(warnings, fmt.Sprintf("accessibility: %q is not a language tag", lang))
+		lang = ""
+	}
+	updated, err := updatePDFInfo(pdf, lang, title)
+	if err != nil {
+		return pdf, append(warnings, "accessibility: the language and title can not be added: "+err.Error())
+	}
+	return updated, warnings
+}
+
+// updatePDFInfo sets Lang and ViewerPreferences/DisplayDocTitle in the catalog and the title in the document information
+// of the PDF with an incremental update
+func updatePDFInfo(pdf []byte, lang, title string) ([]byte, error) {
+	u, err := newPDFUpdate(pdf)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := u.catalog()
+	if err != nil {
+		return nil, err
+	}
+	if lang != "" {
+		catalog.set("/Lang", pdfString(lang))
+	}
+	prefs := &pdfDict{}
+	if v := catalog.get("/ViewerPreferences"); len(v) > 2 && v[:2] == "<<" {
+		prefs, _ = parsePDFDict([]byte(v), 0)
+	}
+	prefs.set("/DisplayDocTitle", "true")
+	catalog.set("/ViewerPreferences", prefs.String())
+	u.set(u.root, catalog, nil)
+
+	if title != "" {
+		info := &pdfDict{}
+		ref, err := parsePDFRef(u.trailer.get("/Info"))
+		if err == nil {
+			info, err = u.object(ref)
+		}
+		if err != nil {
+			info = &pdfDict{}
+			ref = u.add(info, nil)
+			u.trailer.set("/Info", ref.String())
+		}
+		info.set("/Title", pdfString(title))
+		u.set(ref, info, nil)
+	}
+	return u.bytes(), nil
+}
@@ -0,0 +1,129 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testPDF returns a PDF with a cross-reference table like the ones of wkhtmltopdf
+func testPDF() []byte {
+	objects := []string{
+		"<<\n/Title (Report \\(draft\\))\n/Creator (wkhtmltopdf 0.12.6)\n>>",
+		"<<\n/Type /Catalog\n/Pages 3 0 R\n/Outlines 4 0 R\n/PageMode /UseOutlines\n>>",
+		"<<\n/Type /Pages\n/Kids [5 0 R]\n/Count 1\n>>",
+		"<<\n/Type /Outlines\n/Count 0\n>>",
+		"<<\n/Type /Page\n/Parent 3 0 R\n/MediaBox [0 0 595 842]\n>>",
+	}
+	buf := bytes.NewBufferString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(buf, "trailer\n<<\n/Size %d\n/Info 1 0 R\n/Root 2 0 R\n>>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// testPDFObject returns the dictionary of object num in the last revision of the PDF
+func testPDFObject(t *testing.T, pdf []byte, num int) *pdfDict {
+	start := bytes.LastIndex(pdf, []byte("startxref"))
+	xref, _ := strconv.Atoi(string(bytes.Fields(pdf[start+len("startxref"):])[0]))
+	d, err := readPDFObject(pdf, xref, pdfRef{num: num})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestAddAccessibility(t *testing.T) {
+	pdf := testPDF()
+	updated, warnings := addAccessibility(pdf, "de-DE", "")
+	if len(warnings) > 0 {
+		t.Fatalf("Want no warnings, have %q", warnings)
+	}
+	if !bytes.HasPrefix(updated, pdf) {
+		t.Error("Want an incremental update which keeps the PDF")
+	}
+	catalog := testPDFObject(t, updated, 2)
+	if catalog.get("/Lang") != "(de-DE)" || catalog.get("/Pages") != "3 0 R" || catalog.get("/PageMode") != "/UseOutlines" {
+		t.Errorf("Want catalog with language and the old entries, have %s", catalog)
+	}
+	if !strings.Contains(catalog.get("/ViewerPreferences"), "/DisplayDocTitle true") {
+		t.Errorf("Want DisplayDocTitle, have %s", catalog.get("/ViewerPreferences"))
+	}
+	if title := testPDFObject(t, updated, 1).get("/Title"); title != `(Report \(draft\))` {
+		t.Errorf("Want title of wkhtmltopdf kept, have %s", title)
+	}
+
+	// a second update finds the objects through the previous cross-reference table
+	updated, warnings = addAccessibility(updated, "de-DE", "Bericht über 2026")
+	if len(warnings) > 0 {
+		t.Fatalf("Want no warnings, have %q", warnings)
+	}
+	info := testPDFObject(t, updated, 1)
+	if info.get("/Title") != "<FEFF0042006500720069006300680074002000FC00620065007200200032003000320036>" {
+		t.Errorf("Want UTF-16 title, have %s", info.get("/Title"))
+	}
+	if info.get("/Creator") != "(wkhtmltopdf 0.12.6)" {
+		t.Errorf("Want creator kept, have %s", info)
+	}
+	if pages := testPDFObject(t, updated, 3); pages.get("/Count") != "1" {
+		t.Errorf("Want pages from the first revision, have %s", pages)
+	}
+
+	// no language and a PDF which can not be updated are reported
+	_, warnings = addAccessibility(pdf, "", "")
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "no language") {
+		t.Errorf("Want warning for the missing language, have %q", warnings)
+	}
+	stream := []byte("%PDF-1.5\n1 0 obj\n<< /Type /XRef >>\nstream\nendstream\nendobj\nstartxref\n9\n%%EOF\n")
+	have, warnings := addAccessibility(stream, "en", "")
+	if !bytes.Equal(have, stream) || len(warnings) != 1 || !strings.Contains(warnings[0], "cross-reference streams") {
+		t.Errorf("Want PDF unchanged with a warning, have %q", warnings)
+	}
+}
+
+func TestPDFGeneratorAccessibility(t *testing.T) {
+	pdf, err := ioutil.TempFile("", "wkhtmltopdf-accessibility")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(pdf.Name())
+	pdf.Write(testPDF())
+	pdf.Close()
+	bin, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\ncat > /dev/null\ncat " + pdf.Name() + "\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin.Name()
+	pdfg.Accessibility = AccessibilityOptions{Enable: true, Title: "Annual report"}
+	pdfg.AddPage(NewPageReader(strings.NewReader(`<!DOCTYPE html><html class="report" lang="en-GB"><title>Report</title></html>`)))
+	if err := pdfg.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if len(pdfg.Warnings()) > 0 {
+		t.Errorf("Want no warnings, have %q", pdfg.Warnings())
+	}
+	if lang := testPDFObject(t, pdfg.Bytes(), 2).get("/Lang"); lang != "(en-GB)" {
+		t.Errorf("Want language of the html element, have %s", lang)
+	}
+	if title := testPDFObject(t, pdfg.Bytes(), 1).get("/Title"); title != "(Annual report)" {
+		t.Errorf("Want title, have %s", title)
+	}
+}
@@ -0,0 +1,67 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Attachment is a file that is embedded in the generated PDF using qpdf
+type Attachment struct {
+	Filename    string // Name of the attachment in the PDF, defaults to the base name of Path
+	Path        string // Path of the file to attach
+	Data        []byte // Content of the attachment, used when Path is empty
+	MimeType    string // Mime type of the attachment, e.g. text/csv
+	Description string // Description of the attachment
+}
+
+// AddAttachment adds a file to embed in the PDF document when it is created.
+// Note that attachments are not allowed in PDF/A-2b, so a PDF with attachments is not compliant.
+func (pdfg *PDFGenerator) AddAttachment(a Attachment) {
+	pdfg.attachments = append(pdfg.attachments, a)
+}
+
+// ResetAttachments drops all attachments previously added by AddAttachment
+func (pdfg *PDFGenerator) ResetAttachments() {
+	pdfg.attachments = nil
+}
+
+// attach embeds all attachments in the PDF
+func (pdfg *PDFGenerator) attach(ctx context.Context, pdf []byte) ([]byte, error) {
+	dir, err := ioutil.TempDir("", tempPrefix(ctx, "wkhtmltopdf"))
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var args []string
+	for i, a := range pdfg.attachments {
+		path := a.Path
+		filename := a.Filename
+		if path == "" {
+			if filename == "" {
+				return nil, errors.New("attachment " + strconv.Itoa(i) + " has no Filename or Path")
+			}
+			path = filepath.Join(dir, strconv.Itoa(i))
+			err = ioutil.WriteFile(path, a.Data, 0600)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if filename == "" {
+			filename = filepath.Base(path)
+		}
+		args = append(args, "--add-attachment", path, "--key="+filename, "--filename="+filename)
+		if a.MimeType != "" {
+			args = append(args, "--mimetype="+a.MimeType)
+		}
+		if a.Description != "" {
+			args = append(args, "--description="+a.Description)
+		}
+		args = append(args, "--")
+	}
+	return runQPDF(ctx, pdf, args...)
+}
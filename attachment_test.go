@@ -0,0 +1,40 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestAddAttachment(t *testing.T) {
+	pdfg := newTestPostProcessPDFGenerator(t)
+	pdfg.AddAttachment(Attachment{Path: "./testfiles/htmlsimple.html", MimeType: "text/html"})
+	pdfg.AddAttachment(Attachment{
+		Filename:    "data.csv",
+		Data:        []byte("id,name\n1,foo\n"),
+		MimeType:    "text/csv",
+		Description: "Source data",
+	})
+
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(pdfg.Bytes(), []byte("/EmbeddedFiles")) {
+		t.Error("PDF has no embedded files")
+	}
+
+	pdfg.ResetAttachments()
+	if len(pdfg.attachments) != 0 {
+		t.Errorf("Want 0 attachments, have %d", len(pdfg.attachments))
+	}
+}
+
+func TestAttachmentWithoutName(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.AddAttachment(Attachment{Data: []byte("foo")})
+	_, err := pdfg.attach(context.Background(), []byte("%PDF-1.4"))
+	if err == nil {
+		t.Fatal("Want an error for an attachment without name, have no error")
+	}
+}
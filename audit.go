@@ -0,0 +1,110 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// AuditEntry is the line written to the audit log for each wkhtmltopdf, wkhtmltoimage, qpdf or Ghostscript process
+type AuditEntry struct {
+	Time       time.Time `json:"time"`                 // Time the process was started
+	RequestID  string    `json:"request_id,omitempty"` // See WithRequestID
+	Binary     string    `json:"binary"`
+	Args       []string  `json:"args"`                    // Arguments with the secrets replaced like in PDFGenerator.Args
+	InputHash  string    `json:"input_sha256,omitempty"`  // SHA-256 of the input read from stdin, empty for input files, URLs and an *os.File stdin
+	OutputHash string    `json:"output_sha256,omitempty"` // SHA-256 of the output written to stdout, empty for output files and an *os.File stdout
+	Duration   Duration  `json:"duration"`
+	ExitCode   int       `json:"exit_code"`          // -1 when the process did not start or was killed by a signal
+	MaxRSS     uint64    `json:"max_rss,omitempty"`  // Maximum resident set size in bytes, see ResourceUsage
+	CPUTime    Duration  `json:"cpu_time,omitempty"` // User and system CPU time
+	Error      string    `json:"error,omitempty"`
+}
+
+var auditLog struct {
+	w io.Writer
+	sync.Mutex
+}
+
+// SetAuditLog writes an AuditEntry as a line of JSON to w for each process which is run after this call, nil stops the audit log.
+// Writes to w are serialized, errors writing to w are ignored.
+func SetAuditLog(w io.Writer) {
+	auditLog.Lock()
+	auditLog.w = w
+	auditLog.Unlock()
+}
+
+// startAudit hashes the input and output of cmd when the audit log is set,
+// and returns a function which writes the entry when the process ended, or nil when the audit log is not set.
+// A stdin or stdout which is an *os.File is not hashed, so it is still passed to the process without a pipe
+func startAudit(ctx context.Context, cmd *exec.Cmd) func(err error) {
+	auditLog.Lock()
+	enabled := auditLog.w != nil
+	auditLog.Unlock()
+	if !enabled {
+		return nil
+	}
+
+	entry := AuditEntry{
+		Time:      time.Now(),
+		RequestID: RequestID(ctx),
+		Binary:    strings.TrimSuffix(filepath.Base(cmd.Path), ".exe"),
+		Args:      redactArgs(cmd.Args[1:]),
+	}
+	var in, out hash.Hash
+	if _, isFile := cmd.Stdin.(*os.File); cmd.Stdin != nil && !isFile {
+		in = sha256.New()
+		cmd.Stdin = io.TeeReader(cmd.Stdin, in)
+	}
+	if _, isFile := cmd.Stdout.(*os.File); cmd.Stdout != nil && !isFile {
+		out = sha256.New()
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, out)
+	}
+
+	return func(err error) {
+		entry.Duration = Duration(time.Since(entry.Time))
+		if in != nil {
+			entry.InputHash = hex.EncodeToString(in.Sum(nil))
+		}
+		if out != nil {
+			entry.OutputHash = hex.EncodeToString(out.Sum(nil))
+		}
+		entry.ExitCode = exitCode(cmd, err)
+		usage := processUsage(cmd.ProcessState)
+		entry.MaxRSS, entry.CPUTime = usage.MaxRSS, Duration(usage.CPUTime())
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		b, _ := json.Marshal(entry)
+
+		auditLog.Lock()
+		defer auditLog.Unlock()
+		if auditLog.w != nil {
+			auditLog.w.Write(append(b, '\n'))
+		}
+	}
+}
+
+// exitCode returns the exit code of the process of cmd, or -1 when it did not start or was killed by a signal
+func exitCode(cmd *exec.Cmd, err error) int {
+	if cmd.ProcessState == nil {
+		return -1
+	}
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok {
+		return status.ExitStatus()
+	}
+	if err != nil {
+		return -1
+	}
+	return 0
+}
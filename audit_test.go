@@ -0,0 +1,88 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetAuditLog(buf)
+	defer SetAuditLog(nil)
+
+	out := &bytes.Buffer{}
+	cmd := exec.Command("/bin/sh", "-c", "cat; printf ' world'")
+	cmd.Stdin = strings.NewReader("hello")
+	cmd.Stdout = out
+	err := runCommand(WithRequestID(context.Background(), "req-1"), cmd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "hello world" {
+		t.Errorf("Want hello world, have %s", out.String())
+	}
+	runCommand(context.Background(), exec.Command("/bin/sh", "-c", "exit 3"), nil)
+	runCommand(context.Background(), exec.Command("/no/such/wkhtmltopdf", "-q"), nil)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Want 3 audit entries, have %d: %s", len(lines), buf.String())
+	}
+	var entries []AuditEntry
+	for _, l := range lines {
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(l), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+
+	sum := func(s string) string {
+		h := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+	e := entries[0]
+	if e.RequestID != "req-1" || e.Binary != "sh" || len(e.Args) != 2 || e.Args[1] != "cat; printf ' world'" {
+		t.Errorf("Want request, binary and args, have %+v", e)
+	}
+	if e.InputHash != sum("hello") || e.OutputHash != sum("hello world") {
+		t.Errorf("Want hashes of input and output, have %s and %s", e.InputHash, e.OutputHash)
+	}
+	if e.ExitCode != 0 || e.Error != "" || e.Duration <= 0 || e.Time.IsZero() {
+		t.Errorf("Want successful entry, have %+v", e)
+	}
+	if entries[1].ExitCode != 3 || entries[1].Error == "" || entries[1].InputHash != "" {
+		t.Errorf("Want exit code 3, have %+v", entries[1])
+	}
+	if entries[2].ExitCode != -1 || entries[2].Error == "" || entries[2].Binary != "wkhtmltopdf" {
+		t.Errorf("Want entry for process which did not start, have %+v", entries[2])
+	}
+}
+
+func TestAuditLogFileStdout(t *testing.T) {
+	SetAuditLog(ioutil.Discard)
+	defer SetAuditLog(nil)
+
+	f, err := ioutil.TempFile("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	cmd := exec.Command("/bin/sh", "-c", "printf out")
+	cmd.Stdout = f
+	if err := runCommand(context.Background(), cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Stdout != f {
+		t.Errorf("Want the file passed to the process, have %T", cmd.Stdout)
+	}
+}
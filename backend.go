@@ -0,0 +1,59 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ImageBackend renders an image, RenderImage is the wkhtmltoimage backend. Another backend, for example one which takes
+// screenshots with headless Chrome, can be compared with it using CompareBackends when migrating off wkhtmltoimage.
+type ImageBackend func(ctx context.Context, options *ImageOptions) (*ImageResult, error)
+
+// WKHTMLToImage returns a backend which renders with the wkhtmltoimage binary at binPath, for example to compare versions
+func WKHTMLToImage(binPath string) ImageBackend {
+	return func(ctx context.Context, options *ImageOptions) (*ImageResult, error) {
+		options.BinaryPath = binPath
+		return RenderImage(ctx, options)
+	}
+}
+
+// BackendComparison is the result of CompareBackends
+type BackendComparison struct {
+	A, B *ImageResult
+	Diff *RenderDiff // Difference between the image of B and the image of A
+}
+
+// CompareBackends renders the image with backend a and b at the same time and compares the images with CompareRenders.
+// Each backend gets its own copy of options, Output and OutputWriter are not used and the format must be png, jpg or gif.
+func CompareBackends(ctx context.Context, options *ImageOptions, a, b ImageBackend) (*BackendComparison, error) {
+	backends := []ImageBackend{a, b}
+	results := make([]*ImageResult, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, backend := range backends {
+		copied := options.Clone()
+		copied.Output = ""
+		copied.OutputWriter = nil
+		wg.Add(1)
+		go func(i int, backend ImageBackend, options *ImageOptions) {
+			defer wg.Done()
+			results[i], errs[i] = backend(ctx, options)
+		}(i, backend, &copied)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("error rendering with backend %c: %s", 'A'+i, err)
+		}
+		if results[i] == nil {
+			return nil, fmt.Errorf("backend %c returned no result", 'A'+i)
+		}
+	}
+	diff, err := CompareRenders(results[0].Image, results[1].Image)
+	if err != nil {
+		return nil, fmt.Errorf("error comparing renders: %s", err)
+	}
+	return &BackendComparison{A: results[0], B: results[1], Diff: diff}, nil
+}
@@ -0,0 +1,53 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompareBackends(t *testing.T) {
+	before := encodeTestPage(t, testPage(200, 100, 20))
+	after := encodeTestPage(t, testPage(200, 100, 120))
+	backend := func(image []byte) ImageBackend {
+		return func(ctx context.Context, options *ImageOptions) (*ImageResult, error) {
+			if options.Output != "" {
+				return nil, errors.New("output is set")
+			}
+			options.Width = 1
+			return &ImageResult{Image: image}, nil
+		}
+	}
+
+	options := &ImageOptions{Input: "http://example.com", Output: "/tmp/example.png"}
+	cmp, err := CompareBackends(context.Background(), options, backend(before), backend(after))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp.Diff.Pixels != 2*50*49 {
+		t.Errorf("Want %d different pixels, have %d", 2*50*49, cmp.Diff.Pixels)
+	}
+	if string(cmp.A.Image) != string(before) || string(cmp.B.Image) != string(after) {
+		t.Error("Want the images of both backends")
+	}
+	if options.Width != 0 || options.Output != "/tmp/example.png" {
+		t.Errorf("Want options unchanged, have %+v", options)
+	}
+
+	failing := func(ctx context.Context, options *ImageOptions) (*ImageResult, error) {
+		return nil, errors.New("chrome not found")
+	}
+	_, err = CompareBackends(context.Background(), options, backend(before), failing)
+	if err == nil || !strings.Contains(err.Error(), "backend B: chrome not found") {
+		t.Errorf("Want error of backend B, have %v", err)
+	}
+
+	empty := func(ctx context.Context, options *ImageOptions) (*ImageResult, error) {
+		return nil, nil
+	}
+	_, err = CompareBackends(context.Background(), options, empty, backend(after))
+	if err == nil || !strings.Contains(err.Error(), "backend A returned no result") {
+		t.Errorf("Want error for backend A without a result, have %v", err)
+	}
+}
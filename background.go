@@ -0,0 +1,20 @@
+package wkhtmltopdf
+
+// SetBackground sets whether the background colors and images of the page are printed. Set it to false for PDFs which
+// are printed, so ink-heavy backgrounds are dropped, and keep the default true for PDFs which are read on screen
+func (popt *pageOptions) SetBackground(background bool) {
+	popt.NoBackground.Set(!background)
+}
+
+// Background returns true if the background colors and images of the page are printed
+func (popt *pageOptions) Background() bool {
+	return !popt.NoBackground.value
+}
+
+// SetBackground sets whether backgrounds are printed on the cover, the TOC and the pages which were added,
+// call it after AddPage
+func (pdfg *PDFGenerator) SetBackground(background bool) {
+	for _, popt := range pdfg.allPageOptions() {
+		popt.SetBackground(background)
+	}
+}
@@ -0,0 +1,26 @@
+package wkhtmltopdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetBackground(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.Cover.Input = "cover.html"
+	screen := NewPage("screen.html")
+	pdfg.AddPage(screen)
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	if !screen.Background() {
+		t.Error("Want backgrounds printed by default")
+	}
+	pdfg.SetBackground(false)
+	if n := strings.Count(pdfg.ArgString(), "--no-background"); n != 3 {
+		t.Errorf("Want no background on the cover and 2 pages, have %d in %s", n, pdfg.ArgString())
+	}
+
+	screen.SetBackground(true)
+	if !screen.Background() || strings.Contains(strings.Join(screen.Args(), " "), "--no-background") {
+		t.Errorf("Want background of the page printed, have %s", screen.Args())
+	}
+}
@@ -0,0 +1,60 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"encoding/base64"
+)
+
+// Values of ImageOptions.Output which return the image base64 encoded in ImageResult.Image instead of saving it,
+// for callers which embed the image in JSON or HTML
+const (
+	OutputBase64  = "base64:"        // ImageResult.Image is the base64 encoded image
+	OutputDataURI = "base64:datauri" // ImageResult.Image is a data URI, e.g. data:image/png;base64,iVBORw0...
+)
+
+// base64Output returns true if output is OutputBase64 or OutputDataURI
+func base64Output(output string) bool {
+	return output == OutputBase64 || output == OutputDataURI
+}
+
+// Base64 returns the image base64 encoded
+func (r *ImageResult) Base64() string {
+	return base64.StdEncoding.EncodeToString(r.Image)
+}
+
+// DataURI returns the image as data URI, which can be used as src of an img element
+func (r *ImageResult) DataURI() string {
+	return dataURI(imageContentType(r.format), r.Image)
+}
+
+// Base64 returns the PDF in the internal buffer base64 encoded
+func (pdfg *PDFGenerator) Base64() string {
+	return base64.StdEncoding.EncodeToString(pdfg.Bytes())
+}
+
+// DataURI returns the PDF in the internal buffer as data URI
+func (pdfg *PDFGenerator) DataURI() string {
+	return dataURI("application/pdf", pdfg.Bytes())
+}
+
+func dataURI(contentType string, b []byte) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(b)
+}
+
+// renderBase64 renders the image without Output and OutputWriter and encodes it with the encoding set in Output
+func renderBase64(ctx context.Context, options *ImageOptions) (*ImageResult, error) {
+	copied := *options
+	copied.Output = ""
+	copied.OutputWriter = nil
+	res, err := RenderImage(ctx, &copied)
+	options.BinaryPath = copied.BinaryPath
+	if err != nil || res == nil {
+		return res, err
+	}
+	if options.Output == OutputDataURI {
+		res.Image = []byte(res.DataURI())
+	} else {
+		res.Image = []byte(res.Base64())
+	}
+	return res, nil
+}
@@ -0,0 +1,50 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestImageResultBase64(t *testing.T) {
+	res := &ImageResult{Image: []byte("<svg></svg>"), format: "svg"}
+	if have := res.Base64(); have != "PHN2Zz48L3N2Zz4=" {
+		t.Errorf("Want PHN2Zz48L3N2Zz4=, have %s", have)
+	}
+	if have := res.DataURI(); have != "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=" {
+		t.Errorf("Want data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=, have %s", have)
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.outbuf.WriteString("%PDF")
+	if have := pdfg.DataURI(); have != "data:application/pdf;base64,JVBERg==" {
+		t.Errorf("Want data:application/pdf;base64,JVBERg==, have %s", have)
+	}
+}
+
+func TestRenderImageBase64(t *testing.T) {
+	f, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	// the binary writes an svg to stdout and fails if the output is not stdout
+	f.WriteString("#!/bin/sh\nfor last; do :; done\n[ \"$last\" = - ] || exit 1\nprintf '<svg></svg>'\n")
+	f.Close()
+	os.Chmod(f.Name(), 0700)
+
+	for output, want := range map[string]string{
+		OutputBase64:  "PHN2Zz48L3N2Zz4=",
+		OutputDataURI: "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=",
+	} {
+		job := &Job{Image: &ImageOptions{BinaryPath: f.Name(), Input: "http://example.com", Format: "svg", Output: output}}
+		have, err := job.Render(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(have) != want {
+			t.Errorf("Want %s, have %s", want, have)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WriteBatchFile writes the arguments of each PDF as a line for wkhtmltopdf --read-args-from-stdin, so huge batches can
+// be converted offline in one process with `wkhtmltopdf --read-args-from-stdin < batch.txt`. Each PDF needs an OutputFile,
+// and pages must be files or URLs, the HTML of page readers can not be passed in the batch file. PDFs which are post
+// processed can not be written in a batch file
+func WriteBatchFile(w io.Writer, pdfgs []*PDFGenerator) error {
+	for i, pdfg := range pdfgs {
+		line, err := pdfg.batchLine()
+		if err != nil {
+			return fmt.Errorf("PDF %d: %s", i, err)
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchLine returns the line of arguments of the PDF in a batch file
+func (pdfg *PDFGenerator) batchLine() (string, error) {
+	if pdfg.OutputFile == "" {
+		return "", errors.New("a PDF in a batch file needs an OutputFile")
+	}
+	if pdfg.outlineWriter != nil || pdfg.tocXSL != nil {
+		return "", errors.New("SetOutlineOutput and SetTOCStyleSheet can not be used in a batch file")
+	}
+	// the PDFs in a batch are written by wkhtmltopdf alone, nothing runs after them
+	if pdfg.postProcessing() {
+		return "", errors.New("post processing, such as Linearize, PDFA, Watermark, attachments, Sign, Accessibility and ColorProfile, can not be used in a batch file")
+	}
+	for _, page := range pdfg.pages {
+		if page.Reader() != nil {
+			return "", errors.New("page readers can not be used in a batch file")
+		}
+	}
+	if err := pdfg.checkArgs(); err != nil {
+		return "", err
+	}
+	return argsLine(pdfg.args())
+}
+
+// RunBatch creates the PDFs one after the other in a single wkhtmltopdf process started with --read-args-from-stdin,
+// for offline conversions where starting wkhtmltopdf and Qt for each PDF takes longer than the PDFs themselves.
+// The process is started with the binary and the work dir of the first PDF, the other PDFs need the same work dir.
+// The returned errors are those of the PDFs in the same order, nil for a PDF which was created. The error is set when
+// the process can not be started
+func RunBatch(ctx context.Context, pdfgs []*PDFGenerator) ([]error, error) {
+	if len(pdfgs) == 0 {
+		return nil, nil
+	}
+	pool := &WarmPool{BinaryPath: pdfgs[0].binPath, WorkDir: pdfgs[0].workDir}
+	if err := pool.Start(); err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+	errs := make([]error, len(pdfgs))
+	for i, pdfg := range pdfgs {
+		errs[i] = pool.Create(ctx, pdfg)
+	}
+	return errs, nil
+}
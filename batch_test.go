@@ -0,0 +1,82 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteBatchFile(t *testing.T) {
+	var pdfgs []*PDFGenerator
+	for _, name := range []string{"one", "two"} {
+		pdfg := NewPDFPreparer()
+		pdfg.Title.Set("Report " + name)
+		pdfg.OutputFile = name + ".pdf"
+		pdfg.AddPage(NewPage(name + ".html"))
+		pdfgs = append(pdfgs, pdfg)
+	}
+	var buf bytes.Buffer
+	if err := WriteBatchFile(&buf, pdfgs); err != nil {
+		t.Fatal(err)
+	}
+	want := `"--title" "Report one" "page" "one.html" "one.pdf"` + "\n" + `"--title" "Report two" "page" "two.html" "two.pdf"` + "\n"
+	if buf.String() != want {
+		t.Errorf("Want %s, have %s", want, buf.String())
+	}
+
+	pdfgs[1].OutputFile = ""
+	if err := WriteBatchFile(&buf, pdfgs); err == nil || !strings.Contains(err.Error(), "PDF 1") {
+		t.Errorf("Want error for the PDF without an OutputFile, have %v", err)
+	}
+	pdfgs[1].OutputFile = "two.pdf"
+	pdfgs[1].Linearize = true
+	if err := WriteBatchFile(&buf, pdfgs); err == nil || !strings.Contains(err.Error(), "post processing") {
+		t.Errorf("Want error for a PDF which is post processed, have %v", err)
+	}
+	pdfgs[1].Linearize = false
+	pdfgs[1].AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	if err := WriteBatchFile(&buf, pdfgs); err == nil {
+		t.Error("Want error for a page reader")
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	bin, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString(testWarmBinary)
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	var pdfgs []*PDFGenerator
+	for _, input := range []string{"<html>One</html>", "https://fail.example.com", "<html>Two</html>"} {
+		pdfg := NewPDFPreparer()
+		pdfg.binPath = bin.Name()
+		if strings.HasPrefix(input, "https://") {
+			pdfg.AddPage(NewPage(input))
+		} else {
+			pdfg.AddPage(NewPageReader(strings.NewReader(input)))
+		}
+		pdfgs = append(pdfgs, pdfg)
+	}
+	errs, err := RunBatch(context.Background(), pdfgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs[0] != nil || errs[1] == nil || errs[2] != nil {
+		t.Fatalf("Want an error for the second PDF only, have %v", errs)
+	}
+	one := strings.SplitN(pdfgs[0].Buffer().String(), " ", 2)
+	two := strings.SplitN(pdfgs[2].Buffer().String(), " ", 2)
+	if one[0] != two[0] {
+		t.Errorf("Want the PDFs created by one process, have pids %s and %s", one[0], two[0])
+	}
+	if one[1] != "<html>One</html>" || two[1] != "<html>Two</html>" {
+		t.Errorf("Want the pages in order, have %q and %q", one[1], two[1])
+	}
+}
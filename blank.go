@@ -0,0 +1,95 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"strings"
+)
+
+// blankWarning is the warning for an image which has one color, see BlankCheck
+const blankWarning = "The image is blank"
+
+// BlankCheck sets how images with one color are detected, which wkhtmltoimage renders without an error when a page
+// did not load or its scripts failed. Pixels with differences of anti-aliasing and jpg compression have the same color.
+// Only png, jpg and gif images are checked.
+type BlankCheck struct {
+	// MinPixels is the number of pixels an image must have to be checked, for example so small icons with one color are not blank.
+	// Default 0, all images are checked
+	MinPixels int
+	// Error returns a BlankImageError for a blank image. Default false, a warning is added to ImageResult.Warnings
+	Error bool
+}
+
+// BlankImageError is returned for a blank image when BlankCheck.Error is set.
+// Result holds the image and the diagnostics of the render, such as console messages and failed requests.
+type BlankImageError struct {
+	Color  color.NRGBA
+	Result *ImageResult
+}
+
+func (e *BlankImageError) Error() string {
+	msg := fmt.Sprintf("blank %dx%d image of color #%02x%02x%02x%02x", e.Result.Width, e.Result.Height, e.Color.R, e.Color.G, e.Color.B, e.Color.A)
+	var diagnostics []string
+	if n := len(e.Result.Failed); n > 0 {
+		diagnostics = append(diagnostics, fmt.Sprintf("%d failed requests, first %s", n, e.Result.Failed[0].URL))
+	}
+	if n := len(e.Result.Console); n > 0 {
+		diagnostics = append(diagnostics, fmt.Sprintf("%d console messages, first %s", n, e.Result.Console[0]))
+	}
+	if len(diagnostics) > 0 {
+		msg += " (" + strings.Join(diagnostics, "; ") + ")"
+	}
+	return msg
+}
+
+// checkBlank sets res.Blank when the image in res, or in Output when that is set, has one color, and adds a warning
+// or returns a BlankImageError
+func checkBlank(res *ImageResult, options *ImageOptions) error {
+	src := res.Image
+	if output := options.outputFile(); output != "" {
+		var err error
+		src, err = ioutil.ReadFile(output)
+		if err != nil {
+			return err
+		}
+	}
+	img, err := res.decode(src)
+	if err != nil {
+		// svg and bmp are not checked
+		return nil
+	}
+	b := img.Bounds()
+	res.Width, res.Height = b.Dx(), b.Dy()
+	if b.Dx()*b.Dy() < options.BlankCheck.MinPixels {
+		return nil
+	}
+	c, ok := singleColor(img)
+	if !ok {
+		return nil
+	}
+	res.Blank = true
+	if options.BlankCheck.Error {
+		return &BlankImageError{Color: c, Result: res}
+	}
+	res.Warnings = append(res.Warnings, blankWarning)
+	return nil
+}
+
+// singleColor returns the color of the image if all pixels have a similar color
+func singleColor(img image.Image) (color.NRGBA, bool) {
+	b := img.Bounds()
+	if b.Empty() {
+		return color.NRGBA{}, false
+	}
+	first := img.At(b.Min.X, b.Min.Y)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !similarColors(first, img.At(x, y)) {
+				return color.NRGBA{}, false
+			}
+		}
+	}
+	return color.NRGBAModel.Convert(first).(color.NRGBA), true
+}
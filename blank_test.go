@@ -0,0 +1,82 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSingleColor(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	// small differences of jpg compression
+	img.Set(3, 4, color.RGBA{250, 250, 252, 255})
+	c, ok := singleColor(img)
+	if !ok || c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("Want white, have %v %t", c, ok)
+	}
+	if _, ok := singleColor(testPage(40, 30, 10)); ok {
+		t.Error("Want test page not blank")
+	}
+}
+
+func TestRenderImageBlank(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	err = ioutil.WriteFile(filepath.Join(dir, "blank.png"), encodeTestPage(t, img), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\necho 'Warning: Failed to load http://example.com/app.js (ignore)' >&2\ncat blank.png\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := &ImageOptions{BinaryPath: bin, Input: "http://example.com", WorkDir: dir, BlankCheck: &BlankCheck{}}
+	res, err := RenderImage(context.Background(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Blank || res.Width != 40 {
+		t.Errorf("Want blank 40 pixel wide image, have %t %d", res.Blank, res.Width)
+	}
+	want := []string{"Failed to load http://example.com/app.js (ignore)", blankWarning}
+	if !reflect.DeepEqual(res.Warnings, want) {
+		t.Errorf("Want %q, have %q", want, res.Warnings)
+	}
+
+	options.BlankCheck = &BlankCheck{MinPixels: 40 * 30, Error: true}
+	_, err = RenderImage(context.Background(), options)
+	berr, ok := err.(*BlankImageError)
+	if !ok {
+		t.Fatalf("Want *BlankImageError, have %v", err)
+	}
+	if have := berr.Error(); have != "blank 40x30 image of color #ffffffff (1 failed requests, first http://example.com/app.js)" {
+		t.Errorf("Want blank image error with failed request, have %s", have)
+	}
+
+	// smaller images are not checked
+	options.BlankCheck = &BlankCheck{MinPixels: 40*30 + 1, Error: true}
+	res, err = RenderImage(context.Background(), options)
+	if err != nil || res.Blank {
+		t.Errorf("Want image not checked, have %t %v", res.Blank, err)
+	}
+	// a relative Output is read from WorkDir
+	options = &ImageOptions{BinaryPath: bin, Input: "http://example.com", Output: "blank.png", WorkDir: dir, BlankCheck: &BlankCheck{}}
+	res, err = RenderImage(context.Background(), options)
+	if err != nil || !res.Blank {
+		t.Errorf("Want blank image in WorkDir, have %t %v", res.Blank, err)
+	}
+}
@@ -0,0 +1,262 @@
+package wkhtmltopdf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// RowIterator returns the data rows of GenerateBulk one at a time, Next returns io.EOF after the last row
+type RowIterator interface {
+	Next() (interface{}, error)
+}
+
+// csvRows returns the rows of a CSV file as maps of the column names in the header to the values
+type csvRows struct {
+	r      *csv.Reader
+	header []string
+}
+
+// CSVRows returns the rows of CSV data with a header row, each row is a map[string]string of the column names
+// to the values, so a template can use {{.Name}} for the column Name
+func CSVRows(r io.Reader) RowIterator {
+	return &csvRows{r: csv.NewReader(r)}
+}
+
+func (c *csvRows) Next() (interface{}, error) {
+	if c.header == nil {
+		header, err := c.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		c.header = header
+	}
+	record, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]string, len(c.header))
+	for i, name := range c.header {
+		row[name] = record[i]
+	}
+	return row, nil
+}
+
+// jsonRows returns the values of a JSON array or of a stream of JSON values
+type jsonRows struct {
+	r       *bufio.Reader
+	dec     *json.Decoder
+	inArray bool
+}
+
+// JSONRows returns the values of a JSON array, or of newline delimited JSON with one value per line.
+// Numbers are json.Number, so they are printed by templates as they are in the JSON.
+func JSONRows(r io.Reader) RowIterator {
+	return &jsonRows{r: bufio.NewReader(r)}
+}
+
+func (j *jsonRows) Next() (interface{}, error) {
+	if j.dec == nil {
+		// the first character which is not white space tells if the rows are an array
+		for {
+			c, err := j.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+				continue
+			}
+			j.r.UnreadByte()
+			j.inArray = c == '['
+			break
+		}
+		j.dec = json.NewDecoder(j.r)
+		j.dec.UseNumber()
+		if j.inArray {
+			if _, err := j.dec.Token(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if j.inArray && !j.dec.More() {
+		return nil, io.EOF
+	}
+	var row interface{}
+	if err := j.dec.Decode(&row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// BulkOptions are the options of GenerateBulk
+type BulkOptions struct {
+	Sink        OutputSink // Sink which stores the outputs, required
+	Concurrency int        // Number of rows that are rendered at the same time (default 1)
+	Limiter     *Limiter   // Optional Limiter to run the renders through
+	Pool        *WarmPool  // Optional WarmPool to create the PDFs with, relative paths are resolved from its WorkDir instead of the Dir of the store
+	// NewPDF returns the PDFGenerator with the options of the PDF of a row, the HTML of the template is added as a page.
+	// Default NewPDFGenerator
+	NewPDF func() (*PDFGenerator, error)
+	// Image are the options of the images, when it is set images are rendered instead of PDFs. Input and Html are set
+	// to the HTML of the template.
+	Image *ImageOptions
+}
+
+// BulkError is the error of a row which could not be rendered or stored
+type BulkError struct {
+	Row int    // Index of the row, starting at 0
+	Key string // Key of the output of the row
+	Err error
+}
+
+func (e *BulkError) Error() string {
+	return "row " + strconv.Itoa(e.Row) + " (" + e.Key + "): " + e.Err.Error()
+}
+
+// Unwrap returns the error of the render or of the Sink
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// BulkResult is the result of GenerateBulk
+type BulkResult struct {
+	Stored int          // Number of outputs which were stored in the Sink
+	Errors []*BulkError // Errors of the rows which failed, in the order they failed
+}
+
+// GenerateBulk renders the template of store with each row of rows and stores the outputs in the Sink of the options,
+// for example to create thousands of personalized certificates from a CSV file.
+// naming returns the key of the output of a row, without the file extension which is added; when it is nil the key
+// is the index of the row. A row which fails does not stop the other rows, its error is added to the result.
+// The error of rows or ctx is returned with the result of the rows which were rendered until then.
+func GenerateBulk(ctx context.Context, store *TemplateStore, name string, rows RowIterator, naming func(i int, row interface{}) string, options BulkOptions) (*BulkResult, error) {
+	if options.Sink == nil {
+		return nil, errors.New("GenerateBulk needs a Sink to store the outputs")
+	}
+	if _, err := store.Template(name); err != nil {
+		return nil, err
+	}
+	n := options.Concurrency
+	if n < 1 {
+		n = 1
+	}
+	type bulkRow struct {
+		i    int
+		data interface{}
+	}
+	result := &BulkResult{}
+	var mu sync.Mutex
+	queue := make(chan bulkRow)
+	wg := sync.WaitGroup{}
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range queue {
+				key := strconv.Itoa(row.i)
+				if naming != nil {
+					key = naming(row.i, row.data)
+				}
+				key, err := options.generate(ctx, store, name, key, row.data)
+				mu.Lock()
+				if err != nil {
+					result.Errors = append(result.Errors, &BulkError{Row: row.i, Key: key, Err: err})
+				} else {
+					result.Stored++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	var err error
+	for i := 0; ; i++ {
+		var data interface{}
+		data, err = rows.Next()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			err = fmt.Errorf("error reading row %d: %s", i, err)
+			break
+		}
+		select {
+		case queue <- bulkRow{i: i, data: data}:
+			continue
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		break
+	}
+	close(queue)
+	wg.Wait()
+	return result, err
+}
+
+// generate renders the template with the data of a row and stores the output with the key and the extension
+// of the output in the Sink, it returns the key with the extension
+func (options *BulkOptions) generate(ctx context.Context, store *TemplateStore, name, key string, data interface{}) (string, error) {
+	var output []byte
+	var job Job
+	render := func(ctx context.Context) error {
+		html, err := store.Execute(name, data)
+		if err != nil {
+			return err
+		}
+		if options.Image != nil {
+			image := *options.Image
+			job.Image = &image
+			image.Input = "-"
+			image.Html = string(html)
+			if image.WorkDir == "" {
+				image.WorkDir = store.Dir
+			}
+			result, err := RenderImage(ctx, &image)
+			if err != nil {
+				return err
+			}
+			output = result.Image
+			return nil
+		}
+		newPDF := options.NewPDF
+		if newPDF == nil {
+			newPDF = NewPDFGenerator
+		}
+		pdfg, err := newPDF()
+		if err != nil {
+			return err
+		}
+		job.PDF = pdfg
+		// the processes of a pool run in the WorkDir of the pool
+		if pdfg.workDir == "" && options.Pool == nil {
+			pdfg.SetWorkDir(store.Dir)
+		}
+		pdfg.AddPage(NewPageReader(bytes.NewReader(html)))
+		if options.Pool != nil {
+			err = options.Pool.Create(ctx, pdfg)
+		} else {
+			err = pdfg.CreateContext(ctx)
+		}
+		output = pdfg.Bytes()
+		return err
+	}
+	var err error
+	if options.Limiter != nil {
+		err = options.Limiter.DoContext(ctx, "", render)
+	} else {
+		err = render(ctx)
+	}
+	if err != nil {
+		return key, err
+	}
+	ext, contentType := job.outputType()
+	key += "." + ext
+	return key, options.Sink.Put(ctx, key, contentType, output)
+}
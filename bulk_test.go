@@ -0,0 +1,158 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRows(t *testing.T) {
+	read := func(rows RowIterator) []interface{} {
+		var all []interface{}
+		for {
+			row, err := rows.Next()
+			if err == io.EOF {
+				return all
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, row)
+		}
+	}
+	got := read(CSVRows(strings.NewReader("Name,Course\nJane Doe,Go\nJohn Roe,\"PDF, advanced\"\n")))
+	want := []interface{}{
+		map[string]string{"Name": "Jane Doe", "Course": "Go"},
+		map[string]string{"Name": "John Roe", "Course": "PDF, advanced"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CSVRows = %v, want %v", got, want)
+	}
+	array := read(JSONRows(strings.NewReader(` [{"Name": "Jane Doe", "Score": 100}, {"Name": "John Roe", "Score": 1e3}]`)))
+	lines := read(JSONRows(strings.NewReader("{\"Name\": \"Jane Doe\", \"Score\": 100}\n{\"Name\": \"John Roe\", \"Score\": 1e3}\n")))
+	want = []interface{}{
+		map[string]interface{}{"Name": "Jane Doe", "Score": json.Number("100")},
+		map[string]interface{}{"Name": "John Roe", "Score": json.Number("1e3")},
+	}
+	if !reflect.DeepEqual(array, want) {
+		t.Errorf("JSONRows of an array = %v, want %v", array, want)
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("JSONRows of lines = %v, want %v", lines, want)
+	}
+}
+
+func TestGenerateBulk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-bulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the template fails for the name "bad"
+	template := `<svg>{{.Name}}{{if eq .Name "bad"}}{{index .Name 99}}{{end}}</svg>`
+	if err := ioutil.WriteFile(filepath.Join(dir, "certificate.html"), []byte(template), 0600); err != nil {
+		t.Fatal(err)
+	}
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	store := NewTemplateStore(dir)
+	sink := &testSink{outputs: map[string][]byte{}, types: map[string]string{}}
+	rows := CSVRows(strings.NewReader("Name\njane\nbad\njohn\n"))
+	naming := func(i int, row interface{}) string {
+		return "certificates/" + row.(map[string]string)["Name"]
+	}
+	result, err := GenerateBulk(context.Background(), store, "certificate.html", rows, naming, BulkOptions{
+		Sink:        sink,
+		Concurrency: 2,
+		Limiter:     &Limiter{},
+		Image:       &ImageOptions{BinaryPath: bin.Name(), Format: "svg"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stored != 2 || len(result.Errors) != 1 {
+		t.Fatalf("result = %+v, want 2 stored and 1 error", result)
+	}
+	if e := result.Errors[0]; e.Row != 1 || e.Key != "certificates/bad" {
+		t.Errorf("error = %s, want row 1 certificates/bad", e)
+	}
+	for _, name := range []string{"jane", "john"} {
+		key := "certificates/" + name + ".svg"
+		if got := string(sink.outputs[key]); got != "<svg>"+name+"</svg>" {
+			t.Errorf("output %s = %q", key, got)
+		}
+		if sink.types[key] != "image/svg+xml" {
+			t.Errorf("content type of %s = %q", key, sink.types[key])
+		}
+	}
+
+	// reading the rows fails
+	result, err = GenerateBulk(context.Background(), store, "certificate.html", JSONRows(strings.NewReader(`[{"Name": "jane"}, {`)), nil, BulkOptions{
+		Sink:  sink,
+		Image: &ImageOptions{BinaryPath: bin.Name(), Format: "svg"},
+	})
+	if err == nil || result.Stored != 1 {
+		t.Errorf("GenerateBulk with invalid JSON = %+v, %v, want 1 stored and an error", result, err)
+	}
+	if _, ok := sink.outputs["0.svg"]; !ok {
+		t.Error("output without naming is not stored with the index of the row")
+	}
+
+	if _, err := GenerateBulk(context.Background(), store, "certificate.html", rows, nil, BulkOptions{}); err == nil {
+		t.Error("GenerateBulk without a Sink did not return an error")
+	}
+	if _, err := GenerateBulk(context.Background(), store, "missing.html", rows, nil, BulkOptions{Sink: sink}); err == nil {
+		t.Error("GenerateBulk with a missing template did not return an error")
+	}
+}
+
+func TestGenerateBulkPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-bulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "certificate.html"), []byte(`<html>{{.Name}}</html>`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "wkhtmltopdf")
+	if err := ioutil.WriteFile(bin, []byte(testWarmBinary), 0700); err != nil {
+		t.Fatal(err)
+	}
+	pool := &WarmPool{BinaryPath: bin}
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	sink := &testSink{outputs: map[string][]byte{}, types: map[string]string{}}
+	result, err := GenerateBulk(context.Background(), NewTemplateStore(dir), "certificate.html", CSVRows(strings.NewReader("Name\njane\n")), nil, BulkOptions{
+		Sink: sink,
+		Pool: pool,
+		NewPDF: func() (*PDFGenerator, error) {
+			return NewPDFPreparer(), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stored != 1 || len(result.Errors) != 0 {
+		t.Fatalf("result = %+v, want 1 stored", result)
+	}
+	if got := string(sink.outputs["0.pdf"]); !strings.HasSuffix(got, " <html>jane</html>") {
+		t.Errorf("output 0.pdf = %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrCanceled is returned when the context of a render is canceled or times out before the output is created,
+// for use with errors.Is. The error also unwraps to the context error, so context.DeadlineExceeded tells a timeout
+// from a cancellation. The output file created by the render and its temporary files are removed,
+// output which was already written to an output writer can not be removed.
+var ErrCanceled = errors.New("render canceled")
+
+// canceledError is the error of a canceled render, it is ErrCanceled and unwraps to the context error
+type canceledError struct {
+	err error
+}
+
+func (e *canceledError) Error() string {
+	return ErrCanceled.Error() + ": " + e.err.Error()
+}
+
+// Unwrap returns the context error
+func (e *canceledError) Unwrap() error {
+	return e.err
+}
+
+// Is returns true for ErrCanceled
+func (e *canceledError) Is(target error) bool {
+	return target == ErrCanceled
+}
+
+// canceled returns the error of a render whose context is done, with the request ID of ctx
+func canceled(ctx context.Context) error {
+	return requestError(ctx, &canceledError{err: ctx.Err()})
+}
+
+// newOutputFile returns the path of the output file of a render, relative to workDir, when the file does not exist yet
+// so it can be removed when the render is canceled. An existing file is not removed, the render did not create it.
+func newOutputFile(file, workDir string) string {
+	if file == "" || file == "-" {
+		return ""
+	}
+	if workDir != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(workDir, file)
+	}
+	if _, err := os.Lstat(file); !os.IsNotExist(err) {
+		return ""
+	}
+	return file
+}
+
+// removeOutputFile removes the output file returned by newOutputFile, file can be empty
+func removeOutputFile(file string) {
+	if file != "" {
+		os.Remove(file)
+	}
+}
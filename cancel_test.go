@@ -0,0 +1,109 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testSlowBinary writes part of the output file, which is the last argument, and hangs until it is killed
+const testSlowBinary = "#!/bin/sh\nfor out; do :; done\n[ \"$out\" = - ] || echo partial > \"$out\"\nexec sleep 5\n"
+
+// writeSlowBinary writes testSlowBinary to dir and returns its path
+func writeSlowBinary(t *testing.T, dir string) string {
+	bin := filepath.Join(dir, "wkhtmltox")
+	err := ioutil.WriteFile(bin, []byte(testSlowBinary), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+// dirFiles returns the names of the files in dir
+func dirFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	return names
+}
+
+func TestCreateContextCanceledRemovesOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = writeSlowBinary(t, dir)
+	pdfg.SetWorkDir(dir)
+	pdfg.OutputFile = "out.pdf"
+	pdfg.SetOutlineOutput(ioutil.Discard)
+	pdfg.AddPage(NewPage("http://example.com"))
+
+	ctx, cancel := context.WithTimeout(WithRequestID(context.Background(), "cancel-test-pdf"), 200*time.Millisecond)
+	defer cancel()
+	err = pdfg.CreateContext(ctx)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Want ErrCanceled with %v, have %v", context.DeadlineExceeded, err)
+	}
+	if err == nil || err.Error() != "request cancel-test-pdf: render canceled: context deadline exceeded" {
+		t.Errorf("Want error with request ID, have %v", err)
+	}
+	if files := dirFiles(t, dir); len(files) != 1 {
+		t.Errorf("Want only the binary in the work dir, have %v", files)
+	}
+	// the outline is dumped to a temporary file
+	files, _ := filepath.Glob(filepath.Join(os.TempDir(), "*cancel-test-pdf*"))
+	if len(files) != 0 {
+		t.Errorf("Want temporary files removed, have %v", files)
+	}
+
+	// an output file the render did not create is kept
+	err = ioutil.WriteFile(filepath.Join(dir, "out.pdf"), []byte("%PDF-1.4"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := pdfg.CreateContext(ctx); !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Want ErrCanceled with %v, have %v", context.Canceled, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.pdf")); err != nil {
+		t.Errorf("Want existing output file kept, have %v", err)
+	}
+}
+
+func TestRenderImageCanceledRemovesOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bin := writeSlowBinary(t, dir)
+
+	for _, options := range []*ImageOptions{
+		{BinaryPath: bin, Input: "http://example.com", Output: "out.png", WorkDir: dir},
+		{BinaryPath: bin, Input: "-", Html: "<html>Hi</html>", Format: "png", WorkDir: dir, StdinStrategy: StdinTempFile, StdoutStrategy: StdoutTempFile},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(200*time.Millisecond, cancel)
+		res, err := RenderImage(ctx, options)
+		if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) || res != nil {
+			t.Errorf("Want ErrCanceled with %v, have %v", context.Canceled, err)
+		}
+		if files := dirFiles(t, dir); len(files) != 1 {
+			t.Errorf("Want only the binary in the work dir, have %v", files)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,221 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// CapabilityReport is the version and the options of a wkhtmltopdf or wkhtmltoimage binary, see ProbeCapabilities
+type CapabilityReport struct {
+	Binary    string
+	Version   string          // Output of --version, for example "wkhtmltopdf 0.12.6 (with patched qt)"
+	PatchedQt bool            // Built with patched Qt, which is needed for options such as headers, footers, outlines and TOCs
+	Options   map[string]bool // Long options without "--" listed by --extended-help
+}
+
+// Supports returns true if the binary supports the long option, with or without "--". Binaries without patched Qt
+// list the options of headers, footers, outlines and TOCs in --extended-help but ignore them, they are not supported
+func (c *CapabilityReport) Supports(option string) bool {
+	option = strings.TrimPrefix(option, opt)
+	if !c.PatchedQt && patchedQtOption(option) {
+		return false
+	}
+	return c.Options[option]
+}
+
+var patchedQt struct {
+	options map[string]bool
+	once    sync.Once
+}
+
+// patchedQtOption returns true if the long option only works when wkhtmltopdf is built with patched Qt
+func patchedQtOption(option string) bool {
+	patchedQt.once.Do(func() {
+		// the page options which need patched Qt, see the reduced functionality in the wkhtmltopdf manual
+		patchedQt.options = map[string]bool{
+			"default-header": true, "disable-external-links": true, "enable-external-links": true, "disable-internal-links": true,
+			"enable-internal-links": true, "disable-forms": true, "enable-forms": true, "disable-smart-shrinking": true,
+			"enable-smart-shrinking": true, "print-media-type": true, "no-print-media-type": true, "exclude-from-outline": true,
+			"include-in-outline": true, "enable-toc-back-links": true, "disable-toc-back-links": true, "page-offset": true,
+		}
+		outline, hf, toc := newOutlineOptions(), newHeaderAndFooterOptions(), newTocOptions()
+		for _, o := range []interface{}{&outline, &hf, &toc} {
+			rv := reflect.ValueOf(o).Elem()
+			for i := 0; i < rv.NumField(); i++ {
+				name, _, _ := optionValues(rv.Field(i).Addr().Interface())
+				patchedQt.options[name] = true
+			}
+		}
+	})
+	return patchedQt.options[option]
+}
+
+// helpOptionRe matches the long options in the output of --extended-help, e.g. "  -q, --quiet" or "      --cookie <name> <value>"
+var helpOptionRe = regexp.MustCompile(`(?m)^\s+(?:-\w,\s+)?--([a-z0-9-]+)`)
+
+var capabilities struct {
+	probed map[string]*CapabilityReport
+	sync.Mutex
+}
+
+// ProbeCapabilities runs the binary with --version and --extended-help and returns its version and options.
+// The result is cached by binPath for the lifetime of the program.
+func ProbeCapabilities(ctx context.Context, binPath string) (*CapabilityReport, error) {
+	capabilities.Lock()
+	c, ok := capabilities.probed[binPath]
+	capabilities.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	version, err := exec.CommandContext(ctx, binPath, "--version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting version of %s: %s", binPath, err)
+	}
+	help, err := exec.CommandContext(ctx, binPath, "--extended-help").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error getting options of %s: %s", binPath, err)
+	}
+	c = &CapabilityReport{
+		Binary:    binPath,
+		Version:   strings.TrimSpace(string(version)),
+		PatchedQt: strings.Contains(string(version), "patched qt"),
+		Options:   make(map[string]bool),
+	}
+	for _, m := range helpOptionRe.FindAllStringSubmatch(string(help), -1) {
+		c.Options[m[1]] = true
+	}
+
+	capabilities.Lock()
+	if capabilities.probed == nil {
+		capabilities.probed = make(map[string]*CapabilityReport)
+	}
+	capabilities.probed[binPath] = c
+	capabilities.Unlock()
+	return c, nil
+}
+
+// Capabilities probes the wkhtmltopdf binary which is found like in NewPDFGenerator, see ProbeCapabilities
+func Capabilities(ctx context.Context) (*CapabilityReport, error) {
+	pdfg := NewPDFPreparer()
+	if err := pdfg.findPath(); err != nil {
+		return nil, err
+	}
+	return ProbeCapabilities(ctx, pdfg.binPath)
+}
+
+// ImageCapabilities probes the wkhtmltoimage binary which is found like in GenerateImage, see ProbeCapabilities
+func ImageCapabilities(ctx context.Context) (*CapabilityReport, error) {
+	findPath()
+	path := GetWKHTMLToImagePath()
+	if path == "" {
+		return nil, fmt.Errorf("wkhtmltoimage not found")
+	}
+	return ProbeCapabilities(ctx, path)
+}
+
+var arity struct {
+	values map[string]int
+	once   sync.Once
+}
+
+// optionArity returns the number of values of a long option, 0 for options which are not known
+func optionArity(option string) int {
+	arity.once.Do(func() {
+		// the options of wkhtmltoimage which are not options of wkhtmltopdf
+		arity.values = map[string]int{"format": 1, "height": 1, "width": 1, "quality": 1}
+		global, outline, page, hf, toc := newGlobalOptions(), newOutlineOptions(), newPageOptions(), newHeaderAndFooterOptions(), newTocOptions()
+		for _, o := range []interface{}{&global, &outline, &page, &hf, &toc} {
+			rv := reflect.ValueOf(o).Elem()
+			for i := 0; i < rv.NumField(); i++ {
+				name, _, _ := optionValues(rv.Field(i).Addr().Interface())
+				switch rv.Field(i).Interface().(type) {
+				case boolOption:
+					arity.values[name] = 0
+				case mapOption:
+					arity.values[name] = 2
+				default:
+					arity.values[name] = 1
+				}
+			}
+		}
+	})
+	return arity.values[option]
+}
+
+// UnsupportedOptionsError is returned in StrictOptions mode when the binary does not support options which are set
+type UnsupportedOptionsError struct {
+	Binary  string
+	Version string
+	Options []string // The unsupported options, e.g. "--header-html"
+}
+
+func (e *UnsupportedOptionsError) Error() string {
+	return fmt.Sprintf("%s not supported by %s (%s)", strings.Join(e.Options, ", "), e.Version, e.Binary)
+}
+
+// unsupportedWarning is the warning for an option which was removed from the arguments by filterArgs
+func (c *CapabilityReport) unsupportedWarning(option string) string {
+	return fmt.Sprintf("%s is not supported by %s and was not used", option, c.Version)
+}
+
+// supportedArgs leaves the options which the wkhtmltopdf binary at binPath does not support out of args,
+// or returns an UnsupportedOptionsError in StrictOptions mode. It returns the report of the binary and the options
+// which were left out, the report is nil when SetCapabilities was not called and StrictOptions is not set.
+func (pdfg *PDFGenerator) supportedArgs(ctx context.Context, binPath string, args []string) ([]string, []string, *CapabilityReport, error) {
+	caps := pdfg.caps
+	if caps == nil && pdfg.StrictOptions {
+		var err error
+		caps, err = ProbeCapabilities(ctx, binPath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if caps == nil {
+		return args, nil, nil, nil
+	}
+	args, unsupported := caps.filterArgs(args)
+	if pdfg.StrictOptions && len(unsupported) > 0 {
+		return nil, nil, nil, &UnsupportedOptionsError{Binary: caps.Binary, Version: caps.Version, Options: unsupported}
+	}
+	return args, unsupported, caps, nil
+}
+
+// addUnsupportedWarnings adds a warning for each option which was left out by supportedArgs
+func (pdfg *PDFGenerator) addUnsupportedWarnings(caps *CapabilityReport, unsupported []string) {
+	for _, option := range unsupported {
+		w := caps.unsupportedWarning(option)
+		pdfg.warnings = append(pdfg.warnings, w)
+		if pdfg.OnWarning != nil {
+			pdfg.OnWarning(w)
+		}
+	}
+}
+
+// filterArgs returns args without the options which the binary does not support, and the options which were removed
+func (c *CapabilityReport) filterArgs(args []string) (supported, unsupported []string) {
+	supported = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], opt) || args[i] == "-" {
+			supported = append(supported, args[i])
+			continue
+		}
+		name := strings.TrimPrefix(args[i], opt)
+		n := optionArity(name)
+		if i+n >= len(args) {
+			n = len(args) - 1 - i
+		}
+		if c.Supports(name) {
+			supported = append(supported, args[i:i+n+1]...)
+		} else {
+			unsupported = append(unsupported, args[i])
+		}
+		i += n
+	}
+	return supported, unsupported
+}
@@ -0,0 +1,154 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testExtendedHelp = `Name:
+  wkhtmltopdf 0.12.4
+
+Global Options:
+      --collate                       Collate when printing multiple copies
+  -q, --quiet                         Be less verbose
+      --title <text>                  The title of the generated pdf file
+
+Page Options:
+      --cookie <name> <value>         Set an additional cookie (repeatable)
+      --zoom <float>                  Use this zoom factor (default 1)
+`
+
+// testCapabilitiesBinary writes a binary which prints the version and testExtendedHelp, and the arguments of a render
+func testCapabilitiesBinary(t *testing.T) string {
+	f, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("#!/bin/sh\ncase \"$1\" in\n--version) echo 'wkhtmltopdf 0.12.4';;\n--extended-help) cat <<'EOF'\n" +
+		testExtendedHelp + "EOF\n;;\n*) echo \"$@\" >&2;;\nesac\n")
+	f.Close()
+	os.Chmod(f.Name(), 0700)
+	return f.Name()
+}
+
+func TestProbeCapabilities(t *testing.T) {
+	bin := testCapabilitiesBinary(t)
+	defer os.Remove(bin)
+
+	c, err := ProbeCapabilities(context.Background(), bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != "wkhtmltopdf 0.12.4" || c.PatchedQt {
+		t.Errorf("Want version wkhtmltopdf 0.12.4 without patched qt, have %q %t", c.Version, c.PatchedQt)
+	}
+	want := map[string]bool{"collate": true, "quiet": true, "title": true, "cookie": true, "zoom": true}
+	if !reflect.DeepEqual(c.Options, want) {
+		t.Errorf("Want options %v, have %v", want, c.Options)
+	}
+	if !c.Supports("--cookie") || c.Supports("enable-smart-width") {
+		t.Error("Want --cookie supported and enable-smart-width not supported")
+	}
+	if cached, _ := ProbeCapabilities(context.Background(), bin); cached != c {
+		t.Error("Want cached capabilities")
+	}
+}
+
+func TestFilterArgs(t *testing.T) {
+	c := &CapabilityReport{Version: "wkhtmltopdf 0.12.4", Options: map[string]bool{"quiet": true, "cookie": true, "zoom": true}}
+	args := []string{"--quiet", "--title", "Report", "page", "https://example.com", "--cookie", "a", "b", "--header-html", "h.html", "--zoom", "2", "--print-media-type", "-"}
+	supported, unsupported := c.filterArgs(args)
+	want := []string{"--quiet", "page", "https://example.com", "--cookie", "a", "b", "--zoom", "2", "-"}
+	if !reflect.DeepEqual(supported, want) {
+		t.Errorf("Want %q, have %q", want, supported)
+	}
+	if !reflect.DeepEqual(unsupported, []string{"--title", "--header-html", "--print-media-type"}) {
+		t.Errorf("Want unsupported --title --header-html --print-media-type, have %q", unsupported)
+	}
+}
+
+func TestSupportsPatchedQt(t *testing.T) {
+	options := map[string]bool{"header-html": true, "outline": true, "xsl-style-sheet": true, "print-media-type": true, "title": true}
+	c := &CapabilityReport{Version: "wkhtmltopdf 0.12.6", Options: options}
+	for _, option := range []string{"--header-html", "outline", "xsl-style-sheet", "print-media-type"} {
+		if c.Supports(option) {
+			t.Errorf("Want %s not supported without patched qt", option)
+		}
+	}
+	if !c.Supports("title") {
+		t.Error("Want --title supported without patched qt")
+	}
+	supported, unsupported := c.filterArgs([]string{"--header-html", "h.html", "--title", "Report", "-"})
+	if !reflect.DeepEqual(supported, []string{"--title", "Report", "-"}) || !reflect.DeepEqual(unsupported, []string{"--header-html"}) {
+		t.Errorf("Want --header-html left out, have %q and %q", supported, unsupported)
+	}
+	c.PatchedQt = true
+	if !c.Supports("--header-html") || !c.Supports("outline") {
+		t.Error("Want --header-html and --outline supported with patched qt")
+	}
+}
+
+func TestPDFGeneratorCapabilities(t *testing.T) {
+	bin := testCapabilitiesBinary(t)
+	defer os.Remove(bin)
+	c, err := ProbeCapabilities(context.Background(), bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin
+	pdfg.SetCapabilities(c)
+	pdfg.Title.Set("Report")
+	page := NewPage("https://example.com")
+	page.PrintMediaType.Set(true)
+	pdfg.AddPage(page)
+	var stderr strings.Builder
+	pdfg.SetErrorOutput(&stderr)
+	if err := pdfg.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if have := strings.TrimSpace(stderr.String()); have != "--title Report page https://example.com -" {
+		t.Errorf("Want --title Report page https://example.com -, have %s", have)
+	}
+	want := []string{"--print-media-type is not supported by wkhtmltopdf 0.12.4 and was not used"}
+	if !reflect.DeepEqual(pdfg.Warnings(), want) {
+		t.Errorf("Want %q, have %q", want, pdfg.Warnings())
+	}
+}
+
+func TestStrictOptions(t *testing.T) {
+	bin := testCapabilitiesBinary(t)
+	defer os.Remove(bin)
+
+	// the capabilities are probed when they are not set
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin
+	pdfg.StrictOptions = true
+	page := NewPage("https://example.com")
+	page.PrintMediaType.Set(true)
+	pdfg.AddPage(page)
+	var stderr strings.Builder
+	pdfg.SetErrorOutput(&stderr)
+	err := pdfg.Create()
+	uerr, ok := err.(*UnsupportedOptionsError)
+	if !ok {
+		t.Fatalf("Want *UnsupportedOptionsError, have %v", err)
+	}
+	if !reflect.DeepEqual(uerr.Options, []string{"--print-media-type"}) || uerr.Version != "wkhtmltopdf 0.12.4" {
+		t.Errorf("Want --print-media-type unsupported by wkhtmltopdf 0.12.4, have %q %q", uerr.Options, uerr.Version)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Want binary not run, have %s", stderr.String())
+	}
+
+	options := &ImageOptions{BinaryPath: bin, Input: "https://example.com", Format: "png", StrictOptions: true}
+	_, err = RenderImage(context.Background(), options)
+	if _, ok := err.(*UnsupportedOptionsError); !ok {
+		t.Errorf("Want *UnsupportedOptionsError, have %v", err)
+	}
+}
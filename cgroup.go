@@ -0,0 +1,25 @@
+package wkhtmltopdf
+
+var cgroupPath stringStore
+
+// SetCgroup sets the directory of a cgroup v2 group, for example /sys/fs/cgroup/render, which the wkhtmltopdf,
+// wkhtmltoimage, qpdf and Ghostscript processes are started in. The memory and CPU limits of the group then cap the
+// render load independently of the Go program in the same container. Built with Go 1.20 or later the processes are
+// created in the group with clone3, which needs Linux 5.7, so they never run outside of it. Older Go versions move
+// the processes into the group right after they are started.
+// The program needs write access to cgroup.procs of the group, an empty path stops starting processes in it.
+// An error is returned if dir is not a cgroup v2 group, and on systems other than Linux.
+func SetCgroup(dir string) error {
+	if dir != "" {
+		if err := checkCgroup(dir); err != nil {
+			return err
+		}
+	}
+	cgroupPath.Set(dir)
+	return nil
+}
+
+// GetCgroup returns the cgroup set with SetCgroup
+func GetCgroup() string {
+	return cgroupPath.Get()
+}
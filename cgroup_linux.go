@@ -0,0 +1,17 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// checkCgroup returns an error if dir is not a cgroup v2 group, which has cgroup.controllers and cgroup.procs files
+func checkCgroup(dir string) error {
+	for _, name := range []string{"cgroup.controllers", "cgroup.procs"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("%s is not a cgroup v2 group: %s", dir, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package wkhtmltopdf
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// checkCgroup returns an error, cgroups are only supported on Linux
+func checkCgroup(dir string) error {
+	return errors.New("cgroups are only supported on Linux")
+}
+
+// startInCgroup does nothing on this system
+func startInCgroup(cmd *exec.Cmd) (func() error, error) {
+	return func() error { return nil }, nil
+}
@@ -0,0 +1,30 @@
+//go:build go1.20
+// +build go1.20
+
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// startInCgroup makes cmd start in the cgroup set with SetCgroup with SysProcAttr.CgroupFD, so the process is created
+// in the group and never runs outside of it. The returned function closes the group and is called after cmd.Start
+func startInCgroup(cmd *exec.Cmd) (func() error, error) {
+	dir := GetCgroup()
+	if dir == "" {
+		return func() error { return nil }, nil
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error opening cgroup: %s", err)
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(f.Fd())
+	return f.Close, nil
+}
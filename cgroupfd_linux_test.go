@@ -0,0 +1,57 @@
+//go:build go1.20
+// +build go1.20
+
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestSetCgroup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetCgroup("")
+
+	if err := SetCgroup(dir); err == nil {
+		t.Error("Want error for a directory which is not a cgroup")
+	}
+	// a directory with the files of a cgroup v2 group, the pids are appended to cgroup.procs
+	for _, name := range []string{"cgroup.controllers", "cgroup.procs"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetCgroup(dir); err != nil {
+		t.Fatal(err)
+	}
+	// the process is created in the group through its file descriptor, a directory which is not a cgroup is an error
+	// of clone3, so the process is not started
+	cmd := exec.Command("true")
+	closeCgroup, err := startInCgroup(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeCgroup()
+	if attr := cmd.SysProcAttr; attr == nil || !attr.UseCgroupFD {
+		t.Fatalf("Want UseCgroupFD set, have %+v", attr)
+	}
+	if link, _ := os.Readlink("/proc/self/fd/" + strconv.Itoa(cmd.SysProcAttr.CgroupFD)); link != dir {
+		t.Errorf("Want the descriptor of %s, have %s", dir, link)
+	}
+	cmd = exec.Command("true")
+	if err := runCommand(context.Background(), cmd, nil); err == nil {
+		t.Error("Want error when the process can not be created in the cgroup")
+	}
+	if cmd.Process != nil {
+		t.Error("Want the process not started")
+	}
+}
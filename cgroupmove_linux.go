@@ -0,0 +1,35 @@
+//go:build !go1.20
+// +build !go1.20
+
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// startInCgroup returns a function which moves the started process into the cgroup set with SetCgroup by writing its
+// pid to cgroup.procs. Go before 1.20 can not create the process in the group, so it runs outside of it for a moment
+func startInCgroup(cmd *exec.Cmd) (func() error, error) {
+	dir := GetCgroup()
+	return func() error {
+		if dir == "" || cmd.Process == nil {
+			return nil
+		}
+		f, err := os.OpenFile(filepath.Join(dir, "cgroup.procs"), os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return fmt.Errorf("error moving process to cgroup: %s", err)
+		}
+		_, err = f.WriteString(strconv.Itoa(cmd.Process.Pid) + "\n")
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("error moving process to cgroup: %s", err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,50 @@
+//go:build !go1.20
+// +build !go1.20
+
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestSetCgroup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetCgroup("")
+
+	if err := SetCgroup(dir); err == nil {
+		t.Error("Want error for a directory which is not a cgroup")
+	}
+	// a directory with the files of a cgroup v2 group, the pids are appended to cgroup.procs
+	for _, name := range []string{"cgroup.controllers", "cgroup.procs"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetCgroup(dir); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("true")
+	if err := runCommand(context.Background(), cmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	procs, _ := ioutil.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if want := strconv.Itoa(cmd.Process.Pid) + "\n"; string(procs) != want {
+		t.Errorf("Want %q in cgroup.procs, have %q", want, procs)
+	}
+
+	// the process is killed when it can not be moved
+	os.Remove(filepath.Join(dir, "cgroup.procs"))
+	if err := runCommand(context.Background(), exec.Command("sleep", "10"), nil); err == nil {
+		t.Error("Want error when cgroup.procs can not be written")
+	}
+}
@@ -0,0 +1,239 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ColorProfileSRGB is the built-in sRGB color profile for ImageOptions.ColorProfile and PDFGenerator.ColorProfile
+const ColorProfileSRGB = "srgb"
+
+// srgbDescription is the description of the built-in sRGB profile
+const srgbDescription = "sRGB IEC61966-2.1"
+
+var srgb struct {
+	once    sync.Once
+	profile []byte
+}
+
+// srgbProfile returns the built-in ICC v2 sRGB profile, with the D50 adapted sRGB primaries and a tone curve table
+func srgbProfile() []byte {
+	srgb.once.Do(func() {
+		// tone curve of sRGB with 1024 entries, shared by the red, green and blue channels
+		curve := &bytes.Buffer{}
+		curve.WriteString("curv\x00\x00\x00\x00")
+		binary.Write(curve, binary.BigEndian, uint32(1024))
+		for i := 0; i < 1024; i++ {
+			v := float64(i) / 1023
+			if v <= 0.04045 {
+				v /= 12.92
+			} else {
+				v = math.Pow((v+0.055)/1.055, 2.4)
+			}
+			binary.Write(curve, binary.BigEndian, uint16(math.Floor(v*65535+0.5)))
+		}
+		desc := &bytes.Buffer{}
+		desc.WriteString("desc\x00\x00\x00\x00")
+		binary.Write(desc, binary.BigEndian, uint32(len(srgbDescription)+1))
+		desc.WriteString(srgbDescription + "\x00")
+		// no Unicode and ScriptCode descriptions
+		desc.Write(make([]byte, 4+4+2+1+67))
+		tags := []struct {
+			sig  string
+			data []byte
+		}{
+			{"desc", desc.Bytes()},
+			{"cprt", []byte("text\x00\x00\x00\x00No copyright, use freely\x00")},
+			{"wtpt", iccXYZ(0.9642, 1, 0.8249)},
+			{"rXYZ", iccXYZ(0.4360747, 0.2225045, 0.0139322)},
+			{"gXYZ", iccXYZ(0.3850649, 0.7168786, 0.0971045)},
+			{"bXYZ", iccXYZ(0.1430804, 0.0606169, 0.7141733)},
+			{"rTRC", curve.Bytes()},
+			{"gTRC", curve.Bytes()},
+			{"bTRC", curve.Bytes()},
+		}
+
+		header := make([]byte, 128)
+		binary.BigEndian.PutUint32(header[8:], 0x02100000) // version 2.1
+		copy(header[12:], "mntrRGB XYZ ")
+		copy(header[36:], "acsp")
+		copy(header[68:], iccXYZ(0.9642, 1, 0.8249)[8:]) // D50 illuminant of the profile connection space
+		table := &bytes.Buffer{}
+		binary.Write(table, binary.BigEndian, uint32(len(tags)))
+		data := &bytes.Buffer{}
+		offset := len(header) + 4 + 12*len(tags)
+		offsets := make(map[string]int)
+		for _, tag := range tags {
+			key := string(tag.data)
+			o, ok := offsets[key]
+			if !ok {
+				o = offset + data.Len()
+				offsets[key] = o
+				data.Write(tag.data)
+				// tags start at 4 byte boundaries
+				for data.Len()%4 != 0 {
+					data.WriteByte(0)
+				}
+			}
+			table.WriteString(tag.sig)
+			binary.Write(table, binary.BigEndian, uint32(o))
+			binary.Write(table, binary.BigEndian, uint32(len(tag.data)))
+		}
+		profile := append(append(header, table.Bytes()...), data.Bytes()...)
+		binary.BigEndian.PutUint32(profile, uint32(len(profile)))
+		srgb.profile = profile
+	})
+	return srgb.profile
+}
+
+// iccXYZ returns an XYZ tag with the values as s15Fixed16 numbers
+func iccXYZ(x, y, z float64) []byte {
+	b := []byte("XYZ \x00\x00\x00\x00")
+	for _, v := range []float64{x, y, z} {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(int32(math.Floor(v*65536+0.5))))
+		b = append(b, n[:]...)
+	}
+	return b
+}
+
+// loadColorProfile returns the ICC profile with name, ColorProfileSRGB or the path to an RGB profile, relative paths are
+// resolved from dir, and the description which is used as the name of the profile in images and PDFs
+func loadColorProfile(name, dir string) ([]byte, string, error) {
+	if name == ColorProfileSRGB {
+		return srgbProfile(), srgbDescription, nil
+	}
+	path := name
+	if dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	profile, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading color profile: %s", err)
+	}
+	if len(profile) < 132 || string(profile[36:40]) != "acsp" {
+		return nil, "", fmt.Errorf("color profile %s is not an ICC profile", name)
+	}
+	if string(profile[16:20]) != "RGB " {
+		return nil, "", fmt.Errorf("color profile %s is not an RGB profile", name)
+	}
+	return profile, strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)), nil
+}
+
+// embedColorProfile embeds the ICC profile in png and jpg images, replacing the color profile which they have.
+// Images of other formats are not changed.
+func embedColorProfile(img []byte, format string, profile []byte, name string) ([]byte, error) {
+	switch format {
+	case "png":
+		// the iCCP chunk has the name of the profile, which is at most 79 latin-1 characters, and the compressed profile
+		if len(name) > 79 {
+			name = name[:79]
+		}
+		data := &bytes.Buffer{}
+		data.WriteString(name)
+		data.Write([]byte{0, 0}) // null separator and zlib compression
+		w := zlib.NewWriter(data)
+		w.Write(profile)
+		w.Close()
+		// a png with an iCCP chunk must not have an sRGB chunk
+		return filterPNGChunks(img, func(chunkType string) bool { return chunkType != "iCCP" && chunkType != "sRGB" }, "iCCP", data.Bytes())
+	case "jpg":
+		return embedJPEGColorProfile(img, profile)
+	}
+	return img, nil
+}
+
+// iccSegmentSize is the maximum size of the profile data in one APP2 segment of a jpg
+const iccSegmentSize = 65535 - 2 - 14
+
+// embedJPEGColorProfile replaces the ICC profile of the jpg, which is stored in APP2 segments after the JFIF segment
+func embedJPEGColorProfile(img, profile []byte) ([]byte, error) {
+	if len(img) < 4 || img[0] != 0xff || img[1] != 0xd8 {
+		return nil, errors.New("invalid JPEG")
+	}
+	count := (len(profile) + iccSegmentSize - 1) / iccSegmentSize
+	if count > 255 {
+		return nil, errors.New("color profile is too large for a JPEG")
+	}
+	var segments []byte
+	for i := 0; i < count; i++ {
+		chunk := profile[i*iccSegmentSize:]
+		if len(chunk) > iccSegmentSize {
+			chunk = chunk[:iccSegmentSize]
+		}
+		n := len(chunk) + 2 + 14
+		segments = append(segments, 0xff, 0xe2, byte(n>>8), byte(n))
+		segments = append(segments, "ICC_PROFILE\x00"...)
+		segments = append(segments, byte(i+1), byte(count))
+		segments = append(segments, chunk...)
+	}
+
+	out := make([]byte, 0, len(img)+len(segments))
+	out = append(out, img[:2]...)
+	inserted := false
+	p := img[2:]
+	for {
+		if len(p) < 4 || p[0] != 0xff {
+			return nil, errors.New("invalid JPEG segment")
+		}
+		marker := p[1]
+		if !inserted && marker != 0xe0 {
+			out = append(out, segments...)
+			inserted = true
+		}
+		// the entropy coded data after the start of scan is copied as is
+		if marker == 0xda {
+			return append(out, p...), nil
+		}
+		n := int(p[2])<<8 | int(p[3])
+		if n < 2 || len(p) < n+2 {
+			return nil, errors.New("invalid JPEG segment")
+		}
+		if marker != 0xe2 || !bytes.HasPrefix(p[4:n+2], []byte("ICC_PROFILE\x00")) {
+			out = append(out, p[:n+2]...)
+		}
+		p = p[n+2:]
+	}
+}
+
+// addOutputIntent adds an output intent with the ICC profile to the PDF with an incremental update, so printers and
+// viewers know the color space of the colors in the PDF. A PDF which has an output intent, such as a PDF/A, is not changed.
+func addOutputIntent(pdf, profile []byte, name string) ([]byte, error) {
+	u, err := newPDFUpdate(pdf)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := u.catalog()
+	if err != nil {
+		return nil, err
+	}
+	if catalog.get("/OutputIntents") != "" {
+		return pdf, nil
+	}
+	compressed := &bytes.Buffer{}
+	w := zlib.NewWriter(compressed)
+	w.Write(profile)
+	w.Close()
+	icc := &pdfDict{}
+	icc.set("/N", "3")
+	icc.set("/Filter", "/FlateDecode")
+	ref := u.add(icc, compressed.Bytes())
+
+	intent := &pdfDict{}
+	intent.set("/Type", "/OutputIntent")
+	intent.set("/S", "/GTS_PDFA1")
+	intent.set("/OutputConditionIdentifier", pdfString(name))
+	intent.set("/Info", pdfString(name))
+	intent.set("/DestOutputProfile", ref.String())
+	catalog.set("/OutputIntents", "["+intent.String()+"]")
+	u.set(u.root, catalog, nil)
+	return u.bytes(), nil
+}
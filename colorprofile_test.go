@@ -0,0 +1,222 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSRGBProfile(t *testing.T) {
+	profile := srgbProfile()
+	if size := binary.BigEndian.Uint32(profile); int(size) != len(profile) {
+		t.Errorf("Want size %d in the header, have %d", len(profile), size)
+	}
+	if string(profile[36:40]) != "acsp" || string(profile[12:24]) != "mntrRGB XYZ " {
+		t.Errorf("Want RGB display profile, have % x", profile[12:40])
+	}
+	count := int(binary.BigEndian.Uint32(profile[128:]))
+	tags := make(map[string]bool)
+	for i := 0; i < count; i++ {
+		entry := profile[132+12*i:]
+		offset, size := binary.BigEndian.Uint32(entry[4:]), binary.BigEndian.Uint32(entry[8:])
+		if offset%4 != 0 || int(offset+size) > len(profile) {
+			t.Errorf("Want aligned tag %s in the profile, have offset %d size %d", entry[:4], offset, size)
+		}
+		tags[string(entry[:4])] = true
+	}
+	for _, tag := range []string{"desc", "cprt", "wtpt", "rXYZ", "gXYZ", "bXYZ", "rTRC", "gTRC", "bTRC"} {
+		if !tags[tag] {
+			t.Errorf("Want tag %s", tag)
+		}
+	}
+}
+
+func TestLoadColorProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "display.icc"), srgbProfile(), 0600)
+	cmyk := append([]byte(nil), srgbProfile()...)
+	copy(cmyk[16:], "CMYK")
+	ioutil.WriteFile(filepath.Join(dir, "print.icc"), cmyk, 0600)
+	ioutil.WriteFile(filepath.Join(dir, "profile.txt"), []byte("not a profile"), 0600)
+
+	profile, name, err := loadColorProfile("display.icc", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "display" || !bytes.Equal(profile, srgbProfile()) {
+		t.Errorf("Want the profile named display, have %s", name)
+	}
+	if _, _, err := loadColorProfile("print.icc", dir); err == nil || !strings.Contains(err.Error(), "not an RGB profile") {
+		t.Errorf("Want error for CMYK profile, have %v", err)
+	}
+	if _, _, err := loadColorProfile("profile.txt", dir); err == nil || !strings.Contains(err.Error(), "not an ICC profile") {
+		t.Errorf("Want error for invalid profile, have %v", err)
+	}
+	if _, _, err := loadColorProfile("missing.icc", dir); err == nil {
+		t.Error("Want error for missing profile")
+	}
+}
+
+func TestEmbedColorProfilePNG(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 16, 8)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := replacePNGChunks(buf.Bytes(), "sRGB", []byte{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := embedColorProfile(src, "png", srgbProfile(), srgbDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// embedding it again replaces the iCCP chunk
+	img, err = embedColorProfile(img, "png", srgbProfile(), srgbDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(img, []byte("iCCP")); n != 1 {
+		t.Fatalf("Want 1 iCCP chunk, have %d", n)
+	}
+	if bytes.Contains(img, []byte("sRGB\x00")) {
+		t.Error("Want sRGB chunk removed")
+	}
+	i := bytes.Index(img, []byte("iCCP"))
+	size := int(binary.BigEndian.Uint32(img[i-4:]))
+	data := img[i+4 : i+4+size]
+	if !bytes.HasPrefix(data, []byte(srgbDescription+"\x00\x00")) {
+		t.Fatalf("Want profile name and compression method, have %q", data[:20])
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data[len(srgbDescription)+2:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, _ := ioutil.ReadAll(r)
+	if !bytes.Equal(profile, srgbProfile()) {
+		t.Error("Want the profile in the iCCP chunk")
+	}
+	if _, err := png.Decode(bytes.NewReader(img)); err != nil {
+		t.Errorf("Want valid png, have %s", err)
+	}
+}
+
+func TestEmbedColorProfileJPEG(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := jpeg.Encode(buf, image.NewRGBA(image.Rect(0, 0, 16, 8)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := setDPI(buf.Bytes(), "jpg", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a profile which needs two segments
+	large := make([]byte, iccSegmentSize+100)
+	copy(large, srgbProfile())
+	img, err := embedColorProfile(src, "jpg", large, "large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(img, []byte("ICC_PROFILE\x00")); n != 2 {
+		t.Fatalf("Want 2 ICC segments, have %d", n)
+	}
+	img, err = embedColorProfile(img, "jpg", srgbProfile(), srgbDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(img, []byte("ICC_PROFILE\x00")); n != 1 {
+		t.Fatalf("Want 1 ICC segment, have %d", n)
+	}
+	// the ICC segment follows the JFIF segment
+	jfif := 4 + int(binary.BigEndian.Uint16(img[4:]))
+	if img[jfif] != 0xff || img[jfif+1] != 0xe2 || string(img[jfif+4:jfif+16]) != "ICC_PROFILE\x00" {
+		t.Fatalf("Want ICC segment after JFIF, have % x", img[jfif:jfif+16])
+	}
+	if img[jfif+16] != 1 || img[jfif+17] != 1 || !bytes.Equal(img[jfif+18:jfif+18+len(srgbProfile())], srgbProfile()) {
+		t.Error("Want the profile in segment 1 of 1")
+	}
+	if img[13] != 1 || binary.BigEndian.Uint16(img[14:]) != 300 {
+		t.Error("Want the DPI kept")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(img)); err != nil {
+		t.Errorf("Want valid jpg, have %s", err)
+	}
+
+	if _, err := embedColorProfile([]byte("not a jpg"), "jpg", srgbProfile(), srgbDescription); err == nil {
+		t.Error("Want error for invalid jpg")
+	}
+	gif := []byte("GIF89a")
+	if img, err := embedColorProfile(gif, "gif", srgbProfile(), srgbDescription); err != nil || !bytes.Equal(img, gif) {
+		t.Errorf("Want gif unchanged, have %v", err)
+	}
+}
+
+func TestAddOutputIntent(t *testing.T) {
+	pdf := testPDF()
+	updated, err := addOutputIntent(pdf, srgbProfile(), srgbDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(updated, pdf) {
+		t.Error("Want an incremental update which keeps the PDF")
+	}
+	catalog := testPDFObject(t, updated, 2)
+	intents := catalog.get("/OutputIntents")
+	for _, want := range []string{"/Type /OutputIntent", "/S /GTS_PDFA1", "(sRGB IEC61966-2.1)", "/DestOutputProfile 6 0 R"} {
+		if !strings.Contains(intents, want) {
+			t.Errorf("Want %s in the output intents, have %s", want, intents)
+		}
+	}
+	if catalog.get("/Pages") != "3 0 R" {
+		t.Errorf("Want the catalog entries kept, have %s", catalog)
+	}
+	if icc := testPDFObject(t, updated, 6); icc.get("/N") != "3" || icc.get("/Filter") != "/FlateDecode" {
+		t.Errorf("Want compressed RGB ICC stream, have %s", icc)
+	}
+
+	// a PDF with an output intent is not changed
+	again, err := addOutputIntent(updated, srgbProfile(), srgbDescription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, updated) {
+		t.Error("Want PDF with an output intent unchanged")
+	}
+}
+
+func TestImageColorProfile(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := &ImageResult{Image: buf.Bytes()}
+	options := &ImageOptions{ColorProfile: ColorProfileSRGB, OutputFormats: []string{"png", "jpg"}}
+	if !options.postProcessing() {
+		t.Fatal("Want post processing for ColorProfile")
+	}
+	if err := postProcessImage(res, options); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(res.Image, []byte("iCCP")) || !bytes.Contains(res.Images["jpg"], []byte("ICC_PROFILE\x00")) {
+		t.Error("Want the profile in the png and the jpg")
+	}
+
+	options.ColorProfile = "missing.icc"
+	if err := postProcessImage(&ImageResult{Image: buf.Bytes()}, options); err == nil {
+		t.Error("Want error for missing profile")
+	}
+}
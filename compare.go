@@ -0,0 +1,92 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// diffTolerance is the difference per color channel (0-255) below which pixels are the same,
+// so small differences in anti-aliasing and jpg compression are not reported
+const diffTolerance = 16
+
+// RenderDiff is the difference between two rendered images, see CompareRenders
+type RenderDiff struct {
+	Score  float64 // Fraction of the pixels which are different, from 0 for the same images to 1
+	Pixels int     // Number of pixels which are different
+	Image  []byte  // png of the second image with the different pixels in red and the other pixels faded
+}
+
+// CompareRenders compares two png, jpg or gif images, for example screenshots of a page before and after a change,
+// and returns how much they differ with an image which highlights the differences.
+// Pixels which are only in one of the images, when they have a different size, are different.
+func CompareRenders(a, b []byte) (*RenderDiff, error) {
+	imgA, _, err := image.Decode(bytes.NewReader(a))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding first image: %s", err)
+	}
+	imgB, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding second image: %s", err)
+	}
+
+	ba, bb := imgA.Bounds(), imgB.Bounds()
+	width, height := ba.Dx(), ba.Dy()
+	if bb.Dx() > width {
+		width = bb.Dx()
+	}
+	if bb.Dy() > height {
+		height = bb.Dy()
+	}
+
+	diff := &RenderDiff{}
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	highlight := color.NRGBA{255, 0, 0, 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pa := image.Pt(ba.Min.X+x, ba.Min.Y+y)
+			pb := image.Pt(bb.Min.X+x, bb.Min.Y+y)
+			inA, inB := pa.In(ba), pb.In(bb)
+			if !inA || !inB || !similarColors(imgA.At(pa.X, pa.Y), imgB.At(pb.X, pb.Y)) {
+				diff.Pixels++
+				out.SetNRGBA(x, y, highlight)
+				continue
+			}
+			out.SetNRGBA(x, y, fade(imgB.At(pb.X, pb.Y)))
+		}
+	}
+	if width > 0 && height > 0 {
+		diff.Score = float64(diff.Pixels) / float64(width*height)
+	}
+
+	buf := &bytes.Buffer{}
+	err = png.Encode(buf, out)
+	if err != nil {
+		return nil, err
+	}
+	diff.Image = buf.Bytes()
+	return diff, nil
+}
+
+// similarColors returns true if no color channel differs more than diffTolerance
+func similarColors(a, b color.Color) bool {
+	ca := color.NRGBAModel.Convert(a).(color.NRGBA)
+	cb := color.NRGBAModel.Convert(b).(color.NRGBA)
+	return absDiff(ca.R, cb.R) <= diffTolerance && absDiff(ca.G, cb.G) <= diffTolerance &&
+		absDiff(ca.B, cb.B) <= diffTolerance && absDiff(ca.A, cb.A) <= diffTolerance
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// fade returns the color mixed with white, so the highlighted differences stand out
+func fade(c color.Color) color.NRGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return color.NRGBA{uint8(192 + uint16(n.R)/4), uint8(192 + uint16(n.G)/4), uint8(192 + uint16(n.B)/4), 255}
+}
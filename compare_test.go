@@ -0,0 +1,59 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodeTestPage(t *testing.T, img image.Image) []byte {
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompareRenders(t *testing.T) {
+	a := encodeTestPage(t, testPage(200, 100, 20))
+	diff, err := CompareRenders(a, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Pixels != 0 || diff.Score != 0 {
+		t.Errorf("Want no difference for the same image, have %d pixels", diff.Pixels)
+	}
+
+	// the box of 50x49 pixels moved 100 pixels to the right
+	diff, err = CompareRenders(a, encodeTestPage(t, testPage(200, 100, 120)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Pixels != 2*50*49 {
+		t.Errorf("Want %d different pixels, have %d", 2*50*49, diff.Pixels)
+	}
+	img, err := png.Decode(bytes.NewReader(diff.Image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := color.NRGBAModel.Convert(img.At(130, 80)).(color.NRGBA); c != (color.NRGBA{255, 0, 0, 255}) {
+		t.Errorf("Want highlighted difference, have %v", c)
+	}
+
+	// the extra rows of a longer page are different
+	diff, err = CompareRenders(a, encodeTestPage(t, testPage(200, 110, 20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Pixels < 200*10 {
+		t.Errorf("Want at least %d different pixels, have %d", 200*10, diff.Pixels)
+	}
+
+	_, err = CompareRenders(a, []byte("not an image"))
+	if err == nil {
+		t.Error("Want error for invalid image")
+	}
+}
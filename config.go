@@ -0,0 +1,295 @@
+package wkhtmltopdf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the default settings of a service which creates PDF documents and images,
+// so they can be configured from a file with LoadConfig instead of in code.
+// Settings which are not set keep the defaults of this package.
+type Config struct {
+	WKHTMLToPDFPath   string `json:"wkhtmltopdf_path"`   // Path to wkhtmltopdf, see SetPath
+	WKHTMLToImagePath string `json:"wkhtmltoimage_path"` // Path to wkhtmltoimage
+	QPDFPath          string `json:"qpdf_path"`          // Path to qpdf, see SetQPDFPath
+	GhostscriptPath   string `json:"ghostscript_path"`   // Path to Ghostscript, see SetGhostscriptPath
+
+	ImageFormat  string `json:"image_format"`  // Format of images when ImageOptions.Format is not set (default png)
+	ImageQuality int    `json:"image_quality"` // Quality of images when ImageOptions.Quality is not set (default 94)
+
+	Timeout          Duration `json:"timeout"`            // Maximum duration of each render, see Limiter.Timeout
+	Concurrency      int      `json:"concurrency"`        // Number of jobs a Worker renders at the same time
+	RendersPerSecond float64  `json:"renders_per_second"` // See Limiter.RendersPerSecond
+	MaxPerTenant     int      `json:"max_per_tenant"`     // See Limiter.MaxPerTenant
+	MaxFailures      int      `json:"max_failures"`       // See Limiter.MaxFailures
+	BreakDuration    Duration `json:"break_duration"`     // See Limiter.BreakDuration
+	ResultRetention  Duration `json:"result_retention"`   // See Worker.Retention
+
+	MaxInputBytes int64 `json:"max_input_bytes"` // See InputLimits.MaxInputBytes
+	MaxURLLength  int   `json:"max_url_length"`  // See InputLimits.MaxURLLength
+	ValidateUTF8  bool  `json:"validate_utf8"`   // See InputLimits.ValidateUTF8
+}
+
+// Duration is a time.Duration which is written as a string like "30s" or "1m30s" in a config file
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, a number is a number of seconds
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) != nil {
+		// not a string, try seconds
+		var sec float64
+		err := json.Unmarshal(b, &sec)
+		if err != nil {
+			return fmt.Errorf("invalid duration %s", b)
+		}
+		*d = Duration(sec * float64(time.Second))
+		return nil
+	}
+	td, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(td)
+	return nil
+}
+
+// LoadConfig reads a config file in JSON, or in YAML when the file name ends with .yaml or .yml.
+// The YAML file contains one setting per line, e.g.
+//
+//	wkhtmltopdf_path: /usr/local/bin/wkhtmltopdf
+//	timeout: 30s
+//
+// Each setting can be overridden with an environment variable WKHTML_ followed by the setting in upper case,
+// for example WKHTML_TIMEOUT=1m. Unknown settings are an error.
+// Call Apply to use the paths and image format, and NewLimiter or NewWorker to use the limits.
+func LoadConfig(path string) (*Config, error) {
+	c, _, err := loadConfig(path)
+	return c, err
+}
+
+// loadConfig reads a config file like LoadConfig and also returns the names of the settings which are set
+// in the file or environment, so a setting which is set to 0 can be told apart from one which is not set
+func loadConfig(path string) (*Config, map[string]bool, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := new(Config)
+	values := make(map[string]string)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		values, err = parseYAMLConfig(b)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading config %s: %s", path, err)
+		}
+		err = c.set(values)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading config %s: %s", path, err)
+		}
+	default:
+		err = decodeJSON(b, c, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading config %s: %s", path, err)
+		}
+		var raw map[string]json.RawMessage
+		err = json.Unmarshal(b, &raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading config %s: %s", path, err)
+		}
+		for k := range raw {
+			values[k] = ""
+		}
+	}
+
+	env := configEnv()
+	err = c.set(env)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading config from environment: %s", err)
+	}
+
+	set := make(map[string]bool)
+	for k := range values {
+		set[k] = true
+	}
+	for k := range env {
+		set[k] = true
+	}
+	return c, set, nil
+}
+
+// ConfigFromEnv reads the config from environment variables only, for deployments which are configured
+// with the environment instead of a file. The variables are WKHTML_ followed by the setting of LoadConfig in upper case:
+//
+//	WKHTML_WKHTMLTOPDF_PATH, WKHTML_WKHTMLTOIMAGE_PATH, WKHTML_QPDF_PATH, WKHTML_GHOSTSCRIPT_PATH,
+//	WKHTML_IMAGE_FORMAT, WKHTML_IMAGE_QUALITY, WKHTML_TIMEOUT, WKHTML_CONCURRENCY,
+//	WKHTML_RENDERS_PER_SECOND, WKHTML_MAX_PER_TENANT, WKHTML_MAX_FAILURES, WKHTML_BREAK_DURATION, WKHTML_RESULT_RETENTION,
+//	WKHTML_MAX_INPUT_BYTES, WKHTML_MAX_URL_LENGTH and WKHTML_VALIDATE_UTF8
+func ConfigFromEnv() (*Config, error) {
+	c := new(Config)
+	err := c.set(configEnv())
+	if err != nil {
+		return nil, fmt.Errorf("error reading config from environment: %s", err)
+	}
+	return c, nil
+}
+
+// Apply sets the paths of the binaries, the default image format and the input limits for the whole package
+func (c *Config) Apply() {
+	if c.WKHTMLToPDFPath != "" {
+		SetPath(c.WKHTMLToPDFPath)
+	}
+	if c.WKHTMLToImagePath != "" {
+		binImagePath.Set(c.WKHTMLToImagePath)
+	}
+	if c.QPDFPath != "" {
+		SetQPDFPath(c.QPDFPath)
+	}
+	if c.GhostscriptPath != "" {
+		SetGhostscriptPath(c.GhostscriptPath)
+	}
+	imageDefaults.Lock()
+	if c.ImageFormat != "" {
+		imageDefaults.options.Format = c.ImageFormat
+	}
+	if c.ImageQuality != 0 {
+		imageDefaults.options.Quality = c.ImageQuality
+	}
+	imageDefaults.Unlock()
+	inputLimits.Lock()
+	if c.MaxInputBytes != 0 {
+		inputLimits.limits.MaxInputBytes = c.MaxInputBytes
+	}
+	if c.MaxURLLength != 0 {
+		inputLimits.limits.MaxURLLength = c.MaxURLLength
+	}
+	if c.ValidateUTF8 {
+		inputLimits.limits.ValidateUTF8 = true
+	}
+	inputLimits.Unlock()
+}
+
+// NewLimiter returns a Limiter with the timeout, rate, tenant and circuit breaker settings of the config
+func (c *Config) NewLimiter() *Limiter {
+	return &Limiter{
+		Timeout:          time.Duration(c.Timeout),
+		RendersPerSecond: c.RendersPerSecond,
+		MaxPerTenant:     c.MaxPerTenant,
+		MaxFailures:      c.MaxFailures,
+		BreakDuration:    time.Duration(c.BreakDuration),
+	}
+}
+
+// NewWorker returns a Worker with the concurrency and result retention of the config and a Limiter created with NewLimiter
+func (c *Config) NewWorker(consumer Consumer, publisher Publisher) *Worker {
+	return &Worker{
+		Consumer:    consumer,
+		Publisher:   publisher,
+		Limiter:     c.NewLimiter(),
+		Concurrency: c.Concurrency,
+		Retention:   time.Duration(c.ResultRetention),
+	}
+}
+
+// set sets the settings in values by their JSON name, values are JSON values or strings without quotes
+func (c *Config) set(values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString("{")
+	i := 0
+	for k, v := range values {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		i++
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteString(":")
+		if configStringSetting(k) || !json.Valid([]byte(v)) {
+			vb, _ := json.Marshal(v)
+			v = string(vb)
+		}
+		buf.WriteString(v)
+	}
+	buf.WriteString("}")
+
+	return decodeJSON(buf.Bytes(), c, true)
+}
+
+// configStringSetting returns true if the setting is a string, so its value is never a JSON number or boolean
+func configStringSetting(name string) bool {
+	rt := reflect.TypeOf(Config{})
+	for i := 0; i < rt.NumField(); i++ {
+		if rt.Field(i).Tag.Get("json") == name {
+			return rt.Field(i).Type.Kind() == reflect.String
+		}
+	}
+	return false
+}
+
+// configEnv returns the settings which are set with a WKHTML_ environment variable
+func configEnv() map[string]string {
+	values := make(map[string]string)
+	rt := reflect.TypeOf(Config{})
+	for i := 0; i < rt.NumField(); i++ {
+		name := rt.Field(i).Tag.Get("json")
+		v, ok := os.LookupEnv("WKHTML_" + strings.ToUpper(name))
+		if ok {
+			values[name] = v
+		}
+	}
+	return values
+}
+
+// parseYAMLConfig parses a YAML file with "name: value" lines, comments start with #
+func parseYAMLConfig(b []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(b))
+	line := 0
+	for s.Scan() {
+		line++
+		l := strings.TrimSpace(s.Text())
+		if l == "" || l == "---" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		i := strings.Index(l, ":")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: want name: value", line)
+		}
+		name := strings.TrimSpace(l[:i])
+		value := strings.TrimSpace(l[i+1:])
+		switch {
+		case strings.HasPrefix(value, `"`):
+			uq, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err)
+			}
+			value = uq
+		case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) > 1:
+			value = strings.Replace(value[1:len(value)-1], "''", "'", -1)
+		default:
+			if c := strings.Index(value, " #"); c >= 0 {
+				value = strings.TrimSpace(value[:c])
+			}
+		}
+		values[name] = value
+	}
+	return values, s.Err()
+}
@@ -0,0 +1,139 @@
+package wkhtmltopdf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestConfig(t *testing.T, name, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	path, cleanup := writeTestConfig(t, "render.yaml", `
+# render settings
+wkhtmltopdf_path: /opt/bin/wkhtmltopdf
+image_format: "jpg"
+timeout: 1m30s # per render
+concurrency: 4
+renders_per_second: 2.5
+break_duration: 10
+`)
+	defer cleanup()
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{
+		WKHTMLToPDFPath:  "/opt/bin/wkhtmltopdf",
+		ImageFormat:      "jpg",
+		Timeout:          Duration(90 * time.Second),
+		Concurrency:      4,
+		RendersPerSecond: 2.5,
+		BreakDuration:    Duration(10 * time.Second),
+	}
+	if *c != want {
+		t.Errorf("Want %+v, have %+v", want, *c)
+	}
+
+	l := c.NewLimiter()
+	if l.Timeout != 90*time.Second || l.RendersPerSecond != 2.5 {
+		t.Errorf("Want limiter with config settings, have %+v", l)
+	}
+}
+
+func TestLoadConfigJSONAndEnv(t *testing.T) {
+	path, cleanup := writeTestConfig(t, "render.json", `{"qpdf_path": "/usr/bin/qpdf", "max_failures": 3, "timeout": "5s"}`)
+	defer cleanup()
+
+	os.Setenv("WKHTML_TIMEOUT", "20s")
+	os.Setenv("WKHTML_GHOSTSCRIPT_PATH", "123")
+	defer os.Unsetenv("WKHTML_TIMEOUT")
+	defer os.Unsetenv("WKHTML_GHOSTSCRIPT_PATH")
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.QPDFPath != "/usr/bin/qpdf" || c.MaxFailures != 3 {
+		t.Errorf("Want settings from file, have %+v", c)
+	}
+	if c.Timeout != Duration(20*time.Second) {
+		t.Errorf("Want timeout from environment 20s, have %v", time.Duration(c.Timeout))
+	}
+	if c.GhostscriptPath != "123" {
+		t.Errorf("Want ghostscript path 123, have %s", c.GhostscriptPath)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown.yaml": "no_such_setting: 1",
+		"invalid.yml":  "concurrency: many",
+		"unknown.json": `{"no_such_setting": 1}`,
+		"syntax.yaml":  "just a line",
+	} {
+		path, cleanup := writeTestConfig(t, name, content)
+		_, err := LoadConfig(path)
+		if err == nil {
+			t.Errorf("Want an error for %s, have no error", name)
+		}
+		cleanup()
+	}
+}
+
+func TestConfigApply(t *testing.T) {
+	defer func() { imageDefaults.options = ImageOptions{} }()
+	old := GetQPDFPath()
+	defer SetQPDFPath(old)
+	defer SetInputLimits(InputLimits{})
+
+	c := &Config{QPDFPath: "/test/qpdf", ImageFormat: "bmp", ImageQuality: 50, MaxInputBytes: 1 << 20}
+	c.Apply()
+	if GetQPDFPath() != "/test/qpdf" {
+		t.Errorf("Want qpdf path /test/qpdf, have %s", GetQPDFPath())
+	}
+	options := &ImageOptions{Quality: 80}
+	setImageDefaults(options)
+	if options.Format != "bmp" || options.Quality != 80 {
+		t.Errorf("Want format bmp and quality 80, have %s and %d", options.Format, options.Quality)
+	}
+	if l := GetInputLimits(); l.MaxInputBytes != 1<<20 {
+		t.Errorf("Want MaxInputBytes %d, have %d", 1<<20, l.MaxInputBytes)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	os.Setenv("WKHTML_CONCURRENCY", "8")
+	os.Setenv("WKHTML_IMAGE_QUALITY", "75")
+	defer os.Unsetenv("WKHTML_CONCURRENCY")
+	defer os.Unsetenv("WKHTML_IMAGE_QUALITY")
+
+	c, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Concurrency != 8 || c.ImageQuality != 75 {
+		t.Errorf("Want concurrency 8 and image quality 75, have %d and %d", c.Concurrency, c.ImageQuality)
+	}
+
+	os.Setenv("WKHTML_TIMEOUT", "soon")
+	defer os.Unsetenv("WKHTML_TIMEOUT")
+	_, err = ConfigFromEnv()
+	if err == nil {
+		t.Error("Want an error for an invalid timeout, have no error")
+	}
+}
@@ -0,0 +1,271 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultQuality is the quality wkhtmltoimage uses when ImageOptions.Quality is not set
+const defaultQuality = 94
+
+// postProcessing returns true if the rendered image has to be modified or converted after wkhtmltoimage is done
+func (options *ImageOptions) postProcessing() bool {
+	return len(options.OutputFormats) > 0 || options.DPI > 0 || options.OptimizePNG != nil || options.StripMetadata || options.Hash ||
+		options.ColorProfile != ""
+}
+
+// renderedFormat returns the format wkhtmltoimage renders
+func (options *ImageOptions) renderedFormat() string {
+	if options.Format == "" {
+		return "png"
+	}
+	return options.Format
+}
+
+// outputFile returns the path of Output, relative paths are resolved against WorkDir like wkhtmltoimage does
+func (options *ImageOptions) outputFile() string {
+	if options.Output == "" || options.WorkDir == "" || filepath.IsAbs(options.Output) {
+		return options.Output
+	}
+	return filepath.Join(options.WorkDir, options.Output)
+}
+
+// postProcessImage runs the post processing steps on the image in res, or in Output when that is set,
+// and sets the image in each of the OutputFormats in res.Images, which are written next to Output when it is set
+func postProcessImage(res *ImageResult, options *ImageOptions) error {
+	src := res.Image
+	output := options.outputFile()
+	if output != "" {
+		var err error
+		src, err = ioutil.ReadFile(output)
+		if err != nil {
+			return err
+		}
+	}
+	rendered := options.renderedFormat()
+	img, err := processImage(src, rendered, options)
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		err = ioutil.WriteFile(output, img, 0666)
+		if err != nil {
+			return err
+		}
+	} else {
+		res.Image = img
+	}
+	res.setSize(img)
+
+	// only decode when a hash is needed or a format is different from the rendered one
+	var decoded image.Image
+	if options.Hash {
+		decoded, err = res.decode(src)
+		if err != nil {
+			return fmt.Errorf("error decoding %s image: %s", rendered, err)
+		}
+		res.Hash = differenceHash(decoded)
+	}
+	if len(options.OutputFormats) == 0 {
+		return nil
+	}
+	res.Images = make(map[string][]byte, len(options.OutputFormats))
+	for _, format := range options.OutputFormats {
+		if format == rendered {
+			res.Images[format] = img
+			continue
+		}
+		if decoded == nil {
+			decoded, err = res.decode(src)
+			if err != nil {
+				return fmt.Errorf("error decoding %s image: %s", rendered, err)
+			}
+		}
+		converted, err := encodeImage(decoded, format, options.Quality)
+		if err != nil {
+			return err
+		}
+		converted, err = processImage(converted, format, options)
+		if err != nil {
+			return err
+		}
+		res.Images[format] = converted
+		if output != "" {
+			err = ioutil.WriteFile(strings.TrimSuffix(output, filepath.Ext(output))+"."+format, converted, 0666)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// processImage runs the post processing steps which change the encoded image of format
+func processImage(img []byte, format string, options *ImageOptions) ([]byte, error) {
+	var err error
+	if options.OptimizePNG != nil && format == "png" {
+		img, err = optimizePNG(img, options.OptimizePNG)
+		if err != nil {
+			return nil, fmt.Errorf("error optimizing PNG: %s", err)
+		}
+	}
+	if options.StripMetadata {
+		img, err = stripMetadata(img, format)
+		if err != nil {
+			return nil, fmt.Errorf("error removing metadata: %s", err)
+		}
+	}
+	if options.DPI > 0 {
+		img, err = setDPI(img, format, options.DPI)
+		if err != nil {
+			return nil, fmt.Errorf("error setting DPI: %s", err)
+		}
+	}
+	if options.ColorProfile != "" {
+		profile, name, err := loadColorProfile(options.ColorProfile, options.WorkDir)
+		if err != nil {
+			return nil, err
+		}
+		img, err = embedColorProfile(img, format, profile, name)
+		if err != nil {
+			return nil, fmt.Errorf("error embedding color profile: %s", err)
+		}
+	}
+	return img, nil
+}
+
+// decode decodes img and adds the time to res.Timing
+func (res *ImageResult) decode(img []byte) (image.Image, error) {
+	start := time.Now()
+	decoded, _, err := image.Decode(bytes.NewReader(img))
+	res.Timing.Decode += time.Since(start)
+	return decoded, err
+}
+
+// setSize sets the Width and Height of res from the encoded image, they stay 0 if the format can not be decoded
+func (res *ImageResult) setSize(img []byte) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(img))
+	if err == nil {
+		res.Width, res.Height = config.Width, config.Height
+	}
+}
+
+// encodeImage encodes img in format, quality is used for jpg
+func encodeImage(img image.Image, format string, quality int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(buf, img)
+	case "jpg":
+		if quality == 0 {
+			quality = defaultQuality
+		}
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: quality})
+	case "gif":
+		err = gif.Encode(buf, img, nil)
+	default:
+		return nil, fmt.Errorf("unsupported output format %s, supported are png, jpg and gif", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s image: %s", format, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// setDPI sets the pixel density of png and jpg images, which is used when the image is printed or embedded in a document.
+// Images of other formats are not changed.
+func setDPI(img []byte, format string, dpi int) ([]byte, error) {
+	switch format {
+	case "png":
+		// the pHYs chunk has the density in pixels per meter, it is written after the IHDR chunk and replaces an existing pHYs chunk
+		ppm := uint32(math.Floor(float64(dpi)/0.0254 + 0.5))
+		phys := make([]byte, 9)
+		binary.BigEndian.PutUint32(phys[0:], ppm)
+		binary.BigEndian.PutUint32(phys[4:], ppm)
+		phys[8] = 1 // unit is meter
+		return replacePNGChunks(img, "pHYs", phys)
+	case "jpg":
+		if len(img) < 4 || img[0] != 0xff || img[1] != 0xd8 {
+			return nil, errors.New("invalid JPEG")
+		}
+		density := make([]byte, 5)
+		density[0] = 1 // unit is dots per inch
+		binary.BigEndian.PutUint16(density[1:], uint16(dpi))
+		binary.BigEndian.PutUint16(density[3:], uint16(dpi))
+		// set the density in the JFIF APP0 segment, or add one after the start of image marker
+		if len(img) >= 18 && img[2] == 0xff && img[3] == 0xe0 && string(img[6:11]) == "JFIF\x00" {
+			out := append([]byte{}, img...)
+			copy(out[13:], density)
+			return out, nil
+		}
+		app0 := append([]byte{0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00, 0x01, 0x01}, density...)
+		app0 = append(app0, 0x00, 0x00)
+		out := make([]byte, 0, len(img)+len(app0))
+		out = append(out, img[:2]...)
+		out = append(out, app0...)
+		return append(out, img[2:]...), nil
+	}
+	return img, nil
+}
+
+// pngMagic is the signature every png starts with
+const pngMagic = "\x89PNG\r\n\x1a\n"
+
+// replacePNGChunks removes the chunks of type from the png and adds a new one after the IHDR chunk when data is not nil
+func replacePNGChunks(img []byte, typ string, data []byte) ([]byte, error) {
+	return filterPNGChunks(img, func(chunkType string) bool { return chunkType != typ }, typ, data)
+}
+
+// filterPNGChunks returns the png with only the chunks for which keep returns true,
+// and a new chunk of type typ with data after the IHDR chunk when data is not nil
+func filterPNGChunks(img []byte, keep func(chunkType string) bool, typ string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(img, []byte(pngMagic)) {
+		return nil, errors.New("invalid PNG")
+	}
+	out := make([]byte, 0, len(img)+len(data)+12)
+	out = append(out, pngMagic...)
+	for p := img[len(pngMagic):]; len(p) > 0; {
+		if len(p) < 12 {
+			return nil, errors.New("invalid PNG chunk")
+		}
+		n := int(binary.BigEndian.Uint32(p))
+		if n < 0 || len(p) < n+12 {
+			return nil, errors.New("invalid PNG chunk")
+		}
+		chunkType := string(p[4:8])
+		if chunkType == "IHDR" || keep(chunkType) {
+			out = append(out, p[:n+12]...)
+		}
+		if chunkType == "IHDR" && data != nil {
+			out = appendPNGChunk(out, typ, data)
+		}
+		p = p[n+12:]
+	}
+	return out, nil
+}
+
+// appendPNGChunk appends a chunk of type typ with data to the png
+func appendPNGChunk(img []byte, typ string, data []byte) []byte {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	img = append(img, length[:]...)
+	start := len(img)
+	img = append(img, typ...)
+	img = append(img, data...)
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.ChecksumIEEE(img[start:]))
+	return append(img, crc[:]...)
+}
@@ -0,0 +1,159 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImageOutputFormats(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered := buf.Bytes()
+	out := filepath.Join(os.TempDir(), "wkhtmltoimage-formats.png")
+	defer os.Remove(strings.TrimSuffix(out, ".png") + ".jpg")
+	err = ioutil.WriteFile(out, rendered, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out)
+
+	res := &ImageResult{}
+	err = postProcessImage(res, &ImageOptions{Output: out, OutputFormats: []string{"png", "jpg"}, Quality: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res.Images["png"], rendered) {
+		t.Error("Expected the rendered png")
+	}
+	jpg, err := ioutil.ReadFile(strings.TrimSuffix(out, ".png") + ".jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jpg) == 0 || !bytes.Equal(res.Images["jpg"], jpg) {
+		t.Error("Expected the jpg to be saved next to the png")
+	}
+
+	// a relative Output is in WorkDir
+	dir := filepath.Dir(out)
+	os.Remove(strings.TrimSuffix(out, ".png") + ".jpg")
+	err = postProcessImage(&ImageResult{}, &ImageOptions{Output: filepath.Base(out), WorkDir: dir, OutputFormats: []string{"jpg"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(out, ".png") + ".jpg"); err != nil {
+		t.Error("Expected the jpg to be saved in WorkDir, got ", err)
+	}
+
+	err = postProcessImage(&ImageResult{Image: rendered}, &ImageOptions{OutputFormats: []string{"webp"}})
+	if err == nil {
+		t.Error("Expected err to not be nil, got nil")
+	}
+}
+
+func TestSetDPIPNG(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 16, 8)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := setDPI(buf.Bytes(), "png", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// setting it again replaces the pHYs chunk
+	img, err = setDPI(img, "png", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(img, []byte("pHYs")); n != 1 {
+		t.Fatalf("Want 1 pHYs chunk, have %d", n)
+	}
+	i := bytes.Index(img, []byte("pHYs")) + 4
+	if ppm := binary.BigEndian.Uint32(img[i:]); ppm != 11811 {
+		t.Errorf("Want 11811 pixels per meter, have %d", ppm)
+	}
+	_, err = png.Decode(bytes.NewReader(img))
+	if err != nil {
+		t.Errorf("Want valid png, have %s", err)
+	}
+
+	res := &ImageResult{}
+	res.setSize(img)
+	if res.Width != 16 || res.Height != 8 {
+		t.Errorf("Want 16x8, have %dx%d", res.Width, res.Height)
+	}
+}
+
+func TestSetDPIJPEG(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := jpeg.Encode(buf, image.NewRGBA(image.Rect(0, 0, 16, 8)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first call adds a JFIF segment, the second changes it
+	img, err := setDPI(buf.Bytes(), "jpg", 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err = setDPI(img, "jpg", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(img, []byte("JFIF\x00")) != 1 {
+		t.Fatal("Want 1 JFIF segment")
+	}
+	if img[13] != 1 || binary.BigEndian.Uint16(img[14:]) != 300 || binary.BigEndian.Uint16(img[16:]) != 300 {
+		t.Errorf("Want 300 DPI, have % x", img[13:18])
+	}
+	_, err = jpeg.Decode(bytes.NewReader(img))
+	if err != nil {
+		t.Errorf("Want valid jpg, have %s", err)
+	}
+
+	_, err = setDPI([]byte("not a jpg"), "jpg", 300)
+	if err == nil {
+		t.Error("Want error for invalid jpg")
+	}
+}
+
+func TestImageQualityEndToEnd(t *testing.T) {
+	src := testScreenshot(true)
+	// a rendered jpg is returned as written by wkhtmltoimage
+	buf := &bytes.Buffer{}
+	decoded, _ := png.Decode(bytes.NewReader(src))
+	err := jpeg.Encode(buf, decoded, &jpeg.Options{Quality: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	iw := &bytes.Buffer{}
+	newImageWriter(iw, "jpg").Write(buf.Bytes())
+	if !bytes.Equal(iw.Bytes(), buf.Bytes()) {
+		t.Error("Want the jpg of wkhtmltoimage unchanged")
+	}
+
+	// converted jpg images use Quality
+	low := &ImageResult{Image: src}
+	err = postProcessImage(low, &ImageOptions{OutputFormats: []string{"jpg"}, Quality: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	high := &ImageResult{Image: src}
+	err = postProcessImage(high, &ImageOptions{OutputFormats: []string{"jpg"}, Quality: 95})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(low.Images["jpg"]) >= len(high.Images["jpg"]) {
+		t.Errorf("Want quality 20 smaller than quality 95, have %d and %d bytes", len(low.Images["jpg"]), len(high.Images["jpg"]))
+	}
+}
@@ -0,0 +1,109 @@
+package wkhtmltopdf
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// ErrRendererCrashed is the error a CrashError unwraps to, for use with errors.Is
+var ErrRendererCrashed = errors.New("renderer crashed")
+
+// CrashError is returned when wkhtmltopdf or wkhtmltoimage is killed by a signal, such as a segmentation fault on some pages,
+// instead of exiting with an error. Crashes are often not reproducible and the render can be tried again.
+type CrashError struct {
+	Binary    string // Name of the binary, wkhtmltopdf or wkhtmltoimage
+	Signal    string // Name of the signal, e.g. SIGSEGV
+	Stderr    string // Output written to stderr before the crash
+	RequestID string // Request ID of the render, see WithRequestID
+}
+
+func (ce *CrashError) Error() string {
+	msg := ce.Binary + " crashed with " + ce.Signal
+	if ce.RequestID != "" {
+		msg = "request " + ce.RequestID + ": " + msg
+	}
+	if ce.Stderr != "" {
+		msg += ": " + ce.Stderr
+	}
+	return msg
+}
+
+// Unwrap returns ErrRendererCrashed
+func (ce *CrashError) Unwrap() error {
+	return ErrRendererCrashed
+}
+
+// ErrRendererKilled is the error a KilledError unwraps to, for use with errors.Is
+var ErrRendererKilled = errors.New("renderer killed")
+
+// KilledError is returned when wkhtmltopdf or wkhtmltoimage is stopped with SIGKILL or SIGTERM while the context of the
+// render is not done, most often by the OOM killer because the page needs too much memory. It is not a CrashError,
+// rendering the page again would most likely be killed again.
+type KilledError struct {
+	Binary    string // Name of the binary, wkhtmltopdf or wkhtmltoimage
+	Signal    string // Name of the signal, SIGKILL or SIGTERM
+	Stderr    string // Output written to stderr before the process was killed
+	RequestID string // Request ID of the render, see WithRequestID
+}
+
+func (ke *KilledError) Error() string {
+	msg := ke.Binary + " was killed with " + ke.Signal
+	if ke.Signal == "SIGKILL" {
+		msg += ", possibly out of memory"
+	}
+	if ke.RequestID != "" {
+		msg = "request " + ke.RequestID + ": " + msg
+	}
+	if ke.Stderr != "" {
+		msg += ": " + ke.Stderr
+	}
+	return msg
+}
+
+// Unwrap returns ErrRendererKilled
+func (ke *KilledError) Unwrap() error {
+	return ErrRendererKilled
+}
+
+// signalNames holds the names of the signals which end a crashed renderer, and of SIGKILL and SIGTERM
+// which are not crashes, they are sent to stop the renderer, e.g. by the OOM killer
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// signalError returns a KilledError if err is the error of a binary which was stopped with SIGKILL or SIGTERM,
+// a CrashError if it was killed by another signal, otherwise nil. Call it after checking the context of the render
+func signalError(binPath string, err error, stderr string) error {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return nil
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return nil
+	}
+	name, ok := signalNames[status.Signal()]
+	if !ok {
+		name = status.Signal().String()
+	}
+	binary := strings.TrimSuffix(filepath.Base(binPath), ".exe")
+	if status.Signal() == syscall.SIGKILL || status.Signal() == syscall.SIGTERM {
+		return &KilledError{Binary: binary, Signal: name, Stderr: strings.TrimSpace(stderr)}
+	}
+	return &CrashError{Binary: binary, Signal: name, Stderr: strings.TrimSpace(stderr)}
+}
+
+// isCrash returns true if err is or wraps a CrashError
+func isCrash(err error) bool {
+	var ce *CrashError
+	return errors.As(err, &ce)
+}
@@ -0,0 +1,125 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// crashingBinary writes a script which crashes with a segmentation fault, after the first run it prints output if once is set
+func crashingBinary(t *testing.T, once bool) string {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\ncat >/dev/null\n"
+	if once {
+		script += "if [ -f " + dir + "/ran ]; then printf 'output'; exit 0; fi\ntouch " + dir + "/ran\n"
+	}
+	script += "echo 'Loading page (1/2)' >&2\nkill -SEGV $$\n"
+	path := filepath.Join(dir, "wkhtmltoimage")
+	err = ioutil.WriteFile(path, []byte(script), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCrashError(t *testing.T) {
+	path := crashingBinary(t, false)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = path
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	err := pdfg.CreateContext(WithRequestID(context.Background(), "req-1"))
+	ce, ok := err.(*CrashError)
+	if !ok {
+		t.Fatalf("Want CrashError, have %v", err)
+	}
+	if ce.Signal != "SIGSEGV" || ce.Stderr != "Loading page (1/2)" || ce.RequestID != "req-1" {
+		t.Errorf("Want SIGSEGV with stderr and request ID, have %+v", ce)
+	}
+	if ce.Unwrap() != ErrRendererCrashed {
+		t.Errorf("Want ErrRendererCrashed, have %v", ce.Unwrap())
+	}
+	want := "request req-1: wkhtmltoimage crashed with SIGSEGV: Loading page (1/2)"
+	if err.Error() != want {
+		t.Errorf("Want %s, have %s", want, err.Error())
+	}
+}
+
+func TestKilledError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-killed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wkhtmltopdf")
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\ncat >/dev/null\nkill -KILL $$\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = path
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	err = pdfg.CreateContext(WithRequestID(context.Background(), "req-1"))
+	if isCrash(err) || !errors.Is(err, ErrRendererKilled) {
+		t.Fatalf("Want KilledError, have %v", err)
+	}
+	if want := "request req-1: wkhtmltopdf was killed with SIGKILL, possibly out of memory"; err.Error() != want {
+		t.Errorf("Want %s, have %s", want, err.Error())
+	}
+}
+
+func TestCrashSignals(t *testing.T) {
+	for _, sig := range []string{"KILL", "TERM"} {
+		err := exec.Command("/bin/sh", "-c", "kill -"+sig+" $$").Run()
+		se := signalError("wkhtmltopdf", err, "")
+		if isCrash(se) {
+			t.Errorf("Want SIG%s not to be a crash, have %v", sig, se)
+		}
+		var ke *KilledError
+		if !errors.As(se, &ke) || ke.Signal != "SIG"+sig || !errors.Is(se, ErrRendererKilled) {
+			t.Errorf("Want a KilledError with SIG%s, have %v", sig, se)
+		}
+	}
+	err := exec.Command("/bin/sh", "-c", "kill -ABRT $$").Run()
+	se := signalError("wkhtmltopdf", err, "")
+	if ce, ok := se.(*CrashError); !ok || ce.Signal != "SIGABRT" {
+		t.Fatalf("Want a crash with SIGABRT, have %v", se)
+	}
+	if !isCrash(fmt.Errorf("page 2: %w", se)) {
+		t.Error("Want a wrapped CrashError to be a crash")
+	}
+	if signalError("wkhtmltopdf", exec.Command("/bin/false").Run(), "") != nil {
+		t.Error("Want no signal error for an exit code")
+	}
+}
+
+func TestImageRetryOnCrash(t *testing.T) {
+	path := crashingBinary(t, true)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	options := &ImageOptions{BinaryPath: path, Input: "-", Html: "<html>Hi</html>", Format: "svg"}
+	_, err := RenderImage(context.Background(), options)
+	if !isCrash(err) {
+		t.Fatalf("Want CrashError, have %v", err)
+	}
+
+	options.RetryOnCrash = true
+	os.Remove(filepath.Join(filepath.Dir(path), "ran"))
+	res, err := RenderImage(context.Background(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Image) != "output" {
+		t.Errorf("Want output, have %q", res.Image)
+	}
+}
@@ -0,0 +1,72 @@
+package wkhtmltopdf
+
+import "sort"
+
+// Credentials are cookies and HTTP headers which are sent with the request for the page and with the requests for its
+// images, stylesheets and other resources, so assets behind authentication do not fail with 401 during the render.
+//
+// The cookies are set for the host of the page, resources on other hosts do not get them. The headers are sent to every host.
+type Credentials struct {
+	Cookies map[string]string // Cookie values, they should be url encoded
+	Headers map[string]string // HTTP headers, for example Authorization
+}
+
+// SetCredentials sets the cookies and headers of c in Cookie and CustomHeader, and sets CustomHeaderPropagation
+// so the headers are also sent for resources. The values are replaced with **** in Args, errors and logs.
+func (popt *pageOptions) SetCredentials(c Credentials) {
+	for _, k := range sortedKeys(c.Cookies) {
+		popt.Cookie.Set(k, c.Cookies[k])
+	}
+	for _, k := range sortedKeys(c.Headers) {
+		popt.CustomHeader.Set(k, c.Headers[k])
+	}
+	if len(c.Headers) > 0 {
+		popt.CustomHeaderPropagation.Set(true)
+	}
+}
+
+// args returns the wkhtmltoimage options for the cookies and headers
+func (c Credentials) args() []string {
+	var args []string
+	for _, k := range sortedKeys(c.Cookies) {
+		args = append(args, "--cookie", k, c.Cookies[k])
+	}
+	for _, k := range sortedKeys(c.Headers) {
+		args = append(args, "--custom-header", k, c.Headers[k])
+	}
+	if len(c.Headers) > 0 {
+		args = append(args, "--custom-header-propagation")
+	}
+	return args
+}
+
+// merge sets the cookies and headers of overrides, without changing the maps of c
+func (c *Credentials) merge(overrides Credentials) {
+	c.Cookies = mergeMap(c.Cookies, overrides.Cookies)
+	c.Headers = mergeMap(c.Headers, overrides.Headers)
+}
+
+// mergeMap returns a new map with the values of m and overrides, or m if overrides is empty
+func mergeMap(m, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return m
+	}
+	merged := make(map[string]string, len(m)+len(overrides))
+	for k, v := range m {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// sortedKeys returns the keys of m in order, so the arguments are the same for each render
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
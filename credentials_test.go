@@ -0,0 +1,111 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetCredentials(t *testing.T) {
+	page := NewPage("https://example.com")
+	page.SetCredentials(Credentials{Cookies: map[string]string{"session": "abc"}, Headers: map[string]string{"Authorization": "Bearer xyz"}})
+	args := strings.Join(page.Args(), " ")
+	for _, want := range []string{"--cookie session abc", "--custom-header Authorization Bearer xyz", "--custom-header-propagation"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Want %s in %s", want, args)
+		}
+	}
+
+	page = NewPage("https://example.com")
+	page.SetCredentials(Credentials{Cookies: map[string]string{"session": "abc"}})
+	if strings.Contains(strings.Join(page.Args(), " "), "propagation") {
+		t.Errorf("Want no header propagation without headers, have %v", page.Args())
+	}
+}
+
+func TestImageCredentials(t *testing.T) {
+	shared := ImageOptions{Input: "http://example.com", Credentials: Credentials{Headers: map[string]string{"X-Tenant": "a"}}}
+	options := shared.Clone()
+	options.Merge(ImageOptions{Credentials: Credentials{Cookies: map[string]string{"session": "abc"}, Headers: map[string]string{"Authorization": "Bearer xyz"}}})
+	if len(shared.Credentials.Headers) != 1 {
+		t.Errorf("Want shared headers unchanged, have %v", shared.Credentials.Headers)
+	}
+	v, err := buildParams(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--cookie", "session", "abc", "--custom-header", "Authorization", "Bearer xyz", "--custom-header", "X-Tenant", "a", "--custom-header-propagation"}
+	if !reflect.DeepEqual(v[2:12], want) {
+		t.Errorf("Want %q, have %q", want, v[2:12])
+	}
+
+	options.Credentials.Headers["X-Name"] = "a\x00b"
+	if _, err := buildParams(&options); err == nil {
+		t.Error("Want error for a header with a NUL byte")
+	}
+}
+
+func TestImageCredentialsRedacted(t *testing.T) {
+	f, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	// the binary writes its arguments to stderr
+	f.WriteString("#!/bin/sh\necho \"Warning: $*\" >&2\n")
+	f.Close()
+	os.Chmod(f.Name(), 0700)
+
+	var stderr bytes.Buffer
+	options := &ImageOptions{BinaryPath: f.Name(), Input: "http://example.com", ErrorWriter: &stderr,
+		Credentials: Credentials{Cookies: map[string]string{"session": "s3cret"}, Headers: map[string]string{"X-Tenant": "acme-42"}}}
+	res, err := RenderImage(context.Background(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stderr.String(), "s3cret") || !strings.Contains(stderr.String(), "--cookie session ****") {
+		t.Errorf("Want the cookie redacted in %q", stderr.String())
+	}
+	if strings.Contains(stderr.String(), "acme-42") || !strings.Contains(stderr.String(), "--custom-header X-Tenant ****") {
+		t.Errorf("Want the header redacted in %q", stderr.String())
+	}
+	if len(res.Warnings) != 1 || strings.Contains(res.Warnings[0], "s3cret") {
+		t.Errorf("Want the cookie redacted in %q", res.Warnings)
+	}
+}
+
+func TestPDFCredentialsRedacted(t *testing.T) {
+	// the binary writes its arguments to stderr and fails
+	bin, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\necho \"Error: $@\" >&2\nexit 1\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin.Name()
+	var over PageOptions
+	over.SetCredentials(Credentials{Headers: map[string]string{"X-Tenant": "acme-42"}})
+	page := NewPage("https://example.com")
+	page.CustomHeader.Set("X-Trace", "trace-1")
+	page.PageOptions.Merge(over)
+	pdfg.AddPage(page)
+	// the headers of merged and cloned pages are redacted too
+	cloned := NewPage("https://example.com")
+	cloned.PageOptions = page.PageOptions.Clone()
+	pdfg.AddPage(cloned)
+	if args := strings.Join(pdfg.Args(), " "); strings.Contains(args, "acme-42") || strings.Contains(args, "trace-1") {
+		t.Errorf("Want the headers redacted in %s", args)
+	}
+	err = pdfg.Create()
+	if err == nil || strings.Contains(err.Error(), "acme-42") || !strings.Contains(err.Error(), "--custom-header X-Tenant ****") {
+		t.Errorf("Want the credential header redacted in %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// warningPrefix starts the lines wkhtmltopdf and wkhtmltoimage write to stderr for problems which do not stop the render,
+// such as resources that failed to load with LoadErrorHandling or LoadMediaErrorHandling set to ignore
+const warningPrefix = "Warning: "
+
+// WarningFunc is called for each warning as soon as wkhtmltopdf or wkhtmltoimage writes it,
+// for example to log failed resources while a long render is still running
+type WarningFunc func(warning string)
+
+// parseWarnings returns the warnings from the stderr output of wkhtmltopdf or wkhtmltoimage
+func parseWarnings(stderr string) []string {
+	var warnings []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if w, ok := parseWarning(line); ok {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+// parseWarning returns the warning without prefix if line is a warning
+func parseWarning(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, warningPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(line, warningPrefix), true
+}
+
+// lineWriter is an io.Writer which calls fn for each complete line written to it
+type lineWriter struct {
+	fn  func(line string)
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	lw.buf.Write(p)
+	for {
+		i := bytes.IndexByte(lw.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(lw.buf.Next(i + 1))
+		lw.fn(strings.TrimRight(line, "\r\n"))
+	}
+}
+
+// warningWriter returns a lineWriter which calls fn for each warning
+func warningWriter(fn WarningFunc) *lineWriter {
+	return &lineWriter{fn: func(line string) {
+		if w, ok := parseWarning(line); ok {
+			fn(w)
+		}
+	}}
+}
+
+// slowScriptWarning is the warning for a script which was stopped because it ran too long, unless NoStopSlowScripts is set
+const slowScriptWarning = "A slow script was stopped"
+
+// slowScriptStopped returns true if the warnings contain slowScriptWarning
+func slowScriptStopped(warnings []string) bool {
+	for _, w := range warnings {
+		if strings.HasPrefix(w, slowScriptWarning) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConsoleMessage is a message the page wrote to the javascript console, or a javascript error
+type ConsoleMessage struct {
+	Source  string // URL of the script or page, can be empty
+	Line    int    // Line number in the source, 0 if unknown
+	Message string
+}
+
+func (cm ConsoleMessage) String() string {
+	return cm.Source + ":" + strconv.Itoa(cm.Line) + " " + cm.Message
+}
+
+// consoleRe matches the warnings for console messages, which are "source:line message" when debug-javascript is set
+var consoleRe = regexp.MustCompile(`^(\S*):(\d+) (.*)$`)
+
+// parseConsole returns the console messages from the warnings
+func parseConsole(warnings []string) []ConsoleMessage {
+	var messages []ConsoleMessage
+	for _, w := range warnings {
+		m := consoleRe.FindStringSubmatch(w)
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		messages = append(messages, ConsoleMessage{Source: m[1], Line: line, Message: m[3]})
+	}
+	return messages
+}
+
+// FailedRequest is a page or resource which wkhtmltopdf or wkhtmltoimage failed to load.
+// The status codes are 0 when they are not reported, for example for local files.
+type FailedRequest struct {
+	URL           string
+	NetworkStatus int    // Qt network error code, e.g. 3 for host not found or 203 for content not found
+	HTTPStatus    int    // HTTP status code of the response, e.g. 404
+	Error         string // Error message, or the load error handling which was used, e.g. ignore
+}
+
+var (
+	// failedStatusRe matches "Failed to load url, with network status code 203 and http status code 404 - message"
+	failedStatusRe = regexp.MustCompile(`^Failed to load (\S+?),? with network status code (\d+) and http status code (\d+)(?: - (.*))?$`)
+	// failedHandlingRe matches "Failed to load url (ignore)" and "Failed loading page url (message)"
+	failedHandlingRe = regexp.MustCompile(`^Failed (?:to load|loading page) (\S+)(?: \((.*)\))?$`)
+)
+
+// parseFailedRequests returns the pages and resources which failed to load from the stderr output,
+// only failed requests are written by wkhtmltopdf and wkhtmltoimage, also when quiet mode is not used
+func parseFailedRequests(stderr string) []FailedRequest {
+	var failed []FailedRequest
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, warningPrefix):
+			line = strings.TrimPrefix(line, warningPrefix)
+		case strings.HasPrefix(line, "Error: "):
+			line = strings.TrimPrefix(line, "Error: ")
+		default:
+			continue
+		}
+		if m := failedStatusRe.FindStringSubmatch(line); m != nil {
+			network, _ := strconv.Atoi(m[2])
+			status, _ := strconv.Atoi(m[3])
+			failed = append(failed, FailedRequest{URL: m[1], NetworkStatus: network, HTTPStatus: status, Error: m[4]})
+			continue
+		}
+		if m := failedHandlingRe.FindStringSubmatch(line); m != nil {
+			failed = append(failed, FailedRequest{URL: m[1], Error: m[2]})
+		}
+	}
+	return failed
+}
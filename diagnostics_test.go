@@ -0,0 +1,147 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWarnings(t *testing.T) {
+	stderr := "Loading pages (1/6)\n" +
+		"Warning: Failed to load file:///missing.png (ignore)\n" +
+		"Counting pages (2/6)\r\n" +
+		"Warning: Received createRequest signal on a disposed ResourceObject's NetworkAccessManager.\r\n" +
+		"Done\n"
+	want := []string{
+		"Failed to load file:///missing.png (ignore)",
+		"Received createRequest signal on a disposed ResourceObject's NetworkAccessManager.",
+	}
+	have := parseWarnings(stderr)
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("Want %q, have %q", want, have)
+	}
+}
+
+func TestWarningWriter(t *testing.T) {
+	var have []string
+	w := warningWriter(func(warning string) {
+		have = append(have, warning)
+	})
+	fmt.Fprint(w, "Loading\nWarning: Failed to ")
+	if len(have) != 0 {
+		t.Errorf("Want no warning before the line is complete, have %q", have)
+	}
+	fmt.Fprint(w, "load a.png (ignore)\nWarning: b\n")
+	want := []string{"Failed to load a.png (ignore)", "b"}
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("Want %q, have %q", want, have)
+	}
+}
+
+func TestPDFWarnings(t *testing.T) {
+	pdfg := newTestPDFGenerator(t)
+	pdfg.ResetPages()
+	pdfg.Cover.Input = ""
+	pdfg.TOC.Include = false
+
+	page := NewPageReader(strings.NewReader(`<html><body>Hi<img src="file:///no/such/image.png"></body></html>`))
+	page.LoadMediaErrorHandling.Set("ignore")
+	pdfg.AddPage(page)
+
+	var live []string
+	pdfg.OnWarning = func(warning string) {
+		live = append(live, warning)
+	}
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, w := range pdfg.Warnings() {
+		if strings.Contains(w, "image.png") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Want a warning for image.png, have %q", pdfg.Warnings())
+	}
+	failed := pdfg.FailedRequests()
+	if len(failed) != 1 || failed[0].URL != "file:///no/such/image.png" {
+		t.Errorf("Want failed request for file:///no/such/image.png, have %+v", failed)
+	}
+	if !reflect.DeepEqual(live, pdfg.Warnings()) {
+		t.Errorf("Want OnWarning called for %q, have %q", pdfg.Warnings(), live)
+	}
+}
+
+func TestSlowScriptStopped(t *testing.T) {
+	if slowScriptStopped(parseWarnings("Loading pages (1/6)\nWarning: Failed to load a.png (ignore)\n")) {
+		t.Error("Want no stopped script")
+	}
+	if !slowScriptStopped(parseWarnings("Loading pages (1/6)\nWarning: A slow script was stopped\nDone\n")) {
+		t.Error("Want a stopped script")
+	}
+}
+
+func TestParseConsole(t *testing.T) {
+	warnings := []string{
+		"Failed to load file:///missing.png (ignore)",
+		"http://localhost:8080/app.js:12 TypeError: 'undefined' is not a function (evaluating 'render()')",
+		":0 hello: world",
+	}
+	want := []ConsoleMessage{
+		{Source: "http://localhost:8080/app.js", Line: 12, Message: "TypeError: 'undefined' is not a function (evaluating 'render()')"},
+		{Source: "", Line: 0, Message: "hello: world"},
+	}
+	have := parseConsole(warnings)
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("Want %+v, have %+v", want, have)
+	}
+	if have[0].String() != warnings[1] {
+		t.Errorf("Want %q, have %q", warnings[1], have[0].String())
+	}
+}
+
+func TestPDFJavascriptConsole(t *testing.T) {
+	pdfg := newTestPDFGenerator(t)
+	pdfg.ResetPages()
+	pdfg.Cover.Input = ""
+	pdfg.TOC.Include = false
+
+	page := NewPageReader(strings.NewReader(`<html><body>Hi<script>console.log("hello from js")</script></body></html>`))
+	page.DebugJavascript.Set(true)
+	pdfg.AddPage(page)
+
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, m := range pdfg.JavascriptConsole() {
+		if m.Message == "hello from js" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Want console message hello from js, have %+v", pdfg.JavascriptConsole())
+	}
+}
+
+func TestParseFailedRequests(t *testing.T) {
+	stderr := "Loading pages (1/6)\n" +
+		"Warning: Failed to load http://localhost/logo.png, with network status code 203 and http status code 404 - Error downloading http://localhost/logo.png - server replied: Not Found\n" +
+		"Warning: Failed to load file:///tmp/missing.css (ignore)\n" +
+		"Error: Failed loading page http://no.such.host (sometimes it will work just to ignore this error with --load-error-handling ignore)\n" +
+		"Exit with code 1 due to network error: HostNotFoundError\n"
+	want := []FailedRequest{
+		{URL: "http://localhost/logo.png", NetworkStatus: 203, HTTPStatus: 404, Error: "Error downloading http://localhost/logo.png - server replied: Not Found"},
+		{URL: "file:///tmp/missing.css", Error: "ignore"},
+		{URL: "http://no.such.host", Error: "sometimes it will work just to ignore this error with --load-error-handling ignore"},
+	}
+	have := parseFailedRequests(stderr)
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("Want %+v, have %+v", want, have)
+	}
+}
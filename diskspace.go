@@ -0,0 +1,50 @@
+package wkhtmltopdf
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ErrInsufficientSpace is returned before a render starts when the output directory has less free space than
+// MinFreeSpace, instead of failing when the output is written after a long render
+var ErrInsufficientSpace = errors.New("insufficient free space in output directory")
+
+// CheckOutputDir returns an error if dir does not exist, is not writable or has less than minFree bytes of free space.
+// The free space is not checked on systems other than Linux, macOS and Windows.
+func CheckOutputDir(dir string, minFree uint64) error {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("output directory: %s", err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("output directory: %s is not a directory", dir)
+	}
+	f, err := ioutil.TempFile(dir, ".wkhtmltopdf-preflight")
+	if err != nil {
+		return fmt.Errorf("output directory is not writable: %s", err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	if minFree == 0 {
+		return nil
+	}
+	free, ok := freeSpace(dir)
+	if ok && free < minFree {
+		return ErrInsufficientSpace
+	}
+	return nil
+}
+
+// checkOutputFile checks the directory of the output file with CheckOutputDir, relative paths are resolved from workDir
+func checkOutputFile(file, workDir string, minFree uint64) error {
+	if file == "" || file == "-" || minFree == 0 {
+		return nil
+	}
+	if workDir != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(workDir, file)
+	}
+	return CheckOutputDir(filepath.Dir(file), minFree)
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package wkhtmltopdf
+
+// freeSpace is not implemented on this system, the free space is not checked
+func freeSpace(dir string) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,58 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckOutputDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-preflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := CheckOutputDir(dir, 1); err != nil {
+		t.Errorf("Want no error, have %s", err)
+	}
+	if err := CheckOutputDir(filepath.Join(dir, "missing"), 1); err == nil {
+		t.Error("Want error for missing directory")
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("Want preflight file removed, have %d files", len(files))
+	}
+	if _, ok := freeSpace(dir); ok {
+		if err := CheckOutputDir(dir, 1<<62); err != ErrInsufficientSpace {
+			t.Errorf("Want %s, have %v", ErrInsufficientSpace, err)
+		}
+	}
+}
+
+func TestMinFreeSpace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-preflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, ok := freeSpace(dir); !ok {
+		t.Skip("free space is not available on this system")
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPage("http://example.com"))
+	pdfg.SetWorkDir(dir)
+	pdfg.OutputFile = "out.pdf"
+	pdfg.MinFreeSpace = 1 << 62
+	if err := pdfg.CreateContext(context.Background()); err != ErrInsufficientSpace {
+		t.Errorf("Want %s, have %v", ErrInsufficientSpace, err)
+	}
+
+	options := &ImageOptions{BinaryPath: "/bin/false", Input: "http://example.com", Output: "out.png", WorkDir: dir, MinFreeSpace: 1 << 62}
+	if _, err := RenderImage(context.Background(), options); err != ErrInsufficientSpace {
+		t.Errorf("Want %s, have %v", ErrInsufficientSpace, err)
+	}
+}
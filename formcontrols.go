@@ -0,0 +1,65 @@
+package wkhtmltopdf
+
+// FormControls sets the SVG files used to draw checkboxes and radio buttons, so form controls in documents and
+// screenshots match the styling of the site instead of the WebKit defaults. Empty files use the default.
+type FormControls struct {
+	Checkbox           string // SVG file for unchecked checkboxes
+	CheckedCheckbox    string // SVG file for checked checkboxes
+	Radiobutton        string // SVG file for unchecked radio buttons
+	CheckedRadiobutton string // SVG file for checked radio buttons
+}
+
+// SetFormControls sets the CheckboxSvg, CheckboxCheckedSvg, RadiobuttonSvg and RadiobuttonCheckedSvg options
+// to the files in fc which are not empty
+func (popt *pageOptions) SetFormControls(fc FormControls) {
+	for _, o := range []struct {
+		option *stringOption
+		file   string
+	}{
+		{&popt.CheckboxSvg, fc.Checkbox},
+		{&popt.CheckboxCheckedSvg, fc.CheckedCheckbox},
+		{&popt.RadiobuttonSvg, fc.Radiobutton},
+		{&popt.RadiobuttonCheckedSvg, fc.CheckedRadiobutton},
+	} {
+		if o.file != "" {
+			o.option.Set(o.file)
+		}
+	}
+}
+
+// args returns the wkhtmltoimage options for the files which are set
+func (fc FormControls) args() []string {
+	var args []string
+	for _, o := range fc.options() {
+		if o.file != "" {
+			args = append(args, opt+o.option, o.file)
+		}
+	}
+	return args
+}
+
+// options returns the files with the name of their option
+func (fc FormControls) options() []struct{ option, file string } {
+	return []struct{ option, file string }{
+		{"checkbox-svg", fc.Checkbox},
+		{"checkbox-checked-svg", fc.CheckedCheckbox},
+		{"radiobutton-svg", fc.Radiobutton},
+		{"radiobutton-checked-svg", fc.CheckedRadiobutton},
+	}
+}
+
+// merge sets the files which are set in overrides
+func (fc *FormControls) merge(overrides FormControls) {
+	if overrides.Checkbox != "" {
+		fc.Checkbox = overrides.Checkbox
+	}
+	if overrides.CheckedCheckbox != "" {
+		fc.CheckedCheckbox = overrides.CheckedCheckbox
+	}
+	if overrides.Radiobutton != "" {
+		fc.Radiobutton = overrides.Radiobutton
+	}
+	if overrides.CheckedRadiobutton != "" {
+		fc.CheckedRadiobutton = overrides.CheckedRadiobutton
+	}
+}
@@ -0,0 +1,39 @@
+package wkhtmltopdf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetFormControls(t *testing.T) {
+	page := NewPage("https://example.com")
+	page.SetFormControls(FormControls{Checkbox: "box.svg", CheckedRadiobutton: "radio-checked.svg"})
+	args := strings.Join(page.Args(), " ")
+	for _, want := range []string{"--checkbox-svg box.svg", "--radiobutton-checked-svg radio-checked.svg"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Want %s in %s", want, args)
+		}
+	}
+	if strings.Contains(args, "--checkbox-checked-svg") {
+		t.Errorf("Want no --checkbox-checked-svg in %s", args)
+	}
+}
+
+func TestImageFormControls(t *testing.T) {
+	options := ImageOptions{Input: "http://example.com", FormControls: FormControls{Checkbox: "box.svg", CheckedCheckbox: "checked.svg"}}
+	options.Merge(ImageOptions{FormControls: FormControls{Radiobutton: "radio.svg"}})
+	v, err := buildParams(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--checkbox-svg", "box.svg", "--checkbox-checked-svg", "checked.svg", "--radiobutton-svg", "radio.svg"}
+	if !reflect.DeepEqual(v[4:10], want) {
+		t.Errorf("Want %v, have %v", want, v[4:10])
+	}
+
+	options.FormControls.CheckedRadiobutton = "--enable-local-file-access"
+	if _, err := buildParams(&options); err == nil {
+		t.Error("Want error for a form control file which starts with --")
+	}
+}
@@ -0,0 +1,154 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"image"
+	"strings"
+	"unicode"
+)
+
+// glyphCell is the size in pixels of the cell a character is rendered in by CheckGlyphs
+const glyphCell = 48
+
+// glyphColumns is the number of cells in a row
+const glyphColumns = 16
+
+// tofuCharacter is a private use character which no font has, it is rendered as the glyph of missing characters
+const tofuCharacter = "\U0010FFFD"
+
+// CheckGlyphs renders each character of text with wkhtmltoimage and returns the characters which are rendered like
+// a character which no font has, the empty box known as tofu, or not at all. Run it at deploy time with the scripts and
+// emoji the documents need, so a missing font is found before it shows up in customer invoices.
+// fontFamily is the CSS font-family the characters are rendered with, "" for the default font. BinaryPath, WorkDir
+// and the settings of the process in options are used, Input, Html, Format, Width, Height and the output are set.
+// Emoji sequences and characters with combining marks are checked together
+func CheckGlyphs(ctx context.Context, text, fontFamily string, options ImageOptions) ([]string, error) {
+	chars := glyphClusters(text)
+	if len(chars) == 0 {
+		return nil, nil
+	}
+	options.Input = "-"
+	options.Html = glyphPage(append([]string{tofuCharacter}, chars...), fontFamily)
+	options.Format = "png"
+	options.Width = glyphColumns * glyphCell
+	options.Height = 0
+	options.Output = ""
+	options.OutputWriter = nil
+	options.OutputFormats = nil
+	res, err := RenderImage(ctx, &options)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(res.Image))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding the rendered characters: %s", err)
+	}
+	tofu, ok := glyphAt(img, 0)
+	if !ok {
+		return nil, fmt.Errorf("the rendered characters are %dx%d pixels, want at least %dx%d", img.Bounds().Dx(), img.Bounds().Dy(), glyphCell, glyphCell)
+	}
+	var missing []string
+	for i, c := range chars {
+		glyph, ok := glyphAt(img, i+1)
+		if !ok {
+			return nil, fmt.Errorf("the rendered characters are %dx%d pixels, %q is outside", img.Bounds().Dx(), img.Bounds().Dy(), c)
+		}
+		if _, blank := singleColor(glyph); blank || similarImages(glyph, tofu) {
+			missing = append(missing, c)
+		}
+	}
+	return missing, nil
+}
+
+// glyphClusters returns the characters of text without spaces and duplicates, with combining marks, variation selectors,
+// skin tones and zero width joiners kept with the character before them and flags of two regional indicators together
+func glyphClusters(text string) []string {
+	var clusters []string
+	seen := make(map[string]bool)
+	var cur []rune
+	flush := func() {
+		if c := string(cur); len(cur) > 0 && !seen[c] {
+			seen[c] = true
+			clusters = append(clusters, c)
+		}
+		cur = nil
+	}
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			flush()
+			continue
+		}
+		if len(cur) > 0 {
+			last := cur[len(cur)-1]
+			join := unicode.In(r, unicode.Mn, unicode.Me) || unicode.Is(unicode.Variation_Selector, r) ||
+				r == '\u200d' || last == '\u200d' || (r >= 0x1f3fb && r <= 0x1f3ff) ||
+				isRegionalIndicator(r) && isRegionalIndicator(last) && len(cur) == 1
+			if join {
+				cur = append(cur, r)
+				continue
+			}
+		}
+		flush()
+		cur = append(cur, r)
+	}
+	flush()
+	return clusters
+}
+
+// isRegionalIndicator returns whether r is one of the letters of flag emoji
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// cssReplacer removes the characters which end a CSS value or the style element from the font family
+var cssReplacer = strings.NewReplacer("<", "", ">", "", "{", "", "}", "", ";", "")
+
+// glyphPage returns the HTML which renders each character in a cell of a grid
+func glyphPage(chars []string, fontFamily string) string {
+	var buf bytes.Buffer
+	buf.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><style>`)
+	fmt.Fprintf(&buf, `body{margin:0;height:%dpx;background:#fff;color:#000}`, (len(chars)+glyphColumns-1)/glyphColumns*glyphCell)
+	fmt.Fprintf(&buf, `div{position:absolute;width:%dpx;height:%dpx;overflow:hidden;font-size:%dpx;line-height:%dpx;text-align:center;font-family:%s}`,
+		glyphCell, glyphCell, glyphCell*2/3, glyphCell, cssReplacer.Replace(fontFamily))
+	buf.WriteString(`</style></head><body>`)
+	for i, c := range chars {
+		fmt.Fprintf(&buf, `<div style="left:%dpx;top:%dpx">%s</div>`, i%glyphColumns*glyphCell, i/glyphColumns*glyphCell, html.EscapeString(c))
+	}
+	buf.WriteString(`</body></html>`)
+	return buf.String()
+}
+
+// glyphAt returns the cell of the i-th character in the rendered image
+func glyphAt(img image.Image, i int) (image.Image, bool) {
+	b := img.Bounds()
+	r := image.Rect(i%glyphColumns*glyphCell, i/glyphColumns*glyphCell, i%glyphColumns*glyphCell+glyphCell, i/glyphColumns*glyphCell+glyphCell).Add(b.Min)
+	if !r.In(b) {
+		return nil, false
+	}
+	sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, false
+	}
+	return sub.SubImage(r), true
+}
+
+// similarImages returns whether the images have the same size and similar colors in each pixel
+func similarImages(a, b image.Image) bool {
+	ba, bb := a.Bounds(), b.Bounds()
+	if ba.Size() != bb.Size() {
+		return false
+	}
+	for y := 0; y < ba.Dy(); y++ {
+		for x := 0; x < ba.Dx(); x++ {
+			if !similarColors(a.At(ba.Min.X+x, ba.Min.Y+y), b.At(bb.Min.X+x, bb.Min.Y+y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
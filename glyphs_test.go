@@ -0,0 +1,65 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGlyphClusters(t *testing.T) {
+	have := glyphClusters("Ab A é 👍🏽 👩‍💻 🇯🇵 ❤️")
+	want := []string{"A", "b", "é", "👍🏽", "👩‍💻", "🇯🇵", "❤️"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("Want %q, have %q", want, have)
+	}
+}
+
+func TestCheckGlyphs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the tofu box, a rendered A, the tofu box for 字 and nothing for 😀
+	img := image.NewRGBA(image.Rect(0, 0, glyphColumns*glyphCell, glyphCell))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	black := image.NewUniform(color.Black)
+	for _, cell := range []int{0, 2} {
+		box := image.Rect(cell*glyphCell+12, 8, cell*glyphCell+36, 40)
+		draw.Draw(img, box, black, image.Point{}, draw.Src)
+		draw.Draw(img, box.Inset(2), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.Draw(img, image.Rect(glyphCell+16, 10, glyphCell+30, 38), black, image.Point{}, draw.Src)
+	if err := ioutil.WriteFile(filepath.Join(dir, "glyphs.png"), encodeTestPage(t, img), 0600); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	if err := ioutil.WriteFile(bin, []byte("#!/bin/sh\ncat > page.html\ncat glyphs.png\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	missing, err := CheckGlyphs(context.Background(), "A字 😀", `"Noto Sans"; }`, ImageOptions{BinaryPath: bin, WorkDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"字", "😀"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("Want missing %q, have %q", want, missing)
+	}
+	page, _ := ioutil.ReadFile(filepath.Join(dir, "page.html"))
+	if !strings.Contains(string(page), `font-family:"Noto Sans" }`) || !strings.Contains(string(page), "\U0010FFFD") {
+		t.Errorf("Want the font family and the tofu character in the page, have %s", page)
+	}
+
+	// more characters than the image has cells
+	if _, err := CheckGlyphs(context.Background(), "abcdefghijklmnopq", "", ImageOptions{BinaryPath: bin, WorkDir: dir}); err == nil {
+		t.Error("Want error for characters outside of the image")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/SebastiaanKlippert/go-wkhtmltopdf
+
+go 1.13
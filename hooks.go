@@ -0,0 +1,57 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"sync"
+)
+
+// Hooks are called around each Job.Render, including the jobs of a Worker and a Scheduler, for example to audit or bill
+// renders or to rewrite the input. Either function can be nil.
+type Hooks struct {
+	// Before is called before the job is rendered and can change the job, an error stops the render and is returned
+	Before func(ctx context.Context, job *Job) error
+	// After is called with the output and the error of the render, the error it returns replaces the error of the render
+	After func(ctx context.Context, job *Job, output []byte, err error) error
+}
+
+var hooks struct {
+	list []Hooks
+	sync.Mutex
+}
+
+// RegisterHooks adds hooks which are called around each Job.Render, hooks should be registered when the program starts.
+// Before functions are called in the order they were registered and After functions in the reverse order,
+// only the After functions of hooks which Before was called for are called.
+func RegisterHooks(h Hooks) {
+	hooks.Lock()
+	hooks.list = append(hooks.list, h)
+	hooks.Unlock()
+}
+
+// withHooks calls render between the registered Before and After hooks
+func withHooks(ctx context.Context, job *Job, render func() ([]byte, error)) ([]byte, error) {
+	hooks.Lock()
+	list := hooks.list
+	hooks.Unlock()
+
+	var output []byte
+	var err error
+	i := 0
+	for ; i < len(list); i++ {
+		if list[i].Before == nil {
+			continue
+		}
+		if err = list[i].Before(ctx, job); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		output, err = render()
+	}
+	for i--; i >= 0; i-- {
+		if list[i].After != nil {
+			err = list[i].After(ctx, job, output, err)
+		}
+	}
+	return output, err
+}
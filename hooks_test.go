@@ -0,0 +1,81 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithHooks(t *testing.T) {
+	defer func(list []Hooks) { hooks.list = list }(hooks.list)
+	hooks.list = nil
+
+	var calls []string
+	RegisterHooks(Hooks{
+		Before: func(ctx context.Context, job *Job) error {
+			calls = append(calls, "before 1")
+			job.Image.Input = "http://example.com/rewritten"
+			return nil
+		},
+		After: func(ctx context.Context, job *Job, output []byte, err error) error {
+			calls = append(calls, "after 1 "+string(output))
+			return err
+		},
+	})
+	RegisterHooks(Hooks{
+		After: func(ctx context.Context, job *Job, output []byte, err error) error {
+			calls = append(calls, "after 2")
+			return errors.New("quota exceeded")
+		},
+	})
+
+	job := &Job{Image: &ImageOptions{Input: "http://example.com"}}
+	output, err := withHooks(context.Background(), job, func() ([]byte, error) {
+		calls = append(calls, "render "+job.Image.Input)
+		return []byte("output"), nil
+	})
+	if err == nil || err.Error() != "quota exceeded" {
+		t.Errorf("Want quota exceeded, have %v", err)
+	}
+	if string(output) != "output" {
+		t.Errorf("Want output, have %s", output)
+	}
+	want := []string{"before 1", "render http://example.com/rewritten", "after 2", "after 1 output"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Want %q, have %q", want, calls)
+	}
+}
+
+func TestWithHooksBeforeError(t *testing.T) {
+	defer func(list []Hooks) { hooks.list = list }(hooks.list)
+	hooks.list = nil
+
+	var calls []string
+	RegisterHooks(Hooks{
+		After: func(ctx context.Context, job *Job, output []byte, err error) error {
+			calls = append(calls, "after 1 "+err.Error())
+			return err
+		},
+	})
+	RegisterHooks(Hooks{
+		Before: func(ctx context.Context, job *Job) error {
+			return errors.New("denied")
+		},
+		After: func(ctx context.Context, job *Job, output []byte, err error) error {
+			calls = append(calls, "after 2")
+			return err
+		},
+	})
+	_, err := withHooks(context.Background(), &Job{}, func() ([]byte, error) {
+		calls = append(calls, "render")
+		return nil, nil
+	})
+	if err == nil || err.Error() != "denied" {
+		t.Errorf("Want denied, have %v", err)
+	}
+	want := []string{"after 1 denied"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Want %q, have %q", want, calls)
+	}
+}
@@ -0,0 +1,133 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ResultStore stores the output of jobs by their IdempotencyKey, so a job which is submitted again,
+// for example because an HTTP request was retried, returns the original output instead of being rendered again.
+// A ResultStore must be safe for concurrent use. It can be backed by a shared cache such as Redis when
+// several workers handle the same queue. The keys of jobs with a Job.Profile start with the quoted profile name,
+// so a tenant can not get the output of another tenant which uses the same IdempotencyKey.
+type ResultStore interface {
+	Get(key string) (output []byte, ok bool)
+	Put(key string, output []byte)
+}
+
+// MemoryResultStore is a ResultStore which keeps the output of the last jobs in memory
+type MemoryResultStore struct {
+	size    int
+	results map[string][]byte
+	stored  map[string]time.Time // the time each result was last stored, see DeleteBefore
+	keys    []string             // keys in the order they were added, the oldest is removed first
+	mu      sync.Mutex
+}
+
+// NewMemoryResultStore returns a MemoryResultStore which keeps the output of the last size jobs
+func NewMemoryResultStore(size int) *MemoryResultStore {
+	return &MemoryResultStore{size: size, results: make(map[string][]byte), stored: make(map[string]time.Time)}
+}
+
+// Get returns the output of the job with key
+func (s *MemoryResultStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	output, ok := s.results[key]
+	return output, ok
+}
+
+// Put stores the output of the job with key
+func (s *MemoryResultStore) Put(key string, output []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.results[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.results[key] = output
+	s.stored[key] = time.Now()
+	for len(s.keys) > s.size {
+		delete(s.results, s.keys[0])
+		delete(s.stored, s.keys[0])
+		s.keys = s.keys[1:]
+	}
+}
+
+// DeleteBefore deletes the results which were stored before t, it implements ResultExpirer
+func (s *MemoryResultStore) DeleteBefore(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := s.keys[:0]
+	for _, key := range s.keys {
+		if s.stored[key].Before(t) {
+			delete(s.results, key)
+			delete(s.stored, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	s.keys = keys
+}
+
+// resultKey returns the key of the output of a job with the idempotency key in a ResultStore, scoped by its profile.
+// Keys of jobs without a profile are scoped by the empty profile, so they can not be confused with keys of a profile
+func resultKey(profile, key string) string {
+	if key == "" {
+		return key
+	}
+	// the quoted name can not be confused with a name that contains the separator
+	return strconv.Quote(profile) + "/" + key
+}
+
+// idempotentCall is a render in progress for an idempotency key
+type idempotentCall struct {
+	done   chan struct{}
+	output []byte
+	err    error
+}
+
+// idempotent calls render once for jobs with the same key at the same time and stores the output of a successful
+// render in store, a key which is already in store returns the stored output without calling render
+func idempotent(ctx context.Context, store ResultStore, calls *idempotentCalls, key string, render func() ([]byte, error)) ([]byte, error) {
+	if key == "" || store == nil {
+		return render()
+	}
+	if output, ok := store.Get(key); ok {
+		return output, nil
+	}
+
+	calls.Lock()
+	if calls.m == nil {
+		calls.m = make(map[string]*idempotentCall)
+	}
+	if c, ok := calls.m[key]; ok {
+		calls.Unlock()
+		select {
+		case <-c.done:
+			return c.output, c.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	c := &idempotentCall{done: make(chan struct{})}
+	calls.m[key] = c
+	calls.Unlock()
+
+	c.output, c.err = render()
+	if c.err == nil {
+		store.Put(key, c.output)
+	}
+	calls.Lock()
+	delete(calls.m, key)
+	calls.Unlock()
+	close(c.done)
+	return c.output, c.err
+}
+
+// idempotentCalls holds the renders in progress by idempotency key
+type idempotentCalls struct {
+	m map[string]*idempotentCall
+	sync.Mutex
+}
@@ -0,0 +1,91 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestMemoryResultStore(t *testing.T) {
+	s := NewMemoryResultStore(2)
+	s.Put("a", []byte("1"))
+	s.Put("b", []byte("2"))
+	s.Put("a", []byte("3"))
+	if output, _ := s.Get("a"); string(output) != "3" {
+		t.Errorf("Want 3, have %s", output)
+	}
+	s.Put("c", []byte("4"))
+	if _, ok := s.Get("a"); ok {
+		t.Error("Want oldest key to be removed")
+	}
+	if output, ok := s.Get("c"); !ok || string(output) != "4" {
+		t.Errorf("Want 4, have %s", output)
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	store := NewMemoryResultStore(10)
+	calls := &idempotentCalls{}
+	var mu sync.Mutex
+	renders := 0
+	start := make(chan struct{})
+	render := func() ([]byte, error) {
+		<-start
+		mu.Lock()
+		renders++
+		mu.Unlock()
+		return []byte("%PDF"), nil
+	}
+
+	var wg sync.WaitGroup
+	outputs := make([][]byte, 5)
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs[i], _ = idempotent(context.Background(), store, calls, "key", render)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	// a render which started after the first one finished returns the stored output
+	output, _ := idempotent(context.Background(), store, calls, "key", render)
+	outputs = append(outputs, output)
+
+	if renders != 1 {
+		t.Errorf("Want 1 render, have %d", renders)
+	}
+	for _, output := range outputs {
+		if string(output) != "%PDF" {
+			t.Errorf("Want %%PDF, have %s", output)
+		}
+	}
+}
+
+func TestIdempotentDoesNotStoreErrors(t *testing.T) {
+	store := NewMemoryResultStore(10)
+	calls := &idempotentCalls{}
+	_, err := idempotent(context.Background(), store, calls, "key", func() ([]byte, error) {
+		return nil, errors.New("failed")
+	})
+	if err == nil {
+		t.Error("Want error")
+	}
+	if _, ok := store.Get("key"); ok {
+		t.Error("Want failed render not to be stored")
+	}
+}
+
+func TestResultKey(t *testing.T) {
+	// a key without a profile can not be the key of a profile
+	if resultKey("", `"acme"/order-1`) == resultKey("acme", "order-1") {
+		t.Error("Want keys without a profile scoped")
+	}
+	if have := resultKey("", "order-1"); have != `""/order-1` {
+		t.Errorf("Want \"\"/order-1, have %s", have)
+	}
+	if have := resultKey("acme", ""); have != "" {
+		t.Errorf("Want no key, have %s", have)
+	}
+}
@@ -0,0 +1,139 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrInvalidInput is the error an InputError unwraps to, for use with errors.Is
+var ErrInvalidInput = errors.New("invalid input")
+
+// InputError is returned before wkhtmltopdf or wkhtmltoimage is started when an input exceeds the InputLimits
+// or its HTML is not valid UTF-8, so oversized or malformed jobs do not take a process of a shared render service
+type InputError struct {
+	Input  string // URL or file name of the input, shortened when it is long, or "-" for HTML from stdin
+	Reason string // Why the input was rejected, e.g. "is not valid UTF-8"
+}
+
+func (e *InputError) Error() string {
+	return "input " + e.Input + " " + e.Reason
+}
+
+// Unwrap returns ErrInvalidInput
+func (e *InputError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// InputLimits are limits of the inputs of all PDFs and images, see SetInputLimits
+type InputLimits struct {
+	MaxInputBytes int64 // Maximum size of the HTML of a PageReader or of ImageOptions.Html, 0 means no limit
+	MaxURLLength  int   // Maximum length of the URL or file name of a page, cover or ImageOptions.Input, 0 means no limit
+	ValidateUTF8  bool  // The HTML of a PageReader or of ImageOptions.Html must be valid UTF-8
+}
+
+var inputLimits struct {
+	limits InputLimits
+	sync.Mutex
+}
+
+// SetInputLimits sets the limits which the inputs of all PDFs and images are checked against before wkhtmltopdf
+// or wkhtmltoimage is started, an InputError is returned for an input which exceeds them.
+// The HTML of a PageReader is read into memory to check it when MaxInputBytes or ValidateUTF8 is set.
+func SetInputLimits(l InputLimits) {
+	inputLimits.Lock()
+	inputLimits.limits = l
+	inputLimits.Unlock()
+}
+
+// GetInputLimits returns the limits set with SetInputLimits
+func GetInputLimits() InputLimits {
+	inputLimits.Lock()
+	defer inputLimits.Unlock()
+	return inputLimits.limits
+}
+
+// checkInput returns an InputError if the URL or file name is longer than MaxURLLength
+func (l InputLimits) checkInput(input string) error {
+	if l.MaxURLLength <= 0 || len(input) <= l.MaxURLLength {
+		return nil
+	}
+	short := input
+	if len(short) > 64 {
+		short = short[:64] + "..."
+	}
+	return &InputError{Input: short, Reason: fmt.Sprintf("is %d characters long, the maximum is %d", len(input), l.MaxURLLength)}
+}
+
+// checksHTML returns true if HTML is checked by checkHTML, so it has to be read
+func (l InputLimits) checksHTML() bool {
+	return l.MaxInputBytes > 0 || l.ValidateUTF8
+}
+
+// checkHTML returns an InputError if html is larger than MaxInputBytes or is not valid UTF-8 when ValidateUTF8 is set
+func (l InputLimits) checkHTML(html []byte) error {
+	if l.MaxInputBytes > 0 && int64(len(html)) > l.MaxInputBytes {
+		return &InputError{Input: "-", Reason: fmt.Sprintf("is larger than %d bytes", l.MaxInputBytes)}
+	}
+	if l.ValidateUTF8 && !utf8.Valid(html) {
+		return &InputError{Input: "-", Reason: "is not valid UTF-8"}
+	}
+	return nil
+}
+
+// readHTML reads at most one byte more than MaxInputBytes of the HTML from r and checks it with checkHTML
+func (l InputLimits) readHTML(r io.Reader) ([]byte, error) {
+	if l.MaxInputBytes > 0 {
+		r = io.LimitReader(r, l.MaxInputBytes+1)
+	}
+	html, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return html, l.checkHTML(html)
+}
+
+// checkInputs checks the inputs of pdfg with the InputLimits. The HTML of a PageReader is replaced by the HTML
+// which was read to check it.
+func (pdfg *PDFGenerator) checkInputs() error {
+	l := GetInputLimits()
+	if pdfg.Cover.Input != "" {
+		if err := l.checkInput(pdfg.Cover.Input); err != nil {
+			return err
+		}
+	}
+	for _, p := range pdfg.pages {
+		switch p := p.(type) {
+		case *Page:
+			if err := l.checkInput(p.Input); err != nil {
+				return err
+			}
+		case *PageReader:
+			if p.Input == nil || !l.checksHTML() {
+				continue
+			}
+			html, err := l.readHTML(p.Input)
+			if err != nil {
+				return err
+			}
+			p.Input = bytes.NewReader(html)
+		}
+	}
+	return nil
+}
+
+// checkInputs checks the Input or the Html of the image options with the InputLimits
+func (options *ImageOptions) checkInputs() error {
+	l := GetInputLimits()
+	if options.Input != "-" {
+		return l.checkInput(options.Input)
+	}
+	if options.Html == "" || !l.checksHTML() {
+		return nil
+	}
+	return l.checkHTML([]byte(options.Html))
+}
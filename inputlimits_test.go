@@ -0,0 +1,55 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestInputLimits(t *testing.T) {
+	defer SetInputLimits(InputLimits{})
+	SetInputLimits(InputLimits{MaxInputBytes: 16, MaxURLLength: 30, ValidateUTF8: true})
+
+	// the inputs are rejected before wkhtmltopdf is started
+	create := func(p page) error {
+		pdfg := NewPDFPreparer()
+		pdfg.binPath = "/nonexistent/wkhtmltopdf"
+		pdfg.AddPage(p)
+		return pdfg.Create()
+	}
+	tests := []struct {
+		page page
+		want string
+	}{
+		{NewPageReader(strings.NewReader("<html>Too large</html>")), "input - is larger than 16 bytes"},
+		{NewPageReader(strings.NewReader("<p>\xff</p>")), "input - is not valid UTF-8"},
+		{NewPage("https://example.com/" + strings.Repeat("a", 20)), "input https://example.com/aaaaaaaaaaaaaaaaaaaa is 40 characters long, the maximum is 30"},
+	}
+	for _, tc := range tests {
+		err := create(tc.page)
+		if !errors.Is(err, ErrInvalidInput) || err.Error() != tc.want {
+			t.Errorf("Want %s, have %v", tc.want, err)
+		}
+	}
+
+	// the HTML which was read is passed to wkhtmltopdf
+	pr := NewPageReader(strings.NewReader("<p>Grüße</p>"))
+	err := create(pr)
+	if errors.Is(err, ErrInvalidInput) {
+		t.Fatal(err)
+	}
+	html, _ := ioutil.ReadAll(pr.Input)
+	if string(html) != "<p>Grüße</p>" {
+		t.Errorf("Want <p>Grüße</p>, have %s", html)
+	}
+
+	// the InputError is found through the request ID which is added to the error
+	options := &ImageOptions{BinaryPath: "/nonexistent/wkhtmltoimage", Input: "-", Html: "<html>Too large</html>", Format: "png"}
+	_, err = RenderImage(WithRequestID(context.Background(), "req-1"), options)
+	var ie *InputError
+	if !errors.Is(err, ErrInvalidInput) || !errors.As(err, &ie) || ie.Input != "-" {
+		t.Errorf("Want InputError for image, have %v", err)
+	}
+}
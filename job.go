@@ -0,0 +1,264 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// JobVersion is the version of the JSON job format written by Job.ToJSON
+const JobVersion = 2
+
+// Constants for job types in the JSON job format
+const (
+	JobTypePDF   = "pdf"
+	JobTypeImage = "image"
+)
+
+// Job is a PDF or image job which can be saved as versioned JSON with ToJSON and restored with JobFromJSON,
+// so jobs which are queued or stored keep working after upgrading this package.
+// Exactly one of PDF and Image must be set.
+type Job struct {
+	PDF    *PDFGenerator
+	Image  *ImageOptions
+	Preset string // Name of a preset registered with RegisterPreset, its options are used when they are not set in the job
+	// IdempotencyKey identifies the job when it is submitted more than once, a Worker with a ResultStore
+	// returns the output of the first job with the same key without rendering again
+	IdempotencyKey string
+	// RequestID is added to error messages and temporary file names of the render, see WithRequestID.
+	// A Worker uses a random request ID for jobs which do not have one.
+	RequestID string
+	// Transforms are the names of transformers registered with RegisterTransformer,
+	// they change the HTML before and the output after rendering in this order
+	Transforms []string
+	// Profile is the name of a Profile of the Worker which renders the job
+	Profile string
+}
+
+// jsonJob is version 2 of the JSON job format, version 1 is the output of PDFGenerator.ToJSON
+type jsonJob struct {
+	Version    int           `json:"version"`
+	Type       string        `json:"type"`
+	Preset     string        `json:"preset,omitempty"`
+	Key        string        `json:"idempotency_key,omitempty"`
+	Request    string        `json:"request_id,omitempty"`
+	Transforms []string      `json:"transforms,omitempty"`
+	Profile    string        `json:"profile,omitempty"`
+	PDF        *jsonPDFJob   `json:"pdf,omitempty"`
+	Image      *ImageOptions `json:"image,omitempty"`
+}
+
+// jsonPDFJob is the PDFGenerator in version 2 of the JSON job format,
+// it adds the post processing options which are not in version 1.
+type jsonPDFJob struct {
+	jsonPDFGenerator
+	Linearize     bool
+	PDFA          PDFAOptions
+	Watermark     Watermark
+	Attachments   []Attachment
+	Accessibility AccessibilityOptions
+	ColorProfile  string
+}
+
+// ToJSON creates the JSON of the job in the current version of the job format.
+// The Sign function of a PDFGenerator can not be saved and must be set again after JobFromJSON.
+func (j *Job) ToJSON() ([]byte, error) {
+	jj := &jsonJob{Version: JobVersion, Preset: j.Preset, Key: j.IdempotencyKey, Request: j.RequestID, Transforms: j.Transforms, Profile: j.Profile}
+	switch {
+	case j.PDF != nil && j.Image != nil:
+		return nil, errors.New("job has both PDF and Image set")
+	case j.PDF != nil:
+		jpdf, err := j.PDF.toJSONPDFGenerator()
+		if err != nil {
+			return nil, err
+		}
+		jj.Type = JobTypePDF
+		jj.PDF = &jsonPDFJob{
+			jsonPDFGenerator: *jpdf,
+			Linearize:        j.PDF.Linearize,
+			PDFA:             j.PDF.PDFA,
+			Watermark:        j.PDF.Watermark,
+			Attachments:      j.PDF.attachments,
+			Accessibility:    j.PDF.Accessibility,
+			ColorProfile:     j.PDF.ColorProfile,
+		}
+	case j.Image != nil:
+		jj.Type = JobTypeImage
+		jj.Image = j.Image
+	default:
+		return nil, errors.New("job has no PDF or Image set")
+	}
+	return json.Marshal(jj)
+}
+
+// JobFromJSON restores a job from JSON created with Job.ToJSON, or from JSON created with PDFGenerator.ToJSON
+// which is version 1 of the job format.
+// In strict mode unknown fields are an error instead of being ignored, this detects jobs from a newer version of this
+// package and typing errors in handwritten jobs.
+// A PDF job looks for wkhtmltopdf in the same way as NewPDFGenerator.
+func JobFromJSON(jsonReader io.Reader, strict bool) (*Job, error) {
+	b, err := ioutil.ReadAll(jsonReader)
+	if err != nil {
+		return nil, err
+	}
+	return jobFromJSON(b, strict, nil, false)
+}
+
+// jobFromJSON restores a job from JSON, with the settings of its profile in profiles when profiles is not nil.
+// When queued is true the job is from a message queue and the BinaryPath of an image job is ignored,
+// so whoever can publish jobs can not choose the executable which is run
+func jobFromJSON(b []byte, strict bool, profiles map[string]Profile, queued bool) (*Job, error) {
+	version := struct {
+		Version *int   `json:"version"`
+		Profile string `json:"profile"`
+	}{}
+	err := json.Unmarshal(b, &version)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling JSON: %s", err)
+	}
+
+	profile, err := lookupProfile(profiles, version.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// version 1 has no version field
+	if version.Version == nil {
+		jp := new(jsonPDFGenerator)
+		err = decodeJSON(b, jp, strict)
+		if err != nil {
+			return nil, err
+		}
+		pdfg, err := jp.pdfGenerator(nil, profile.pdfPath())
+		if err != nil {
+			return nil, err
+		}
+		return &Job{PDF: pdfg}, nil
+	}
+
+	if *version.Version != JobVersion {
+		return nil, fmt.Errorf("unsupported job version %d", *version.Version)
+	}
+	jj := new(jsonJob)
+	err = decodeJSON(b, jj, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	preset, err := lookupPreset(profile.presetName(jj.Preset))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case jj.Type == JobTypePDF && jj.PDF != nil:
+		pdfg, err := jj.PDF.pdfGenerator(preset, profile.pdfPath())
+		if err != nil {
+			return nil, err
+		}
+		pdfg.Linearize = jj.PDF.Linearize
+		pdfg.PDFA = jj.PDF.PDFA
+		pdfg.Watermark = jj.PDF.Watermark
+		pdfg.attachments = jj.PDF.Attachments
+		pdfg.Accessibility = jj.PDF.Accessibility
+		pdfg.ColorProfile = jj.PDF.ColorProfile
+		return &Job{PDF: pdfg, Preset: jj.Preset, IdempotencyKey: jj.Key, RequestID: jj.Request, Transforms: jj.Transforms, Profile: jj.Profile}, nil
+	case jj.Type == JobTypeImage && jj.Image != nil:
+		if queued {
+			jj.Image.BinaryPath = ""
+		}
+		if preset != nil {
+			mergeImageOptions(jj.Image, preset.Image)
+		}
+		profile.setImagePath(jj.Image)
+		return &Job{Image: jj.Image, Preset: jj.Preset, IdempotencyKey: jj.Key, RequestID: jj.Request, Transforms: jj.Transforms, Profile: jj.Profile}, nil
+	}
+	return nil, fmt.Errorf("job of type %q has no %s set", jj.Type, jj.Type)
+}
+
+// Render creates the PDF or image of the job and returns it, between the hooks registered with RegisterHooks.
+// The output of a PDF job is always returned, also when it has an OutputFile or output writer set,
+// the image of an image job is returned base64 encoded when its Output is OutputBase64 or OutputDataURI.
+func (j *Job) Render(ctx context.Context) ([]byte, error) {
+	if j.RequestID != "" {
+		ctx = WithRequestID(ctx, j.RequestID)
+	}
+	return withHooks(ctx, j, func() ([]byte, error) {
+		return j.render(ctx)
+	})
+}
+
+// render creates the PDF or image of the job with its transforms
+func (j *Job) render(ctx context.Context) ([]byte, error) {
+	if len(j.Transforms) == 0 {
+		return j.renderOutput(ctx)
+	}
+	list, err := lookupTransformers(j.Transforms)
+	if err != nil {
+		return nil, err
+	}
+	// the HTML of an image job is changed in a copy, so the job can be rendered again
+	job := *j
+	if j.Image != nil {
+		options := *j.Image
+		job.Image = &options
+	}
+	err = job.transformHTML(ctx, list)
+	if err != nil {
+		return nil, err
+	}
+	output, err := job.renderOutput(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return transformOutput(ctx, list, output)
+}
+
+// renderOutput creates the PDF or image of the job
+func (j *Job) renderOutput(ctx context.Context) ([]byte, error) {
+	switch {
+	case j.PDF != nil:
+		j.PDF.OutputFile = ""
+		j.PDF.SetOutput(nil)
+		err := j.PDF.CreateContext(ctx)
+		recordRenderStatus(ctx, "application/pdf", 0, 0, j.PDF.Warnings())
+		if err != nil {
+			return nil, err
+		}
+		return j.PDF.Bytes(), nil
+	case j.Image != nil:
+		options := *j.Image
+		// the image is returned, base64 encoded if the output is OutputBase64 or OutputDataURI
+		if !base64Output(options.Output) {
+			options.Output = ""
+		}
+		res, err := RenderImage(ctx, &options)
+		if res == nil {
+			return []byte{}, err
+		}
+		contentType := imageContentType(res.format)
+		if base64Output(options.Output) {
+			contentType = "text/plain"
+		}
+		recordRenderStatus(ctx, contentType, res.Width, res.Height, res.Warnings)
+		return res.Image, err
+	}
+	return nil, errors.New("job has no PDF or Image set")
+}
+
+// decodeJSON unmarshals b in v, in strict mode unknown fields are an error
+func decodeJSON(b []byte, v interface{}, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(v)
+	if err != nil {
+		return fmt.Errorf("error unmarshaling JSON: %s", err)
+	}
+	return nil
+}
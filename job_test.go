@@ -0,0 +1,129 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPDFJobJSON(t *testing.T) {
+	pdfg := newTestPDFGenerator(t)
+	pdfg.Linearize = true
+	pdfg.Watermark.Text = "DRAFT"
+	pdfg.Accessibility = AccessibilityOptions{Enable: true, Lang: "en"}
+	pdfg.ColorProfile = ColorProfileSRGB
+	pdfg.AddAttachment(Attachment{Filename: "data.csv", Data: []byte("a,b")})
+
+	jb, err := (&Job{PDF: pdfg}).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(jb, []byte(`{"version":2,"type":"pdf","pdf":{`)) {
+		t.Errorf("Want version 2 PDF job, have %s", jb[:40])
+	}
+
+	job, err := JobFromJSON(bytes.NewReader(jb), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.PDF == nil || job.Image != nil {
+		t.Fatal("Want PDF job")
+	}
+	want := wantArgString()
+	if job.PDF.ArgString() != want {
+		t.Errorf("Want argstring:\n%s\nHave:\n%s", want, job.PDF.ArgString())
+	}
+	if !job.PDF.Linearize || job.PDF.Watermark.Text != "DRAFT" || job.PDF.Accessibility != pdfg.Accessibility || job.PDF.ColorProfile != ColorProfileSRGB {
+		t.Error("Post processing options are not restored")
+	}
+	if !reflect.DeepEqual(job.PDF.attachments, pdfg.attachments) {
+		t.Errorf("Want attachments %v, have %v", pdfg.attachments, job.PDF.attachments)
+	}
+}
+
+func TestImageJobJSON(t *testing.T) {
+	options := &ImageOptions{Input: "http://example.com", Format: "jpg", Width: 800, Quality: 80}
+	jb, err := (&Job{Image: options, IdempotencyKey: "request-1", Transforms: []string{"inline-assets", "webp"}}).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := JobFromJSON(bytes.NewReader(jb), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(job.Image, options) {
+		t.Errorf("Want image options %+v, have %+v", options, job.Image)
+	}
+	if job.IdempotencyKey != "request-1" {
+		t.Errorf("Want idempotency key request-1, have %q", job.IdempotencyKey)
+	}
+	if !reflect.DeepEqual(job.Transforms, []string{"inline-assets", "webp"}) {
+		t.Errorf("Want transforms inline-assets and webp, have %q", job.Transforms)
+	}
+}
+
+func TestJobFromJSONVersion1(t *testing.T) {
+	pdfg := newTestPDFGenerator(t)
+	jb, err := pdfg.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := JobFromJSON(bytes.NewReader(jb), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := wantArgString()
+	if job.PDF.ArgString() != want {
+		t.Errorf("Want argstring:\n%s\nHave:\n%s", want, job.PDF.ArgString())
+	}
+}
+
+func TestJobFromJSONStrict(t *testing.T) {
+	jb := `{"version":2,"type":"image","image":{"Input":"http://example.com","Colour":"red"}}`
+
+	_, err := JobFromJSON(strings.NewReader(jb), false)
+	if err != nil {
+		t.Errorf("Want no error without strict mode, have %v", err)
+	}
+	_, err = JobFromJSON(strings.NewReader(jb), true)
+	if err == nil {
+		t.Error("Want an error for an unknown field in strict mode, have no error")
+	}
+}
+
+func TestJobFromJSONErrors(t *testing.T) {
+	for _, jb := range []string{
+		`{"version":3,"type":"pdf","pdf":{}}`,
+		`{"version":2,"type":"pdf"}`,
+		`{"version":2,"type":"video","image":{}}`,
+		`not json`,
+	} {
+		_, err := JobFromJSON(strings.NewReader(jb), false)
+		if err == nil {
+			t.Errorf("Want an error for job %s, have no error", jb)
+		}
+	}
+
+	_, err := (&Job{}).ToJSON()
+	if err == nil {
+		t.Error("Want an error for an empty job, have no error")
+	}
+}
+
+func TestJobRender(t *testing.T) {
+	job, err := JobFromJSON(strings.NewReader(`{"version":2,"type":"pdf","pdf":{"Pages":[{"InputFile":"-","Base64PageData":"PGh0bWw+SGk8L2h0bWw+"}]}}`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := job.Render(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Error("Output is not a PDF")
+	}
+}
@@ -26,6 +26,14 @@ type jsonPage struct {
 // ToJSON creates JSON of the complete representation of the PDFGenerator.
 // It also saves all pages, for a PageReader page, the content is stored as a Base64 string in the JSON.
 func (pdfg *PDFGenerator) ToJSON() ([]byte, error) {
+	jpdf, err := pdfg.toJSONPDFGenerator()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jpdf)
+}
+
+func (pdfg *PDFGenerator) toJSONPDFGenerator() (*jsonPDFGenerator, error) {
 
 	jpdf := &jsonPDFGenerator{
 		TOC:            pdfg.TOC,
@@ -53,7 +61,7 @@ func (pdfg *PDFGenerator) ToJSON() ([]byte, error) {
 		}
 		jpdf.Pages = append(jpdf.Pages, jp)
 	}
-	return json.Marshal(jpdf)
+	return jpdf, nil
 }
 
 // NewPDFGeneratorFromJSON creates a new PDFGenerator and restores all the settings and pages
@@ -67,21 +75,43 @@ func NewPDFGeneratorFromJSON(jsonReader io.Reader) (*PDFGenerator, error) {
 		return nil, fmt.Errorf("error unmarshaling JSON: %s", err)
 	}
 
-	pdfg, err := NewPDFGenerator()
-	if err != nil {
-		return nil, fmt.Errorf("error creating PDF generator: %s", err)
+	return jp.pdfGenerator(nil, "")
+}
+
+// pdfGenerator creates a new PDFGenerator with the options of the preset, which can be nil,
+// and sets the options and pages of jp on it. It uses wkhtmltopdf at binPath, or looks for it when binPath is empty.
+func (jp *jsonPDFGenerator) pdfGenerator(preset *Preset, binPath string) (*PDFGenerator, error) {
+
+	pdfg := NewPDFPreparer()
+	if binPath != "" {
+		pdfg.binPath = binPath
+	} else {
+		err := pdfg.findPath()
+		if err != nil {
+			return nil, fmt.Errorf("error creating PDF generator: %s", err)
+		}
 	}
+	preset.setDocument(pdfg)
 
-	pdfg.TOC = jp.TOC
-	pdfg.Cover = jp.Cover
-	pdfg.globalOptions = jp.GlobalOptions
-	pdfg.outlineOptions = jp.OutlineOptions
+	// the options in JSON are set over the defaults and preset, so options which are not set keep those
+	pdfg.TOC.Include = pdfg.TOC.Include || jp.TOC.Include
+	mergeOptions(&pdfg.TOC.pageOptions, &jp.TOC.pageOptions)
+	mergeOptions(&pdfg.TOC.tocOptions, &jp.TOC.tocOptions)
+	if jp.Cover.Input != "" {
+		pdfg.Cover.Input = jp.Cover.Input
+	}
+	mergeOptions(&pdfg.Cover.pageOptions, &jp.Cover.pageOptions)
+	mergeOptions(&pdfg.globalOptions, &jp.GlobalOptions)
+	mergeOptions(&pdfg.outlineOptions, &jp.OutlineOptions)
 
 	for i, p := range jp.Pages {
+		po := preset.newPageOptions()
+		mergeOptions(&po.pageOptions, &p.PageOptions.pageOptions)
+		mergeOptions(&po.headerAndFooterOptions, &p.PageOptions.headerAndFooterOptions)
 		if p.Base64PageData == "" {
 			pdfg.AddPage(&Page{
 				Input:       p.InputFile,
-				PageOptions: p.PageOptions,
+				PageOptions: po,
 			})
 			continue
 		}
@@ -91,7 +121,7 @@ func NewPDFGeneratorFromJSON(jsonReader io.Reader) (*PDFGenerator, error) {
 		}
 		pdfg.AddPage(&PageReader{
 			Input:       bytes.NewReader(buf),
-			PageOptions: p.PageOptions,
+			PageOptions: po,
 		})
 	}
 
@@ -115,6 +145,9 @@ func (bo *boolOption) UnmarshalJSON(b []byte) error {
 	}
 	bo.value = jbo.Value
 	bo.option = jbo.Option
+	if n, ok := renamedOptions[bo.option]; ok {
+		bo.option = n
+	}
 	return nil
 }
 
@@ -182,6 +215,23 @@ func (fo *floatOption) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// lengthOption has the JSON of a uintOption, so JSON with lengths in whole mm is the same as before they were lengthOptions
+func (lo *lengthOption) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonFloatOption{lo.option, lo.isSet, lo.value})
+}
+
+func (lo *lengthOption) UnmarshalJSON(b []byte) error {
+	jlo := new(jsonFloatOption)
+	err := json.Unmarshal(b, jlo)
+	if err != nil {
+		return err
+	}
+	lo.value = jlo.Value
+	lo.isSet = jlo.IsSet
+	lo.option = jlo.Option
+	return nil
+}
+
 type jsonMapOption struct {
 	Option string
 	Value  map[string]string
@@ -26,12 +26,12 @@ func TestPDFGenerator_ToJSON(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	l := 15230
+	l := 16068
 	if len(jb) != l {
 		t.Errorf("Want %d JSON bytes, have %d", l, len(jb))
 	}
 
-	want := `{"GlobalOptions":{"CookieJar":{"Option":"cookie-jar","Value":""},"Copies":{"Option":"copies","IsSet":false,"Value":0},"Dpi":{"Option":"dpi","IsSet":true,"Value":600},"ExtendedHelp":{"Option":"extended-help","Value":false},"Grayscale":{"Option":"grayscale","Value":false},"Help":{"Option":"true","Value":false},"HTMLDoc":{"Option":"htmldoc","Value":false},"ImageDpi":{"Option":"image-dpi","IsSet":false,"Value":0},"ImageQuality":{"Option":"image-quality","IsSet":false,"Value":0},"License":{"Option":"license","Value":false},"Lowquality":{"Option":"lowquality","Value":false},"ManPage":{"Option":"manpage","Value":false},"MarginBottom":{"Option":"margin-bottom","IsSet":true,"Value":40},"MarginLeft":{"Option":"margin-left","IsSet":true,"Value":0},"MarginRight":{"Option":"margin-right","IsSet":false,"Value":0},"MarginTop":{"Option":"margin-top","IsSet":false,"Value":0},"NoCollate":{"Option":"nocollate","Value":false},"NoPdfCompression":{"Option":"no-pdf-compression","Value":false},"Orientation":{"Option":"orientation","Value":""},"PageHeight":{"Option":"page-height","IsSet":false,"Value":0},"PageSize":{"Option":"page-size","Value":"A4"},"PageWidth":{"Option":"page-width","IsSet":false,"Value":0},"Quiet":{"Option":"quiet","Value":false},"ReadArgsFromStdin":{"Option":"read-args-from-stdin","Value":false},"Readme":{"Option":"readme","Value":false},"Title":{"Option":"title","Value":""},"Version":{"Option":"version","Value":false}},"OutlineOptions":{"DumpDefaultTocXsl":{"Option":"dump-default-toc-xsl","Value":false},"DumpOutline":{"Option":"dump-outline","Value":""},"NoOutline":{"Option":"no-outline","Value":false},"OutlineDepth":{"Option":"outline-depth","IsSet":false,"Value":0}},"Cover":{"Input":"https://wkhtmltopdf.org/index.html","Allow":{"Option":"allow","Value":null},"BypassProxyFor":{"Option":"bypass-proxy-for","Value":null},"CacheDir":{"Option":"cache-dir","Value":""},"CheckboxCheckedSvg":{"Option":"checkbox-checked-svg","Value":""},"CheckboxSvg":{"Option":"checkbox-svg","Value":""},"Cookie":{"Option":"cookie","Value":null},"CustomHeader":{"Option":"custom-header","Value":null},"CustomHeaderPropagation":{"Option":"custom-header-propagation","Value":false},"DebugJavascript":{"Option":"debug-javascript","Value":false},"DefaultHeader":{"Option":"default-header","Value":false},"DisableExternalLinks":{"Option":"disable-external-links","Value":false},"DisableInternalLinks":{"Option":"disable-internal-links","Value":false},"DisableJavascript":{"Option":"disable-javascript","Value":false},"DisableLocalFileAccess":{"Option":"disable-local-file-access","Value":false},"DisableSmartShrinking":{"Option":"disable-smart-shrinking","Value":false},"EnableForms":{"Option":"enable-forms","Value":false},"EnablePlugins":{"Option":"enable-plugins","Value":false},"EnableTocBackLinks":{"Option":"enable-toc-back-links","Value":false},"Encoding":{"Option":"encoding","Value":""},"ExcludeFromOutline":{"Option":"exclude-from-outline","Value":false},"JavascriptDelay":{"Option":"javascript-delay","IsSet":false,"Value":0},"KeepRelativeLinks":{"Option":"keep-relative-links","Value":false},"LoadErrorHandling":{"Option":"load-error-handling","Value":""},"LoadMediaErrorHandling":{"Option":"load-media-error-handling","Value":""},"MinimumFontSize":{"Option":"minimum-font-size","IsSet":false,"Value":0},"NoBackground":{"Option":"no-background","Value":false},"NoCustomHeaderPropagation":{"Option":"no-custom-header-propagation","Value":false},"NoImages":{"Option":"no-images","Value":false},"NoStopSlowScripts":{"Option":"no-stop-slow-scripts","Value":false},"PageOffset":{"Option":"page-offset","IsSet":false,"Value":0},"Password":{"Option":"password","Value":""},"Post":{"Option":"post","Value":null},"PostFile":{"Option":"post-file","Value":null},"PrintMediaType":{"Option":"print-media-type","Value":false},"Proxy":{"Option":"proxy","Value":""},"RadiobuttonCheckedSvg":{"Option":"radiobutton-checked-svg","Value":""},"RadiobuttonSvg":{"Option":"radiobutton-svg","Value":""},"RunScript":{"Option":"run-script","Value":null},"SslCrtPath":{"Option":"ssl-crt-path","Value":""},"SslKeyPassword":{"Option":"ssl-key-password","Value":""},"SslKeyPath":{"Option":"ssl-key-path","Value":""},"Username":{"Option":"username","Value":""},"UserStyleSheet":{"Option":"user-style-sheet","Value":""},"ViewportSize":{"Option":"viewport-size","Value":""},"WindowStatus":{"Option":"window-status","Value":""},"Zoom":{"Option":"zoom","IsSet":true,"Value":0.75}},"TOC":{"Include":true,"Allow":{"Option":"allow","Value":null},"BypassProxyFor":{"Option":"bypass-proxy-for","Value":null},"CacheDir":{"Option":"cache-dir","Value":""},"CheckboxCheckedSvg":{"Option":"checkbox-checked-svg","Value":""},"CheckboxSvg":{"Option":"checkbox-svg","Value":""},"Cookie":{"Option":"cookie","Value":null},"CustomHeader":{"Option":"custom-header","Value":null},"CustomHeaderPropagation":{"Option":"custom-header-propagation","Value":false},"DebugJavascript":{"Option":"debug-javascript","Value":false},"DefaultHeader":{"Option":"default-header","Value":false},"DisableExternalLinks":{"Option":"disable-external-links","Value":false},"DisableInternalLinks":{"Option":"disable-internal-links","Value":false},"DisableJavascript":{"Option":"disable-javascript","Value":false},"DisableLocalFileAccess":{"Option":"disable-local-file-access","Value":false},"DisableSmartShrinking":{"Option":"disable-smart-shrinking","Value":false},"EnableForms":{"Option":"enable-forms","Value":false},"EnablePlugins":{"Option":"enable-plugins","Value":false},"EnableTocBackLinks":{"Option":"enable-toc-back-links","Value":false},"Encoding":{"Option":"encoding","Value":""},"ExcludeFromOutline":{"Option":"exclude-from-outline","Value":false},"JavascriptDelay":{"Option":"javascript-delay","IsSet":false,"Value":0},"KeepRelativeLinks":{"Option":"keep-relative-links","Value":false},"LoadErrorHandling":{"Option":"load-error-handling","Value":""},"LoadMediaErrorHandling":{"Option":"load-media-error-handling","Value":""},"MinimumFontSize":{"Option":"minimum-font-size","IsSet":false,"Value":0},"NoBackground":{"Option":"no-background","Value":false},"NoCustomHeaderPropagation":{"Option":"no-custom-header-propagation","Value":false},"NoImages":{"Option":"no-images","Value":false},"NoStopSlowScripts":{"Option":"no-stop-slow-scripts","Value":false},"PageOffset":{"Option":"page-offset","IsSet":false,"Value":0},"Password":{"Option":"password","Value":""},"Post":{"Option":"post","Value":null},"PostFile":{"Option":"post-file","Value":null},"PrintMediaType":{"Option":"print-media-type","Value":false},"Proxy":{"Option":"proxy","Value":""},"RadiobuttonCheckedSvg":{"Option":"radiobutton-checked-svg","Value":""},"RadiobuttonSvg":{"Option":"radiobutton-svg","Value":""},"RunScript":{"Option":"run-script","Value":null},"SslCrtPath":{"Option":"ssl-crt-path","Value":""},"SslKeyPassword":{"Option":"ssl-key-password","Value":""},"SslKeyPath":{"Option":"ssl-key-path","Value":""},"Username":{"Option":"username","Value":""},"UserStyleSheet":{"Option":"user-style-sheet","Value":""},"ViewportSize":{"Option":"viewport-size","Value":""},"WindowStatus":{"Option":"window-status","Value":""},"Zoom":{"Option":"zoom","IsSet":false,"Value":0},"DisableDottedLines":{"Option":"disable-dotted-lines","Value":true},"DisableTocLinks":{"Option":"disable-toc-links","Value":false},"TocHeaderText":{"Option":"toc-header-text","Value":""},"TocLevelIndentation":{"Option":"toc-level-indentation","IsSet":false,"Value":0},"TocTextSizeShrink":{"Option":"toc-text-size-shrink","IsSet":false,"Value":0},"XslStyleSheet":{"Option":"xsl-style-sheet","Value":""}},"Pages":[{"PageOptions":{"Allow":{"Option":"allow","Value":["/usr/local/html","/usr/local/images"]},"BypassProxyFor":{"Option":"bypass-proxy-for","Value":null},"CacheDir":{"Option":"cache-dir","Value":""},"CheckboxCheckedSvg":{"Option":"checkbox-checked-svg","Value":""},"CheckboxSvg":{"Option":"checkbox-svg","Value":""},"Cookie":{"Option":"cookie","Value":null},"CustomHeader":{"Option":"custom-header","Value":{"X-AppKey":"abcdef"}},"CustomHeaderPropagation":{"Option":"custom-header-propagation","Value":false},"DebugJavascript":{"Option":"debug-javascript","Value":false},"DefaultHeader":{"Option":"default-header","Value":false},"DisableExternalLinks":{"Option":"disable-external-links","Value":false},"DisableInternalLinks":{"Option":"disable-internal-links","Value":false},"DisableJavascript":{"Option":"disable-javascript","Value":false},"DisableLocalFileAccess":{"Option":"disable-local-file-access","Value":false},"DisableSmartShrinking":{"Option":"disable-smart-shrinking","Value":true},"EnableForms":{"Option":"enable-forms","Value":false},"EnablePlugins":{"Option":"enable-plugins","Value":false},"EnableTocBackLinks":{"Option":"enable-toc-back-links","Value":false},"Encoding":{"Option":"encoding","Value":""},"ExcludeFromOutline":{"Option":"exclude-from-outline","Value":false},"JavascriptDelay":{"Option":"javascript-delay","IsSet":false,"Value":0},"KeepRelativeLinks":{"Option":"keep-relative-links","Value":false},"LoadErrorHandling":{"Option":"load-error-handling","Value":""},"LoadMediaErrorHandling":{"Option":"load-media-error-handling","Value":""},"MinimumFontSize":{"Option":"minimum-font-size","IsSet":false,"Value":0},"NoBackground":{"Option":"no-background","Value":false},"NoCustomHeaderPropagation":{"Option":"no-custom-header-propagation","Value":false},"NoImages":{"Option":"no-images","Value":false},"NoStopSlowScripts":{"Option":"no-stop-slow-scripts","Value":false},"PageOffset":{"Option":"page-offset","IsSet":false,"Value":0},"Password":{"Option":"password","Value":""},"Post":{"Option":"post","Value":null},"PostFile":{"Option":"post-file","Value":null},"PrintMediaType":{"Option":"print-media-type","Value":false},"Proxy":{"Option":"proxy","Value":""},"RadiobuttonCheckedSvg":{"Option":"radiobutton-checked-svg","Value":""},"RadiobuttonSvg":{"Option":"radiobutton-svg","Value":""},"RunScript":{"Option":"run-script","Value":null},"SslCrtPath":{"Option":"ssl-crt-path","Value":""},"SslKeyPassword":{"Option":"ssl-key-password","Value":""},"SslKeyPath":{"Option":"ssl-key-path","Value":""},"Username":{"Option":"username","Value":""},"UserStyleSheet":{"Option":"user-style-sheet","Value":""},"ViewportSize":{"Option":"viewport-size","Value":"3840x2160"},"WindowStatus":{"Option":"window-status","Value":""},"Zoom":{"Option":"zoom","IsSet":false,"Value":0},"FooterCenter":{"Option":"footer-center","Value":""},"FooterFontName":{"Option":"footer-font-name","Value":""},"FooterFontSize":{"Option":"footer-font-size","IsSet":false,"Value":0},"FooterHTML":{"Option":"footer-html","Value":""},"FooterLeft":{"Option":"footer-left","Value":""},"FooterLine":{"Option":"footer-line","Value":false},"FooterRight":{"Option":"footer-right","Value":""},"FooterSpacing":{"Option":"footer-spacing","IsSet":false,"Value":0},"HeaderCenter":{"Option":"header-center","Value":""},"HeaderFontName":{"Option":"header-font-name","Value":""},"HeaderFontSize":{"Option":"header-font-size","IsSet":false,"Value":0},"HeaderHTML":{"Option":"header-html","Value":""},"HeaderLeft":{"Option":"header-left","Value":""},"HeaderLine":{"Option":"header-line","Value":false},"HeaderRight":{"Option":"header-right","Value":""},"HeaderSpacing":{"Option":"header-spacing","IsSet":true,"Value":10.01},"Replace":{"Option":"replace","Value":null}},"InputFile":"https://www.google.com","Base64PageData":""},{"PageOptions":{"Allow":{"Option":"allow","Value":null},"BypassProxyFor":{"Option":"bypass-proxy-for","Value":null},"CacheDir":{"Option":"cache-dir","Value":""},"CheckboxCheckedSvg":{"Option":"checkbox-checked-svg","Value":""},"CheckboxSvg":{"Option":"checkbox-svg","Value":""},"Cookie":{"Option":"cookie","Value":null},"CustomHeader":{"Option":"custom-header","Value":null},"CustomHeaderPropagation":{"Option":"custom-header-propagation","Value":false},"DebugJavascript":{"Option":"debug-javascript","Value":false},"DefaultHeader":{"Option":"default-header","Value":false},"DisableExternalLinks":{"Option":"disable-external-links","Value":false},"DisableInternalLinks":{"Option":"disable-internal-links","Value":false},"DisableJavascript":{"Option":"disable-javascript","Value":false},"DisableLocalFileAccess":{"Option":"disable-local-file-access","Value":false},"DisableSmartShrinking":{"Option":"disable-smart-shrinking","Value":false},"EnableForms":{"Option":"enable-forms","Value":false},"EnablePlugins":{"Option":"enable-plugins","Value":false},"EnableTocBackLinks":{"Option":"enable-toc-back-links","Value":false},"Encoding":{"Option":"encoding","Value":""},"ExcludeFromOutline":{"Option":"exclude-from-outline","Value":false},"JavascriptDelay":{"Option":"javascript-delay","IsSet":false,"Value":0},"KeepRelativeLinks":{"Option":"keep-relative-links","Value":false},"LoadErrorHandling":{"Option":"load-error-handling","Value":""},"LoadMediaErrorHandling":{"Option":"load-media-error-handling","Value":""},"MinimumFontSize":{"Option":"minimum-font-size","IsSet":false,"Value":0},"NoBackground":{"Option":"no-background","Value":false},"NoCustomHeaderPropagation":{"Option":"no-custom-header-propagation","Value":false},"NoImages":{"Option":"no-images","Value":false},"NoStopSlowScripts":{"Option":"no-stop-slow-scripts","Value":false},"PageOffset":{"Option":"page-offset","IsSet":false,"Value":0},"Password":{"Option":"password","Value":""},"Post":{"Option":"post","Value":null},"PostFile":{"Option":"post-file","Value":null},"PrintMediaType":{"Option":"print-media-type","Value":false},"Proxy":{"Option":"proxy","Value":""},"RadiobuttonCheckedSvg":{"Option":"radiobutton-checked-svg","Value":""},"RadiobuttonSvg":{"Option":"radiobutton-svg","Value":""},"RunScript":{"Option":"run-script","Value":null},"SslCrtPath":{"Option":"ssl-crt-path","Value":""},"SslKeyPassword":{"Option":"ssl-key-password","Value":""},"SslKeyPath":{"Option":"ssl-key-path","Value":""},"Username":{"Option":"username","Value":""},"UserStyleSheet":{"Option":"user-style-sheet","Value":""},"ViewportSize":{"Option":"viewport-size","Value":""},"WindowStatus":{"Option":"window-status","Value":""},"Zoom":{"Option":"zoom","IsSet":false,"Value":0},"FooterCenter":{"Option":"footer-center","Value":""},"FooterFontName":{"Option":"footer-font-name","Value":""},"FooterFontSize":{"Option":"footer-font-size","IsSet":false,"Value":0},"FooterHTML":{"Option":"footer-html","Value":""},"FooterLeft":{"Option":"footer-left","Value":""},"FooterLine":{"Option":"footer-line","Value":false},"FooterRight":{"Option":"footer-right","Value":""},"FooterSpacing":{"Option":"footer-spacing","IsSet":false,"Value":0},"HeaderCenter":{"Option":"header-center","Value":""},"HeaderFontName":{"Option":"header-font-name","Value":""},"HeaderFontSize":{"Option":"header-font-size","IsSet":false,"Value":0},"HeaderHTML":{"Option":"header-html","Value":""},"HeaderLeft":{"Option":"header-left","Value":""},"HeaderLine":{"Option":"header-line","Value":false},"HeaderRight":{"Option":"header-right","Value":""},"HeaderSpacing":{"Option":"header-spacing","IsSet":false,"Value":0},"Replace":{"Option":"replace","Value":null}},"InputFile":"-","Base64PageData":"PCFkb2N0eXBlIGh0bWw+PGh0bWw+PGhlYWQ+PHRpdGxlPldLSFRNTFRPUERGIFRFU1Q8L3RpdGxlPjwvaGVhZD48Ym9keT5IRUxMTyBQREY8L2JvZHk+PC9odG1sPg=="}]}`
+	want := `{"GlobalOptions":{"CookieJar":{"Option":"cookie-jar","Value":""},"Copies":{"Option":"copies","IsSet":false,"Value":0},"Dpi":{"Option":"dpi","IsSet":true,"Value":600},"ExtendedHelp":{"Option":"extended-help","Value":false},"Grayscale":{"Option":"grayscale","Value":false},"Help":{"Option":"true","Value":false},"HTMLDoc":{"Option":"htmldoc","Value":false},"ImageDpi":{"Option":"image-dpi","IsSet":false,"Value":0},"ImageQuality":{"Option":"image-quality","IsSet":false,"Value":0},"License":{"Option":"license","Value":false},"Lowquality":{"Option":"lowquality","Value":false},"ManPage":{"Option":"manpage","Value":false},"MarginBottom":{"Option":"margin-bottom","IsSet":true,"Value":40},"MarginLeft":{"Option":"margin-left","IsSet":true,"Value":0},"MarginRight":{"Option":"margin-right","IsSet":false,"Value":0},"MarginTop":{"Option":"margin-top","IsSet":false,"Value":0},"NoCollate":{"Option":"no-collate","Value":false},"NoPdfCompression":{"Option":"no-pdf-compression","Value":false},"Orientation":{"Option":"orientation","Value":""},"PageHeight":{"Option":"page-height","IsSet":false,"Value":0},"PageSize":{"Option":"page-size","Value":"A4"},"PageWidth":{"Option":"page-width","IsSet":false,"Value":0},"Quiet":{"Option":"quiet","Value":false},"ReadArgsFromStdin":{"Option":"read-args-from-stdin","Value":false},"Readme":{"Option":"readme","Value":false},"Title":{"Option":"title","Value":""},"Version":{"Option":"version","Value":false}},"OutlineOptions":{"DumpDefaultTocXsl":{"Option":"dump-default-toc-xsl","Value":false},"DumpOutline":{"Option":"dump-outline","Value":""},"NoOutline":{"Option":"no-outline","Value":false},"Outline":{"Option":"outline","Value":false},"OutlineDepth":{"Option":"outline-depth","IsSet":false,"Value":0}},"Cover":{"Input":"https://wkhtmltopdf.org/index.html","Allow":{"Option":"allow","Value":null},"BypassProxyFor":{"Option":"bypass-proxy-for","Value":null},"CacheDir":{"Option":"cache-dir","Value":""},"CheckboxCheckedSvg":{"Option":"checkbox-checked-svg","Value":""},"CheckboxSvg":{"Option":"checkbox-svg","Value":""},"Cookie":{"Option":"cookie","Value":null},"CustomHeader":{"Option":"custom-header","Value":null},"CustomHeaderPropagation":{"Option":"custom-header-propagation","Value":false},"DebugJavascript":{"Option":"debug-javascript","Value":false},"DefaultHeader":{"Option":"default-header","Value":false},"DisableExternalLinks":{"Option":"disable-external-links","Value":false},"DisableForms":{"Option":"disable-forms","Value":false},"DisableInternalLinks":{"Option":"disable-internal-links","Value":false},"DisableJavascript":{"Option":"disable-javascript","Value":false},"DisableLocalFileAccess":{"Option":"disable-local-file-access","Value":false},"DisableSmartShrinking":{"Option":"disable-smart-shrinking","Value":false},"EnableExternalLinks":{"Option":"enable-external-links","Value":false},"EnableForms":{"Option":"enable-forms","Value":false},"EnableInternalLinks":{"Option":"enable-internal-links","Value":false},"EnablePlugins":{"Option":"enable-plugins","Value":false},"EnableTocBackLinks":{"Option":"enable-toc-back-links","Value":false},"Encoding":{"Option":"encoding","Value":""},"ExcludeFromOutline":{"Option":"exclude-from-outline","Value":false},"JavascriptDelay":{"Option":"javascript-delay","IsSet":false,"Value":0},"KeepRelativeLinks":{"Option":"keep-relative-links","Value":false},"LoadErrorHandling":{"Option":"load-error-handling","Value":""},"LoadMediaErrorHandling":{"Option":"load-media-error-handling","Value":""},"MinimumFontSize":{"Option":"minimum-font-size","IsSet":false,"Value":0},"NoBackground":{"Option":"no-background","Value":false},"NoCustomHeaderPropagation":{"Option":"no-custom-header-propagation","Value":false},"NoImages":{"Option":"no-images","Value":false},"NoStopSlowScripts":{"Option":"no-stop-slow-scripts","Value":false},"PageOffset":{"Option":"page-offset","IsSet":false,"Value":0},"Password":{"Option":"password","Value":""},"Post":{"Option":"post","Value":null},"PostFile":{"Option":"post-file","Value":null},"PrintMediaType":{"Option":"print-media-type","Value":false},"Proxy":{"Option":"proxy","Value":""},"RadiobuttonCheckedSvg":{"Option":"radiobutton-checked-svg","Value":""},"RadiobuttonSvg":{"Option":"radiobutton-svg","Value":""},"RunScript":{"Option":"run-script","Value":null},"SslCrtPath":{"Option":"ssl-crt-path","Value":""},"SslKeyPassword":{"Option":"ssl-key-password","Value":""},"SslKeyPath":{"Option":"ssl-key-path","Value":""},"Username":{"Option":"username","Value":""},"UserStyleSheet":{"Option":"user-style-sheet","Value":""},"ViewportSize":{"Option":"viewport-size","Value":""},"WindowStatus":{"Option":"window-status","Value":""},"Zoom":{"Option":"zoom","IsSet":true,"Value":0.75}},"TOC":{"Include":true,"Allow":{"Option":"allow","Value":null},"BypassProxyFor":{"Option":"bypass-proxy-for","Value":null},"CacheDir":{"Option":"cache-dir","Value":""},"CheckboxCheckedSvg":{"Option":"checkbox-checked-svg","Value":""},"CheckboxSvg":{"Option":"checkbox-svg","Value":""},"Cookie":{"Option":"cookie","Value":null},"CustomHeader":{"Option":"custom-header","Value":null},"CustomHeaderPropagation":{"Option":"custom-header-propagation","Value":false},"DebugJavascript":{"Option":"debug-javascript","Value":false},"DefaultHeader":{"Option":"default-header","Value":false},"DisableExternalLinks":{"Option":"disable-external-links","Value":false},"DisableForms":{"Option":"disable-forms","Value":false},"DisableInternalLinks":{"Option":"disable-internal-links","Value":false},"DisableJavascript":{"Option":"disable-javascript","Value":false},"DisableLocalFileAccess":{"Option":"disable-local-file-access","Value":false},"DisableSmartShrinking":{"Option":"disable-smart-shrinking","Value":false},"EnableExternalLinks":{"Option":"enable-external-links","Value":false},"EnableForms":{"Option":"enable-forms","Value":false},"EnableInternalLinks":{"Option":"enable-internal-links","Value":false},"EnablePlugins":{"Option":"enable-plugins","Value":false},"EnableTocBackLinks":{"Option":"enable-toc-back-links","Value":false},"Encoding":{"Option":"encoding","Value":""},"ExcludeFromOutline":{"Option":"exclude-from-outline","Value":false},"JavascriptDelay":{"Option":"javascript-delay","IsSet":false,"Value":0},"KeepRelativeLinks":{"Option":"keep-relative-links","Value":false},"LoadErrorHandling":{"Option":"load-error-handling","Value":""},"LoadMediaErrorHandling":{"Option":"load-media-error-handling","Value":""},"MinimumFontSize":{"Option":"minimum-font-size","IsSet":false,"Value":0},"NoBackground":{"Option":"no-background","Value":false},"NoCustomHeaderPropagation":{"Option":"no-custom-header-propagation","Value":false},"NoImages":{"Option":"no-images","Value":false},"NoStopSlowScripts":{"Option":"no-stop-slow-scripts","Value":false},"PageOffset":{"Option":"page-offset","IsSet":false,"Value":0},"Password":{"Option":"password","Value":""},"Post":{"Option":"post","Value":null},"PostFile":{"Option":"post-file","Value":null},"PrintMediaType":{"Option":"print-media-type","Value":false},"Proxy":{"Option":"proxy","Value":""},"RadiobuttonCheckedSvg":{"Option":"radiobutton-checked-svg","Value":""},"RadiobuttonSvg":{"Option":"radiobutton-svg","Value":""},"RunScript":{"Option":"run-script","Value":null},"SslCrtPath":{"Option":"ssl-crt-path","Value":""},"SslKeyPassword":{"Option":"ssl-key-password","Value":""},"SslKeyPath":{"Option":"ssl-key-path","Value":""},"Username":{"Option":"username","Value":""},"UserStyleSheet":{"Option":"user-style-sheet","Value":""},"ViewportSize":{"Option":"viewport-size","Value":""},"WindowStatus":{"Option":"window-status","Value":""},"Zoom":{"Option":"zoom","IsSet":false,"Value":0},"DisableDottedLines":{"Option":"disable-dotted-lines","Value":true},"DisableTocLinks":{"Option":"disable-toc-links","Value":false},"TocHeaderText":{"Option":"toc-header-text","Value":""},"TocLevelIndentation":{"Option":"toc-level-indentation","IsSet":false,"Value":0},"TocTextSizeShrink":{"Option":"toc-text-size-shrink","IsSet":false,"Value":0},"XslStyleSheet":{"Option":"xsl-style-sheet","Value":""}},"Pages":[{"PageOptions":{"Allow":{"Option":"allow","Value":["/usr/local/html","/usr/local/images"]},"BypassProxyFor":{"Option":"bypass-proxy-for","Value":null},"CacheDir":{"Option":"cache-dir","Value":""},"CheckboxCheckedSvg":{"Option":"checkbox-checked-svg","Value":""},"CheckboxSvg":{"Option":"checkbox-svg","Value":""},"Cookie":{"Option":"cookie","Value":null},"CustomHeader":{"Option":"custom-header","Value":{"X-AppKey":"abcdef"}},"CustomHeaderPropagation":{"Option":"custom-header-propagation","Value":false},"DebugJavascript":{"Option":"debug-javascript","Value":false},"DefaultHeader":{"Option":"default-header","Value":false},"DisableExternalLinks":{"Option":"disable-external-links","Value":false},"DisableForms":{"Option":"disable-forms","Value":false},"DisableInternalLinks":{"Option":"disable-internal-links","Value":false},"DisableJavascript":{"Option":"disable-javascript","Value":false},"DisableLocalFileAccess":{"Option":"disable-local-file-access","Value":false},"DisableSmartShrinking":{"Option":"disable-smart-shrinking","Value":true},"EnableExternalLinks":{"Option":"enable-external-links","Value":false},"EnableForms":{"Option":"enable-forms","Value":false},"EnableInternalLinks":{"Option":"enable-internal-links","Value":false},"EnablePlugins":{"Option":"enable-plugins","Value":false},"EnableTocBackLinks":{"Option":"enable-toc-back-links","Value":false},"Encoding":{"Option":"encoding","Value":""},"ExcludeFromOutline":{"Option":"exclude-from-outline","Value":false},"JavascriptDelay":{"Option":"javascript-delay","IsSet":false,"Value":0},"KeepRelativeLinks":{"Option":"keep-relative-links","Value":false},"LoadErrorHandling":{"Option":"load-error-handling","Value":""},"LoadMediaErrorHandling":{"Option":"load-media-error-handling","Value":""},"MinimumFontSize":{"Option":"minimum-font-size","IsSet":false,"Value":0},"NoBackground":{"Option":"no-background","Value":false},"NoCustomHeaderPropagation":{"Option":"no-custom-header-propagation","Value":false},"NoImages":{"Option":"no-images","Value":false},"NoStopSlowScripts":{"Option":"no-stop-slow-scripts","Value":false},"PageOffset":{"Option":"page-offset","IsSet":false,"Value":0},"Password":{"Option":"password","Value":""},"Post":{"Option":"post","Value":null},"PostFile":{"Option":"post-file","Value":null},"PrintMediaType":{"Option":"print-media-type","Value":false},"Proxy":{"Option":"proxy","Value":""},"RadiobuttonCheckedSvg":{"Option":"radiobutton-checked-svg","Value":""},"RadiobuttonSvg":{"Option":"radiobutton-svg","Value":""},"RunScript":{"Option":"run-script","Value":null},"SslCrtPath":{"Option":"ssl-crt-path","Value":""},"SslKeyPassword":{"Option":"ssl-key-password","Value":""},"SslKeyPath":{"Option":"ssl-key-path","Value":""},"Username":{"Option":"username","Value":""},"UserStyleSheet":{"Option":"user-style-sheet","Value":""},"ViewportSize":{"Option":"viewport-size","Value":"3840x2160"},"WindowStatus":{"Option":"window-status","Value":""},"Zoom":{"Option":"zoom","IsSet":false,"Value":0},"FooterCenter":{"Option":"footer-center","Value":""},"FooterFontName":{"Option":"footer-font-name","Value":""},"FooterFontSize":{"Option":"footer-font-size","IsSet":false,"Value":0},"FooterHTML":{"Option":"footer-html","Value":""},"FooterLeft":{"Option":"footer-left","Value":""},"FooterLine":{"Option":"footer-line","Value":false},"FooterRight":{"Option":"footer-right","Value":""},"FooterSpacing":{"Option":"footer-spacing","IsSet":false,"Value":0},"HeaderCenter":{"Option":"header-center","Value":""},"HeaderFontName":{"Option":"header-font-name","Value":""},"HeaderFontSize":{"Option":"header-font-size","IsSet":false,"Value":0},"HeaderHTML":{"Option":"header-html","Value":""},"HeaderLeft":{"Option":"header-left","Value":""},"HeaderLine":{"Option":"header-line","Value":false},"HeaderRight":{"Option":"header-right","Value":""},"HeaderSpacing":{"Option":"header-spacing","IsSet":true,"Value":10.01},"Replace":{"Option":"replace","Value":null}},"InputFile":"https://www.google.com","Base64PageData":""},{"PageOptions":{"Allow":{"Option":"allow","Value":null},"BypassProxyFor":{"Option":"bypass-proxy-for","Value":null},"CacheDir":{"Option":"cache-dir","Value":""},"CheckboxCheckedSvg":{"Option":"checkbox-checked-svg","Value":""},"CheckboxSvg":{"Option":"checkbox-svg","Value":""},"Cookie":{"Option":"cookie","Value":null},"CustomHeader":{"Option":"custom-header","Value":null},"CustomHeaderPropagation":{"Option":"custom-header-propagation","Value":false},"DebugJavascript":{"Option":"debug-javascript","Value":false},"DefaultHeader":{"Option":"default-header","Value":false},"DisableExternalLinks":{"Option":"disable-external-links","Value":false},"DisableForms":{"Option":"disable-forms","Value":false},"DisableInternalLinks":{"Option":"disable-internal-links","Value":false},"DisableJavascript":{"Option":"disable-javascript","Value":false},"DisableLocalFileAccess":{"Option":"disable-local-file-access","Value":false},"DisableSmartShrinking":{"Option":"disable-smart-shrinking","Value":false},"EnableExternalLinks":{"Option":"enable-external-links","Value":false},"EnableForms":{"Option":"enable-forms","Value":false},"EnableInternalLinks":{"Option":"enable-internal-links","Value":false},"EnablePlugins":{"Option":"enable-plugins","Value":false},"EnableTocBackLinks":{"Option":"enable-toc-back-links","Value":false},"Encoding":{"Option":"encoding","Value":""},"ExcludeFromOutline":{"Option":"exclude-from-outline","Value":false},"JavascriptDelay":{"Option":"javascript-delay","IsSet":false,"Value":0},"KeepRelativeLinks":{"Option":"keep-relative-links","Value":false},"LoadErrorHandling":{"Option":"load-error-handling","Value":""},"LoadMediaErrorHandling":{"Option":"load-media-error-handling","Value":""},"MinimumFontSize":{"Option":"minimum-font-size","IsSet":false,"Value":0},"NoBackground":{"Option":"no-background","Value":false},"NoCustomHeaderPropagation":{"Option":"no-custom-header-propagation","Value":false},"NoImages":{"Option":"no-images","Value":false},"NoStopSlowScripts":{"Option":"no-stop-slow-scripts","Value":false},"PageOffset":{"Option":"page-offset","IsSet":false,"Value":0},"Password":{"Option":"password","Value":""},"Post":{"Option":"post","Value":null},"PostFile":{"Option":"post-file","Value":null},"PrintMediaType":{"Option":"print-media-type","Value":false},"Proxy":{"Option":"proxy","Value":""},"RadiobuttonCheckedSvg":{"Option":"radiobutton-checked-svg","Value":""},"RadiobuttonSvg":{"Option":"radiobutton-svg","Value":""},"RunScript":{"Option":"run-script","Value":null},"SslCrtPath":{"Option":"ssl-crt-path","Value":""},"SslKeyPassword":{"Option":"ssl-key-password","Value":""},"SslKeyPath":{"Option":"ssl-key-path","Value":""},"Username":{"Option":"username","Value":""},"UserStyleSheet":{"Option":"user-style-sheet","Value":""},"ViewportSize":{"Option":"viewport-size","Value":""},"WindowStatus":{"Option":"window-status","Value":""},"Zoom":{"Option":"zoom","IsSet":false,"Value":0},"FooterCenter":{"Option":"footer-center","Value":""},"FooterFontName":{"Option":"footer-font-name","Value":""},"FooterFontSize":{"Option":"footer-font-size","IsSet":false,"Value":0},"FooterHTML":{"Option":"footer-html","Value":""},"FooterLeft":{"Option":"footer-left","Value":""},"FooterLine":{"Option":"footer-line","Value":false},"FooterRight":{"Option":"footer-right","Value":""},"FooterSpacing":{"Option":"footer-spacing","IsSet":false,"Value":0},"HeaderCenter":{"Option":"header-center","Value":""},"HeaderFontName":{"Option":"header-font-name","Value":""},"HeaderFontSize":{"Option":"header-font-size","IsSet":false,"Value":0},"HeaderHTML":{"Option":"header-html","Value":""},"HeaderLeft":{"Option":"header-left","Value":""},"HeaderLine":{"Option":"header-line","Value":false},"HeaderRight":{"Option":"header-right","Value":""},"HeaderSpacing":{"Option":"header-spacing","IsSet":false,"Value":0},"Replace":{"Option":"replace","Value":null}},"InputFile":"-","Base64PageData":"PCFkb2N0eXBlIGh0bWw+PGh0bWw+PGhlYWQ+PHRpdGxlPldLSFRNTFRPUERGIFRFU1Q8L3RpdGxlPjwvaGVhZD48Ym9keT5IRUxMTyBQREY8L2JvZHk+PC9odG1sPg=="}]}`
 	if want != string(jb) {
 		t.Errorf("Want JSON:\n%s\nHave:\n%s", want, string(jb))
 	}
@@ -0,0 +1,91 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// LambdaEvent is the event for the handler returned by NewLambdaHandler, either PDF or Image must be set
+type LambdaEvent struct {
+	PDF   json.RawMessage `json:"pdf,omitempty"`   // JSON created with Job.ToJSON or PDFGenerator.ToJSON
+	Image *ImageOptions   `json:"image,omitempty"` // Options for an image, BinaryPath and Output are ignored
+}
+
+// LambdaResponse is the response of the handler returned by NewLambdaHandler
+type LambdaResponse struct {
+	ContentType string `json:"contentType"`
+	Base64      string `json:"base64"` // The PDF or image as base64 encoded string
+}
+
+// NewLambdaHandler returns a handler for AWS Lambda which can be started with lambda.Start from github.com/aws/aws-lambda-go.
+// The wkhtmltopdf and wkhtmltoimage binaries are used from binDir, which is /opt/bin when they are added to the
+// function as a Lambda layer. When a binary is not in binDir it is searched for in the same way as without Lambda.
+// The handler renders a PDF or image for the event and returns it base64 encoded.
+func NewLambdaHandler(binDir string) func(ctx context.Context, event LambdaEvent) (LambdaResponse, error) {
+	return func(ctx context.Context, event LambdaEvent) (LambdaResponse, error) {
+		switch {
+		case len(event.PDF) > 0:
+			if path := filepath.Join(binDir, "wkhtmltopdf"); fileExists(path) {
+				SetPath(path)
+			}
+			job, err := JobFromJSON(bytes.NewReader(event.PDF), false)
+			if err == nil && job.PDF == nil {
+				err = errors.New("event pdf is not a PDF job")
+			}
+			if err != nil {
+				return LambdaResponse{}, err
+			}
+			pdfg := job.PDF
+			// the output is returned in the response
+			pdfg.OutputFile = ""
+			err = pdfg.CreateContext(ctx)
+			if err != nil {
+				return LambdaResponse{}, err
+			}
+			return LambdaResponse{
+				ContentType: "application/pdf",
+				Base64:      base64.StdEncoding.EncodeToString(pdfg.Bytes()),
+			}, nil
+
+		case event.Image != nil:
+			options := *event.Image
+			options.BinaryPath = ""
+			options.Output = ""
+			if path := filepath.Join(binDir, "wkhtmltoimage"); fileExists(path) {
+				options.BinaryPath = path
+			}
+			img, err := GenerateImageContext(ctx, &options)
+			if err != nil {
+				return LambdaResponse{}, err
+			}
+			return LambdaResponse{
+				ContentType: imageContentType(options.Format),
+				Base64:      base64.StdEncoding.EncodeToString(img),
+			}, nil
+		}
+		return LambdaResponse{}, errors.New("event has no pdf or image")
+	}
+}
+
+// imageContentType returns the content type for an image format as used in ImageOptions
+func imageContentType(format string) string {
+	switch format {
+	case "jpg", "jpeg":
+		return "image/jpeg"
+	case "svg":
+		return "image/svg+xml"
+	case "bmp":
+		return "image/bmp"
+	}
+	return "image/png"
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,63 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLambdaHandlerPDF(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	jb, err := pdfg.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the event as it is received from Lambda
+	var event LambdaEvent
+	err = json.Unmarshal([]byte(`{"pdf":`+string(jb)+`}`), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewLambdaHandler("/opt/bin")
+	resp, err := handler(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ContentType != "application/pdf" {
+		t.Errorf("Want content type application/pdf, have %s", resp.ContentType)
+	}
+	pdf, err := base64.StdEncoding.DecodeString(resp.Base64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Error("Response is not a PDF")
+	}
+}
+
+func TestLambdaHandlerNoJob(t *testing.T) {
+	_, err := NewLambdaHandler("/opt/bin")(context.Background(), LambdaEvent{})
+	if err == nil {
+		t.Fatal("Want an error for an empty event, have no error")
+	}
+}
+
+func TestImageContentType(t *testing.T) {
+	for format, want := range map[string]string{
+		"":    "image/png",
+		"png": "image/png",
+		"jpg": "image/jpeg",
+		"svg": "image/svg+xml",
+		"bmp": "image/bmp",
+	} {
+		if have := imageContentType(format); have != want {
+			t.Errorf("Want %s for %q, have %s", want, format, have)
+		}
+	}
+}
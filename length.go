@@ -0,0 +1,57 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Length is a length in mm for page sizes and margins, for example 2*Centimeter or 0.5*Inch
+type Length float64
+
+// Units of Length
+const (
+	Millimeter Length = 1
+	Centimeter Length = 10
+	Inch       Length = 25.4
+	Point      Length = 25.4 / 72
+)
+
+// lengthUnits are the units ParseLength accepts
+var lengthUnits = map[string]Length{
+	"mm": Millimeter,
+	"cm": Centimeter,
+	"in": Inch,
+	"pt": Point,
+}
+
+// ParseLength parses a number with a unit, such as "210mm", "21cm", "8.5in" or "72pt". A number without a unit is in mm
+func ParseLength(s string) (Length, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	number := strings.TrimRight(s, "abcdefghijklmnopqrstuvwxyz")
+	unit := strings.TrimSpace(s[len(number):])
+	factor := Millimeter
+	if unit != "" {
+		var ok bool
+		factor, ok = lengthUnits[unit]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q in %q, want mm, cm, in or pt", unit, s)
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("%q is not a length", s)
+	}
+	return Length(v) * factor, nil
+}
+
+// mm returns the length in mm rounded to a thousandth of a mm, which is written to the arguments of wkhtmltopdf
+func (l Length) mm() float64 {
+	return math.Round(float64(l)*1000) / 1000
+}
+
+// String returns the length in mm, for example 12.7mm
+func (l Length) String() string {
+	return strconv.FormatFloat(l.mm(), 'f', -1, 64) + "mm"
+}
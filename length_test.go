@@ -0,0 +1,58 @@
+package wkhtmltopdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLength(t *testing.T) {
+	for s, want := range map[string]float64{
+		"210mm":  210,
+		"21cm":   210,
+		"8.5in":  215.9,
+		"72pt":   25.4,
+		"297":    297,
+		" 11 IN": 279.4,
+		"0.5 in": 12.7,
+	} {
+		have, err := ParseLength(s)
+		if err != nil {
+			t.Errorf("Want %q parsed, have %s", s, err)
+		} else if have.mm() != want {
+			t.Errorf("Want %q to be %v mm, have %v", s, want, have)
+		}
+	}
+	for _, s := range []string{"", "mm", "10px", "-5mm", "ten mm", "1e999in"} {
+		if _, err := ParseLength(s); err == nil {
+			t.Errorf("Want error for %q", s)
+		}
+	}
+}
+
+func TestLength(t *testing.T) {
+	if s := (0.5 * Inch).String(); s != "12.7mm" {
+		t.Errorf("Want 12.7mm, have %s", s)
+	}
+	if s := (2*Centimeter + 5*Millimeter).String(); s != "25mm" {
+		t.Errorf("Want 25mm, have %s", s)
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.MarginTop.SetLength(0.5 * Inch)
+	if err := pdfg.MarginBottom.SetString("2cm"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pdfg.MarginLeft.SetString("2 furlongs"); err == nil || !strings.Contains(err.Error(), "--margin-left") {
+		t.Errorf("Want error for the margin-left, have %v", err)
+	}
+	args := strings.Join(pdfg.Args(), " ")
+	if !strings.Contains(args, "--margin-top 12.7 ") || !strings.Contains(args, "--margin-bottom 20 ") {
+		t.Errorf("Want margins in mm, have %s", args)
+	}
+	if strings.Contains(args, "--margin-left") {
+		t.Errorf("Want margin-left unset after an error, have %s", args)
+	}
+	if err := setOptionValues(&pdfg.MarginRight, []string{"0.25in"}); err != nil || pdfg.MarginRight.value != 6.35 {
+		t.Errorf("Want margin from a string with a unit, have %v %v", pdfg.MarginRight.value, err)
+	}
+}
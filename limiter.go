@@ -0,0 +1,229 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Limiter.Do when the circuit breaker tripped after too many consecutive failures
+var ErrCircuitOpen = errors.New("circuit breaker is open after too many consecutive render failures")
+
+// ErrLimiterClosed is returned by Limiter.Do after Close is called
+var ErrLimiterClosed = errors.New("limiter is closed")
+
+// Limiter limits the rate of renders and the number of concurrent renders per tenant,
+// and has a circuit breaker which returns ErrCircuitOpen immediately after too many consecutive crashes or timeouts,
+// instead of starting more processes which are likely to fail or hang as well.
+// A Limiter can be shared by any number of goroutines, the settings should not be changed after first use
+// except with ConfigReloader, which changes them for the renders started after a reload.
+type Limiter struct {
+	Timeout          time.Duration // Maximum duration of each render, 0 means no limit
+	RendersPerSecond float64       // Maximum number of renders started per second, 0 means no limit
+	MaxPerTenant     int           // Maximum number of concurrent renders per tenant, 0 means no limit
+	MaxFailures      int           // Number of consecutive crashed or timed out renders after which the circuit breaker trips, 0 disables the breaker
+	BreakDuration    time.Duration // Time the circuit breaker stays open before renders are tried again (default 30s)
+
+	mu        sync.Mutex
+	next      time.Time
+	tenants   map[string]*tenantLimit
+	failures  int
+	openUntil time.Time
+	closed    bool
+	kill      chan struct{}
+	killOnce  sync.Once
+	inFlight  sync.WaitGroup
+}
+
+// tenantLimit is the semaphore of a tenant and the number of renders which are waiting for it or running
+type tenantLimit struct {
+	sem   chan struct{}
+	users int
+}
+
+// Do runs render for tenant within the limits, for example
+//
+//	err := limiter.Do(customerID, pdfg.Create)
+//
+// It waits until the rate and tenant limits allow a new render and returns the error from render.
+func (l *Limiter) Do(tenant string, render func() error) error {
+	return l.DoContext(context.Background(), tenant, func(context.Context) error {
+		return render()
+	})
+}
+
+// DoContext is like Do but stops waiting when ctx is done and passes a context to render
+// which is canceled when ctx is done, after Timeout or when Close kills the remaining renders, for example
+//
+//	err := limiter.DoContext(ctx, customerID, pdfg.CreateContext)
+func (l *Limiter) DoContext(ctx context.Context, tenant string, render func(ctx context.Context) error) error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrLimiterClosed
+	}
+	if l.kill == nil {
+		l.kill = make(chan struct{})
+	}
+	kill := l.kill
+	timeout := l.Timeout
+	l.inFlight.Add(1)
+	l.mu.Unlock()
+	defer l.inFlight.Done()
+
+	if l.open() {
+		return ErrCircuitOpen
+	}
+
+	start := time.Now()
+	atomic.AddInt64(&stats.waiting, 1)
+	sem, release := l.tenant(tenant)
+	defer release()
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			atomic.AddInt64(&stats.waiting, -1)
+			return ctx.Err()
+		}
+	}
+	err := l.wait(ctx)
+	atomic.AddInt64(&stats.waiting, -1)
+	addTiming(ctx, Timing{Queue: time.Since(start)})
+	if err != nil {
+		return err
+	}
+
+	var renderCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		renderCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		renderCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	go func() {
+		select {
+		case <-kill:
+			cancel()
+		case <-renderCtx.Done():
+		}
+	}()
+
+	err = render(renderCtx)
+	l.record(err, err != nil && renderCtx.Err() == context.DeadlineExceeded)
+	return err
+}
+
+// Close stops accepting new renders and waits for the renders in progress to finish.
+// When ctx is done before that, the contexts passed to the remaining renders are canceled,
+// which kills their processes when CreateContext or GenerateImageContext is used, and the context error is returned.
+func (l *Limiter) Close(ctx context.Context) error {
+	l.mu.Lock()
+	l.closed = true
+	if l.kill == nil {
+		l.kill = make(chan struct{})
+	}
+	l.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		l.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// Close can be called again by another goroutine or after a timeout
+		l.killOnce.Do(func() { close(l.kill) })
+		<-done
+		return ctx.Err()
+	}
+}
+
+// open returns true if the circuit breaker is open
+func (l *Limiter) open() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.MaxFailures > 0 && l.failures >= l.MaxFailures && time.Now().Before(l.openUntil)
+}
+
+// tenant returns the semaphore for tenant, or nil if there is no limit per tenant, and a function which must be called
+// when the render is done with it. The semaphore of a tenant is removed when no render is waiting for it or running
+func (l *Limiter) tenant(tenant string) (chan struct{}, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.MaxPerTenant <= 0 {
+		return nil, func() {}
+	}
+	if l.tenants == nil {
+		l.tenants = make(map[string]*tenantLimit)
+	}
+	// a new limit is used for renders which start waiting after it is set
+	t, ok := l.tenants[tenant]
+	if !ok || cap(t.sem) != l.MaxPerTenant {
+		t = &tenantLimit{sem: make(chan struct{}, l.MaxPerTenant)}
+		l.tenants[tenant] = t
+	}
+	t.users++
+	return t.sem, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		t.users--
+		if t.users == 0 && l.tenants[tenant] == t {
+			delete(l.tenants, tenant)
+		}
+	}
+}
+
+// wait blocks until a new render may be started or ctx is done
+func (l *Limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	if l.RendersPerSecond <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(time.Duration(float64(time.Second) / l.RendersPerSecond))
+	l.mu.Unlock()
+
+	t := time.NewTimer(start.Sub(now))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// record updates the circuit breaker with the result of a render. Only crashes of the renderer and renders which
+// timed out are failures, errors of the input, the options or a canceled context do not change the breaker
+func (l *Limiter) record(err error, timedOut bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err == nil {
+		l.failures = 0
+		return
+	}
+	if !timedOut && !isCrash(err) {
+		return
+	}
+	l.failures++
+	if l.MaxFailures > 0 && l.failures >= l.MaxFailures {
+		d := l.BreakDuration
+		if d == 0 {
+			d = 30 * time.Second
+		}
+		l.openUntil = time.Now().Add(d)
+	}
+}
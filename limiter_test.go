@@ -0,0 +1,239 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiterCircuitBreaker(t *testing.T) {
+	l := &Limiter{MaxFailures: 2, BreakDuration: 50 * time.Millisecond}
+	errCrash := &CrashError{Binary: "wkhtmltopdf", Signal: "SIGSEGV"}
+	calls := 0
+	fail := func() error {
+		calls++
+		return errCrash
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := l.Do("", fail); err != errCrash {
+			t.Errorf("Want crash error, have %v", err)
+		}
+	}
+	if err := l.Do("", fail); err != ErrCircuitOpen {
+		t.Errorf("Want ErrCircuitOpen, have %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Want 2 calls, have %d", calls)
+	}
+
+	// after the break duration one render is tried again
+	time.Sleep(60 * time.Millisecond)
+	if err := l.Do("", func() error { return nil }); err != nil {
+		t.Errorf("Want no error, have %v", err)
+	}
+	if err := l.Do("", fail); err != errCrash {
+		t.Errorf("Want crash error after reset, have %v", err)
+	}
+}
+
+func TestLimiterCircuitBreakerIgnoresOtherErrors(t *testing.T) {
+	l := &Limiter{MaxFailures: 2, Timeout: 10 * time.Millisecond}
+	errInput := errors.New("error reading input")
+	for i := 0; i < 3; i++ {
+		if err := l.Do("", func() error { return errInput }); err != errInput {
+			t.Errorf("Want input error, have %v", err)
+		}
+		if err := l.Do("", func() error { return &UnsupportedOptionsError{} }); err == ErrCircuitOpen {
+			t.Error("Want an unsupported option not to open the circuit")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := l.DoContext(ctx, "", func(ctx context.Context) error { return ctx.Err() })
+		if err != context.Canceled {
+			t.Errorf("Want %v, have %v", context.Canceled, err)
+		}
+	}
+
+	// renders which time out are failures
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	for i := 0; i < 2; i++ {
+		l.DoContext(context.Background(), "", hang)
+	}
+	if err := l.DoContext(context.Background(), "", hang); err != ErrCircuitOpen {
+		t.Errorf("Want ErrCircuitOpen after timeouts, have %v", err)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	l := &Limiter{RendersPerSecond: 100}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		l.Do("", func() error { return nil })
+	}
+	// the first render starts immediately
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("Want 5 renders to take at least 40ms, took %s", d)
+	}
+}
+
+func TestLimiterMaxPerTenant(t *testing.T) {
+	l := &Limiter{MaxPerTenant: 2}
+	var mu sync.Mutex
+	active := map[string]int{}
+	max := map[string]int{}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		tenant := "a"
+		if i%2 == 0 {
+			tenant = "b"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Do(tenant, func() error {
+				mu.Lock()
+				active[tenant]++
+				if active[tenant] > max[tenant] {
+					max[tenant] = active[tenant]
+				}
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mu.Lock()
+				active[tenant]--
+				mu.Unlock()
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	for tenant, m := range max {
+		if m > 2 {
+			t.Errorf("Want at most 2 concurrent renders for tenant %s, have %d", tenant, m)
+		}
+	}
+}
+
+func TestLimiterRemovesIdleTenants(t *testing.T) {
+	l := &Limiter{MaxPerTenant: 1}
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go l.Do("a", func() error {
+		close(started)
+		<-done
+		return nil
+	})
+	<-started
+	for i := 0; i < 100; i++ {
+		l.Do(fmt.Sprintf("tenant-%d", i), func() error { return nil })
+	}
+
+	l.mu.Lock()
+	n := len(l.tenants)
+	l.mu.Unlock()
+	if n != 1 {
+		t.Errorf("Want only the running tenant kept, have %d tenants", n)
+	}
+
+	close(done)
+	l.Close(context.Background())
+	if len(l.tenants) != 0 {
+		t.Errorf("Want no tenants after the renders are done, have %d", len(l.tenants))
+	}
+}
+
+func TestLimiterClose(t *testing.T) {
+	l := &Limiter{}
+	started := make(chan struct{})
+	result := make(chan error)
+	go func() {
+		result <- l.DoContext(context.Background(), "", func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	<-started
+
+	// the running render does not finish by itself, so it is canceled after the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := l.Close(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Want deadline exceeded, have %v", err)
+	}
+	if err := <-result; err != context.Canceled {
+		t.Errorf("Want render to be canceled, have %v", err)
+	}
+
+	if err := l.Do("", func() error { return nil }); err != ErrLimiterClosed {
+		t.Errorf("Want ErrLimiterClosed, have %v", err)
+	}
+}
+
+func TestLimiterCloseTwice(t *testing.T) {
+	l := &Limiter{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go l.Do("", func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	// both calls time out while the render is running
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- l.Close(ctx) }()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != context.Canceled {
+			t.Errorf("Want %v, have %v", context.Canceled, err)
+		}
+	}
+}
+
+func TestLimiterCloseWaits(t *testing.T) {
+	l := &Limiter{}
+	started := make(chan struct{})
+	finished := false
+	go l.Do("", func() error {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		finished = true
+		return nil
+	})
+	<-started
+
+	err := l.Close(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !finished {
+		t.Error("Want Close to wait for the render to finish")
+	}
+}
+
+func TestLimiterTimeout(t *testing.T) {
+	l := &Limiter{Timeout: 10 * time.Millisecond}
+	err := l.DoContext(context.Background(), "", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
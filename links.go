@@ -0,0 +1,35 @@
+package wkhtmltopdf
+
+import "fmt"
+
+// SetLinks sets whether links to anchors in the documents, such as the entries of a table of contents and cross-references,
+// and links to web pages are clickable in the PDF. Both are clickable by default
+func (popt *pageOptions) SetLinks(internal, external bool) {
+	popt.EnableInternalLinks.Set(internal)
+	popt.DisableInternalLinks.Set(!internal)
+	popt.EnableExternalLinks.Set(external)
+	popt.DisableExternalLinks.Set(!external)
+}
+
+// SetLinks sets whether internal and external links are clickable on the cover, the TOC and the pages which were added,
+// call it after AddPage
+func (pdfg *PDFGenerator) SetLinks(internal, external bool) {
+	for _, popt := range pdfg.allPageOptions() {
+		popt.SetLinks(internal, external)
+	}
+}
+
+// checkLinks returns an error for link options which contradict each other, wkhtmltopdf uses the last one
+// in the arguments without an error
+func (popt *pageOptions) checkLinks() error {
+	for _, pair := range [][2]*boolOption{
+		{&popt.EnableInternalLinks, &popt.DisableInternalLinks},
+		{&popt.EnableExternalLinks, &popt.DisableExternalLinks},
+		{&popt.KeepRelativeLinks, &popt.DisableExternalLinks},
+	} {
+		if pair[0].value && pair[1].value {
+			return fmt.Errorf("--%s can not be used with --%s", pair[0].option, pair[1].option)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package wkhtmltopdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetLinks(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.TOC.Include = true
+	page := NewPage("page.html")
+	pdfg.AddPage(page)
+	pdfg.SetLinks(true, false)
+	if err := pdfg.checkArgs(); err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(page.Args(), " ")
+	if !strings.Contains(args, "--enable-internal-links") || !strings.Contains(args, "--disable-external-links") {
+		t.Errorf("Want internal links only, have %s", args)
+	}
+	if strings.Contains(args, "--disable-internal-links") || strings.Contains(args, "--enable-external-links") {
+		t.Errorf("Want no contradicting options, have %s", args)
+	}
+	if n := strings.Count(pdfg.ArgString(), "--enable-internal-links"); n != 2 {
+		t.Errorf("Want internal links on the TOC and the page, have %s", pdfg.ArgString())
+	}
+
+	page.KeepRelativeLinks.Set(true)
+	if err := pdfg.checkArgs(); err == nil || !strings.Contains(err.Error(), "--keep-relative-links") {
+		t.Errorf("Want error for relative links without external links, have %v", err)
+	}
+	page.KeepRelativeLinks.Set(false)
+	page.EnableExternalLinks.Set(true)
+	if err := pdfg.checkArgs(); err == nil || !strings.Contains(err.Error(), "--enable-external-links can not be used with --disable-external-links") {
+		t.Errorf("Want error for contradicting options, have %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// MIMEPart is a rendered PDF or image as part of a multipart email, with the content type, disposition
+// and for inline images the content ID set, so it can be added to a message with WriteTo
+type MIMEPart struct {
+	Header textproto.MIMEHeader
+	Body   []byte // The PDF or image, it is base64 encoded by WriteTo
+}
+
+// RenderAttachment creates the PDF of pdfg and returns it as attachment with filename, for example "invoice.pdf"
+func RenderAttachment(ctx context.Context, pdfg *PDFGenerator, filename string) (*MIMEPart, error) {
+	if err := pdfg.CreateContext(ctx); err != nil {
+		return nil, err
+	}
+	return newMIMEPart("application/pdf", "attachment", filename, pdfg.Bytes()), nil
+}
+
+// RenderInline renders the image and returns it as inline part with contentID, which is shown in the HTML of the email
+// with <img src="cid:contentID">. The Output of options is not used.
+func RenderInline(ctx context.Context, options *ImageOptions, contentID string) (*MIMEPart, error) {
+	copied := *options
+	copied.Output = ""
+	res, err := RenderImage(ctx, &copied)
+	if err != nil {
+		return nil, err
+	}
+	format := copied.Format
+	if format == "" {
+		format = "png"
+	}
+	part := newMIMEPart(imageContentType(format), "inline", contentID+"."+format, res.Image)
+	part.Header.Set("Content-ID", "<"+contentID+">")
+	return part, nil
+}
+
+func newMIMEPart(contentType, disposition, filename string, body []byte) *MIMEPart {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"name": filename}))
+	h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+	h.Set("Content-Transfer-Encoding", "base64")
+	return &MIMEPart{Header: h, Body: body}
+}
+
+// WriteTo adds the part to the multipart message of w, with the body base64 encoded in lines of 76 characters
+func (p *MIMEPart) WriteTo(w *multipart.Writer) error {
+	pw, err := w.CreatePart(p.Header)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(p.Body)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(pw, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = io.WriteString(pw, encoded+"\r\n")
+	return err
+}
+
+// ContentID returns the content ID of an inline part without the angle brackets, or an empty string for an attachment
+func (p *MIMEPart) ContentID() string {
+	return strings.Trim(p.Header.Get("Content-ID"), "<>")
+}
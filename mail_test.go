@@ -0,0 +1,77 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"mime/multipart"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMIMEPart(t *testing.T) {
+	pdf := bytes.Repeat([]byte("%PDF"), 50)
+	part := newMIMEPart("application/pdf", "attachment", "invoice 1.pdf", pdf)
+	if have := part.Header.Get("Content-Disposition"); have != `attachment; filename="invoice 1.pdf"` {
+		t.Errorf(`Want attachment; filename="invoice 1.pdf", have %s`, have)
+	}
+	if have := part.Header.Get("Content-Type"); have != `application/pdf; name="invoice 1.pdf"` {
+		t.Errorf(`Want application/pdf; name="invoice 1.pdf", have %s`, have)
+	}
+	if part.ContentID() != "" {
+		t.Errorf("Want no content ID, have %s", part.ContentID())
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := part.WriteTo(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	r := multipart.NewReader(&buf, w.Boundary())
+	p, err := r.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(p)
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("Want lines of at most 76 characters, have %d", len(line))
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Replace(string(body), "\r\n", "", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, pdf) {
+		t.Errorf("Want the PDF, have %q", decoded)
+	}
+}
+
+func TestRenderInline(t *testing.T) {
+	f, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("#!/bin/sh\nprintf '<svg></svg>'\n")
+	f.Close()
+	os.Chmod(f.Name(), 0700)
+
+	part, err := RenderInline(context.Background(), &ImageOptions{BinaryPath: f.Name(), Input: "http://example.com", Format: "svg"}, "chart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.ContentID() != "chart" || part.Header.Get("Content-ID") != "<chart>" {
+		t.Errorf("Want content ID <chart>, have %s", part.Header.Get("Content-ID"))
+	}
+	if have := part.Header.Get("Content-Type"); have != `image/svg+xml; name=chart.svg` {
+		t.Errorf("Want image/svg+xml; name=chart.svg, have %s", have)
+	}
+	if string(part.Body) != "<svg></svg>" {
+		t.Errorf("Want <svg></svg>, have %s", part.Body)
+	}
+}
@@ -0,0 +1,46 @@
+package wkhtmltopdf
+
+import "errors"
+
+// metadataChunks are the png chunks with text, timestamps and EXIF data
+var metadataChunks = map[string]bool{"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true}
+
+// stripMetadata removes the text and EXIF metadata from png and jpg images, color profiles and the pixel density are kept.
+// Images of other formats are not changed.
+func stripMetadata(img []byte, format string) ([]byte, error) {
+	switch format {
+	case "png":
+		return filterPNGChunks(img, func(chunkType string) bool { return !metadataChunks[chunkType] }, "", nil)
+	case "jpg":
+		return stripJPEGMetadata(img)
+	}
+	return img, nil
+}
+
+// stripJPEGMetadata removes the APP1 (EXIF and XMP), APP13 (IPTC) and comment segments from the jpg
+func stripJPEGMetadata(img []byte) ([]byte, error) {
+	if len(img) < 4 || img[0] != 0xff || img[1] != 0xd8 {
+		return nil, errors.New("invalid JPEG")
+	}
+	out := make([]byte, 0, len(img))
+	out = append(out, img[:2]...)
+	p := img[2:]
+	for {
+		if len(p) < 4 || p[0] != 0xff {
+			return nil, errors.New("invalid JPEG segment")
+		}
+		marker := p[1]
+		// the entropy coded data after the start of scan is copied as is
+		if marker == 0xda {
+			return append(out, p...), nil
+		}
+		n := int(p[2])<<8 | int(p[3])
+		if n < 2 || len(p) < n+2 {
+			return nil, errors.New("invalid JPEG segment")
+		}
+		if marker != 0xe1 && marker != 0xed && marker != 0xfe {
+			out = append(out, p[:n+2]...)
+		}
+		p = p[n+2:]
+	}
+}
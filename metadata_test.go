@@ -0,0 +1,51 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func TestStripMetadataPNG(t *testing.T) {
+	src := appendPNGChunk([]byte(pngMagic), "IHDR", make([]byte, 13))
+	src = appendPNGChunk(src, "tEXt", []byte("Title\x00https://example.com/private"))
+	src = appendPNGChunk(src, "pHYs", make([]byte, 9))
+	src = appendPNGChunk(src, "IEND", nil)
+	img, err := stripMetadata(src, "png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(img, []byte("private")) {
+		t.Error("Want png without text chunk")
+	}
+	if !bytes.Contains(img, []byte("pHYs")) {
+		t.Error("Want pHYs chunk to be kept")
+	}
+}
+
+func TestStripMetadataJPEG(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := jpeg.Encode(buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exif := []byte{0xff, 0xe1, 0x00, 0x0c, 'E', 'x', 'i', 'f', 0, 0, 's', 'e', 'c', 'r'}
+	comment := []byte{0xff, 0xfe, 0x00, 0x06, 'h', 'i', 'd', 'e'}
+	src := append(append(append([]byte{0xff, 0xd8}, exif...), comment...), buf.Bytes()[2:]...)
+
+	img, err := stripMetadata(src, "jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(img, []byte("Exif")) || bytes.Contains(img, []byte("hide")) {
+		t.Error("Want jpg without EXIF and comment")
+	}
+	if !bytes.Equal(img, buf.Bytes()) {
+		t.Error("Want the jpg without metadata to be unchanged")
+	}
+	_, err = jpeg.Decode(bytes.NewReader(img))
+	if err != nil {
+		t.Errorf("Want valid jpg, have %s", err)
+	}
+}
@@ -0,0 +1,89 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Change is a visible change of a page watched by a Monitor
+type Change struct {
+	URL    string
+	Before []byte      // png screenshot of the page before the change
+	After  []byte      // png screenshot of the page after the change
+	Diff   *RenderDiff // Difference of the screenshots, with an image which highlights the changed pixels
+	Time   time.Time   // Time the change was found
+}
+
+// Monitor takes screenshots of pages on a schedule and reports when their appearance changed by more than the Threshold,
+// for example to find broken layouts or defaced pages. It uses a Scheduler to render the pages and CompareRenders
+// to compare them. The first screenshot of a page is stored without reporting a change.
+// The settings should not be changed after Run is called.
+type Monitor struct {
+	// Image are the options of the screenshots, Input is set to the URL of a page. The Format is always png, and SmartWidth
+	// is false unless it is set, so screenshots of the same page have the same width and can be compared
+	Image     ImageOptions
+	Results   ResultStore // Stores the last changed screenshot of each page by its URL, required
+	Limiter   *Limiter    // Limits the renders with the URL as tenant, optional
+	Threshold float64     // Minimum RenderDiff.Score of a change, 0 means any different pixel
+	OnChange  func(change Change)
+	// Changes receives the changes when it is set, the render of a page waits until its change is received
+	Changes chan<- Change
+	OnError func(url string, err error) // Called when a screenshot fails, it is taken again at the next time of the page
+
+	scheduler Scheduler
+	once      sync.Once
+}
+
+// Watch adds or replaces the page with url, its first screenshot is taken at the next time of the schedule
+func (m *Monitor) Watch(url string, schedule Schedule) error {
+	options := m.Image
+	options.Input = url
+	options.Html = ""
+	options.Format = "png"
+	if options.SmartWidth == nil {
+		smartWidth := false
+		options.SmartWidth = &smartWidth
+	}
+	return m.scheduler.Add(url, schedule, &Job{Image: &options})
+}
+
+// Unwatch removes the page with url, its stored screenshot is kept in Results
+func (m *Monitor) Unwatch(url string) {
+	m.scheduler.Remove(url)
+}
+
+// Run takes the screenshots of the pages at their scheduled times until ctx is done,
+// it returns after the running renders finished
+func (m *Monitor) Run(ctx context.Context) error {
+	if m.Results == nil {
+		return errors.New("Monitor has no Results set")
+	}
+	m.once.Do(func() {
+		m.scheduler.Results = m.Results
+		m.scheduler.Limiter = m.Limiter
+		m.scheduler.Threshold = m.Threshold
+		m.scheduler.OnError = m.OnError
+		m.scheduler.changed = m.changed
+	})
+	return m.scheduler.Run(ctx)
+}
+
+// changed reports the change of the screenshot of a page, the first screenshot and screenshots which could not be
+// compared are not reported
+func (m *Monitor) changed(ctx context.Context, url string, before, after []byte, diff *RenderDiff) {
+	if before == nil || diff == nil {
+		return
+	}
+	change := Change{URL: url, Before: before, After: after, Diff: diff, Time: time.Now()}
+	if m.OnChange != nil {
+		m.OnChange(change)
+	}
+	if m.Changes != nil {
+		select {
+		case m.Changes <- change:
+		case <-ctx.Done():
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	page := filepath.Join(dir, "page.png")
+	writePage := func(x int) []byte {
+		buf := &bytes.Buffer{}
+		png.Encode(buf, testPage(64, 64, x))
+		if err := ioutil.WriteFile(page+".tmp", buf.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Rename(page+".tmp", page)
+		return buf.Bytes()
+	}
+	before := writePage(0)
+	// the binary fails without fixed-width png screenshots
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	script := "#!/bin/sh\ncase \"$*\" in *--disable-smart-width*) ;; *) exit 1;; esac\ncase \"$*\" in *\"--format png\"*) cat " + page + ";; *) exit 1;; esac\n"
+	if err := ioutil.WriteFile(bin, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan Change)
+	called := make(chan Change, 10)
+	m := &Monitor{
+		Image:   ImageOptions{BinaryPath: bin, Format: "jpg"},
+		Results: NewMemoryResultStore(10),
+		Changes: changes,
+		OnChange: func(change Change) {
+			called <- change
+		},
+		OnError: func(url string, err error) {
+			t.Errorf("Want no error, have %s", err)
+		},
+	}
+	if err := m.Watch("https://example.com", Every(20*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx) }()
+
+	// the first screenshot and the same page are not a change
+	select {
+	case c := <-changes:
+		t.Errorf("Want no change, have %+v", c.Diff)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	after := writePage(32)
+	select {
+	case c := <-changes:
+		if c.URL != "https://example.com" || !bytes.Equal(c.Before, before) || !bytes.Equal(c.After, after) {
+			t.Errorf("Want change of https://example.com with the screenshots, have %s", c.URL)
+		}
+		if c.Diff == nil || c.Diff.Pixels == 0 {
+			t.Errorf("Want diff with different pixels, have %+v", c.Diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Want change, have none")
+	}
+	if len(called) != 1 {
+		t.Errorf("Want OnChange called once, have %d", len(called))
+	}
+
+	// a blocked send to Changes does not stop Run
+	writePage(0)
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Want context.Canceled, have %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	m.Unwatch("https://example.com")
+}
+
+func TestMonitorNoResults(t *testing.T) {
+	m := &Monitor{}
+	if err := m.Run(context.Background()); err == nil {
+		t.Error("Want error without Results")
+	}
+}
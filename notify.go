@@ -0,0 +1,95 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Status values of a Completion
+const (
+	CompletionDone   = "done"
+	CompletionFailed = "failed"
+)
+
+// Completion is the outcome of a job, which is sent to a Webhook or passed to a callback when the job is done,
+// so upstream systems do not have to poll for results
+type Completion struct {
+	ID     string `json:"id"`              // ID of the job, for example the id returned by Spool.Next
+	Status string `json:"status"`          // CompletionDone or CompletionFailed
+	Error  string `json:"error,omitempty"` // Error of a failed job
+	URL    string `json:"url,omitempty"`   // URL to download the output from, when the output was stored
+	Size   int    `json:"size,omitempty"`  // Size of the output in bytes
+}
+
+// NewCompletion returns the completion of the job with id from its output and error
+func NewCompletion(id string, output []byte, err error) Completion {
+	if err != nil {
+		return Completion{ID: id, Status: CompletionFailed, Error: err.Error()}
+	}
+	return Completion{ID: id, Status: CompletionDone, Size: len(output)}
+}
+
+// Webhook posts completions as JSON to a callback URL
+type Webhook struct {
+	URL string
+	// Secret signs the body with HMAC-SHA256 when it is set, the hex encoded signature is sent in the
+	// X-Signature header as sha256=<signature> so the receiver can check the completion came from this process
+	Secret []byte
+	// Client sends the requests, default http.DefaultClient
+	Client *http.Client
+}
+
+// Notify posts the completion to the URL of the webhook, a response without a 2xx status is an error
+func (wh *Webhook) Notify(ctx context.Context, c Completion) error {
+	body, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if len(wh.Secret) > 0 {
+		req.Header.Set("X-Signature", "sha256="+signWebhook(wh.Secret, body))
+	}
+	client := wh.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %s", wh.URL, resp.Status)
+	}
+	return nil
+}
+
+// signWebhook returns the hex encoded HMAC-SHA256 of body with secret
+func signWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// OnComplete posts the completion of a job of a Worker and can be used as Worker.OnComplete. The ID is the request ID
+// of the job and the URL is the download URL of ResultURL. Errors of the webhook are ignored, use Notify to handle them
+func (wh *Webhook) OnComplete(ctx context.Context, job *Job, output []byte, err error) {
+	var id string
+	if job != nil {
+		id = job.RequestID
+	}
+	c := NewCompletion(id, output, err)
+	c.URL = ResultURL(ctx)
+	wh.Notify(ctx, c)
+}
@@ -0,0 +1,93 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWebhook(t *testing.T) {
+	var body, signature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, signature = string(b), r.Header.Get("X-Signature")
+		if strings.Contains(body, "failed") {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	wh := &Webhook{URL: srv.URL, Secret: []byte("secret")}
+	if err := wh.Notify(context.Background(), NewCompletion("job-1", []byte("%PDF"), nil)); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":"job-1","status":"done","size":4}`; body != want {
+		t.Errorf("Want %s, have %s", want, body)
+	}
+	if want := "sha256=" + signWebhook([]byte("secret"), []byte(body)); signature != want {
+		t.Errorf("Want signature %s, have %s", want, signature)
+	}
+
+	err := wh.Notify(context.Background(), NewCompletion("job-2", nil, errors.New("crash")))
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Want error for the status, have %v", err)
+	}
+	if want := `{"id":"job-2","status":"failed","error":"crash"}`; body != want {
+		t.Errorf("Want %s, have %s", want, body)
+	}
+}
+
+func TestWorkerOnComplete(t *testing.T) {
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	var mu sync.Mutex
+	var bodies []string
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if bodies = append(bodies, string(b)); len(bodies) == 2 {
+			close(done)
+		}
+	}))
+	defer srv.Close()
+
+	defer setTestImagePath(bin.Name())()
+	jb, err := (&Job{RequestID: "req-1", Image: &ImageOptions{Input: "-", Html: "<svg></svg>", Format: "svg"}}).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := newTestQueue(&testMessage{data: jb}, &testMessage{data: []byte("{not json")})
+	wh := &Webhook{URL: srv.URL}
+	w := &Worker{Consumer: q, Publisher: q, Concurrency: 2, OnComplete: wh.OnComplete}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// the webhook is called after the result is published
+		<-done
+		cancel()
+	}()
+	w.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(bodies)
+	if len(bodies) != 2 || !strings.HasPrefix(bodies[0], `{"id":"","status":"failed","error":"error unmarshaling JSON`) ||
+		bodies[1] != `{"id":"req-1","status":"done","size":11}` {
+		t.Errorf("Want a failed and a done completion, have %q", bodies)
+	}
+}
@@ -1,8 +1,11 @@
 package wkhtmltopdf
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 )
 
 const opt = "--"
@@ -21,16 +24,16 @@ type globalOptions struct {
 	License           boolOption   // Output license information and exit
 	Lowquality        boolOption   // Generates lower quality pdf/ps. Useful to shrink the result document space
 	ManPage           boolOption   // Output program man page
-	MarginBottom      uintOption   // Set the page bottom margin
-	MarginLeft        uintOption   // Set the page left margin (default 10mm)
-	MarginRight       uintOption   // Set the page right margin (default 10mm)
-	MarginTop         uintOption   // Set the page top margin
+	MarginBottom      lengthOption // Set the page bottom margin in mm, or with a unit using SetString
+	MarginLeft        lengthOption // Set the page left margin in mm, or with a unit using SetString (default 10mm)
+	MarginRight       lengthOption // Set the page right margin in mm, or with a unit using SetString (default 10mm)
+	MarginTop         lengthOption // Set the page top margin in mm, or with a unit using SetString
 	NoCollate         boolOption   // Do not collate when printing multiple copies (default collate)
 	NoPdfCompression  boolOption   // Do not use lossless compression on pdf objects
 	Orientation       stringOption // Set orientation to Landscape or Portrait (default Portrait)
-	PageHeight        uintOption   // Page height
+	PageHeight        lengthOption // Page height in mm, or with a unit using SetString
 	PageSize          stringOption // Set paper size to: A4, Letter, etc. (default A4)
-	PageWidth         uintOption   // Page width
+	PageWidth         lengthOption // Page width in mm, or with a unit using SetString
 	Quiet             boolOption   // Be less verbose
 	ReadArgsFromStdin boolOption   // Read command line arguments from stdin
 	Readme            boolOption   // Output program readme
@@ -46,6 +49,7 @@ type outlineOptions struct {
 	DumpDefaultTocXsl boolOption   // Dump the default TOC xsl style sheet to stdout
 	DumpOutline       stringOption // Dump the outline to a file
 	NoOutline         boolOption   // Do not put an outline into the pdf
+	Outline           boolOption   // Put an outline into the pdf (default)
 	OutlineDepth      uintOption   // Set the depth of the outline (default 4)
 }
 
@@ -65,11 +69,14 @@ type pageOptions struct {
 	DebugJavascript           boolOption   // Show javascript debugging output
 	DefaultHeader             boolOption   // Add a default header, with the name of the page to the left, and the page number to the right, this is short for: --header-left='[webpage]' --header-right='[page]/[toPage]' --top 2cm --header-line
 	DisableExternalLinks      boolOption   // Do not make links to remote web pages
+	DisableForms              boolOption   // Do not turn HTML form fields into pdf form fields (default)
 	DisableInternalLinks      boolOption   // Do not make local links
 	DisableJavascript         boolOption   // Do not allow web pages to run javascript
 	DisableLocalFileAccess    boolOption   // Do not allowed conversion of a local file to read in other local files, unless explicitly allowed with --allow
 	DisableSmartShrinking     boolOption   // Disable the intelligent shrinking strategy used by WebKit that makes the pixel/dpi ratio none constant
+	EnableExternalLinks       boolOption   // Make links to remote web pages (default)
 	EnableForms               boolOption   // Turn HTML form fields into pdf form fields
+	EnableInternalLinks       boolOption   // Make local links (default)
 	EnablePlugins             boolOption   // Enable installed plugins (plugins will likely not work)
 	EnableTocBackLinks        boolOption   // Link from section header to toc
 	Encoding                  stringOption // Set the default text encoding, for input
@@ -274,6 +281,49 @@ func (fo *floatOption) Unset() {
 	fo.isSet = false
 }
 
+// lengthOption is a length in mm, the unit wkhtmltopdf uses for lengths without a unit
+type lengthOption struct {
+	option string
+	value  float64
+	isSet  bool
+}
+
+func (lo lengthOption) Parse() []string {
+	args := make([]string, 0)
+	if lo.isSet == false {
+		return args
+	}
+	args = append(args, opt+lo.option)
+	args = append(args, strconv.FormatFloat(lo.value, 'f', -1, 64))
+	return args
+}
+
+// Set sets the length in mm
+func (lo *lengthOption) Set(value uint) {
+	lo.isSet = true
+	lo.value = float64(value)
+}
+
+// SetLength sets the length, for example 2*Centimeter
+func (lo *lengthOption) SetLength(length Length) {
+	lo.isSet = true
+	lo.value = length.mm()
+}
+
+// SetString sets the length from a number with a unit, for example "10mm" or "0.5in", see ParseLength
+func (lo *lengthOption) SetString(length string) error {
+	l, err := ParseLength(length)
+	if err != nil {
+		return fmt.Errorf("invalid value of --%s: %s", lo.option, err)
+	}
+	lo.SetLength(l)
+	return nil
+}
+
+func (lo *lengthOption) Unset() {
+	lo.isSet = false
+}
+
 type boolOption struct {
 	option string
 	value  bool
@@ -308,16 +358,16 @@ func newGlobalOptions() globalOptions {
 		License:           boolOption{option: "license"},
 		Lowquality:        boolOption{option: "lowquality"},
 		ManPage:           boolOption{option: "manpage"},
-		MarginBottom:      uintOption{option: "margin-bottom"},
-		MarginLeft:        uintOption{option: "margin-left"},
-		MarginRight:       uintOption{option: "margin-right"},
-		MarginTop:         uintOption{option: "margin-top"},
-		NoCollate:         boolOption{option: "nocollate"},
+		MarginBottom:      lengthOption{option: "margin-bottom"},
+		MarginLeft:        lengthOption{option: "margin-left"},
+		MarginRight:       lengthOption{option: "margin-right"},
+		MarginTop:         lengthOption{option: "margin-top"},
+		NoCollate:         boolOption{option: "no-collate"},
 		NoPdfCompression:  boolOption{option: "no-pdf-compression"},
 		Orientation:       stringOption{option: "orientation"},
-		PageHeight:        uintOption{option: "page-height"},
+		PageHeight:        lengthOption{option: "page-height"},
 		PageSize:          stringOption{option: "page-size"},
-		PageWidth:         uintOption{option: "page-width"},
+		PageWidth:         lengthOption{option: "page-width"},
 		Quiet:             boolOption{option: "quiet"},
 		ReadArgsFromStdin: boolOption{option: "read-args-from-stdin"},
 		Readme:            boolOption{option: "readme"},
@@ -331,6 +381,7 @@ func newOutlineOptions() outlineOptions {
 		DumpDefaultTocXsl: boolOption{option: "dump-default-toc-xsl"},
 		DumpOutline:       stringOption{option: "dump-outline"},
 		NoOutline:         boolOption{option: "no-outline"},
+		Outline:           boolOption{option: "outline"},
 		OutlineDepth:      uintOption{option: "outline-depth"},
 	}
 }
@@ -348,11 +399,14 @@ func newPageOptions() pageOptions {
 		DebugJavascript:           boolOption{option: "debug-javascript"},
 		DefaultHeader:             boolOption{option: "default-header"},
 		DisableExternalLinks:      boolOption{option: "disable-external-links"},
+		DisableForms:              boolOption{option: "disable-forms"},
 		DisableInternalLinks:      boolOption{option: "disable-internal-links"},
 		DisableJavascript:         boolOption{option: "disable-javascript"},
 		DisableLocalFileAccess:    boolOption{option: "disable-local-file-access"},
 		DisableSmartShrinking:     boolOption{option: "disable-smart-shrinking"},
+		EnableExternalLinks:       boolOption{option: "enable-external-links"},
 		EnableForms:               boolOption{option: "enable-forms"},
+		EnableInternalLinks:       boolOption{option: "enable-internal-links"},
 		EnablePlugins:             boolOption{option: "enable-plugins"},
 		EnableTocBackLinks:        boolOption{option: "enable-toc-back-links"},
 		Encoding:                  stringOption{option: "encoding"},
@@ -372,17 +426,17 @@ func newPageOptions() pageOptions {
 		PostFile:                  mapOption{option: "post-file"},
 		PrintMediaType:            boolOption{option: "print-media-type"},
 		Proxy:                     stringOption{option: "proxy"},
-		RadiobuttonCheckedSvg: stringOption{option: "radiobutton-checked-svg"},
-		RadiobuttonSvg:        stringOption{option: "radiobutton-svg"},
-		RunScript:             sliceOption{option: "run-script"},
-		SslCrtPath:            stringOption{option: "ssl-crt-path"},
-		SslKeyPassword:        stringOption{option: "ssl-key-password"},
-		SslKeyPath:            stringOption{option: "ssl-key-path"},
-		Username:              stringOption{option: "username"},
-		UserStyleSheet:        stringOption{option: "user-style-sheet"},
-		ViewportSize:          stringOption{option: "viewport-size"},
-		WindowStatus:          stringOption{option: "window-status"},
-		Zoom:                  floatOption{option: "zoom"},
+		RadiobuttonCheckedSvg:     stringOption{option: "radiobutton-checked-svg"},
+		RadiobuttonSvg:            stringOption{option: "radiobutton-svg"},
+		RunScript:                 sliceOption{option: "run-script"},
+		SslCrtPath:                stringOption{option: "ssl-crt-path"},
+		SslKeyPassword:            stringOption{option: "ssl-key-password"},
+		SslKeyPath:                stringOption{option: "ssl-key-path"},
+		Username:                  stringOption{option: "username"},
+		UserStyleSheet:            stringOption{option: "user-style-sheet"},
+		ViewportSize:              stringOption{option: "viewport-size"},
+		WindowStatus:              stringOption{option: "window-status"},
+		Zoom:                      floatOption{option: "zoom"},
 	}
 }
 
@@ -437,6 +491,119 @@ func optsToArgs(opts interface{}) []string {
 	return args
 }
 
+// optionValues returns the name and values of an option, ok is false if the option is not set
+func optionValues(o interface{}) (name string, values []string, ok bool) {
+	switch o := o.(type) {
+	case *stringOption:
+		return o.option, []string{o.value}, o.value != ""
+	case *sliceOption:
+		return o.option, o.value, len(o.value) > 0
+	case *mapOption:
+		keys := make([]string, 0, len(o.value))
+		for k := range o.value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			values = append(values, k, o.value[k])
+		}
+		return o.option, values, len(keys) > 0
+	case *uintOption:
+		return o.option, []string{strconv.FormatUint(uint64(o.value), 10)}, o.isSet
+	case *floatOption:
+		return o.option, []string{strconv.FormatFloat(o.value, 'g', -1, 64)}, o.isSet
+	case *lengthOption:
+		return o.option, []string{strconv.FormatFloat(o.value, 'f', -1, 64)}, o.isSet
+	case *boolOption:
+		return o.option, nil, o.value
+	}
+	return "", nil, false
+}
+
+// setOptionValues sets the values returned by optionValues on an option
+func setOptionValues(o interface{}, values []string) error {
+	switch o := o.(type) {
+	case *boolOption:
+		o.Set(true)
+		return nil
+	case *sliceOption:
+		o.value = append([]string(nil), values...)
+		return nil
+	case *mapOption:
+		if len(values)%2 != 0 {
+			return errors.New("want pairs of keys and values")
+		}
+		// set the values in a copy so a map which is shared with other options is not changed
+		m := make(map[string]string, len(o.value)+len(values)/2)
+		for k, v := range o.value {
+			m[k] = v
+		}
+		for i := 0; i < len(values); i += 2 {
+			m[values[i]] = values[i+1]
+		}
+		o.value = m
+		return nil
+	}
+
+	if len(values) != 1 {
+		return fmt.Errorf("want 1 value, have %d", len(values))
+	}
+	switch o := o.(type) {
+	case *stringOption:
+		o.Set(values[0])
+	case *uintOption:
+		v, err := strconv.ParseUint(values[0], 10, 0)
+		if err != nil {
+			return err
+		}
+		o.Set(uint(v))
+	case *floatOption:
+		v, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return err
+		}
+		o.Set(v)
+	case *lengthOption:
+		return o.SetString(values[0])
+	}
+	return nil
+}
+
+// mergeOptions sets each option which is set in src on dst, src and dst must be pointers to the same option struct.
+// A boolean option which is false in src does not change dst.
+func mergeOptions(dst, src interface{}) {
+	dv := reflect.ValueOf(dst).Elem()
+	sv := reflect.ValueOf(src).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		_, values, ok := optionValues(sv.Field(i).Addr().Interface())
+		if ok {
+			setOptionValues(dv.Field(i).Addr().Interface(), values)
+		}
+	}
+}
+
+// cloneOptions replaces the values of slice and map options in the option struct opts points to by copies,
+// so they are not shared with the struct it was copied from
+func cloneOptions(opts interface{}) {
+	rv := reflect.ValueOf(opts).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		switch o := rv.Field(i).Addr().Interface().(type) {
+		case *sliceOption:
+			if o.value != nil {
+				o.value = append([]string(nil), o.value...)
+			}
+		case *mapOption:
+			if o.value != nil {
+				m := make(map[string]string, len(o.value))
+				for k, v := range o.value {
+					m[k] = v
+				}
+				o.value = m
+			}
+		}
+	}
+}
+
 // Constants for orientation modes
 const (
 	OrientationLandscape = "Landscape" // Landscape mode
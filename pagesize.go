@@ -0,0 +1,129 @@
+package wkhtmltopdf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pageSizes are the width and height in mm of the page sizes
+var pageSizes = map[string][2]float64{
+	PageSizeA0:        {841, 1189},
+	PageSizeA1:        {594, 841},
+	PageSizeA2:        {420, 594},
+	PageSizeA3:        {297, 420},
+	PageSizeA4:        {210, 297},
+	PageSizeA5:        {148, 210},
+	PageSizeA6:        {105, 148},
+	PageSizeA7:        {74, 105},
+	PageSizeA8:        {52, 74},
+	PageSizeA9:        {37, 52},
+	PageSizeB0:        {1000, 1414},
+	PageSizeB1:        {707, 1000},
+	PageSizeB10:       {31, 44},
+	PageSizeB2:        {500, 707},
+	PageSizeB3:        {353, 500},
+	PageSizeB4:        {250, 353},
+	PageSizeB5:        {176, 250},
+	PageSizeB6:        {125, 176},
+	PageSizeB7:        {88, 125},
+	PageSizeB8:        {62, 88},
+	PageSizeB9:        {33, 62},
+	PageSizeC5E:       {163, 229},
+	PageSizeComm10E:   {105, 241},
+	PageSizeDLE:       {110, 220},
+	PageSizeExecutive: {190.5, 254},
+	PageSizeFolio:     {210, 330},
+	PageSizeLedger:    {431.8, 279.4},
+	PageSizeLegal:     {215.9, 355.6},
+	PageSizeLetter:    {215.9, 279.4},
+	PageSizeTabloid:   {279.4, 431.8},
+}
+
+// lookupPageSize returns the name of the page size, which wkhtmltopdf matches without case
+func lookupPageSize(pageSize string) (string, bool) {
+	for name := range pageSizes {
+		if strings.EqualFold(name, pageSize) {
+			return name, true
+		}
+	}
+	return "", strings.EqualFold(pageSize, PageSizeCustom)
+}
+
+// pageDimensions returns the width and height in mm for a page size, A4 is used for unknown sizes
+func pageDimensions(pageSize string) (float64, float64) {
+	name, _ := lookupPageSize(pageSize)
+	if size, ok := pageSizes[name]; ok {
+		return size[0], size[1]
+	}
+	return 210, 297
+}
+
+// paperSize returns the width and height in mm of the pages of the PDF, from PageWidth and PageHeight or PageSize,
+// swapped for the landscape orientation
+func (gopt *globalOptions) paperSize() (float64, float64) {
+	width, height := pageDimensions(gopt.PageSize.value)
+	if gopt.PageWidth.isSet && gopt.PageHeight.isSet {
+		width, height = gopt.PageWidth.value, gopt.PageHeight.value
+	}
+	if strings.EqualFold(gopt.Orientation.value, OrientationLandscape) {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// checkPageSetup returns an error if the page size or orientation is unknown, or only one of PageWidth and PageHeight
+// is set, wkhtmltopdf silently uses the default A4 portrait pages for these. Margins which leave no space for the content
+// are an error too
+func (gopt *globalOptions) checkPageSetup() error {
+	if gopt.PageSize.value != "" {
+		if _, ok := lookupPageSize(gopt.PageSize.value); !ok {
+			return fmt.Errorf("invalid value of --page-size %q, want a page size such as A4 or Letter", gopt.PageSize.value)
+		}
+	}
+	if o := gopt.Orientation.value; o != "" && !strings.EqualFold(o, OrientationLandscape) && !strings.EqualFold(o, OrientationPortrait) {
+		return fmt.Errorf("invalid value of --orientation %q, want Landscape or Portrait", o)
+	}
+	if gopt.PageWidth.isSet != gopt.PageHeight.isSet {
+		return errors.New("--page-width and --page-height must be set together")
+	}
+	if gopt.PageWidth.isSet && (gopt.PageWidth.value <= 0 || gopt.PageHeight.value <= 0) {
+		return fmt.Errorf("invalid page size %sx%s mm, want a width and height larger than 0",
+			strconv.FormatFloat(gopt.PageWidth.value, 'f', -1, 64), strconv.FormatFloat(gopt.PageHeight.value, 'f', -1, 64))
+	}
+	width, height := gopt.paperSize()
+	for _, m := range []struct {
+		a, b *lengthOption
+		size float64
+	}{{&gopt.MarginLeft, &gopt.MarginRight, width}, {&gopt.MarginTop, &gopt.MarginBottom, height}} {
+		// margins which are not set are left out, the defaults of wkhtmltopdf always fit
+		var total float64
+		for _, o := range []*lengthOption{m.a, m.b} {
+			if o.isSet && o.value < 0 {
+				return fmt.Errorf("invalid value of --%s %s, want a margin of at least 0", o.option, Length(o.value))
+			}
+			if o.isSet {
+				total += o.value
+			}
+		}
+		if total >= m.size {
+			return fmt.Errorf("--%s and --%s of %s leave no space on pages of %s", m.a.option, m.b.option, Length(total), Length(m.size))
+		}
+	}
+	return nil
+}
+
+// SetPageDimensions sets PageWidth and PageHeight to a custom page size with units, for example
+// SetPageDimensions("8.5in", "11in"). A number without a unit is in mm
+func (gopt *globalOptions) SetPageDimensions(width, height string) error {
+	w, h := gopt.PageWidth, gopt.PageHeight
+	if err := w.SetString(width); err != nil {
+		return err
+	}
+	if err := h.SetString(height); err != nil {
+		return err
+	}
+	gopt.PageWidth, gopt.PageHeight = w, h
+	return nil
+}
@@ -0,0 +1,81 @@
+package wkhtmltopdf
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPageDimensions(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	if err := pdfg.SetPageDimensions("8.5in", "11in"); err != nil {
+		t.Fatal(err)
+	}
+	pdfg.Orientation.Set(OrientationLandscape)
+	if err := pdfg.checkArgs(); err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(pdfg.Args(), " ")
+	if !strings.Contains(args, "--page-height 279.4") || !strings.Contains(args, "--page-width 215.9") {
+		t.Errorf("Want page size in mm, have %s", args)
+	}
+	if w, h := pdfg.paperSize(); w != 279.4 || h != 215.9 {
+		t.Errorf("Want landscape letter, have %vx%v", w, h)
+	}
+	if err := pdfg.SetPageDimensions("8.5in", "11px"); err == nil {
+		t.Error("Want error for unknown unit")
+	}
+	if pdfg.PageHeight.value != 279.4 {
+		t.Error("Want page size unchanged after an error")
+	}
+
+	// whole mm are written as before
+	pdfg.PageWidth.Set(210)
+	if args := strings.Join(pdfg.Args(), " "); !strings.Contains(args, "--page-width 210 ") {
+		t.Errorf("Want page width 210, have %s", args)
+	}
+	b, err := json.Marshal(&pdfg.PageWidth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"Option":"page-width","IsSet":true,"Value":210}` {
+		t.Errorf("Want JSON of a uintOption, have %s", b)
+	}
+	height := new(lengthOption)
+	if err := json.Unmarshal([]byte(`{"Option":"page-height","IsSet":true,"Value":279.4}`), height); err != nil {
+		t.Fatal(err)
+	}
+	if *height != pdfg.PageHeight {
+		t.Errorf("Want %+v, have %+v", pdfg.PageHeight, *height)
+	}
+}
+
+func TestCheckPageSetup(t *testing.T) {
+	for name, tc := range map[string]struct {
+		setup func(pdfg *PDFGenerator)
+		err   string
+	}{
+		"letter":          {func(pdfg *PDFGenerator) { pdfg.PageSize.Set("letter") }, ""},
+		"unknown size":    {func(pdfg *PDFGenerator) { pdfg.PageSize.Set("A11") }, "--page-size"},
+		"orientation":     {func(pdfg *PDFGenerator) { pdfg.Orientation.Set("Sideways") }, "--orientation"},
+		"only width":      {func(pdfg *PDFGenerator) { pdfg.PageWidth.Set(100) }, "set together"},
+		"zero height":     {func(pdfg *PDFGenerator) { pdfg.SetPageDimensions("100mm", "0") }, "larger than 0"},
+		"custom and size": {func(pdfg *PDFGenerator) { pdfg.PageSize.Set(PageSizeCustom); pdfg.SetPageDimensions("10cm", "15cm") }, ""},
+		"margins":         {func(pdfg *PDFGenerator) { pdfg.MarginLeft.SetLength(4 * Inch); pdfg.MarginRight.SetString("11cm") }, "leave no space"},
+		"landscape":       {func(pdfg *PDFGenerator) { pdfg.Orientation.Set(OrientationLandscape); pdfg.MarginTop.Set(210) }, "leave no space"},
+		"negative":        {func(pdfg *PDFGenerator) { pdfg.MarginBottom.SetLength(-Centimeter) }, "at least 0"},
+		"margin portrait": {func(pdfg *PDFGenerator) { pdfg.MarginTop.Set(200); pdfg.MarginBottom.SetString("0.5in") }, ""},
+	} {
+		pdfg := NewPDFPreparer()
+		pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+		tc.setup(pdfg)
+		err := pdfg.checkArgs()
+		if tc.err == "" && err != nil {
+			t.Errorf("%s: want no error, have %s", name, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s: want error with %q, have %v", name, tc.err, err)
+		}
+	}
+}
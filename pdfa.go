@@ -0,0 +1,158 @@
+package wkhtmltopdf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var gsPath stringStore
+
+// SetGhostscriptPath sets the path to Ghostscript, which is used for converting PDF documents to PDF/A
+func SetGhostscriptPath(path string) {
+	gsPath.Set(path)
+}
+
+// GetGhostscriptPath gets the path to Ghostscript
+func GetGhostscriptPath() string {
+	return gsPath.Get()
+}
+
+// PDFAOptions are the options for converting the generated PDF to PDF/A-2b using Ghostscript.
+// Fonts are embedded, XMP metadata is added and colors are converted to RGB.
+type PDFAOptions struct {
+	Convert    bool   // Convert the PDF to PDF/A-2b
+	ICCProfile string // Path to an RGB ICC profile which is used as output intent, without a profile the PDF can not be fully compliant
+}
+
+// PDFAReport returns the problems that could not be fixed during the last PDF/A conversion.
+// It is empty if the PDF is expected to be compliant.
+func (pdfg *PDFGenerator) PDFAReport() []string {
+	return pdfg.pdfaReport
+}
+
+// convertToPDFA converts the PDF to PDF/A-2b and returns the new PDF with a list of problems that could not be fixed
+func convertToPDFA(ctx context.Context, pdf []byte, options PDFAOptions) ([]byte, []string, error) {
+	path, err := findGhostscriptPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := ioutil.TempDir("", tempPrefix(ctx, "wkhtmltopdf"))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.pdf")
+	out := filepath.Join(dir, "out.pdf")
+	err = ioutil.WriteFile(in, pdf, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var report []string
+	args := []string{
+		"-dPDFA=2",
+		"-dBATCH",
+		"-dNOPAUSE",
+		"-dNOOUTERSAVE",
+		"-dPDFACompatibilityPolicy=1",
+		"-dEmbedAllFonts=true",
+		"-sColorConversionStrategy=RGB",
+		"-sDEVICE=pdfwrite",
+		"-sOutputFile=" + out,
+	}
+	if options.ICCProfile != "" {
+		def := filepath.Join(dir, "PDFA_def.ps")
+		err = ioutil.WriteFile(def, []byte(pdfaDef(options.ICCProfile)), 0600)
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, "--permit-file-read="+options.ICCProfile, def)
+	} else {
+		report = append(report, "no ICC profile set, the PDF has no output intent")
+	}
+	args = append(args, in)
+
+	outbuf := &bytes.Buffer{}
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = outbuf
+	cmd.Stderr = outbuf
+	err = runCommand(ctx, cmd, nil)
+	output := outbuf.Bytes()
+	if err != nil {
+		errStr := string(output)
+		if strings.TrimSpace(errStr) == "" {
+			errStr = err.Error()
+		}
+		return nil, nil, fmt.Errorf("error converting to PDF/A: %s", errStr)
+	}
+
+	pdf, err = ioutil.ReadFile(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pdf, append(report, parsePDFAReport(output)...), nil
+}
+
+// parsePDFAReport returns the Ghostscript messages about features that are not allowed in PDF/A.
+// With PDFACompatibilityPolicy 1 Ghostscript drops these features and reports them.
+func parsePDFAReport(output []byte) []string {
+	var report []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimSpace(strings.Trim(line, "*"))
+		if !strings.Contains(line, "PDF/A") || seen[line] {
+			continue
+		}
+		seen[line] = true
+		report = append(report, line)
+	}
+	return report
+}
+
+// pdfaDef returns the PostScript definition for the PDF/A output intent using the ICC profile at path
+func pdfaDef(iccProfile string) string {
+	return `%!
+/ICCProfile (` + escapePostScript(filepath.ToSlash(iccProfile)) + `) def
+[/_objdef {icc_PDFA} /type /stream /OBJ pdfmark
+[{icc_PDFA} <</N 3>> /PUT pdfmark
+[{icc_PDFA} ICCProfile (r) file /PUT pdfmark
+[/_objdef {OutputIntent_PDFA} /type /dict /OBJ pdfmark
+[{OutputIntent_PDFA} <<
+  /Type /OutputIntent
+  /S /GTS_PDFA1
+  /DestOutputProfile {icc_PDFA}
+  /OutputConditionIdentifier (sRGB)
+>> /PUT pdfmark
+[{Catalog} <</OutputIntents [ {OutputIntent_PDFA} ]>> /PUT pdfmark
+`
+}
+
+// escapePostScript escapes a string for use in a PostScript string literal
+func escapePostScript(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(s)
+}
+
+// findGhostscriptPath finds the path to Ghostscript in the same way as the path to wkhtmltopdf is found,
+// using the GHOSTSCRIPT_PATH environment dir as last option
+func findGhostscriptPath() (string, error) {
+	if path := GetGhostscriptPath(); path != "" {
+		return path, nil
+	}
+	path, err := lookPath("gs", "GHOSTSCRIPT_PATH")
+	if err != nil {
+		return "", err
+	}
+	gsPath.Set(path)
+	return path, nil
+}
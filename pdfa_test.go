@@ -0,0 +1,58 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPDFA(t *testing.T) {
+	pdfg := newTestPostProcessPDFGenerator(t)
+	pdfg.PDFA.Convert = true
+
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(pdfg.Bytes(), []byte("pdfaid:part")) {
+		t.Error("PDF has no PDF/A identification")
+	}
+	// no ICC profile is set
+	if len(pdfg.PDFAReport()) == 0 {
+		t.Error("Want PDF/A report, have none")
+	}
+}
+
+func TestParsePDFAReport(t *testing.T) {
+	output := `GPL Ghostscript 9.26 (2018-11-20)
+   **** Transparency is not allowed in PDF/A, reverting to normal PDF output ****
+Processing pages 1 through 1.
+   **** Transparency is not allowed in PDF/A, reverting to normal PDF output ****
+ Annotation set to non-printing, not permitted in PDF/A, annotation will not be present in output file
+`
+	want := []string{
+		"Transparency is not allowed in PDF/A, reverting to normal PDF output",
+		"Annotation set to non-printing, not permitted in PDF/A, annotation will not be present in output file",
+	}
+	have := parsePDFAReport([]byte(output))
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("Want report:\n%q\nHave:\n%q", want, have)
+	}
+}
+
+func TestPDFADef(t *testing.T) {
+	def := pdfaDef("/usr/share/color/icc/sRGB (v2).icc")
+	if !strings.Contains(def, `/ICCProfile (/usr/share/color/icc/sRGB \(v2\).icc) def`) {
+		t.Errorf("ICC profile not escaped in:\n%s", def)
+	}
+}
+
+func TestGhostscriptPath(t *testing.T) {
+	path := "/usr/gs/gs"
+	SetGhostscriptPath(path)
+	defer SetGhostscriptPath("")
+	if GetGhostscriptPath() != path {
+		t.Errorf("Have path %q, want %q", GetGhostscriptPath(), path)
+	}
+}
@@ -0,0 +1,429 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+)
+
+// pdfUpdate changes objects of a PDF with an incremental update, which appends the changed objects with a new
+// cross-reference table so the PDF does not have to be parsed completely. PDFs with cross-reference streams,
+// which wkhtmltopdf does not write, are not supported.
+type pdfUpdate struct {
+	pdf     []byte
+	prev    int      // offset of the last cross-reference table
+	trailer *pdfDict // trailer of the update, starting as a copy of the last trailer
+	size    int      // number of objects including the added objects
+	root    pdfRef
+	objects []pdfObject
+}
+
+// newPDFUpdate reads the last trailer of the PDF
+func newPDFUpdate(pdf []byte) (*pdfUpdate, error) {
+	start := bytes.LastIndex(pdf, []byte("startxref"))
+	if start < 0 {
+		return nil, errors.New("no startxref found")
+	}
+	fields := bytes.Fields(pdf[start+len("startxref"):])
+	if len(fields) == 0 {
+		return nil, errors.New("invalid startxref")
+	}
+	prev, err := strconv.Atoi(string(fields[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid startxref: %s", err)
+	}
+	t := bytes.LastIndex(pdf[:start], []byte("trailer"))
+	if t < 0 {
+		return nil, errors.New("no trailer found, cross-reference streams are not supported")
+	}
+	trailer, err := parsePDFDict(pdf, t+len("trailer"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid trailer: %s", err)
+	}
+	size, err := strconv.Atoi(trailer.get("/Size"))
+	if err != nil {
+		return nil, errors.New("invalid trailer size")
+	}
+	root, err := parsePDFRef(trailer.get("/Root"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid root: %s", err)
+	}
+	return &pdfUpdate{pdf: pdf, prev: prev, trailer: trailer, size: size, root: root}, nil
+}
+
+// object returns the dictionary of the object with ref in the PDF
+func (u *pdfUpdate) object(ref pdfRef) (*pdfDict, error) {
+	return readPDFObject(u.pdf, u.prev, ref)
+}
+
+// catalog returns the document catalog of the PDF
+func (u *pdfUpdate) catalog() (*pdfDict, error) {
+	catalog, err := u.object(u.root)
+	if err != nil {
+		return nil, fmt.Errorf("catalog: %s", err)
+	}
+	return catalog, nil
+}
+
+// set writes the object with ref in the update, replacing the object in the PDF.
+// A stream object has the stream data, its Length is set.
+func (u *pdfUpdate) set(ref pdfRef, dict *pdfDict, stream []byte) {
+	if stream != nil {
+		dict.set("/Length", strconv.Itoa(len(stream)))
+	}
+	for i, o := range u.objects {
+		if o.ref == ref {
+			u.objects[i] = pdfObject{ref: ref, dict: dict, stream: stream}
+			return
+		}
+	}
+	u.objects = append(u.objects, pdfObject{ref: ref, dict: dict, stream: stream})
+}
+
+// add adds a new object to the update and returns its reference
+func (u *pdfUpdate) add(dict *pdfDict, stream []byte) pdfRef {
+	ref := pdfRef{num: u.size}
+	u.size++
+	u.set(ref, dict, stream)
+	return ref
+}
+
+// bytes returns the PDF with the update appended
+func (u *pdfUpdate) bytes() []byte {
+	out := bytes.NewBuffer(append([]byte(nil), u.pdf...))
+	if len(u.pdf) > 0 && u.pdf[len(u.pdf)-1] != '\n' {
+		out.WriteByte('\n')
+	}
+	offsets := make([]int, len(u.objects))
+	for i, o := range u.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d %d obj\n%s\n", o.ref.num, o.ref.gen, o.dict)
+		if o.stream != nil {
+			out.WriteString("stream\n")
+			out.Write(o.stream)
+			out.WriteString("\nendstream\n")
+		}
+		out.WriteString("endobj\n")
+	}
+	xref := out.Len()
+	out.WriteString("xref\n")
+	for i, o := range u.objects {
+		fmt.Fprintf(out, "%d 1\n%010d %05d n \n", o.ref.num, offsets[i], o.ref.gen)
+	}
+	trailer := *u.trailer
+	trailer.keys = append([]string(nil), trailer.keys...)
+	trailer.values = append([]string(nil), trailer.values...)
+	trailer.set("/Size", strconv.Itoa(u.size))
+	trailer.set("/Prev", strconv.Itoa(u.prev))
+	fmt.Fprintf(out, "trailer\n%s\nstartxref\n%d\n%%%%EOF\n", &trailer, xref)
+	return out.Bytes()
+}
+
+// pdfRef is an indirect reference to a PDF object
+type pdfRef struct {
+	num, gen int
+}
+
+func (r pdfRef) String() string {
+	return strconv.Itoa(r.num) + " " + strconv.Itoa(r.gen) + " R"
+}
+
+// parsePDFRef parses a reference such as "3 0 R"
+func parsePDFRef(s string) (pdfRef, error) {
+	f := bytes.Fields([]byte(s))
+	if len(f) != 3 || string(f[2]) != "R" {
+		return pdfRef{}, fmt.Errorf("%q is not a reference", s)
+	}
+	num, err1 := strconv.Atoi(string(f[0]))
+	gen, err2 := strconv.Atoi(string(f[1]))
+	if err1 != nil || err2 != nil {
+		return pdfRef{}, fmt.Errorf("%q is not a reference", s)
+	}
+	return pdfRef{num, gen}, nil
+}
+
+// pdfObject is a dictionary or stream object which is written in an incremental update
+type pdfObject struct {
+	ref    pdfRef
+	dict   *pdfDict
+	stream []byte
+}
+
+// pdfDict is a PDF dictionary with the values as they are written in the PDF, in the order of the keys
+type pdfDict struct {
+	keys   []string
+	values []string
+}
+
+func (d *pdfDict) get(key string) string {
+	for i, k := range d.keys {
+		if k == key {
+			return d.values[i]
+		}
+	}
+	return ""
+}
+
+func (d *pdfDict) set(key, value string) {
+	for i, k := range d.keys {
+		if k == key {
+			d.values[i] = value
+			return
+		}
+	}
+	d.keys = append(d.keys, key)
+	d.values = append(d.values, value)
+}
+
+func (d *pdfDict) String() string {
+	buf := bytes.NewBufferString("<<")
+	for i, k := range d.keys {
+		buf.WriteString("\n" + k + " " + d.values[i])
+	}
+	buf.WriteString("\n>>")
+	return buf.String()
+}
+
+// readPDFObject returns the dictionary of the object with ref, which is found in the cross-reference table at xref
+// or the tables before it
+func readPDFObject(pdf []byte, xref int, ref pdfRef) (*pdfDict, error) {
+	for seen := 0; seen < 1000; seen++ {
+		offset, prev, err := findPDFObject(pdf, xref, ref)
+		if err != nil {
+			return nil, err
+		}
+		if offset >= 0 {
+			i := skipPDFSpace(pdf, offset)
+			header := fmt.Sprintf("%d %d obj", ref.num, ref.gen)
+			if !bytes.HasPrefix(pdf[i:], []byte(header)) {
+				return nil, fmt.Errorf("object %d is not at offset %d", ref.num, offset)
+			}
+			return parsePDFDict(pdf, i+len(header))
+		}
+		if prev < 0 {
+			break
+		}
+		xref = prev
+	}
+	return nil, fmt.Errorf("object %d not found", ref.num)
+}
+
+// findPDFObject returns the offset of the object with ref in the cross-reference table at xref, or -1 when it is not
+// in the table, and the offset of the previous table or -1
+func findPDFObject(pdf []byte, xref int, ref pdfRef) (offset, prev int, err error) {
+	if xref < 0 || xref >= len(pdf) || !bytes.HasPrefix(pdf[xref:], []byte("xref")) {
+		return -1, -1, errors.New("no cross-reference table found, cross-reference streams are not supported")
+	}
+	i := xref + len("xref")
+	offset = -1
+	for {
+		i = skipPDFSpace(pdf, i)
+		if bytes.HasPrefix(pdf[i:], []byte("trailer")) {
+			break
+		}
+		var first, count int
+		if _, err := fmt.Sscanf(pdfLine(pdf, i), "%d %d", &first, &count); err != nil {
+			return -1, -1, errors.New("invalid cross-reference table")
+		}
+		i = bytes.IndexByte(pdf[i:], '\n') + i + 1
+		if ref.num >= first && ref.num < first+count {
+			e := i + (ref.num-first)*20
+			var o, gen int
+			var kind string
+			if _, err := fmt.Sscanf(pdfLine(pdf, e), "%d %d %s", &o, &gen, &kind); err == nil && kind == "n" && gen == ref.gen {
+				offset = o
+			}
+		}
+		i += count * 20
+		if i > len(pdf) {
+			return -1, -1, errors.New("invalid cross-reference table")
+		}
+	}
+	trailer, err := parsePDFDict(pdf, i+len("trailer"))
+	if err != nil {
+		return -1, -1, err
+	}
+	prev = -1
+	if p := trailer.get("/Prev"); p != "" {
+		prev, _ = strconv.Atoi(p)
+	}
+	return offset, prev, nil
+}
+
+// pdfLine returns the line of the cross-reference table at i
+func pdfLine(pdf []byte, i int) string {
+	if i >= len(pdf) {
+		return ""
+	}
+	line := pdf[i:]
+	if end := bytes.IndexAny(line, "\r\n"); end >= 0 {
+		line = line[:end]
+	}
+	return string(line)
+}
+
+// parsePDFDict parses the dictionary after white space at i
+func parsePDFDict(b []byte, i int) (*pdfDict, error) {
+	i = skipPDFSpace(b, i)
+	if !bytes.HasPrefix(b[i:], []byte("<<")) {
+		return nil, errors.New("no dictionary found")
+	}
+	d := &pdfDict{}
+	i += 2
+	for {
+		i = skipPDFSpace(b, i)
+		if i >= len(b) {
+			return nil, errors.New("unterminated dictionary")
+		}
+		if bytes.HasPrefix(b[i:], []byte(">>")) {
+			return d, nil
+		}
+		if b[i] != '/' {
+			return nil, errors.New("dictionary key is not a name")
+		}
+		end, err := pdfTokenEnd(b, i)
+		if err != nil {
+			return nil, err
+		}
+		key := string(b[i:end])
+		i = skipPDFSpace(b, end)
+		end, err = pdfTokenEnd(b, i)
+		if err != nil {
+			return nil, err
+		}
+		// references are three tokens, e.g. 3 0 R
+		if j := skipPDFSpace(b, end); j < len(b) && b[j] >= '0' && b[j] <= '9' {
+			if k, err := pdfTokenEnd(b, j); err == nil {
+				if r := skipPDFSpace(b, k); r < len(b) && b[r] == 'R' && (r+1 == len(b) || pdfDelimiter(b[r+1])) {
+					end = r + 1
+				}
+			}
+		}
+		d.set(key, string(b[i:end]))
+		i = end
+	}
+}
+
+// pdfTokenEnd returns the end of the object starting at i, nested dictionaries, arrays and strings are one object
+func pdfTokenEnd(b []byte, i int) (int, error) {
+	if i >= len(b) {
+		return 0, errors.New("unexpected end of PDF")
+	}
+	switch {
+	case bytes.HasPrefix(b[i:], []byte("<<")):
+		i += 2
+		for {
+			i = skipPDFSpace(b, i)
+			if i >= len(b) {
+				return 0, errors.New("unterminated dictionary")
+			}
+			if bytes.HasPrefix(b[i:], []byte(">>")) {
+				return i + 2, nil
+			}
+			end, err := pdfTokenEnd(b, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end
+		}
+	case b[i] == '[':
+		i++
+		for {
+			i = skipPDFSpace(b, i)
+			if i >= len(b) {
+				return 0, errors.New("unterminated array")
+			}
+			if b[i] == ']' {
+				return i + 1, nil
+			}
+			end, err := pdfTokenEnd(b, i)
+			if err != nil {
+				return 0, err
+			}
+			i = end
+		}
+	case b[i] == '(':
+		depth := 0
+		for ; i < len(b); i++ {
+			switch b[i] {
+			case '\\':
+				i++
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+		return 0, errors.New("unterminated string")
+	case b[i] == '<':
+		end := bytes.IndexByte(b[i:], '>')
+		if end < 0 {
+			return 0, errors.New("unterminated hex string")
+		}
+		return i + end + 1, nil
+	}
+	end := i + 1
+	for end < len(b) && !pdfDelimiter(b[end]) {
+		end++
+	}
+	return end, nil
+}
+
+// pdfDelimiter returns true for white space and the delimiter characters of PDF
+func pdfDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0, '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipPDFSpace returns the index of the first character at or after i which is not white space or in a comment
+func skipPDFSpace(b []byte, i int) int {
+	for i < len(b) {
+		switch b[i] {
+		case ' ', '\t', '\r', '\n', '\f', 0:
+			i++
+		case '%':
+			for i < len(b) && b[i] != '\n' && b[i] != '\r' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// pdfString returns s as a PDF text string, a literal string for ASCII text and a UTF-16 hex string otherwise
+func pdfString(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		buf := bytes.NewBufferString("(")
+		for i := 0; i < len(s); i++ {
+			if s[i] == '(' || s[i] == ')' || s[i] == '\\' {
+				buf.WriteByte('\\')
+			}
+			buf.WriteByte(s[i])
+		}
+		buf.WriteByte(')')
+		return buf.String()
+	}
+	buf := bytes.NewBufferString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(buf, "%04X", u)
+	}
+	buf.WriteByte('>')
+	return buf.String()
+}
@@ -0,0 +1,64 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"image"
+	"math/bits"
+)
+
+// ImageHash returns the difference hash of an encoded png, jpg or gif image. Images which look the same have the same
+// or a similar hash, also when they are encoded differently or have a different size, so a screenshot can be compared
+// with an earlier one without storing or downloading the earlier image, see HashDistance.
+func ImageHash(img []byte) (uint64, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(img))
+	if err != nil {
+		return 0, err
+	}
+	return differenceHash(decoded), nil
+}
+
+// HashDistance returns the number of bits which are different in two image hashes, from 0 for images which look
+// the same to 64. A distance up to about 10 means the images are similar.
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// differenceHash scales the image down to 9x8 gray cells and sets a bit for each cell which is brighter than the cell to its right
+func differenceHash(img image.Image) uint64 {
+	const w, h = 9, 8
+	var sums, counts [h][w]uint64
+	b := img.Bounds()
+	dx, dy := b.Dx(), b.Dy()
+	if dx == 0 || dy == 0 {
+		return 0
+	}
+	// sample at most about 256x256 pixels, which is enough for 72 cells
+	stepX, stepY := dx/256+1, dy/256+1
+	for y := 0; y < dy; y += stepY {
+		for x := 0; x < dx; x += stepX {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			gray := (299*uint64(r) + 587*uint64(g) + 114*uint64(bl)) / 1000
+			cy, cx := y*h/dy, x*w/dx
+			sums[cy][cx] += gray
+			counts[cy][cx]++
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if cellGray(sums[y][x], counts[y][x]) > cellGray(sums[y][x+1], counts[y][x+1]) {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+func cellGray(sum, count uint64) uint64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / count
+}
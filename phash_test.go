@@ -0,0 +1,64 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// testPage returns a png of a page with a dark header and a box at x
+func testPage(width, height, x int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			c := color.RGBA{255, 255, 255, 255}
+			if py < height/5 || px >= x && px < x+width/4 && py > height/2 {
+				c = color.RGBA{20, 40, 120, 255}
+			}
+			img.Set(px, py, c)
+		}
+	}
+	return img
+}
+
+func TestImageHash(t *testing.T) {
+	pngBuf := &bytes.Buffer{}
+	png.Encode(pngBuf, testPage(800, 600, 100))
+	// the same page in another format and size
+	jpgBuf := &bytes.Buffer{}
+	jpeg.Encode(jpgBuf, testPage(400, 300, 50), &jpeg.Options{Quality: 60})
+	// the box moved
+	changedBuf := &bytes.Buffer{}
+	png.Encode(changedBuf, testPage(800, 600, 500))
+
+	a, err := ImageHash(pngBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ImageHash(jpgBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ImageHash(changedBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := HashDistance(a, b); d > 4 {
+		t.Errorf("Want similar hashes for the same page, have distance %d", d)
+	}
+	if d := HashDistance(a, c); d < 10 {
+		t.Errorf("Want different hashes for a changed page, have distance %d", d)
+	}
+
+	res := &ImageResult{Image: pngBuf.Bytes()}
+	err = postProcessImage(res, &ImageOptions{Hash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Hash != a {
+		t.Errorf("Want hash %x in result, have %x", a, res.Hash)
+	}
+}
@@ -0,0 +1,193 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sort"
+)
+
+// PNGOptimization sets how png images are optimized after they are rendered, wkhtmltoimage writes png images which are
+// often several times larger than necessary, for example for thumbnails. The optimized image has no ancillary chunks
+// such as text and timestamps, the DPI is set after optimizing.
+type PNGOptimization struct {
+	// MaxColors quantizes the image to a palette of at most MaxColors colors (2 to 256) when it has more colors.
+	// This is lossy, images with transparency are not quantized. Default 0, only images with at most 256 colors use a palette.
+	MaxColors int
+	// CompressionLevel of the png encoder, default png.BestCompression
+	CompressionLevel png.CompressionLevel
+}
+
+// optimizePNG encodes the png again with a palette when possible and the compression level of po
+func optimizePNG(img []byte, po *PNGOptimization) ([]byte, error) {
+	decoded, err := png.Decode(bytes.NewReader(img))
+	if err != nil {
+		return nil, err
+	}
+	if po.MaxColors != 0 && (po.MaxColors < 2 || po.MaxColors > 256) {
+		return nil, fmt.Errorf("MaxColors must be between 2 and 256, have %d", po.MaxColors)
+	}
+
+	var optimized image.Image = decoded
+	if p := exactPalette(decoded); p != nil {
+		optimized = p
+	} else if po.MaxColors > 0 && opaque(decoded) {
+		pal := medianCut(decoded, po.MaxColors)
+		p := image.NewPaletted(decoded.Bounds(), pal)
+		draw.FloydSteinberg.Draw(p, p.Bounds(), decoded, decoded.Bounds().Min)
+		optimized = p
+	}
+
+	level := po.CompressionLevel
+	if level == png.DefaultCompression {
+		level = png.BestCompression
+	}
+	buf := &bytes.Buffer{}
+	err = (&png.Encoder{CompressionLevel: level}).Encode(buf, optimized)
+	if err != nil {
+		return nil, err
+	}
+	// the original is kept when it is smaller, e.g. when it was already optimized
+	if buf.Len() >= len(img) {
+		return stripPNGChunks(img)
+	}
+	return buf.Bytes(), nil
+}
+
+// criticalChunks are the png chunks which are needed to display the image, tRNS is kept for transparency
+var criticalChunks = map[string]bool{"IHDR": true, "PLTE": true, "tRNS": true, "IDAT": true, "IEND": true}
+
+// stripPNGChunks removes the ancillary chunks from the png
+func stripPNGChunks(img []byte) ([]byte, error) {
+	return filterPNGChunks(img, func(chunkType string) bool { return criticalChunks[chunkType] }, "", nil)
+}
+
+// exactPalette returns the image with a palette if it has at most 256 colors, otherwise nil
+func exactPalette(img image.Image) *image.Paletted {
+	b := img.Bounds()
+	index := make(map[color.NRGBA]uint8)
+	var pal color.Palette
+	p := image.NewPaletted(b, nil)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			i, ok := index[c]
+			if !ok {
+				if len(pal) == 256 {
+					return nil
+				}
+				i = uint8(len(pal))
+				index[c] = i
+				pal = append(pal, c)
+			}
+			p.Pix[p.PixOffset(x, y)] = i
+		}
+	}
+	p.Palette = pal
+	return p
+}
+
+// opaque returns true if the image has no transparent pixels
+func opaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// colorBucket is a color with 5 bits per channel and the number of pixels with that color
+type colorBucket struct {
+	rgb   [3]uint8
+	count int
+}
+
+// medianCut returns a palette of at most n colors for the image, using a histogram of the colors with 5 bits per channel
+func medianCut(img image.Image, n int) color.Palette {
+	counts := make(map[[3]uint8]int)
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			counts[[3]uint8{uint8(r >> 11), uint8(g >> 11), uint8(bl >> 11)}]++
+		}
+	}
+	buckets := make([]colorBucket, 0, len(counts))
+	for rgb, count := range counts {
+		buckets = append(buckets, colorBucket{rgb, count})
+	}
+
+	// split the box with the most pixels along its widest channel until there are n boxes
+	boxes := [][]colorBucket{buckets}
+	for len(boxes) < n {
+		best, pixels := -1, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if c := pixelCount(box); c > pixels {
+				best, pixels = i, c
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		ch := widestChannel(box)
+		sort.Slice(box, func(i, j int) bool { return box[i].rgb[ch] < box[j].rgb[ch] })
+		// the median pixel, not the median color, so large areas get more colors
+		half, split := pixels/2, 1
+		for sum := 0; split < len(box)-1; split++ {
+			sum += box[split-1].count
+			if sum >= half {
+				break
+			}
+		}
+		boxes = append(boxes, box[split:])
+		boxes[best] = box[:split]
+	}
+
+	pal := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var r, g, bl, total int
+		for _, c := range box {
+			r += int(c.rgb[0]) * c.count
+			g += int(c.rgb[1]) * c.count
+			bl += int(c.rgb[2]) * c.count
+			total += c.count
+		}
+		// scale the average from 5 to 8 bits
+		pal = append(pal, color.RGBA{uint8(r * 255 / (31 * total)), uint8(g * 255 / (31 * total)), uint8(bl * 255 / (31 * total)), 255})
+	}
+	return pal
+}
+
+func pixelCount(box []colorBucket) int {
+	n := 0
+	for _, c := range box {
+		n += c.count
+	}
+	return n
+}
+
+// widestChannel returns the channel with the largest range of values in the box
+func widestChannel(box []colorBucket) int {
+	widest, width := 0, -1
+	for ch := 0; ch < 3; ch++ {
+		min, max := uint8(31), uint8(0)
+		for _, c := range box {
+			if c.rgb[ch] < min {
+				min = c.rgb[ch]
+			}
+			if c.rgb[ch] > max {
+				max = c.rgb[ch]
+			}
+		}
+		if int(max-min) > width {
+			widest, width = ch, int(max-min)
+		}
+	}
+	return widest
+}
@@ -0,0 +1,89 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// testScreenshot returns a png with a gradient, with 16 colors or with thousands of colors
+func testScreenshot(gradient bool) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			c := color.RGBA{uint8(x / 16 * 16), 0, 128, 255}
+			if gradient {
+				c = color.RGBA{uint8(x), uint8(y * 2), uint8(x + y), 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	buf := &bytes.Buffer{}
+	(&png.Encoder{CompressionLevel: png.NoCompression}).Encode(buf, img)
+	return buf.Bytes()
+}
+
+func TestOptimizePNGLossless(t *testing.T) {
+	src := testScreenshot(false)
+	img, err := optimizePNG(src, &PNGOptimization{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(img) >= len(src) {
+		t.Errorf("Want smaller png than %d bytes, have %d", len(src), len(img))
+	}
+	decoded, err := png.Decode(bytes.NewReader(img))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded.(*image.Paletted); !ok {
+		t.Errorf("Want png with palette, have %T", decoded)
+	}
+	original, _ := png.Decode(bytes.NewReader(src))
+	r1, g1, b1, _ := original.At(37, 42).RGBA()
+	r2, g2, b2, _ := decoded.At(37, 42).RGBA()
+	if r1 != r2 || g1 != g2 || b1 != b2 {
+		t.Error("Want the same colors in the optimized png")
+	}
+}
+
+func TestOptimizePNGQuantize(t *testing.T) {
+	src := testScreenshot(true)
+	img, err := optimizePNG(src, &PNGOptimization{MaxColors: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(img))
+	if err != nil {
+		t.Fatal(err)
+	}
+	colors := make(map[color.Color]bool)
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			colors[decoded.At(x, y)] = true
+		}
+	}
+	if len(colors) > 64 {
+		t.Errorf("Want png with at most 64 colors, have %d", len(colors))
+	}
+
+	_, err = optimizePNG(src, &PNGOptimization{MaxColors: 1000})
+	if err == nil {
+		t.Error("Want error for MaxColors 1000")
+	}
+}
+
+func TestStripPNGChunks(t *testing.T) {
+	src := appendPNGChunk([]byte(pngMagic), "IHDR", make([]byte, 13))
+	src = appendPNGChunk(src, "tEXt", []byte("Software\x00Qt"))
+	src = appendPNGChunk(src, "IEND", nil)
+	img, err := stripPNGChunks(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(img, []byte("tEXt")) || !bytes.Contains(img, []byte("IEND")) {
+		t.Errorf("Want png without tEXt chunk, have %q", img)
+	}
+}
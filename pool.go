@@ -0,0 +1,71 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBuffer is the capacity above which buffers are not returned to the pool,
+// so one very large document does not keep its memory in use
+const maxPooledBuffer = 8 << 20
+
+var (
+	bufferPool = sync.Pool{New: func() interface{} {
+		atomic.AddUint64(&poolStats.Allocs, 1)
+		return new(bytes.Buffer)
+	}}
+	argsPool = sync.Pool{New: func() interface{} {
+		atomic.AddUint64(&poolStats.Allocs, 1)
+		a := make([]string, 0, 16)
+		return &a
+	}}
+	poolStats PoolStats
+)
+
+// PoolStats counts the buffers and argument slices of renders which are taken from a pool,
+// so the memory saved by reusing them on servers with many renders can be measured
+type PoolStats struct {
+	Gets   uint64 // Buffers and argument slices taken from the pool
+	Allocs uint64 // Buffers and argument slices allocated because the pool was empty
+}
+
+// Reused returns the number of buffers and argument slices which were reused
+func (s PoolStats) Reused() uint64 {
+	return s.Gets - s.Allocs
+}
+
+// GetPoolStats returns the pool statistics since the program started
+func GetPoolStats() PoolStats {
+	return PoolStats{
+		Gets:   atomic.LoadUint64(&poolStats.Gets),
+		Allocs: atomic.LoadUint64(&poolStats.Allocs),
+	}
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	atomic.AddUint64(&poolStats.Gets, 1)
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool, it must not be used after this call
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// getArgs returns an empty argument slice from the pool
+func getArgs() *[]string {
+	atomic.AddUint64(&poolStats.Gets, 1)
+	return argsPool.Get().(*[]string)
+}
+
+// putArgs returns the argument slice a to the pool, it must not be used after this call
+func putArgs(a *[]string) {
+	*a = (*a)[:0]
+	argsPool.Put(a)
+}
@@ -0,0 +1,49 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPoolStats(t *testing.T) {
+	before := GetPoolStats()
+	buf := getBuffer()
+	buf.WriteString("stderr output")
+	putBuffer(buf)
+	buf = getBuffer()
+	if buf.Len() != 0 {
+		t.Errorf("Want empty buffer from pool, have %q", buf.String())
+	}
+	putBuffer(buf)
+	args := getArgs()
+	*args = append(*args, "-q")
+	putArgs(args)
+	if args = getArgs(); len(*args) != 0 {
+		t.Errorf("Want empty args from pool, have %v", *args)
+	}
+
+	stats := GetPoolStats()
+	if stats.Gets-before.Gets != 4 {
+		t.Errorf("Want 4 gets, have %d", stats.Gets-before.Gets)
+	}
+	if stats.Reused() > stats.Gets {
+		t.Errorf("Want reused %d to be at most gets %d", stats.Reused(), stats.Gets)
+	}
+}
+
+func TestPutBufferDropsLargeBuffers(t *testing.T) {
+	buf := bytes.NewBuffer(make([]byte, 0, maxPooledBuffer+1))
+	putBuffer(buf)
+	if buf.Cap() != maxPooledBuffer+1 {
+		t.Error("Want large buffer not to be reset")
+	}
+}
+
+func BenchmarkRenderBuffers(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := getBuffer()
+		buf.Write(make([]byte, 64*1024))
+		putBuffer(buf)
+	}
+}
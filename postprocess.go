@@ -0,0 +1,213 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+var qpdfPath stringStore
+
+// SetQPDFPath sets the path to qpdf, which is used for post processing PDF documents
+func SetQPDFPath(path string) {
+	qpdfPath.Set(path)
+}
+
+// GetQPDFPath gets the path to qpdf
+func GetQPDFPath() string {
+	return qpdfPath.Get()
+}
+
+// SignFunc is called with the finished PDF document and returns the digitally signed PDF document.
+// Signing is done by the caller, for example using a PKCS#12 certificate or a remote signing service.
+type SignFunc func(pdf []byte) ([]byte, error)
+
+// postProcessing returns true if the generated PDF has to be modified after wkhtmltopdf is done
+func (pdfg *PDFGenerator) postProcessing() bool {
+	return pdfg.Linearize || pdfg.PDFA.Convert || pdfg.Watermark.enabled() || len(pdfg.attachments) > 0 || pdfg.Sign != nil ||
+		pdfg.Accessibility.Enable || pdfg.ColorProfile != ""
+}
+
+// postProcess runs all post processing steps on the PDF in buf, or in OutputFile when that is set,
+// and writes the result to the output set on the PDFGenerator
+func (pdfg *PDFGenerator) postProcess(ctx context.Context, buf *bytes.Buffer) error {
+	var err error
+	pdf := buf.Bytes()
+	if pdfg.OutputFile != "" {
+		pdf, err = ioutil.ReadFile(pdfg.outputFile())
+		if err != nil {
+			return err
+		}
+	}
+
+	if pdfg.Watermark.enabled() {
+		pdf, err = pdfg.stamp(ctx, pdf)
+		if err != nil {
+			return err
+		}
+	}
+	if pdfg.PDFA.Convert {
+		pdf, pdfg.pdfaReport, err = convertToPDFA(ctx, pdf, pdfg.PDFA)
+		if err != nil {
+			return err
+		}
+		if len(pdfg.attachments) > 0 {
+			pdfg.pdfaReport = append(pdfg.pdfaReport, "attachments are not allowed in PDF/A-2b")
+		}
+	}
+	if pdfg.ColorProfile != "" {
+		profile, name, err := loadColorProfile(pdfg.ColorProfile, pdfg.workDir)
+		if err != nil {
+			return err
+		}
+		pdf, err = addOutputIntent(pdf, profile, name)
+		if err != nil {
+			return fmt.Errorf("error adding color profile: %s", err)
+		}
+	}
+	// attachments are added after the PDF/A conversion because Ghostscript drops them
+	if len(pdfg.attachments) > 0 {
+		pdf, err = pdfg.attach(ctx, pdf)
+		if err != nil {
+			return err
+		}
+	}
+	if pdfg.Accessibility.Enable {
+		var warnings []string
+		pdf, warnings = addAccessibility(pdf, pdfg.lang, pdfg.Accessibility.Title)
+		for _, w := range warnings {
+			pdfg.warnings = append(pdfg.warnings, w)
+			if pdfg.OnWarning != nil {
+				pdfg.OnWarning(w)
+			}
+		}
+	}
+	if pdfg.Linearize {
+		pdf, err = linearize(ctx, pdf)
+		if err != nil {
+			return err
+		}
+	}
+	// signing must be the last step, any change after signing invalidates the signature
+	if pdfg.Sign != nil {
+		pdf, err = pdfg.Sign(pdf)
+		if err != nil {
+			return fmt.Errorf("error signing PDF: %s", err)
+		}
+	}
+
+	switch {
+	case pdfg.OutputFile != "":
+		return ioutil.WriteFile(pdfg.outputFile(), pdf, 0666)
+	case pdfg.outWriter != nil:
+		_, err = pdfg.outWriter.Write(pdf)
+		return err
+	}
+	_, err = pdfg.outbuf.Write(pdf)
+	return err
+}
+
+// linearize optimizes the PDF for fast web view, so browsers can display the first page before the whole file is downloaded
+func linearize(ctx context.Context, pdf []byte) ([]byte, error) {
+	return runQPDF(ctx, pdf, "--linearize")
+}
+
+// runQPDF runs qpdf with args on the PDF and returns the new PDF.
+// qpdf needs a seekable input so the PDF is written to a temporary directory.
+func runQPDF(ctx context.Context, pdf []byte, args ...string) ([]byte, error) {
+	dir, err := ioutil.TempDir("", tempPrefix(ctx, "wkhtmltopdf"))
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.pdf")
+	out := filepath.Join(dir, "out.pdf")
+	err = ioutil.WriteFile(in, pdf, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	err = execQPDF(ctx, append(append([]string{}, args...), in, out)...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(out)
+}
+
+// execQPDF runs qpdf with args and returns the error output as error when qpdf fails
+func execQPDF(ctx context.Context, args ...string) error {
+	path, err := findQPDFPath()
+	if err != nil {
+		return err
+	}
+
+	errbuf := &bytes.Buffer{}
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = errbuf
+
+	err = runCommand(ctx, cmd, nil)
+	// exit code 3 means qpdf succeeded but had warnings
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.ExitStatus() == 3 {
+			err = nil
+		}
+	}
+	if err != nil {
+		errStr := errbuf.String()
+		if strings.TrimSpace(errStr) == "" {
+			errStr = err.Error()
+		}
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+// findQPDFPath finds the path to qpdf in the same way as the path to wkhtmltopdf is found,
+// using the QPDF_PATH environment dir as last option
+func findQPDFPath() (string, error) {
+	if path := GetQPDFPath(); path != "" {
+		return path, nil
+	}
+	path, err := lookPath("qpdf", "QPDF_PATH")
+	if err != nil {
+		return "", err
+	}
+	qpdfPath.Set(path)
+	return path, nil
+}
+
+// lookPath finds an executable by
+// - first looking in the current dir
+// - looking in the PATH and PATHEXT environment dirs
+// - using the dir in environment variable envDir
+func lookPath(exe, envDir string) (string, error) {
+	exeDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
+	if err != nil {
+		return "", err
+	}
+	path, err := exec.LookPath(filepath.Join(exeDir, exe))
+	if err == nil && path != "" {
+		return path, nil
+	}
+	path, err = exec.LookPath(exe)
+	if err == nil && path != "" {
+		return path, nil
+	}
+	dir := os.Getenv(envDir)
+	if dir == "" {
+		return "", fmt.Errorf("%s not found", exe)
+	}
+	path, err = exec.LookPath(filepath.Join(dir, exe))
+	if err == nil && path != "" {
+		return path, nil
+	}
+	return "", fmt.Errorf("%s not found", exe)
+}
@@ -0,0 +1,104 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func newTestPostProcessPDFGenerator(tb testing.TB) *PDFGenerator {
+	pdfg, err := NewPDFGenerator()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	htmlfile, err := ioutil.ReadFile("./testfiles/htmlsimple.html")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	pdfg.AddPage(NewPageReader(bytes.NewReader(htmlfile)))
+	return pdfg
+}
+
+func TestLinearize(t *testing.T) {
+	pdfg := newTestPostProcessPDFGenerator(t)
+	pdfg.Linearize = true
+
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := pdfg.Bytes()
+	if len(b) > 1024 {
+		b = b[:1024]
+	}
+	if !bytes.Contains(b, []byte("/Linearized")) {
+		t.Error("PDF is not linearized")
+	}
+}
+
+func TestLinearizeOutputFile(t *testing.T) {
+	pdfg := newTestPostProcessPDFGenerator(t)
+	pdfg.Linearize = true
+	pdfg.OutputFile = "./testfiles/TestLinearizeOutputFile.pdf"
+
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(pdfg.OutputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte("/Linearized")) {
+		t.Error("PDF is not linearized")
+	}
+}
+
+func TestSign(t *testing.T) {
+	pdfg := newTestPostProcessPDFGenerator(t)
+	pdfg.Sign = func(pdf []byte) ([]byte, error) {
+		if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+			t.Error("Sign is not called with a PDF")
+		}
+		return append(pdf, []byte("%signed")...), nil
+	}
+	outBuf := new(bytes.Buffer)
+	pdfg.SetOutput(outBuf)
+
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(outBuf.Bytes(), []byte("%signed")) {
+		t.Error("PDF is not signed")
+	}
+}
+
+func TestSignError(t *testing.T) {
+	pdfg := newTestPostProcessPDFGenerator(t)
+	pdfg.Sign = func(pdf []byte) ([]byte, error) {
+		return nil, errors.New("no certificate")
+	}
+
+	err := pdfg.Create()
+	if err == nil {
+		t.Fatal("Want an error when signing fails, have no error")
+	}
+	want := "error signing PDF: no certificate"
+	if err.Error() != want {
+		t.Errorf("Want error %q, have %q", want, err.Error())
+	}
+	if pdfg.Buffer().Len() != 0 {
+		t.Error("Want no output when signing fails")
+	}
+}
+
+func TestQPDFPath(t *testing.T) {
+	path := "/usr/qpdf/qpdf"
+	SetQPDFPath(path)
+	defer SetQPDFPath("")
+	if GetQPDFPath() != path {
+		t.Errorf("Have path %q, want %q", GetQPDFPath(), path)
+	}
+}
@@ -0,0 +1,350 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// htmlTokenRe matches comments, doctypes, processing instructions and tags with their name and attributes
+var htmlTokenRe = regexp.MustCompile(`(?s)<!--.*?-->|<![^>]*>|<\?[^>]*>|<(/?)([a-zA-Z][a-zA-Z0-9:-]*)((?:[^>"']|"[^"]*"|'[^']*')*)>`)
+
+// htmlAttrRe matches an attribute with an optional value in a tag
+var htmlAttrRe = regexp.MustCompile(`([^\s"'<>/=]+)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+)))?`)
+
+// rawTextEnd matches the end tags of the elements which contain text instead of HTML
+var rawTextEnd = map[string]*regexp.Regexp{
+	"script":   regexp.MustCompile(`(?i)</script\b`),
+	"style":    regexp.MustCompile(`(?i)</style\b`),
+	"textarea": regexp.MustCompile(`(?i)</textarea\b`),
+	"title":    regexp.MustCompile(`(?i)</title\b`),
+}
+
+// foreignElements start SVG and MathML content, in which a tag ending in "/>" is self-closing
+var foreignElements = map[string]bool{"svg": true, "math": true}
+
+// voidElements have no end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// optionalEndElements may leave out their end tag
+var optionalEndElements = map[string]bool{
+	"html": true, "head": true, "body": true, "p": true, "li": true, "dt": true, "dd": true, "option": true, "optgroup": true,
+	"tr": true, "td": true, "th": true, "thead": true, "tbody": true, "tfoot": true, "colgroup": true, "rb": true, "rt": true, "rp": true,
+}
+
+// assetAttributes are the attributes of elements which wkhtmltopdf loads when it renders the page
+var assetAttributes = map[string]string{
+	"img": "src", "script": "src", "iframe": "src", "embed": "src", "source": "src", "audio": "src", "video": "src",
+	"track": "src", "input": "src", "object": "data", "link": "href",
+}
+
+// PreflightOptions are the options of Preflight and PreflightHTML
+type PreflightOptions struct {
+	// Client checks assets with http and https URLs with HEAD requests, they are not checked when it is nil
+	Client *http.Client
+}
+
+// PreflightIssue is a problem in the HTML of a page found by Preflight
+type PreflightIssue struct {
+	Page    int    // Index of the page in the order they were added
+	Line    int    // Line in the HTML, 0 for the whole document
+	Message string // Description of the problem
+}
+
+func (i PreflightIssue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("page %d: %s", i.Page, i.Message)
+	}
+	return fmt.Sprintf("page %d line %d: %s", i.Page, i.Line, i.Message)
+}
+
+// Preflight checks the HTML of the local pages and page readers before the PDF is created, so template bugs are found
+// without waiting for a render which fails. It reports tags which are not closed, a missing html or body element,
+// assets which do not exist and file:// URLs which are blocked by DisableLocalFileAccess. Relative paths are resolved
+// from the directory of the page file, or the work dir for page readers which are read into memory. Pages with URLs are not checked
+func (pdfg *PDFGenerator) Preflight(ctx context.Context, options PreflightOptions) ([]PreflightIssue, error) {
+	var issues []PreflightIssue
+	for i, p := range pdfg.pages {
+		var content []byte
+		var popt *pageOptions
+		dir := pdfg.workDir
+		switch p := p.(type) {
+		case *Page:
+			path, ok := localPath(p.Input)
+			if !ok {
+				continue
+			}
+			if pdfg.workDir != "" && !filepath.IsAbs(path) {
+				path = filepath.Join(pdfg.workDir, path)
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			content, popt, dir = b, &p.pageOptions, filepath.Dir(path)
+		case *PageReader:
+			if p.Input == nil {
+				continue
+			}
+			b, err := ioutil.ReadAll(p.Input)
+			if err != nil {
+				return nil, err
+			}
+			p.Input = bytes.NewReader(b)
+			content, popt = b, &p.pageOptions
+		default:
+			continue
+		}
+		pf := &preflight{ctx: ctx, options: options, dir: dir, checked: make(map[string]string)}
+		if popt.DisableLocalFileAccess.value {
+			pf.allow = popt.Allow.value
+			pf.blockLocal = true
+		}
+		for _, issue := range pf.check(content) {
+			issue.Page = i
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// PreflightHTML checks HTML like Preflight, relative paths of assets are resolved from dir. Page of the issues is 0
+func PreflightHTML(ctx context.Context, content []byte, dir string, options PreflightOptions) []PreflightIssue {
+	pf := &preflight{ctx: ctx, options: options, dir: dir, checked: make(map[string]string)}
+	return pf.check(content)
+}
+
+// localPath returns the file path of a page input which is not a URL, or of a file:// URL
+func localPath(input string) (string, bool) {
+	u, err := url.Parse(input)
+	if err != nil || len(u.Scheme) <= 1 {
+		// a scheme of one letter is a Windows drive
+		return input, input != "" && input != "-"
+	}
+	if strings.EqualFold(u.Scheme, "file") {
+		return filepath.FromSlash(u.Path), true
+	}
+	return "", false
+}
+
+// preflight checks the HTML of one page
+type preflight struct {
+	ctx        context.Context
+	options    PreflightOptions
+	dir        string
+	blockLocal bool
+	allow      []string
+	checked    map[string]string // the issues of the assets which were checked, by URL
+}
+
+// openElement is an element which was started at a line
+type openElement struct {
+	name string
+	line int
+}
+
+// check returns the issues of the HTML
+func (pf *preflight) check(content []byte) []PreflightIssue {
+	var issues []PreflightIssue
+	var open []openElement
+	seen := make(map[string]bool)
+	line, counted := 1, 0
+	lineAt := func(offset int) int {
+		line += bytes.Count(content[counted:offset], []byte("\n"))
+		counted = offset
+		return line
+	}
+	for pos := 0; pos < len(content); {
+		base := pos
+		m := htmlTokenRe.FindSubmatchIndex(content[base:])
+		if m == nil {
+			break
+		}
+		pos = base + m[1]
+		if m[4] < 0 {
+			// comment, doctype or processing instruction
+			continue
+		}
+		l := lineAt(base + m[0])
+		name := strings.ToLower(string(content[base+m[4] : base+m[5]]))
+		attrs := string(content[base+m[6] : base+m[7]])
+		if m[3] > m[2] {
+			issues = append(issues, closeElement(&open, name, l)...)
+			continue
+		}
+		seen[name] = true
+		if attr, ok := assetAttributes[name]; ok {
+			values := parseAttributes(attrs)
+			ref, ok := values[attr]
+			if ok && (name != "link" || strings.Contains(strings.ToLower(values["rel"]), "stylesheet")) {
+				if msg := pf.checkAsset(ref); msg != "" {
+					issues = append(issues, PreflightIssue{Line: l, Message: msg})
+				}
+			}
+		}
+		// HTML ignores the slash of "<div/>", only void elements and SVG or MathML content can be self-closing
+		if voidElements[name] || strings.HasSuffix(strings.TrimSpace(attrs), "/") && inForeign(open, name) {
+			continue
+		}
+		if re, ok := rawTextEnd[name]; ok {
+			if e := re.FindIndex(content[pos:]); e != nil {
+				pos += e[0]
+			} else {
+				issues = append(issues, PreflightIssue{Line: l, Message: fmt.Sprintf("<%s> is not closed", name)})
+				break
+			}
+		}
+		open = append(open, openElement{name: name, line: l})
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		if !optionalEndElements[open[i].name] {
+			issues = append(issues, PreflightIssue{Line: open[i].line, Message: fmt.Sprintf("<%s> is not closed", open[i].name)})
+		}
+	}
+	for _, name := range []string{"html", "body"} {
+		if !seen[name] {
+			issues = append(issues, PreflightIssue{Message: fmt.Sprintf("the document has no <%s> element", name)})
+		}
+	}
+	return issues
+}
+
+// inForeign returns true if an element with name is SVG or MathML content
+func inForeign(open []openElement, name string) bool {
+	if foreignElements[name] {
+		return true
+	}
+	for _, e := range open {
+		if foreignElements[e.name] {
+			return true
+		}
+	}
+	return false
+}
+
+// closeElement removes the element of an end tag and the elements in it from open, the issues are the elements in it
+// which were not closed, or the end tag when the element was not started
+func closeElement(open *[]openElement, name string, line int) []PreflightIssue {
+	elements := *open
+	i := len(elements) - 1
+	for i >= 0 && elements[i].name != name {
+		i--
+	}
+	if i < 0 {
+		if voidElements[name] {
+			return nil
+		}
+		return []PreflightIssue{{Line: line, Message: fmt.Sprintf("</%s> has no start tag", name)}}
+	}
+	var issues []PreflightIssue
+	for _, e := range elements[i+1:] {
+		if !optionalEndElements[e.name] {
+			issues = append(issues, PreflightIssue{Line: e.line, Message: fmt.Sprintf("<%s> is not closed before </%s> on line %d", e.name, name, line)})
+		}
+	}
+	*open = elements[:i]
+	return issues
+}
+
+// parseAttributes returns the unescaped values of the attributes by lower case name
+func parseAttributes(attrs string) map[string]string {
+	values := make(map[string]string)
+	for _, m := range htmlAttrRe.FindAllStringSubmatch(attrs, -1) {
+		values[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	return values
+}
+
+// checkAsset returns the issue of an asset, or "" when it can be loaded
+func (pf *preflight) checkAsset(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if msg, ok := pf.checked[ref]; ok {
+		return msg
+	}
+	msg := pf.assetIssue(ref)
+	pf.checked[ref] = msg
+	return msg
+}
+
+func (pf *preflight) assetIssue(ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return fmt.Sprintf("%q is not a valid URL", ref)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme == "" && u.Host != "" {
+		// a protocol relative URL such as //cdn.example.com/app.css, wkhtmltopdf loads it with http from a local page
+		scheme = "http"
+		u.Scheme = scheme
+		ref = u.String()
+	}
+	switch scheme {
+	case "http", "https":
+		if pf.options.Client == nil {
+			return ""
+		}
+		return pf.checkRemote(ref)
+	case "file":
+		path := filepath.FromSlash(u.Path)
+		if pf.blocked(path) {
+			return fmt.Sprintf("%s is blocked by --disable-local-file-access, add its directory to Allow", ref)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Sprintf("%s does not exist", ref)
+		}
+	case "":
+		if u.Path == "" {
+			return ""
+		}
+		path := filepath.FromSlash(u.Path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(pf.dir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Sprintf("%s does not exist at %s", ref, path)
+		}
+	}
+	return ""
+}
+
+// blocked returns whether wkhtmltopdf does not load the local file
+func (pf *preflight) blocked(path string) bool {
+	if !pf.blockLocal {
+		return false
+	}
+	for _, dir := range pf.allow {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRemote sends a HEAD request for the asset and returns an issue when it fails
+func (pf *preflight) checkRemote(ref string) string {
+	req, err := http.NewRequest(http.MethodHead, ref, nil)
+	if err != nil {
+		return fmt.Sprintf("%q is not a valid URL", ref)
+	}
+	resp, err := pf.options.Client.Do(req.WithContext(pf.ctx))
+	if err != nil {
+		return fmt.Sprintf("%s is unreachable: %s", ref, err)
+	}
+	resp.Body.Close()
+	// servers which do not allow HEAD requests are not known to fail for GET
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+		return fmt.Sprintf("%s returned %s", ref, resp.Status)
+	}
+	return ""
+}
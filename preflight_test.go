@@ -0,0 +1,118 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreflightHTML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "logo.png"), []byte("png"), 0666)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app.css" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	html := `<!DOCTYPE html>
+<html><head><title>a < b</title>
+<link rel="stylesheet" href="` + srv.URL + `/app.css"><link rel="stylesheet" href="` + srv.URL + `/missing.css">
+<script>if (a < b) { document.write("<div>") }</script>
+</head>
+<div class="x"><img src="logo.png"><img src="images/chart.png">
+<ul><li>One<li>Two</ul>
+<span><b>bold</span>
+<p>text</div></section>
+<!-- <div> -->
+<table><tr><td>1</table>
+<div>`
+	issues := PreflightHTML(context.Background(), []byte(html), dir, PreflightOptions{Client: srv.Client()})
+	var have []string
+	for _, issue := range issues {
+		have = append(have, issue.String())
+	}
+	want := []string{
+		"page 0 line 3: " + srv.URL + "/missing.css returned 404 Not Found",
+		"page 0 line 6: images/chart.png does not exist at " + filepath.Join(dir, "images/chart.png"),
+		"page 0 line 8: <b> is not closed before </span> on line 8",
+		"page 0 line 9: </section> has no start tag",
+		"page 0 line 12: <div> is not closed",
+		"page 0: the document has no <body> element",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("Want issues\n%s\nhave\n%s", strings.Join(want, "\n"), strings.Join(have, "\n"))
+	}
+}
+
+func TestPreflightSelfClosingAndProtocolRelative(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	html := `<html><body>
+<link rel="stylesheet" href="//` + host + `/app.css">
+<div/>
+<svg><path d="M0 0"/><circle r="1"/></svg><br/>
+</body></html>`
+	issues := PreflightHTML(context.Background(), []byte(html), "", PreflightOptions{Client: srv.Client()})
+	var have []string
+	for _, issue := range issues {
+		have = append(have, issue.String())
+	}
+	want := []string{
+		"page 0 line 2: http://" + host + "/app.css returned 404 Not Found",
+		"page 0 line 3: <div> is not closed before </body> on line 5",
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("Want issues\n%s\nhave\n%s", strings.Join(want, "\n"), strings.Join(have, "\n"))
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preflight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assets := filepath.Join(dir, "assets")
+	os.Mkdir(assets, 0777)
+	ioutil.WriteFile(filepath.Join(assets, "logo.png"), []byte("png"), 0666)
+	ioutil.WriteFile(filepath.Join(dir, "secret.png"), []byte("png"), 0666)
+	ioutil.WriteFile(filepath.Join(dir, "page.html"), []byte(`<html><body><img src="assets/logo.png"></body></html>`), 0666)
+
+	pdfg := NewPDFPreparer()
+	pdfg.SetWorkDir(dir)
+	pdfg.AddPage(NewPage("https://example.com"))
+	pdfg.AddPage(NewPage("page.html"))
+	html := `<html><body><img src="file://` + filepath.ToSlash(filepath.Join(assets, "logo.png")) + `">` +
+		`<img src="file://` + filepath.ToSlash(filepath.Join(dir, "secret.png")) + `"></body></html>`
+	page := NewPageReader(strings.NewReader(html))
+	page.DisableLocalFileAccess.Set(true)
+	page.Allow.Set(assets)
+	pdfg.AddPage(page)
+
+	issues, err := pdfg.Preflight(context.Background(), PreflightOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Page != 2 || !strings.Contains(issues[0].Message, "secret.png is blocked") {
+		t.Errorf("Want the blocked file of the third page, have %v", issues)
+	}
+	if b, _ := ioutil.ReadAll(page.Input); string(b) != html {
+		t.Error("Want the HTML of the page reader kept")
+	}
+}
@@ -0,0 +1,138 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PDFOptions sets options of PDF documents, used as defaults with SetDefaultPDFOptions or in a Preset.
+// Either function can be nil.
+type PDFOptions struct {
+	Document func(pdfg *PDFGenerator) // Sets global, outline, cover and TOC options, it should not add pages
+	Page     func(po *PageOptions)    // Sets options of each page
+}
+
+// Preset is a named set of options which is registered with RegisterPreset and used by jobs which have its name set
+// in Job.Preset. The options of a preset are used when they are not set in the job itself.
+type Preset struct {
+	PDF   PDFOptions
+	Image ImageOptions // Format, Height, Width, Quality, Quiet, DisablePlugins and SmartWidth are used
+}
+
+var defaults struct {
+	pdf     PDFOptions
+	presets map[string]Preset
+	sync.Mutex
+}
+
+// SetDefaultPDFOptions sets the default options for all PDFGenerators and pages created after this call,
+// for example
+//
+//	SetDefaultPDFOptions(PDFOptions{
+//		Document: func(pdfg *PDFGenerator) { pdfg.PageSize.Set(PageSizeLetter) },
+//		Page:     func(po *PageOptions) { po.DisableJavascript.Set(true) },
+//	})
+//
+// Options set on a PDFGenerator or page after it is created, or which are set in a job, take precedence.
+func SetDefaultPDFOptions(options PDFOptions) {
+	defaults.Lock()
+	defaults.pdf = options
+	defaults.Unlock()
+}
+
+// SetDefaultImageOptions sets the Format, Height, Width, Quality, Quiet, DisablePlugins and SmartWidth used by GenerateImage when they are not set
+func SetDefaultImageOptions(options ImageOptions) {
+	imageDefaults.Lock()
+	imageDefaults.options = options
+	imageDefaults.Unlock()
+}
+
+// RegisterPreset registers a preset with a name, for example "a4-report" or "email-preview", which can be used in jobs.
+// Presets should be registered when the program starts, registering a name again replaces the preset.
+func RegisterPreset(name string, preset Preset) {
+	defaults.Lock()
+	defer defaults.Unlock()
+	if defaults.presets == nil {
+		defaults.presets = make(map[string]Preset)
+	}
+	defaults.presets[name] = preset
+}
+
+// lookupPreset returns the preset with name, or nil if name is empty
+func lookupPreset(name string) (*Preset, error) {
+	if name == "" {
+		return nil, nil
+	}
+	defaults.Lock()
+	defer defaults.Unlock()
+	preset, ok := defaults.presets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %q", name)
+	}
+	return &preset, nil
+}
+
+// setPDFDefaults sets the default document options on pdfg
+func setPDFDefaults(pdfg *PDFGenerator) {
+	defaults.Lock()
+	set := defaults.pdf.Document
+	defaults.Unlock()
+	if set != nil {
+		set(pdfg)
+	}
+}
+
+// setPageDefaults sets the default page options on po
+func setPageDefaults(po *PageOptions) {
+	defaults.Lock()
+	set := defaults.pdf.Page
+	defaults.Unlock()
+	if set != nil {
+		set(po)
+	}
+}
+
+// setDocument sets the document options of the preset on pdfg, p can be nil
+func (p *Preset) setDocument(pdfg *PDFGenerator) {
+	if p != nil && p.PDF.Document != nil {
+		p.PDF.Document(pdfg)
+	}
+}
+
+// newPageOptions returns NewPageOptions with the page options of the preset set, p can be nil
+func (p *Preset) newPageOptions() PageOptions {
+	po := NewPageOptions()
+	if p != nil && p.PDF.Page != nil {
+		p.PDF.Page(&po)
+	}
+	return po
+}
+
+// mergeImageOptions sets the Format, Height, Width, Quality, Quiet, DisablePlugins and SmartWidth which are not set in options from defaults.
+// The format of the extension of Output is used before the default format
+func mergeImageOptions(options *ImageOptions, defaults ImageOptions) {
+	if options.Format == "" {
+		options.Format = outputFormat(options.Output)
+	}
+	if options.Format == "" {
+		options.Format = defaults.Format
+	}
+	if options.Height == 0 {
+		options.Height = defaults.Height
+	}
+	if options.Width == 0 {
+		options.Width = defaults.Width
+	}
+	if options.Quality == 0 {
+		options.Quality = defaults.Quality
+	}
+	if options.Quiet == nil {
+		options.Quiet = cloneBool(defaults.Quiet)
+	}
+	if options.DisablePlugins == nil {
+		options.DisablePlugins = cloneBool(defaults.DisablePlugins)
+	}
+	if options.SmartWidth == nil {
+		options.SmartWidth = cloneBool(defaults.SmartWidth)
+	}
+}
@@ -0,0 +1,109 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSetDefaultPDFOptions(t *testing.T) {
+	SetDefaultPDFOptions(PDFOptions{
+		Document: func(pdfg *PDFGenerator) { pdfg.PageSize.Set(PageSizeLetter) },
+		Page:     func(po *PageOptions) { po.DisableJavascript.Set(true) },
+	})
+	defer SetDefaultPDFOptions(PDFOptions{})
+
+	pdfg := NewPDFPreparer()
+	pdfg.Dpi.Set(300)
+	pdfg.AddPage(NewPage("https://www.google.com"))
+
+	want := "--dpi 300 --page-size Letter page https://www.google.com --disable-javascript -"
+	if have := pdfg.ArgString(); have != want {
+		t.Errorf("Want args %q, have %q", want, have)
+	}
+
+	// the options in the JSON are set over the defaults
+	pdfg.PageSize.Set(PageSizeA4)
+	jb, err := pdfg.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewPDFGeneratorFromJSON(bytes.NewReader(jb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "--dpi 300 --page-size A4 page https://www.google.com --disable-javascript -"
+	if have := restored.ArgString(); have != want {
+		t.Errorf("Want args %q, have %q", want, have)
+	}
+}
+
+func TestSetDefaultImageOptions(t *testing.T) {
+	SetDefaultImageOptions(ImageOptions{Format: "jpg", Quality: 60})
+	defer SetDefaultImageOptions(ImageOptions{})
+
+	options := &ImageOptions{Quality: 90}
+	setImageDefaults(options)
+	if options.Format != "jpg" || options.Quality != 90 {
+		t.Errorf("Want format jpg and quality 90, have %s and %d", options.Format, options.Quality)
+	}
+}
+
+func TestPreset(t *testing.T) {
+	RegisterPreset("a4-report", Preset{
+		PDF: PDFOptions{
+			Document: func(pdfg *PDFGenerator) {
+				pdfg.PageSize.Set(PageSizeA4)
+				pdfg.MarginTop.Set(20)
+			},
+			Page: func(po *PageOptions) { po.FooterRight.Set("[page]") },
+		},
+	})
+	RegisterPreset("email-preview", Preset{Image: ImageOptions{Format: "png", Width: 600}})
+	defer func() { defaults.presets = nil }()
+
+	pdfg := NewPDFPreparer()
+	pdfg.MarginTop.Set(5)
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Report</html>")))
+	jb, err := (&Job{PDF: pdfg, Preset: "a4-report"}).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(jb), `"preset":"a4-report"`) {
+		t.Errorf("Want preset in JSON, have %s", jb)
+	}
+
+	pb, err := (&Job{PDF: pdfg, Preset: "a4-report"}).ToProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "--margin-top 5 --page-size A4 page - --footer-right [page] -"
+	job, err := JobFromJSON(bytes.NewReader(jb), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := job.PDF.ArgString(); have != want {
+		t.Errorf("Want args %q, have %q", want, have)
+	}
+	job, err = JobFromProto(bytes.NewReader(pb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := job.PDF.ArgString(); have != want {
+		t.Errorf("Want args %q from protobuf, have %q", want, have)
+	}
+
+	job, err = JobFromJSON(strings.NewReader(`{"version":2,"type":"image","preset":"email-preview","image":{"Input":"-","Width":800}}`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Image.Format != "png" || job.Image.Width != 800 {
+		t.Errorf("Want format png and width 800, have %s and %d", job.Image.Format, job.Image.Width)
+	}
+
+	_, err = JobFromJSON(strings.NewReader(`{"version":2,"type":"image","preset":"nope","image":{"Input":"-"}}`), false)
+	if err == nil || err.Error() != `unknown preset "nope"` {
+		t.Errorf("Want unknown preset error, have %v", err)
+	}
+}
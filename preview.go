@@ -0,0 +1,183 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PreviewHandler is an http.Handler which renders the HTML files in Dir when they are opened in a browser,
+// and reloads the page when a file in Dir changes, for working on templates of emails and invoices.
+// Opening a directory lists its HTML files, the format=pdf parameter shows a PDF instead of a png,
+// for example http://localhost:8080/invoice.html?format=pdf. Use PreviewHandler only during development,
+// it renders any HTML file in Dir for anyone who can connect.
+type PreviewHandler struct {
+	Dir   string
+	Image ImageOptions // Options of the images, Input, Output and Format are set for each file
+}
+
+// ServePreview serves a PreviewHandler for the HTML files in dir on addr, for example
+//
+//	err := wkhtmltopdf.ServePreview("localhost:8080", "templates")
+func ServePreview(addr, dir string) error {
+	return http.ListenAndServe(addr, &PreviewHandler{Dir: dir})
+}
+
+// previewFormats are the formats the PreviewHandler can show with their content types
+var previewFormats = map[string]string{
+	"png": "image/png",
+	"jpg": "image/jpeg",
+	"svg": "image/svg+xml",
+	"pdf": "application/pdf",
+}
+
+func (p *PreviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + r.URL.Path)
+	file := filepath.Join(p.Dir, filepath.FromSlash(name))
+	info, err := os.Stat(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	if _, ok := query["version"]; ok {
+		version, err := dirVersion(p.Dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, version)
+		return
+	}
+	if info.IsDir() {
+		p.list(w, name, file)
+		return
+	}
+	if ext := strings.ToLower(filepath.Ext(file)); ext != ".html" && ext != ".htm" {
+		http.NotFound(w, r)
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = "png"
+	}
+	contentType, ok := previewFormats[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+	if _, ok := query["render"]; !ok {
+		previewPage.Execute(w, map[string]string{"Name": name, "Format": format})
+		return
+	}
+
+	output, err := p.render(r, file, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(output)
+}
+
+// render creates the PDF or image of file
+func (p *PreviewHandler) render(r *http.Request, file, format string) ([]byte, error) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	if format == "pdf" {
+		pdfg, err := NewPDFGenerator()
+		if err != nil {
+			return nil, err
+		}
+		pdfg.AddPage(NewPage(file))
+		err = pdfg.CreateContext(r.Context())
+		if err != nil {
+			return nil, err
+		}
+		return pdfg.Bytes(), nil
+	}
+	options := p.Image.Clone()
+	options.Input = file
+	options.Output = ""
+	options.Format = format
+	return GenerateImageContext(r.Context(), &options)
+}
+
+// list writes the HTML files and subdirectories of dir
+func (p *PreviewHandler) list(w http.ResponseWriter, name, dir string) {
+	f, err := os.Open(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	infos, err := f.Readdir(-1)
+	f.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var files []string
+	for _, info := range infos {
+		ext := strings.ToLower(filepath.Ext(info.Name()))
+		switch {
+		case info.IsDir():
+			files = append(files, info.Name()+"/")
+		case ext == ".html" || ext == ".htm":
+			files = append(files, info.Name())
+		}
+	}
+	sort.Strings(files)
+	previewList.Execute(w, map[string]interface{}{"Name": name, "Path": strings.TrimSuffix(name, "/") + "/", "Files": files})
+}
+
+// dirVersion returns a hash of the state of the files in dir, which changes when a file is added, changed or removed
+func dirVersion(dir string) (string, error) {
+	files, err := snapshotDir(dir)
+	if err != nil {
+		return "", err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	h := fnv.New64a()
+	for _, path := range paths {
+		fmt.Fprintf(h, "%s %d %d\n", path, files[path].modTime.UnixNano(), files[path].size)
+	}
+	return fmt.Sprintf("%x", h.Sum64()), nil
+}
+
+var previewList = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Name}}</title></head>
+<body><h1>{{.Name}}</h1><ul>{{range .Files}}<li><a href="{{$.Path}}{{.}}">{{.}}</a></li>{{end}}</ul></body></html>
+`))
+
+// previewPage shows the render of a file and reloads it when the version of the directory changes
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Name}}</title>
+<style>body { margin: 0; background: #888; } img { display: block; margin: 16px auto; background: #fff; } iframe { border: 0; width: 100vw; height: 100vh; }</style>
+</head><body>
+{{if eq .Format "pdf"}}<iframe src="{{.Name}}?render&amp;format=pdf"></iframe>{{else}}<img src="{{.Name}}?render&amp;format={{.Format}}">{{end}}
+<script>
+var version = null;
+setInterval(function() {
+	fetch("{{.Name}}?version").then(function(r) { return r.text(); }).then(function(v) {
+		if (version !== null && v !== version) { location.reload(); }
+		version = v;
+	});
+}, 1000);
+</script>
+</body></html>
+`))
@@ -0,0 +1,77 @@
+package wkhtmltopdf
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviewHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-preview")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "invoice.html"), []byte("<svg>invoice</svg>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "style.css"), []byte("body {}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// the binary prints its input file
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/bash\nargs=(\"$@\")\ncat \"${args[-2]}\"\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	srv := httptest.NewServer(&PreviewHandler{Dir: dir, Image: ImageOptions{BinaryPath: bin.Name()}})
+	defer srv.Close()
+	get := func(path string) (int, string, string) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+
+	_, _, body := get("/")
+	if !strings.Contains(body, `href="/invoice.html"`) || strings.Contains(body, "style.css") {
+		t.Errorf("Want list with invoice.html only, have %s", body)
+	}
+	_, _, body = get("/invoice.html?format=svg")
+	if !strings.Contains(body, `<img src="/invoice.html?render&amp;format=svg">`) {
+		t.Errorf("Want preview page with image, have %s", body)
+	}
+	_, contentType, body := get("/invoice.html?render&format=svg")
+	if body != "<svg>invoice</svg>" || contentType != "image/svg+xml" {
+		t.Errorf("Want svg render, have %s %s", contentType, body)
+	}
+
+	_, _, version := get("/?version")
+	if err := ioutil.WriteFile(filepath.Join(dir, "style.css"), []byte("body { color: red; }"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, changed := get("/?version"); changed == version {
+		t.Error("Want version to change after a file changed")
+	}
+
+	if status, _, _ := get("/invoice.html?format=gif"); status != http.StatusBadRequest {
+		t.Errorf("Want status 400 for an unknown format, have %d", status)
+	}
+	if status, _, _ := get("/../etc/passwd"); status != http.StatusNotFound {
+		t.Errorf("Want status 404 outside the directory, have %d", status)
+	}
+	if status, _, _ := get("/style.css"); status != http.StatusNotFound {
+		t.Errorf("Want status 404 for a stylesheet, have %d", status)
+	}
+}
@@ -0,0 +1,60 @@
+package wkhtmltopdf
+
+import "fmt"
+
+// SetCopies sets the number of copies of the document in the PDF. Collated copies follow each other (1 2 3 1 2 3),
+// otherwise each page is repeated (1 1 2 2 3 3), for print shops which cut stacks of pages
+func (pdfg *PDFGenerator) SetCopies(copies uint, collate bool) {
+	pdfg.Copies.Set(copies)
+	// collate is the default of wkhtmltopdf, so only --no-collate is written
+	pdfg.NoCollate.Set(!collate)
+}
+
+// SetPageOffset sets the number of the first page in headers, footers and the table of contents, for documents
+// which continue the page numbers of another document. It is set on the cover, the TOC and the pages which were added,
+// call it after AddPage
+func (pdfg *PDFGenerator) SetPageOffset(offset uint) {
+	for _, popt := range pdfg.allPageOptions() {
+		popt.PageOffset.Set(offset)
+	}
+}
+
+// SetDPI sets the resolution of the PDF and the resolution images in it are scaled down to, for example 300 for print.
+// wkhtmltopdf ignores the resolution of the PDF on X11 based systems, the images are always scaled
+func (pdfg *PDFGenerator) SetDPI(dpi uint) {
+	pdfg.Dpi.Set(dpi)
+	pdfg.ImageDpi.Set(dpi)
+}
+
+// SetSmartShrinking sets whether WebKit shrinks the content of the cover, the TOC and the pages which were added to fit
+// the page width. The shrinking depends on the content, so the same template is scaled differently when a table gets wider,
+// disable it to keep the pixel to mm ratio constant and use Zoom to scale. Call it after AddPage
+func (pdfg *PDFGenerator) SetSmartShrinking(enable bool) {
+	for _, popt := range pdfg.allPageOptions() {
+		popt.DisableSmartShrinking.Set(!enable)
+	}
+}
+
+// allPageOptions returns the page options of the cover, the TOC and the pages
+func (pdfg *PDFGenerator) allPageOptions() []*pageOptions {
+	popts := []*pageOptions{&pdfg.Cover.pageOptions, &pdfg.TOC.pageOptions}
+	for _, p := range pdfg.pages {
+		switch p := p.(type) {
+		case *Page:
+			popts = append(popts, &p.pageOptions)
+		case *PageReader:
+			popts = append(popts, &p.pageOptions)
+		}
+	}
+	return popts
+}
+
+// checkPrintOptions returns an error when Copies, Dpi or ImageDpi is set to 0
+func (gopt *globalOptions) checkPrintOptions() error {
+	for _, o := range []*uintOption{&gopt.Copies, &gopt.Dpi, &gopt.ImageDpi} {
+		if o.isSet && o.value == 0 {
+			return fmt.Errorf("invalid value of --%s 0, want at least 1", o.option)
+		}
+	}
+	return nil
+}
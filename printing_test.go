@@ -0,0 +1,87 @@
+package wkhtmltopdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetCopies(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	pdfg.SetCopies(3, false)
+	if err := pdfg.checkArgs(); err != nil {
+		t.Fatal(err)
+	}
+	args := pdfg.ArgString()
+	if !strings.Contains(args, "--copies 3") || !strings.Contains(args, "--no-collate") {
+		t.Errorf("Want 3 copies without collate, have %s", args)
+	}
+	pdfg.SetCopies(2, true)
+	if args := pdfg.ArgString(); !strings.Contains(args, "--copies 2") || strings.Contains(args, "collate") {
+		t.Errorf("Want 2 collated copies, have %s", args)
+	}
+	pdfg.SetCopies(0, true)
+	if err := pdfg.checkArgs(); err == nil || !strings.Contains(err.Error(), "--copies") {
+		t.Errorf("Want error for 0 copies, have %v", err)
+	}
+}
+
+func TestSetPageOffset(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.Cover.Input = "cover.html"
+	pdfg.TOC.Include = true
+	pdfg.AddPage(NewPage("page.html"))
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	pdfg.SetPageOffset(10)
+	if n := strings.Count(pdfg.ArgString(), "--page-offset 10"); n != 4 {
+		t.Errorf("Want page offset on the cover, TOC and 2 pages, have %d in %s", n, pdfg.ArgString())
+	}
+}
+
+func TestProtoRenamedOption(t *testing.T) {
+	ob := &protoBuffer{}
+	ob.stringField(1, "nocollate")
+	global := newGlobalOptions()
+	if err := setProtoOption(ob.b, &global); err != nil {
+		t.Fatal(err)
+	}
+	if !global.NoCollate.value {
+		t.Error("Want NoCollate set from its old name")
+	}
+}
+
+func TestJSONRenamedOption(t *testing.T) {
+	var bo boolOption
+	if err := bo.UnmarshalJSON([]byte(`{"Option":"nocollate","Value":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	if bo.option != "no-collate" || !bo.value {
+		t.Errorf("Want no-collate set from its old name, have %s %v", bo.option, bo.value)
+	}
+}
+
+func TestSetDPI(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	page := NewPage("page.html")
+	pdfg.AddPage(page)
+	pdfg.SetDPI(300)
+	pdfg.SetSmartShrinking(false)
+	if err := pdfg.checkArgs(); err != nil {
+		t.Fatal(err)
+	}
+	args := pdfg.ArgString()
+	if !strings.Contains(args, "--dpi 300") || !strings.Contains(args, "--image-dpi 300") {
+		t.Errorf("Want 300 DPI, have %s", args)
+	}
+	if !strings.Contains(strings.Join(page.Args(), " "), "--disable-smart-shrinking") {
+		t.Errorf("Want smart shrinking disabled, have %s", page.Args())
+	}
+	pdfg.SetSmartShrinking(true)
+	if strings.Contains(pdfg.ArgString(), "--disable-smart-shrinking") {
+		t.Errorf("Want smart shrinking enabled, have %s", pdfg.ArgString())
+	}
+	pdfg.ImageDpi.Set(0)
+	if err := pdfg.checkArgs(); err == nil || !strings.Contains(err.Error(), "--image-dpi") {
+		t.Errorf("Want error for image DPI 0, have %v", err)
+	}
+}
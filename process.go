@@ -0,0 +1,49 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"os/exec"
+	"sync/atomic"
+	"time"
+)
+
+// runCommand runs cmd like cmd.Run, but the process is killed when the Go process dies during the render,
+// so a crash or deploy does not leave orphaned wkhtmltopdf, wkhtmltoimage, qpdf or Ghostscript processes behind.
+// This uses PDEATHSIG on Linux and a job object on Windows, on other systems the process is not killed.
+// On Linux the process is started in the cgroup set with SetCgroup, it is not started or killed when that fails.
+// The process is counted in GetStats and written to the audit log, see SetAuditLog.
+// configure is called with cmd right before it is started when it is not nil.
+func runCommand(ctx context.Context, cmd *exec.Cmd, configure func(*exec.Cmd)) error {
+	killOnParentDeath(cmd)
+	if configure != nil {
+		configure(cmd)
+	}
+	audit := startAudit(ctx, cmd)
+	start := time.Now()
+	joinCgroup, err := startInCgroup(cmd)
+	if err == nil {
+		err = cmd.Start()
+		if jerr := joinCgroup(); err == nil && jerr != nil {
+			err = jerr
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}
+	if err != nil {
+		recordEnd(0, err, false)
+		if audit != nil {
+			audit(err)
+		}
+		return err
+	}
+	recordStart(cmd)
+	atomic.AddInt64(&stats.active, 1)
+	assignProcess(cmd)
+	err = cmd.Wait()
+	atomic.AddInt64(&stats.active, -1)
+	recordEnd(time.Since(start), err, true)
+	if audit != nil {
+		audit(err)
+	}
+	return err
+}
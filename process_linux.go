@@ -0,0 +1,17 @@
+package wkhtmltopdf
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killOnParentDeath makes Linux send SIGKILL to the process when the thread which started it exits
+func killOnParentDeath(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Pdeathsig = syscall.SIGKILL
+}
+
+// assignProcess does nothing on Linux
+func assignProcess(cmd *exec.Cmd) {}
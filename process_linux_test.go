@@ -0,0 +1,49 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestRunCommandKillsOnParentDeath(t *testing.T) {
+	cmd := exec.Command("true")
+	err := runCommand(context.Background(), cmd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.Pdeathsig != syscall.SIGKILL {
+		t.Errorf("Want Pdeathsig SIGKILL, have %+v", cmd.SysProcAttr)
+	}
+}
+
+func TestSetOutputDescriptorSocket(t *testing.T) {
+	bin := outputDescriptorBinary(t)
+	defer os.Remove(bin)
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, w := os.NewFile(uintptr(fds[0]), "r"), os.NewFile(uintptr(fds[1]), "w")
+	defer r.Close()
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin
+	pdfg.AddPage(NewPage("https://example.com"))
+	pdfg.SetOutputDescriptor(w)
+	err = pdfg.Create()
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pdf) != "%PDF-1.4" {
+		t.Errorf("Want %%PDF-1.4, have %q", pdf)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package wkhtmltopdf
+
+import "os/exec"
+
+// killOnParentDeath does nothing on this system
+func killOnParentDeath(cmd *exec.Cmd) {}
+
+// assignProcess does nothing on this system
+func assignProcess(cmd *exec.Cmd) {}
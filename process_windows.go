@@ -0,0 +1,95 @@
+package wkhtmltopdf
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObject          = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+)
+
+const (
+	jobObjectExtendedLimitInformationClass = 9
+	jobObjectLimitKillOnJobClose           = 0x2000
+	processSetQuota                        = 0x0100
+)
+
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// job is a job object which kills its processes when it is closed, the handle is never closed
+// so Windows closes it and kills the processes when the Go process dies
+var job struct {
+	handle syscall.Handle
+	once   sync.Once
+}
+
+// jobHandle returns the job object, or 0 if it can not be created
+func jobHandle() syscall.Handle {
+	job.once.Do(func() {
+		h, _, _ := procCreateJobObject.Call(0, 0)
+		if h == 0 {
+			return
+		}
+		info := jobObjectExtendedLimitInformation{}
+		info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+		ok, _, _ := procSetInformationJobObject.Call(h, jobObjectExtendedLimitInformationClass,
+			uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+		if ok == 0 {
+			syscall.CloseHandle(syscall.Handle(h))
+			return
+		}
+		job.handle = syscall.Handle(h)
+	})
+	return job.handle
+}
+
+// killOnParentDeath does nothing on Windows, the process is added to the job object after it is started
+func killOnParentDeath(cmd *exec.Cmd) {}
+
+// assignProcess adds the started process to the job object
+func assignProcess(cmd *exec.Cmd) {
+	h := jobHandle()
+	if h == 0 {
+		return
+	}
+	p, err := syscall.OpenProcess(syscall.PROCESS_TERMINATE|processSetQuota, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		return
+	}
+	defer syscall.CloseHandle(p)
+	procAssignProcessToJobObject.Call(uintptr(h), uintptr(p))
+}
@@ -0,0 +1,49 @@
+package wkhtmltopdf
+
+import "fmt"
+
+// Profile holds the settings of a tenant of a Worker, which are used for the jobs that have its name set in Job.Profile,
+// so one worker can render the jobs of several tenants with their own binaries, defaults, limits and results.
+// Settings which are not set use the settings of the Worker.
+type Profile struct {
+	Preset            string    // Name of a preset registered with RegisterPreset, used by jobs which do not set a preset
+	WKHTMLToPDFPath   string    // Path to wkhtmltopdf
+	WKHTMLToImagePath string    // Path to wkhtmltoimage, used by image jobs which do not set BinaryPath with a preset
+	Limiter           *Limiter  // Limiter to run the renders through instead of the Limiter of the Worker
+	Publisher         Publisher // Publisher for the results instead of the Publisher of the Worker
+}
+
+// lookupProfile returns the profile with name, or nil if name is empty or profiles is nil
+func lookupProfile(profiles map[string]Profile, name string) (*Profile, error) {
+	if name == "" || profiles == nil {
+		return nil, nil
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+	return &profile, nil
+}
+
+// presetName returns the preset of the profile when name is empty, p can be nil
+func (p *Profile) presetName(name string) string {
+	if name == "" && p != nil {
+		return p.Preset
+	}
+	return name
+}
+
+// pdfPath returns the path to wkhtmltopdf of the profile, p can be nil
+func (p *Profile) pdfPath() string {
+	if p == nil {
+		return ""
+	}
+	return p.WKHTMLToPDFPath
+}
+
+// setImagePath sets the path to wkhtmltoimage of the profile when options has no BinaryPath, p can be nil
+func (p *Profile) setImagePath(options *ImageOptions) {
+	if p != nil && options.BinaryPath == "" {
+		options.BinaryPath = p.WKHTMLToImagePath
+	}
+}
@@ -0,0 +1,734 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"time"
+)
+
+// ToProto creates the protobuf encoding of the job, as defined in job.proto.
+// It is smaller than the JSON from ToJSON because only the options which are set are saved,
+// and options are saved by their command line name so both sides do not need the same version of this package.
+// The Sign function of a PDFGenerator can not be saved and must be set again after JobFromProto.
+func (j *Job) ToProto() ([]byte, error) {
+	buf := &protoBuffer{}
+	buf.uintField(1, JobVersion)
+	buf.stringField(4, j.Preset)
+	buf.stringField(5, j.IdempotencyKey)
+	buf.stringField(6, j.RequestID)
+	for _, name := range j.Transforms {
+		buf.appendStringField(7, name)
+	}
+	buf.stringField(8, j.Profile)
+	switch {
+	case j.PDF != nil && j.Image != nil:
+		return nil, errors.New("job has both PDF and Image set")
+	case j.PDF != nil:
+		pdf, err := pdfToProto(j.PDF)
+		if err != nil {
+			return nil, err
+		}
+		buf.messageField(2, pdf)
+	case j.Image != nil:
+		buf.messageField(3, imageToProto(j.Image))
+	default:
+		return nil, errors.New("job has no PDF or Image set")
+	}
+	return buf.b, nil
+}
+
+// JobFromProto restores a job from the protobuf encoding created with Job.ToProto.
+// Fields which are not in job.proto are ignored, options which are unknown to this version of the package are an error.
+// A PDF job looks for wkhtmltopdf in the same way as NewPDFGenerator.
+func JobFromProto(protoReader io.Reader) (*Job, error) {
+	b, err := ioutil.ReadAll(protoReader)
+	if err != nil {
+		return nil, err
+	}
+
+	var version uint64
+	var pdf, image []byte
+	presetName := ""
+	key := ""
+	requestID := ""
+	var transforms []string
+	profile := ""
+	err = protoFields(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			version = f.v
+		case 2:
+			pdf = f.data
+		case 3:
+			image = f.data
+		case 4:
+			presetName = string(f.data)
+		case 5:
+			key = string(f.data)
+		case 6:
+			requestID = string(f.data)
+		case 7:
+			transforms = append(transforms, string(f.data))
+		case 8:
+			profile = string(f.data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling protobuf: %s", err)
+	}
+	if version != JobVersion {
+		return nil, fmt.Errorf("unsupported job version %d", version)
+	}
+	preset, err := lookupPreset(presetName)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case pdf != nil:
+		pdfg, err := pdfFromProto(pdf, preset)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling protobuf: %s", err)
+		}
+		return &Job{PDF: pdfg, Preset: presetName, IdempotencyKey: key, RequestID: requestID, Transforms: transforms, Profile: profile}, nil
+	case image != nil:
+		options, err := imageFromProto(image)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling protobuf: %s", err)
+		}
+		if preset != nil {
+			mergeImageOptions(options, preset.Image)
+		}
+		return &Job{Image: options, Preset: presetName, IdempotencyKey: key, RequestID: requestID, Transforms: transforms, Profile: profile}, nil
+	}
+	return nil, errors.New("job has no pdf or image set")
+}
+
+func pdfToProto(pdfg *PDFGenerator) ([]byte, error) {
+	buf := &protoBuffer{}
+	protoOptions(buf, 1, &pdfg.globalOptions, &pdfg.outlineOptions)
+	if pdfg.Cover.Input != "" {
+		cover := &protoBuffer{}
+		cover.stringField(1, pdfg.Cover.Input)
+		protoOptions(cover, 3, &pdfg.Cover.pageOptions)
+		buf.messageField(2, cover.b)
+	}
+	buf.boolField(3, pdfg.TOC.Include)
+	protoOptions(buf, 4, &pdfg.TOC.pageOptions, &pdfg.TOC.tocOptions)
+
+	for _, p := range pdfg.pages {
+		pb := &protoBuffer{}
+		pb.stringField(1, p.InputFile())
+		if p.Reader() != nil {
+			data, err := ioutil.ReadAll(p.Reader())
+			if err != nil {
+				return nil, err
+			}
+			pb.bytesField(2, data)
+		}
+		switch tp := p.(type) {
+		case *Page:
+			protoOptions(pb, 3, &tp.PageOptions.pageOptions, &tp.PageOptions.headerAndFooterOptions)
+		case *PageReader:
+			protoOptions(pb, 3, &tp.PageOptions.pageOptions, &tp.PageOptions.headerAndFooterOptions)
+		}
+		buf.messageField(5, pb.b)
+	}
+
+	buf.boolField(6, pdfg.Linearize)
+	if pdfg.PDFA.Convert || pdfg.PDFA.ICCProfile != "" {
+		pdfa := &protoBuffer{}
+		pdfa.boolField(1, pdfg.PDFA.Convert)
+		pdfa.stringField(2, pdfg.PDFA.ICCProfile)
+		buf.messageField(7, pdfa.b)
+	}
+	if wm := pdfg.Watermark; wm != (Watermark{}) {
+		pw := &protoBuffer{}
+		pw.stringField(1, wm.Text)
+		pw.stringField(2, wm.Image)
+		pw.stringField(3, wm.Position)
+		pw.doubleField(4, wm.Opacity)
+		pw.intField(5, int64(wm.Rotation))
+		pw.uintField(6, uint64(wm.FontSize))
+		pw.stringField(7, wm.Color)
+		buf.messageField(8, pw.b)
+	}
+	for _, a := range pdfg.attachments {
+		pa := &protoBuffer{}
+		pa.stringField(1, a.Filename)
+		pa.stringField(2, a.Path)
+		pa.bytesField(3, a.Data)
+		pa.stringField(4, a.MimeType)
+		pa.stringField(5, a.Description)
+		buf.messageField(9, pa.b)
+	}
+	if a := pdfg.Accessibility; a != (AccessibilityOptions{}) {
+		pa := &protoBuffer{}
+		pa.boolField(1, a.Enable)
+		pa.stringField(2, a.Lang)
+		pa.stringField(3, a.Title)
+		buf.messageField(10, pa.b)
+	}
+	buf.stringField(11, pdfg.ColorProfile)
+	return buf.b, nil
+}
+
+func pdfFromProto(b []byte, preset *Preset) (*PDFGenerator, error) {
+	pdfg, err := NewPDFGenerator()
+	if err != nil {
+		return nil, fmt.Errorf("error creating PDF generator: %s", err)
+	}
+	preset.setDocument(pdfg)
+
+	err = protoFields(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			return setProtoOption(f.data, &pdfg.globalOptions, &pdfg.outlineOptions)
+		case 2:
+			return protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					pdfg.Cover.Input = string(f.data)
+				case 3:
+					return setProtoOption(f.data, &pdfg.Cover.pageOptions)
+				}
+				return nil
+			})
+		case 3:
+			pdfg.TOC.Include = f.v != 0
+		case 4:
+			return setProtoOption(f.data, &pdfg.TOC.pageOptions, &pdfg.TOC.tocOptions)
+		case 5:
+			p, err := pageFromProto(f.data, preset)
+			if err != nil {
+				return fmt.Errorf("page %d: %s", len(pdfg.pages), err)
+			}
+			pdfg.AddPage(p)
+		case 6:
+			pdfg.Linearize = f.v != 0
+		case 7:
+			return protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					pdfg.PDFA.Convert = f.v != 0
+				case 2:
+					pdfg.PDFA.ICCProfile = string(f.data)
+				}
+				return nil
+			})
+		case 8:
+			wm := &pdfg.Watermark
+			return protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					wm.Text = string(f.data)
+				case 2:
+					wm.Image = string(f.data)
+				case 3:
+					wm.Position = string(f.data)
+				case 4:
+					wm.Opacity = math.Float64frombits(f.v)
+				case 5:
+					wm.Rotation = int(int32(f.v))
+				case 6:
+					wm.FontSize = uint(f.v)
+				case 7:
+					wm.Color = string(f.data)
+				}
+				return nil
+			})
+		case 9:
+			a := Attachment{}
+			err := protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					a.Filename = string(f.data)
+				case 2:
+					a.Path = string(f.data)
+				case 3:
+					a.Data = f.data
+				case 4:
+					a.MimeType = string(f.data)
+				case 5:
+					a.Description = string(f.data)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			pdfg.AddAttachment(a)
+		case 10:
+			return protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					pdfg.Accessibility.Enable = f.v != 0
+				case 2:
+					pdfg.Accessibility.Lang = string(f.data)
+				case 3:
+					pdfg.Accessibility.Title = string(f.data)
+				}
+				return nil
+			})
+		case 11:
+			pdfg.ColorProfile = string(f.data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pdfg, nil
+}
+
+// pageFromProto returns a PageReader for input "-" and a Page for any other input
+func pageFromProto(b []byte, preset *Preset) (page, error) {
+	input := ""
+	var data []byte
+	options := preset.newPageOptions()
+	err := protoFields(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			input = string(f.data)
+		case 2:
+			data = f.data
+		case 3:
+			return setProtoOption(f.data, &options.pageOptions, &options.headerAndFooterOptions)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if input == "-" {
+		return &PageReader{Input: bytes.NewReader(data), PageOptions: options}, nil
+	}
+	return &Page{Input: input, PageOptions: options}, nil
+}
+
+func imageToProto(options *ImageOptions) []byte {
+	buf := &protoBuffer{}
+	buf.stringField(1, options.BinaryPath)
+	buf.stringField(2, options.Input)
+	buf.stringField(3, options.Format)
+	buf.intField(4, int64(options.Height))
+	buf.intField(5, int64(options.Width))
+	buf.intField(6, int64(options.Quality))
+	buf.stringField(7, options.Html)
+	buf.stringField(8, options.Output)
+	buf.boolField(9, options.DebugJavascript)
+	buf.optionalBoolField(10, options.Quiet)
+	buf.optionalBoolField(11, options.DisablePlugins)
+	buf.boolField(12, options.RetryOnCrash)
+	buf.stringField(13, options.WorkDir)
+	buf.stringField(14, options.StdinStrategy)
+	for _, format := range options.OutputFormats {
+		buf.appendStringField(15, format)
+	}
+	buf.intField(16, int64(options.DPI))
+	if po := options.OptimizePNG; po != nil {
+		pb := &protoBuffer{}
+		pb.intField(1, int64(po.MaxColors))
+		pb.intField(2, int64(po.CompressionLevel))
+		// the message is also written when it is empty, because a nil OptimizePNG disables the optimization
+		buf.messageField(17, pb.b)
+	}
+	buf.boolField(18, options.StripMetadata)
+	buf.boolField(19, options.Hash)
+	buf.uintField(20, options.MinFreeSpace)
+	buf.optionalBoolField(21, options.SmartWidth)
+	if fc := options.FormControls; fc != (FormControls{}) {
+		fb := &protoBuffer{}
+		fb.stringField(1, fc.Checkbox)
+		fb.stringField(2, fc.CheckedCheckbox)
+		fb.stringField(3, fc.Radiobutton)
+		fb.stringField(4, fc.CheckedRadiobutton)
+		buf.messageField(22, fb.b)
+	}
+	buf.optionalBoolField(23, options.StopSlowScripts)
+	buf.intField(24, int64(options.SettleDelay))
+	buf.boolField(25, options.ScrollToBottom)
+	if c := options.Credentials; len(c.Cookies) > 0 || len(c.Headers) > 0 {
+		cb := &protoBuffer{}
+		protoMap(cb, 1, c.Cookies)
+		protoMap(cb, 2, c.Headers)
+		buf.messageField(26, cb.b)
+	}
+	buf.stringField(27, options.StdoutStrategy)
+	if bc := options.BlankCheck; bc != nil {
+		bb := &protoBuffer{}
+		bb.intField(1, int64(bc.MinPixels))
+		bb.boolField(2, bc.Error)
+		// the message is also written when it is empty, because a nil BlankCheck disables the check
+		buf.messageField(28, bb.b)
+	}
+	buf.stringField(29, options.ColorProfile)
+	return buf.b
+}
+
+func imageFromProto(b []byte) (*ImageOptions, error) {
+	options := &ImageOptions{}
+	err := protoFields(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			options.BinaryPath = string(f.data)
+		case 2:
+			options.Input = string(f.data)
+		case 3:
+			options.Format = string(f.data)
+		case 4:
+			options.Height = int(int32(f.v))
+		case 5:
+			options.Width = int(int32(f.v))
+		case 6:
+			options.Quality = int(int32(f.v))
+		case 7:
+			options.Html = string(f.data)
+		case 8:
+			options.Output = string(f.data)
+		case 9:
+			options.DebugJavascript = f.v != 0
+		case 10:
+			quiet := f.v != 0
+			options.Quiet = &quiet
+		case 11:
+			disable := f.v != 0
+			options.DisablePlugins = &disable
+		case 12:
+			options.RetryOnCrash = f.v != 0
+		case 13:
+			options.WorkDir = string(f.data)
+		case 14:
+			options.StdinStrategy = string(f.data)
+		case 15:
+			options.OutputFormats = append(options.OutputFormats, string(f.data))
+		case 16:
+			options.DPI = int(int32(f.v))
+		case 17:
+			po := &PNGOptimization{}
+			err := protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					po.MaxColors = int(int32(f.v))
+				case 2:
+					po.CompressionLevel = png.CompressionLevel(int32(f.v))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			options.OptimizePNG = po
+		case 18:
+			options.StripMetadata = f.v != 0
+		case 19:
+			options.Hash = f.v != 0
+		case 20:
+			options.MinFreeSpace = f.v
+		case 21:
+			smart := f.v != 0
+			options.SmartWidth = &smart
+		case 22:
+			fc := &options.FormControls
+			err := protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					fc.Checkbox = string(f.data)
+				case 2:
+					fc.CheckedCheckbox = string(f.data)
+				case 3:
+					fc.Radiobutton = string(f.data)
+				case 4:
+					fc.CheckedRadiobutton = string(f.data)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		case 23:
+			stop := f.v != 0
+			options.StopSlowScripts = &stop
+		case 24:
+			options.SettleDelay = time.Duration(f.v)
+		case 25:
+			options.ScrollToBottom = f.v != 0
+		case 26:
+			c := &options.Credentials
+			err := protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					return protoMapEntry(f.data, &c.Cookies)
+				case 2:
+					return protoMapEntry(f.data, &c.Headers)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		case 27:
+			options.StdoutStrategy = string(f.data)
+		case 28:
+			bc := &BlankCheck{}
+			err := protoFields(f.data, func(f protoField) error {
+				switch f.num {
+				case 1:
+					bc.MinPixels = int(int32(f.v))
+				case 2:
+					bc.Error = f.v != 0
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			options.BlankCheck = bc
+		case 29:
+			options.ColorProfile = string(f.data)
+		}
+		return nil
+	})
+	return options, err
+}
+
+// protoMap adds an entry message with the key as field 1 and the value as field 2 for each key of m, like a protobuf map
+func protoMap(buf *protoBuffer, field int, m map[string]string) {
+	for _, k := range sortedKeys(m) {
+		eb := &protoBuffer{}
+		eb.stringField(1, k)
+		eb.stringField(2, m[k])
+		buf.messageField(field, eb.b)
+	}
+}
+
+// protoMapEntry sets the key and value of a map entry message in m
+func protoMapEntry(b []byte, m *map[string]string) error {
+	var k, v string
+	err := protoFields(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			k = string(f.data)
+		case 2:
+			v = string(f.data)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if *m == nil {
+		*m = make(map[string]string)
+	}
+	(*m)[k] = v
+	return nil
+}
+
+// protoOptions adds an Option message as field for each option which is set in the option structs
+func protoOptions(buf *protoBuffer, field int, opts ...interface{}) {
+	for _, o := range opts {
+		rv := reflect.ValueOf(o).Elem()
+		for i := 0; i < rv.NumField(); i++ {
+			name, values, ok := optionValues(rv.Field(i).Addr().Interface())
+			if !ok {
+				continue
+			}
+			ob := &protoBuffer{}
+			ob.stringField(1, name)
+			for _, v := range values {
+				ob.appendStringField(2, v)
+			}
+			buf.messageField(field, ob.b)
+		}
+	}
+}
+
+// renamedOptions are the names of options which were written with the wrong name in earlier versions,
+// they are renamed when reading jobs from protobuf or JSON
+var renamedOptions = map[string]string{"nocollate": "no-collate"}
+
+// setProtoOption sets the option from an Option message in the option struct which has an option with the same name
+func setProtoOption(b []byte, opts ...interface{}) error {
+	name := ""
+	var values []string
+	err := protoFields(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			name = string(f.data)
+		case 2:
+			values = append(values, string(f.data))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n, ok := renamedOptions[name]; ok {
+		name = n
+	}
+
+	for _, o := range opts {
+		rv := reflect.ValueOf(o).Elem()
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Field(i).Addr().Interface()
+			if n, _, _ := optionValues(field); n != name {
+				continue
+			}
+			err = setOptionValues(field, values)
+			if err != nil {
+				return fmt.Errorf("option %s: %s", name, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown option %q", name)
+}
+
+// protoBuffer appends fields in the protobuf wire format, fields with the default value are not written like in proto3
+type protoBuffer struct {
+	b []byte
+}
+
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+func (pb *protoBuffer) tag(field, wireType int) {
+	pb.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (pb *protoBuffer) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	pb.b = append(pb.b, b[:n]...)
+}
+
+func (pb *protoBuffer) uintField(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	pb.tag(field, protoVarint)
+	pb.varint(v)
+}
+
+func (pb *protoBuffer) intField(field int, v int64) {
+	pb.uintField(field, uint64(v))
+}
+
+func (pb *protoBuffer) boolField(field int, v bool) {
+	if v {
+		pb.uintField(field, 1)
+	}
+}
+
+// optionalBoolField writes the field when b is not nil, also when it is false
+func (pb *protoBuffer) optionalBoolField(field int, b *bool) {
+	if b == nil {
+		return
+	}
+	pb.tag(field, protoVarint)
+	if *b {
+		pb.varint(1)
+	} else {
+		pb.varint(0)
+	}
+}
+
+func (pb *protoBuffer) doubleField(field int, v float64) {
+	if v == 0 {
+		return
+	}
+	pb.tag(field, protoFixed64)
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	pb.b = append(pb.b, b[:]...)
+}
+
+func (pb *protoBuffer) stringField(field int, s string) {
+	if s != "" {
+		pb.appendStringField(field, s)
+	}
+}
+
+// appendStringField always writes the string, also when it is empty, for repeated fields
+func (pb *protoBuffer) appendStringField(field int, s string) {
+	pb.messageField(field, []byte(s))
+}
+
+func (pb *protoBuffer) bytesField(field int, b []byte) {
+	if len(b) > 0 {
+		pb.messageField(field, b)
+	}
+}
+
+// message always writes the field, also when it is empty, so its presence can be detected
+func (pb *protoBuffer) messageField(field int, b []byte) {
+	pb.tag(field, protoBytes)
+	pb.varint(uint64(len(b)))
+	pb.b = append(pb.b, b...)
+}
+
+// protoField is a field read from the protobuf wire format,
+// v holds the value of varint and fixed fields and data the content of length delimited fields
+type protoField struct {
+	num  int
+	v    uint64
+	data []byte
+}
+
+// protoFields calls fn for each field in b
+func protoFields(b []byte, fn func(f protoField) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("invalid field key")
+		}
+		b = b[n:]
+		f := protoField{num: int(key >> 3)}
+		switch key & 7 {
+		case protoVarint:
+			f.v, n = binary.Uvarint(b)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", f.num)
+			}
+			b = b[n:]
+		case protoFixed64:
+			if len(b) < 8 {
+				return fmt.Errorf("unexpected end of field %d", f.num)
+			}
+			f.v = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case protoFixed32:
+			if len(b) < 4 {
+				return fmt.Errorf("unexpected end of field %d", f.num)
+			}
+			f.v = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		case protoBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return fmt.Errorf("unexpected end of field %d", f.num)
+			}
+			f.data = b[n : n+int(l)]
+			b = b[n+int(l):]
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", key&7, f.num)
+		}
+		err := fn(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobProto(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.Dpi.Set(600)
+	pdfg.Title.Set("Proto")
+	pdfg.TOC.Include = true
+	pdfg.TOC.TocHeaderText.Set("Contents")
+	pdfg.Cover.Input = "https://www.google.com"
+	pdfg.Cover.Zoom.Set(0.75)
+
+	p := NewPage("https://www.github.com")
+	p.Allow.Set("/tmp")
+	p.Allow.Set("/var")
+	p.CustomHeader.Set("X-Header", "value")
+	p.FooterRight.Set("[page]")
+	pdfg.AddPage(p)
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+
+	pdfg.Linearize = true
+	pdfg.Watermark = Watermark{Text: "DRAFT", Opacity: 0.5, Rotation: -45}
+	pdfg.Accessibility = AccessibilityOptions{Enable: true, Lang: "en", Title: "Proto"}
+	pdfg.ColorProfile = ColorProfileSRGB
+	pdfg.AddAttachment(Attachment{Filename: "data.csv", Data: []byte("a,b")})
+
+	pb, err := (&Job{PDF: pdfg}).ToProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := JobFromProto(bytes.NewReader(pb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.PDF == nil {
+		t.Fatal("Want PDF job")
+	}
+
+	want := "--dpi 600 --title Proto cover https://www.google.com --zoom 0.750 toc --toc-header-text Contents " +
+		"page https://www.github.com --allow /tmp --allow /var --custom-header X-Header **** --footer-right [page] page - -"
+	if have := job.PDF.ArgString(); have != want {
+		t.Errorf("Want args %q, have %q", want, have)
+	}
+	html, err := ioutil.ReadAll(job.PDF.pages[1].Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(html) != "<html>Hi</html>" {
+		t.Errorf("Want page data <html>Hi</html>, have %s", html)
+	}
+	if !job.PDF.Linearize || job.PDF.Watermark != pdfg.Watermark || job.PDF.Accessibility != pdfg.Accessibility || job.PDF.ColorProfile != ColorProfileSRGB {
+		t.Errorf("Want post process options restored, have Linearize %v Watermark %+v Accessibility %+v ColorProfile %q", job.PDF.Linearize, job.PDF.Watermark, job.PDF.Accessibility, job.PDF.ColorProfile)
+	}
+	if len(job.PDF.attachments) != 1 || string(job.PDF.attachments[0].Data) != "a,b" {
+		t.Errorf("Want attachment restored, have %+v", job.PDF.attachments)
+	}
+
+	jb, err := (&Job{PDF: job.PDF}).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pb) >= len(jb) {
+		t.Errorf("Want protobuf smaller than JSON, have %d and %d bytes", len(pb), len(jb))
+	}
+}
+
+func TestJobProtoImage(t *testing.T) {
+	quiet := false
+	options := &ImageOptions{Input: "-", Html: "<html>Hi</html>", Format: "png", Width: 800, Quality: 90, DebugJavascript: true, Quiet: &quiet, OptimizePNG: &PNGOptimization{MaxColors: 64}, MinFreeSpace: 1 << 30, SmartWidth: &quiet, FormControls: FormControls{CheckedCheckbox: "checked.svg"}, StopSlowScripts: &quiet, SettleDelay: time.Second, StdoutStrategy: StdoutTempFile, BlankCheck: &BlankCheck{MinPixels: 100}, ColorProfile: ColorProfileSRGB}
+	pb, err := (&Job{Image: options, IdempotencyKey: "request-1", Transforms: []string{"inline-assets", "webp"}}).ToProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := JobFromProto(bytes.NewReader(pb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Image == nil || !reflect.DeepEqual(job.Image, options) {
+		t.Errorf("Want image options %+v, have %+v", options, job.Image)
+	}
+	if job.IdempotencyKey != "request-1" {
+		t.Errorf("Want idempotency key request-1, have %q", job.IdempotencyKey)
+	}
+	if !reflect.DeepEqual(job.Transforms, []string{"inline-assets", "webp"}) {
+		t.Errorf("Want transforms inline-assets and webp, have %q", job.Transforms)
+	}
+}
+
+func TestJobProtoErrors(t *testing.T) {
+	_, err := (&Job{}).ToProto()
+	if err == nil {
+		t.Error("Want error for job without PDF or Image")
+	}
+
+	// version 3 and an unknown field 15
+	_, err = JobFromProto(bytes.NewReader([]byte{0x08, 0x03, 0x78, 0x01}))
+	if err == nil || err.Error() != "unsupported job version 3" {
+		t.Errorf("Want unsupported job version error, have %v", err)
+	}
+
+	// a pdf job with the unknown option --no-such-option
+	b := &protoBuffer{}
+	b.uintField(1, JobVersion)
+	o := &protoBuffer{}
+	o.stringField(1, "no-such-option")
+	pdf := &protoBuffer{}
+	pdf.messageField(1, o.b)
+	b.messageField(2, pdf.b)
+	_, err = JobFromProto(bytes.NewReader(b.b))
+	if err == nil || !strings.Contains(err.Error(), `unknown option "no-such-option"`) {
+		t.Errorf("Want unknown option error, have %v", err)
+	}
+
+	_, err = JobFromProto(bytes.NewReader([]byte{0x08, 0x02, 0x12, 0x05}))
+	if err == nil {
+		t.Error("Want error for truncated message")
+	}
+}
@@ -0,0 +1,107 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// redacted replaces the values of secrets in Args, errors, the error output and the audit log
+const redacted = "****"
+
+// secretOptions are the options which have a secret value
+var secretOptions = map[string]bool{
+	"--password":         true,
+	"--ssl-key-password": true,
+}
+
+// secretPairOptions are the options with a name and a secret value
+var secretPairOptions = map[string]bool{
+	"--cookie":        true,
+	"--custom-header": true,
+}
+
+// secretValues returns the values in args which are secret, which are the values of cookies, passwords
+// and custom headers. All custom headers are secret, the name of a header does not tell whether it has a token
+func secretValues(args []string) []string {
+	var secrets []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case secretPairOptions[args[i]] && i+2 < len(args):
+			secrets = append(secrets, args[i+2])
+			i += 2
+		case secretOptions[args[i]] && i+1 < len(args):
+			secrets = append(secrets, args[i+1])
+			i++
+		}
+	}
+	return secrets
+}
+
+// redactArgs returns a copy of args with the secret values replaced, e.g. "--custom-header Authorization ****"
+func redactArgs(args []string) []string {
+	redactedArgs := make([]string, len(args))
+	copy(redactedArgs, args)
+	for i := 0; i < len(redactedArgs); i++ {
+		switch {
+		case secretPairOptions[redactedArgs[i]] && i+2 < len(redactedArgs):
+			redactedArgs[i+2] = redacted
+			i += 2
+		case secretOptions[redactedArgs[i]] && i+1 < len(redactedArgs):
+			redactedArgs[i+1] = redacted
+			i++
+		}
+	}
+	return redactedArgs
+}
+
+// redactString replaces the secrets in s
+func redactString(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.Replace(s, secret, redacted, -1)
+		}
+	}
+	return s
+}
+
+// secretWriter writes to w with the secrets replaced. The output is written up to the last newline or carriage return,
+// so a secret which is split over several writes is replaced and progress bars are kept, flush writes the rest
+type secretWriter struct {
+	w       io.Writer
+	secrets []string
+	buf     []byte
+	mu      sync.Mutex
+}
+
+// redactWriter returns a secretWriter which writes to w with the secrets replaced, call flush after the process exited
+func redactWriter(w io.Writer, secrets []string) *secretWriter {
+	return &secretWriter{w: w, secrets: secrets}
+}
+
+func (sw *secretWriter) Write(p []byte) (int, error) {
+	if len(sw.secrets) == 0 {
+		return sw.w.Write(p)
+	}
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.buf = append(sw.buf, p...)
+	i := bytes.LastIndexAny(sw.buf, "\r\n")
+	if i < 0 {
+		return len(p), nil
+	}
+	_, err := io.WriteString(sw.w, redactString(string(sw.buf[:i+1]), sw.secrets))
+	sw.buf = append(sw.buf[:0], sw.buf[i+1:]...)
+	return len(p), err
+}
+
+// flush writes the output after the last newline or carriage return
+func (sw *secretWriter) flush() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if len(sw.buf) > 0 {
+		io.WriteString(sw.w, redactString(string(sw.buf), sw.secrets))
+		sw.buf = nil
+	}
+}
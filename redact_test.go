@@ -0,0 +1,76 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactArgs(t *testing.T) {
+	args := []string{"--password", "hunter2", "page", "https://example.com", "--cookie", "session", "abc123",
+		"--custom-header", "Authorization", "Bearer xyz", "--custom-header", "X-Tenant", "acme", "-"}
+	want := []string{"--password", "****", "page", "https://example.com", "--cookie", "session", "****",
+		"--custom-header", "Authorization", "****", "--custom-header", "X-Tenant", "****", "-"}
+	have := redactArgs(args)
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("Want %q, have %q", want, have)
+	}
+	if args[1] != "hunter2" {
+		t.Error("Want args unchanged")
+	}
+	if secrets := secretValues(args); !reflect.DeepEqual(secrets, []string{"hunter2", "abc123", "Bearer xyz", "acme"}) {
+		t.Errorf("Want secrets, have %q", secrets)
+	}
+}
+
+func TestPDFGeneratorRedactsSecrets(t *testing.T) {
+	// the binary writes its arguments to stderr and fails
+	bin, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\necho \"Error: $@\" >&2\nexit 1\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin.Name()
+	page := NewPage("https://example.com")
+	page.CustomHeader.Set("X-Api-Key", "s3cr3t")
+	pdfg.AddPage(page)
+	errOutput := &bytes.Buffer{}
+	pdfg.SetErrorOutput(errOutput)
+
+	if have := pdfg.ArgString(); !strings.Contains(have, "--custom-header X-Api-Key ****") {
+		t.Errorf("Want redacted custom header, have %s", have)
+	}
+	err = pdfg.Create()
+	if err == nil {
+		t.Fatal("Want error")
+	}
+	if strings.Contains(err.Error(), "s3cr3t") || !strings.Contains(err.Error(), "X-Api-Key ****") {
+		t.Errorf("Want redacted error, have %s", err)
+	}
+	if strings.Contains(errOutput.String(), "s3cr3t") || !strings.Contains(errOutput.String(), "X-Api-Key ****") {
+		t.Errorf("Want redacted error output, have %s", errOutput.String())
+	}
+}
+
+func TestRedactWriter(t *testing.T) {
+	var buf bytes.Buffer
+	rw := redactWriter(&buf, []string{"s3cret"})
+	for _, s := range []string{"Loading (10%) s3", "cret\r", "Loading (100%)\nDone s3c", "ret"} {
+		rw.Write([]byte(s))
+	}
+	if want := "Loading (10%) ****\rLoading (100%)\n"; buf.String() != want {
+		t.Errorf("Want %q before flush, have %q", want, buf.String())
+	}
+	rw.flush()
+	if want := "Loading (10%) ****\rLoading (100%)\nDone ****"; buf.String() != want {
+		t.Errorf("Want %q, have %q", want, buf.String())
+	}
+}
@@ -0,0 +1,101 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ConfigReloader loads a config file with LoadConfig and applies it, and loads it again when Reload is called or
+// the process receives SIGHUP, so the paths, image defaults and limits of a render service change without a restart.
+// Renders which are running keep the settings they started with. The concurrency of a Worker is not changed by a reload.
+type ConfigReloader struct {
+	path    string
+	limiter *Limiter
+
+	config *Config
+	mu     sync.Mutex
+}
+
+// NewConfigReloader loads the config file at path and applies it with Config.Apply,
+// and sets the limits of the config on limiter when it is not nil, for example
+//
+//	limiter := new(wkhtmltopdf.Limiter)
+//	reloader, err := wkhtmltopdf.NewConfigReloader("/etc/render.yaml", limiter)
+//	go reloader.ReloadOnSignal(ctx, func(err error) { log.Print(err) })
+func NewConfigReloader(path string, limiter *Limiter) (*ConfigReloader, error) {
+	r := &ConfigReloader{path: path, limiter: limiter}
+	err := r.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config returns the config which was loaded last
+func (r *ConfigReloader) Config() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.config
+}
+
+// Reload loads the config file again and applies it, when the file has an error the current config is kept.
+// Settings which are removed from the file keep the value they had, a limit which is set to 0 is turned off.
+func (r *ConfigReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, set, err := loadConfig(r.path)
+	if err != nil {
+		return err
+	}
+	c.Apply()
+	if r.limiter != nil {
+		c.setLimits(r.limiter, set)
+	}
+	r.config = c
+	return nil
+}
+
+// ReloadOnSignal calls Reload each time the process receives SIGHUP until ctx is done,
+// onError is called with the errors of Reload and can be nil
+func (r *ConfigReloader) ReloadOnSignal(ctx context.Context, onError func(err error)) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			err := r.Reload()
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// setLimits sets the limits of the config whose names are in set on l, which can be in use. Like Apply, limits which are not set
+// keep the value they had, and a limit which is set to 0 is turned off
+func (c *Config) setLimits(l *Limiter, set map[string]bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if set["timeout"] {
+		l.Timeout = time.Duration(c.Timeout)
+	}
+	if set["renders_per_second"] {
+		l.RendersPerSecond = c.RendersPerSecond
+	}
+	if set["max_per_tenant"] {
+		l.MaxPerTenant = c.MaxPerTenant
+	}
+	if set["max_failures"] {
+		l.MaxFailures = c.MaxFailures
+	}
+	if set["break_duration"] {
+		l.BreakDuration = time.Duration(c.BreakDuration)
+	}
+}
@@ -0,0 +1,128 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestConfigReloader(t *testing.T) {
+	path, cleanup := writeTestConfig(t, "render.yaml", "timeout: 30s\nmax_per_tenant: 2\n")
+	defer cleanup()
+
+	limiter := new(Limiter)
+	r, err := NewConfigReloader(path, limiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limiter.Timeout != 30*time.Second || limiter.MaxPerTenant != 2 {
+		t.Errorf("Want timeout 30s and 2 per tenant, have %s and %d", limiter.Timeout, limiter.MaxPerTenant)
+	}
+	limiter.Do("acme", func() error { return nil })
+
+	err = ioutil.WriteFile(path, []byte("timeout: 1m\nmax_per_tenant: 4\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if limiter.Timeout != time.Minute || limiter.MaxPerTenant != 4 {
+		t.Errorf("Want timeout 1m and 4 per tenant, have %s and %d", limiter.Timeout, limiter.MaxPerTenant)
+	}
+	sem, release := limiter.tenant("acme")
+	release()
+	if cap(sem) != 4 {
+		t.Errorf("Want 4 renders for tenant, have %d", cap(sem))
+	}
+
+	// an invalid file keeps the current config
+	err = ioutil.WriteFile(path, []byte("timeout: soon\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Error("Want error for invalid config")
+	}
+	if time.Duration(r.Config().Timeout) != time.Minute || limiter.Timeout != time.Minute {
+		t.Errorf("Want timeout 1m kept, have %s", limiter.Timeout)
+	}
+
+	// limits which are removed from the file keep their value
+	err = ioutil.WriteFile(path, []byte("max_per_tenant: 3\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if limiter.Timeout != time.Minute || limiter.MaxPerTenant != 3 {
+		t.Errorf("Want timeout 1m kept and 3 per tenant, have %s and %d", limiter.Timeout, limiter.MaxPerTenant)
+	}
+
+	// a limit which is set to 0 is turned off
+	err = ioutil.WriteFile(path, []byte("timeout: 0s\nmax_per_tenant: 0\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if limiter.Timeout != 0 || limiter.MaxPerTenant != 0 {
+		t.Errorf("Want no timeout and no tenant limit, have %s and %d", limiter.Timeout, limiter.MaxPerTenant)
+	}
+}
+
+func TestConfigReloaderSignal(t *testing.T) {
+	path, cleanup := writeTestConfig(t, "render.json", `{"max_failures": 3}`)
+	defer cleanup()
+
+	limiter := new(Limiter)
+	r, err := NewConfigReloader(path, limiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the test process would be stopped by a SIGHUP before ReloadOnSignal handles it
+	hup := make(chan os.Signal, 100)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.ReloadOnSignal(ctx, func(err error) { t.Error(err) })
+
+	err = ioutil.WriteFile(path, []byte(`{"max_failures": 5}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := os.FindProcess(os.Getpid())
+	// the signal handler is set up by the goroutine, send the signal until the config is reloaded
+	for i := 0; i < 100 && r.Config().MaxFailures != 5; i++ {
+		if err := p.Signal(syscall.SIGHUP); err != nil {
+			t.Skip("SIGHUP not supported: ", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if r.Config().MaxFailures != 5 {
+		t.Errorf("Want 5 failures after SIGHUP, have %d", r.Config().MaxFailures)
+	}
+	if limiter.MaxFailures != 5 {
+		t.Errorf("Want limiter with 5 failures, have %d", limiter.MaxFailures)
+	}
+
+	// the circuit breaker is turned off with 0 in a JSON file
+	signal.Stop(hup)
+	err = ioutil.WriteFile(path, []byte(`{"max_failures": 0}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if limiter.MaxFailures != 0 {
+		t.Errorf("Want no circuit breaker, have %d failures", limiter.MaxFailures)
+	}
+}
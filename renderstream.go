@@ -0,0 +1,165 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultChunkSize is the size of the output chunks of StreamJob when the chunk size is 0,
+// well below the default message size limit of 4 MB of gRPC
+const DefaultChunkSize = 1 << 20
+
+// RenderStatus is the status of a render, sent by StreamJob after the output
+type RenderStatus struct {
+	ContentType string        // Content type of the output, e.g. application/pdf or image/png
+	Size        int64         // Size of the output in bytes
+	Width       int           // Width of an image in pixels, 0 for PDFs
+	Height      int           // Height of an image in pixels, 0 for PDFs
+	Duration    time.Duration // Time of the render, including transforms and hooks
+	Warnings    []string      // Warnings of the render, see PDFGenerator.Warnings and ImageResult.Warnings
+	Error       string        // Error of the render, no chunks are sent when it is set
+	RequestID   string        // Request ID of the job
+}
+
+// RenderResponse is a message of the output stream of StreamJob, it holds either a chunk of the output or the status.
+// It is the RenderResponse message in job.proto, which the Renderer service streams.
+type RenderResponse struct {
+	Chunk  []byte
+	Status *RenderStatus
+}
+
+// StreamJob renders the job with Job.Render and calls send with the output in chunks of at most chunkSize bytes,
+// followed by one response with the status of the render, so a gRPC service can stream PDFs of hundreds of MB
+// without exceeding its message size limit. A chunkSize of 0 uses DefaultChunkSize.
+// When the render fails only the status is sent and the error of the render is returned, so the service can
+// also set the status code of the call. An error of send is returned without sending more responses.
+func StreamJob(ctx context.Context, job *Job, chunkSize int, send func(*RenderResponse) error) error {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	status := &RenderStatus{RequestID: job.RequestID}
+	ctx = context.WithValue(ctx, renderStatusKey{}, &renderStatusRecorder{status: status})
+	start := time.Now()
+	output, err := job.Render(ctx)
+	status.Duration = time.Since(start)
+	if err != nil {
+		status.Error = err.Error()
+		if serr := send(&RenderResponse{Status: status}); serr != nil {
+			return serr
+		}
+		return err
+	}
+	status.Size = int64(len(output))
+	for len(output) > 0 {
+		n := chunkSize
+		if n > len(output) {
+			n = len(output)
+		}
+		if err := send(&RenderResponse{Chunk: output[:n]}); err != nil {
+			return err
+		}
+		output = output[n:]
+	}
+	return send(&RenderResponse{Status: status})
+}
+
+// renderStatusKey is the context key of the status recorder of StreamJob
+type renderStatusKey struct{}
+
+// renderStatusRecorder holds the status of the render of StreamJob using a context
+type renderStatusRecorder struct {
+	status *RenderStatus
+	sync.Mutex
+}
+
+// recordRenderStatus sets the content type, size of the image and warnings of a render on the status of ctx
+// when it was created by StreamJob
+func recordRenderStatus(ctx context.Context, contentType string, width, height int, warnings []string) {
+	r, ok := ctx.Value(renderStatusKey{}).(*renderStatusRecorder)
+	if !ok {
+		return
+	}
+	r.Lock()
+	r.status.ContentType = contentType
+	r.status.Width = width
+	r.status.Height = height
+	r.status.Warnings = append(r.status.Warnings, warnings...)
+	r.Unlock()
+}
+
+// ToProto creates the protobuf encoding of the response, as defined in job.proto
+func (r *RenderResponse) ToProto() []byte {
+	buf := &protoBuffer{}
+	if r.Status == nil {
+		buf.messageField(1, r.Chunk)
+		return buf.b
+	}
+	status := &protoBuffer{}
+	status.stringField(1, r.Status.ContentType)
+	status.intField(2, r.Status.Size)
+	status.intField(3, int64(r.Status.Width))
+	status.intField(4, int64(r.Status.Height))
+	status.intField(5, int64(r.Status.Duration))
+	for _, w := range r.Status.Warnings {
+		status.appendStringField(6, w)
+	}
+	status.stringField(7, r.Status.Error)
+	status.stringField(8, r.Status.RequestID)
+	buf.messageField(2, status.b)
+	return buf.b
+}
+
+// RenderResponseFromProto restores a response from the protobuf encoding created with RenderResponse.ToProto,
+// for clients of the Renderer service. Fields which are not in job.proto are ignored.
+func RenderResponseFromProto(b []byte) (*RenderResponse, error) {
+	r := &RenderResponse{}
+	var status []byte
+	err := protoFields(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			r.Chunk = f.data
+		case 2:
+			status = f.data
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling protobuf: %s", err)
+	}
+	if status == nil {
+		if r.Chunk == nil {
+			return nil, errors.New("response has no chunk or status set")
+		}
+		return r, nil
+	}
+	r.Chunk = nil
+	r.Status = &RenderStatus{}
+	err = protoFields(status, func(f protoField) error {
+		switch f.num {
+		case 1:
+			r.Status.ContentType = string(f.data)
+		case 2:
+			r.Status.Size = int64(f.v)
+		case 3:
+			r.Status.Width = int(f.v)
+		case 4:
+			r.Status.Height = int(f.v)
+		case 5:
+			r.Status.Duration = time.Duration(f.v)
+		case 6:
+			r.Status.Warnings = append(r.Status.Warnings, string(f.data))
+		case 7:
+			r.Status.Error = string(f.data)
+		case 8:
+			r.Status.RequestID = string(f.data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling protobuf: %s", err)
+	}
+	return r, nil
+}
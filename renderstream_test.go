@@ -0,0 +1,80 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestStreamJob(t *testing.T) {
+	f, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("#!/bin/sh\nprintf '<svg></svg>'\n")
+	f.Close()
+	os.Chmod(f.Name(), 0700)
+
+	job := &Job{Image: &ImageOptions{BinaryPath: f.Name(), Input: "http://example.com", Format: "svg"}, RequestID: "req-1"}
+	var responses []*RenderResponse
+	err = StreamJob(context.Background(), job, 4, func(r *RenderResponse) error {
+		// the responses are sent as protobuf by a gRPC service
+		r, err := RenderResponseFromProto(r.ToProto())
+		if err != nil {
+			return err
+		}
+		responses = append(responses, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 4 {
+		t.Fatalf("Want 3 chunks and the status, have %d responses", len(responses))
+	}
+	var output bytes.Buffer
+	for _, r := range responses[:3] {
+		if len(r.Chunk) > 4 || r.Status != nil {
+			t.Errorf("Want chunk of at most 4 bytes, have %+v", r)
+		}
+		output.Write(r.Chunk)
+	}
+	if output.String() != "<svg></svg>" {
+		t.Errorf("Want <svg></svg>, have %s", output.String())
+	}
+	status := responses[3].Status
+	if status == nil {
+		t.Fatal("Want status as last response")
+	}
+	if status.ContentType != "image/svg+xml" || status.Size != 11 || status.RequestID != "req-1" || status.Duration <= 0 {
+		t.Errorf("Want status of the svg, have %+v", status)
+	}
+
+	responses = nil
+	job.Image.BinaryPath = "/bin/false"
+	err = StreamJob(context.Background(), job, 4, func(r *RenderResponse) error {
+		responses = append(responses, r)
+		return nil
+	})
+	if err == nil || len(responses) != 1 || responses[0].Status == nil || responses[0].Status.Error != err.Error() {
+		t.Errorf("Want only the status with the error %v, have %+v", err, responses)
+	}
+}
+
+func TestRenderResponseProto(t *testing.T) {
+	r := &RenderResponse{Status: &RenderStatus{ContentType: "application/pdf", Size: 1 << 30, Warnings: []string{"a", ""}, Error: "failed"}}
+	have, err := RenderResponseFromProto(r.ToProto())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(have, r) {
+		t.Errorf("Want %+v, have %+v", r.Status, have.Status)
+	}
+	if _, err := RenderResponseFromProto(nil); err == nil {
+		t.Error("Want error for an empty response")
+	}
+}
@@ -0,0 +1,168 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Variables which wkhtmltopdf replaces in the text and HTML of headers and footers, for example
+//
+//	page.FooterRight.Set("Page " + wkhtmltopdf.VarPage + " of " + wkhtmltopdf.VarToPage)
+const (
+	VarPage       = "[page]"       // Number of the page
+	VarFromPage   = "[frompage]"   // Number of the first page
+	VarToPage     = "[topage]"     // Number of the last page
+	VarWebPage    = "[webpage]"    // URL of the page
+	VarSection    = "[section]"    // Name of the current section
+	VarSubsection = "[subsection]" // Name of the current subsection
+	VarDate       = "[date]"       // Current date in the format of the system
+	VarIsoDate    = "[isodate]"    // Current date in ISO 8601 format
+	VarTime       = "[time]"       // Current time in the format of the system
+	VarTitle      = "[title]"      // Title of the page
+	VarDocTitle   = "[doctitle]"   // Title of the document
+	VarSitePage   = "[sitepage]"   // Number of the page within the input
+	VarSitePages  = "[sitepages]"  // Number of pages of the input
+)
+
+// builtinVars are the names of the variables of wkhtmltopdf, which can not be replaced
+var builtinVars = map[string]bool{
+	"page": true, "frompage": true, "topage": true, "webpage": true, "section": true, "subsection": true,
+	"date": true, "isodate": true, "time": true, "title": true, "doctitle": true, "sitepage": true, "sitepages": true,
+}
+
+// timeType and timePtrType are the types of time fields, which are variables when they are embedded
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	timePtrType = reflect.TypeOf(&time.Time{})
+)
+
+// DefaultReplaceTimeLayout is the layout of time.Time fields in ReplaceVars which do not set a layout
+const DefaultReplaceTimeLayout = "2006-01-02"
+
+// ReplaceVars returns the header and footer variables of the exported fields of the struct v, or v points to,
+// for the Replace option. The name of a variable is set with the tag replace:"name" and is the field name in lower case
+// otherwise, a field with the tag replace:"-" is skipped. The fields of embedded structs are included.
+// Strings, booleans, numbers, time.Time and fmt.Stringer are supported, nil pointers are empty. Times are formatted
+// with the layout after the name in the tag, e.g. replace:"due,02 Jan 2006", or DefaultReplaceTimeLayout.
+// Line breaks and other control characters in values are replaced with spaces, as wkhtmltopdf does not support them.
+// An error is returned for unsupported fields and for names which are not letters, digits, - and _
+// or which are variables of wkhtmltopdf such as page.
+func ReplaceVars(v interface{}) (map[string]string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("replace variables need a struct, not %T", v)
+	}
+	vars := make(map[string]string)
+	if err := replaceVars(rv, vars); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// replaceVars adds the variables of the fields of the struct rv to vars
+func replaceVars(rv reflect.Value, vars map[string]string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag := f.Tag.Get("replace")
+		if tag == "-" || f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		fv := rv.Field(i)
+		if f.Anonymous && tag == "" && reflect.Indirect(fv).Kind() == reflect.Struct && f.Type != timeType && f.Type != timePtrType {
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				continue
+			}
+			if err := replaceVars(reflect.Indirect(fv), vars); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name, layout := tag, DefaultReplaceTimeLayout
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, layout = tag[:i], tag[i+1:]
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if err := checkVarName(name); err != nil {
+			return err
+		}
+		value, err := replaceValue(fv, layout)
+		if err != nil {
+			return fmt.Errorf("replace variable %s: %s", name, err)
+		}
+		vars[name] = value
+	}
+	return nil
+}
+
+// checkVarName returns an error if name can not be used as a variable in headers and footers
+func checkVarName(name string) error {
+	if builtinVars[name] {
+		return fmt.Errorf("replace variable %s is a variable of wkhtmltopdf", name)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return fmt.Errorf("replace variable %q has a character which is not a letter, digit, - or _", name)
+		}
+	}
+	return nil
+}
+
+// replaceValue formats the value of a field, times with layout
+func replaceValue(fv reflect.Value, layout string) (string, error) {
+	if fv.Kind() == reflect.Ptr && fv.IsNil() {
+		return "", nil
+	}
+	if t, ok := reflect.Indirect(fv).Interface().(time.Time); ok {
+		return cleanVarValue(t.Format(layout)), nil
+	}
+	if s, ok := fv.Interface().(fmt.Stringer); ok {
+		return cleanVarValue(s.String()), nil
+	}
+	fv = reflect.Indirect(fv)
+	switch fv.Kind() {
+	case reflect.String:
+		return cleanVarValue(fv.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", fv.Type())
+}
+
+// cleanVarValue replaces control characters such as line breaks with spaces
+func cleanVarValue(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// SetReplaceVars sets the Replace option to the variables of the fields of the struct v, see ReplaceVars.
+// Variables which were set before are kept unless v has a variable with the same name.
+func (hopt *headerAndFooterOptions) SetReplaceVars(v interface{}) error {
+	vars, err := ReplaceVars(v)
+	if err != nil {
+		return err
+	}
+	for name, value := range vars {
+		hopt.Replace.Set(name, value)
+	}
+	return nil
+}
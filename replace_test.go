@@ -0,0 +1,78 @@
+package wkhtmltopdf
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type replaceCustomer struct {
+	Customer string
+	internal string
+}
+
+type replaceInvoice struct {
+	replaceCustomer
+	Number   int       `replace:"invoice"`
+	Total    float64   `replace:"total"`
+	Due      time.Time `replace:"due,02 Jan 2006"`
+	Issued   time.Time
+	Paid     bool
+	Notes    *string
+	Link     *url.URL
+	Secret   string `replace:"-"`
+	internal int
+}
+
+func TestReplaceVars(t *testing.T) {
+	link, _ := url.Parse("https://example.com/invoices/42")
+	invoice := replaceInvoice{
+		replaceCustomer: replaceCustomer{Customer: "Acme\nCo"},
+		Number:          42,
+		Total:           99.5,
+		Due:             time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Issued:          time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		Link:            link,
+		Secret:          "secret",
+	}
+	got, err := ReplaceVars(&invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"customer": "Acme Co",
+		"invoice":  "42",
+		"total":    "99.5",
+		"due":      "01 Mar 2026",
+		"issued":   "2026-02-01",
+		"paid":     "false",
+		"notes":    "",
+		"link":     "https://example.com/invoices/42",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReplaceVars = %v, want %v", got, want)
+	}
+
+	page := NewPage("https://example.com")
+	page.Replace.Set("company", "Acme")
+	if err := page.SetReplaceVars(invoice); err != nil {
+		t.Fatal(err)
+	}
+	if page.Replace.value["company"] != "Acme" || page.Replace.value["invoice"] != "42" {
+		t.Errorf("Replace = %v, want company and invoice", page.Replace.value)
+	}
+
+	for _, v := range []interface{}{
+		"not a struct",
+		struct{ Page int }{1},
+		struct {
+			Name string `replace:"first name"`
+		}{"Jane"},
+		struct{ Items []string }{},
+	} {
+		if _, err := ReplaceVars(v); err == nil {
+			t.Errorf("ReplaceVars(%#v) did not return an error", v)
+		}
+	}
+}
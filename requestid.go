@@ -0,0 +1,81 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context with the request ID of a render, for example the ID of the HTTP request or
+// trace which asked for the PDF. The request ID is added to error messages and temporary file names of renders using
+// the context, so a failed render can be traced across services.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID set with WithRequestID, or an empty string.
+// A Worker sets the request ID of each job on the context passed to the Publisher and Limiter, so it can be added to log lines and spans.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns a random request ID for jobs which do not have one
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDError is an error of a render with the request ID of its context
+type requestIDError struct {
+	id  string
+	err error
+}
+
+func (e *requestIDError) Error() string {
+	return "request " + e.id + ": " + e.err.Error()
+}
+
+// Unwrap returns the error of the render, for use with errors.Is and errors.As
+func (e *requestIDError) Unwrap() error {
+	return e.err
+}
+
+// requestError adds the request ID of ctx to err, err can be nil
+func requestError(ctx context.Context, err error) error {
+	id := RequestID(ctx)
+	if err == nil || id == "" {
+		return err
+	}
+	switch e := err.(type) {
+	case *CrashError:
+		e.RequestID = id
+		return e
+	case *KilledError:
+		e.RequestID = id
+		return e
+	}
+	return &requestIDError{id: id, err: err}
+}
+
+// tempPrefix returns the prefix of temporary files and directories, which contains the request ID of ctx when it is set
+func tempPrefix(ctx context.Context, name string) string {
+	id := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, RequestID(ctx))
+	if id == "" {
+		return name
+	}
+	return name + "-" + id + "-"
+}
@@ -0,0 +1,43 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDError(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = "/bin/false"
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+
+	err := pdfg.CreateContext(WithRequestID(context.Background(), "req-42"))
+	if err == nil || !strings.HasPrefix(err.Error(), "request req-42: ") {
+		t.Errorf("Want error with request ID, have %v", err)
+	}
+
+	_, err = (&Job{PDF: pdfg, RequestID: "req-43"}).Render(context.Background())
+	if err == nil || !strings.HasPrefix(err.Error(), "request req-43: ") {
+		t.Errorf("Want error with request ID of job, have %v", err)
+	}
+
+	// the error keeps the error of the render
+	err = requestError(WithRequestID(context.Background(), "req-44"), io.EOF)
+	if u, ok := err.(interface{ Unwrap() error }); !ok || u.Unwrap() != io.EOF {
+		t.Errorf("Want error unwrapping to io.EOF, have %v", err)
+	}
+	if err.Error() != "request req-44: EOF" {
+		t.Errorf("Want request req-44: EOF, have %s", err)
+	}
+}
+
+func TestTempPrefix(t *testing.T) {
+	if p := tempPrefix(context.Background(), "wkhtmltopdf"); p != "wkhtmltopdf" {
+		t.Errorf("Want wkhtmltopdf, have %s", p)
+	}
+	ctx := WithRequestID(context.Background(), "../trace/1 2")
+	if p := tempPrefix(ctx, "wkhtmltopdf"); p != "wkhtmltopdf-.._trace_1_2-" {
+		t.Errorf("Want wkhtmltopdf-.._trace_1_2-, have %s", p)
+	}
+}
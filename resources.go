@@ -0,0 +1,27 @@
+package wkhtmltopdf
+
+import (
+	"os"
+	"time"
+)
+
+// ResourceUsage is the memory and CPU time a wkhtmltopdf or wkhtmltoimage process used, to find templates which use
+// too many resources and to size worker nodes. MaxRSS is 0 on systems where it is not reported, such as Windows.
+type ResourceUsage struct {
+	MaxRSS     uint64        // Maximum resident set size in bytes
+	UserTime   time.Duration // CPU time in user mode
+	SystemTime time.Duration // CPU time in kernel mode
+}
+
+// CPUTime returns the user and system CPU time
+func (u ResourceUsage) CPUTime() time.Duration {
+	return u.UserTime + u.SystemTime
+}
+
+// processUsage returns the resource usage of a process which exited, ps can be nil when the process did not start
+func processUsage(ps *os.ProcessState) ResourceUsage {
+	if ps == nil {
+		return ResourceUsage{}
+	}
+	return ResourceUsage{MaxRSS: maxRSS(ps), UserTime: ps.UserTime(), SystemTime: ps.SystemTime()}
+}
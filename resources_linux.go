@@ -0,0 +1,14 @@
+package wkhtmltopdf
+
+import (
+	"os"
+	"syscall"
+)
+
+// maxRSS returns the maximum resident set size of the process, which Linux reports in kilobytes
+func maxRSS(ps *os.ProcessState) uint64 {
+	if ru, ok := ps.SysUsage().(*syscall.Rusage); ok && ru.Maxrss > 0 {
+		return uint64(ru.Maxrss) * 1024
+	}
+	return 0
+}
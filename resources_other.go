@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package wkhtmltopdf
+
+import "os"
+
+// maxRSS is not reported on this system
+func maxRSS(ps *os.ProcessState) uint64 {
+	return 0
+}
@@ -0,0 +1,38 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestRenderImageResources(t *testing.T) {
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	// the binary uses some CPU time before it prints its input
+	bin.WriteString("#!/bin/sh\ni=0\nwhile [ $i -lt 100000 ]; do i=$((i+1)); done\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	res, err := RenderImage(context.Background(), &ImageOptions{BinaryPath: bin.Name(), Input: "-", Html: "<svg></svg>", Format: "svg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Resources.CPUTime() == 0 {
+		t.Errorf("Want CPU time, have %+v", res.Resources)
+	}
+	if runtime.GOOS == "linux" && res.Resources.MaxRSS < 1024 {
+		t.Errorf("Want max RSS in bytes, have %d", res.Resources.MaxRSS)
+	}
+}
+
+func TestProcessUsageNotStarted(t *testing.T) {
+	if u := processUsage(nil); u != (ResourceUsage{}) {
+		t.Errorf("Want no usage, have %+v", u)
+	}
+}
@@ -0,0 +1,53 @@
+package wkhtmltopdf
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseOptions sets the headers written by WritePDF and WriteImage
+type ResponseOptions struct {
+	Filename   string        // Filename in Content-Disposition, for example "invoice.pdf", no filename when empty
+	Attachment bool          // Download the file instead of showing it in the browser
+	MaxAge     time.Duration // How long the response can be cached, 0 sets Cache-Control to no-store
+	Public     bool          // Allow shared caches to store the response, by default only the browser can
+}
+
+// WritePDF writes the PDF in the internal buffer of pdfg as HTTP response, with Content-Type, Content-Length,
+// Content-Disposition and Cache-Control set
+func WritePDF(w http.ResponseWriter, pdfg *PDFGenerator, options ResponseOptions) error {
+	return writeResponse(w, "application/pdf", pdfg.Bytes(), options)
+}
+
+// WriteImage writes the image of res as HTTP response, with Content-Type, Content-Length, Content-Disposition
+// and Cache-Control set
+func WriteImage(w http.ResponseWriter, res *ImageResult, options ResponseOptions) error {
+	return writeResponse(w, imageContentType(res.format), res.Image, options)
+}
+
+func writeResponse(w http.ResponseWriter, contentType string, body []byte, options ResponseOptions) error {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	disposition := "inline"
+	if options.Attachment {
+		disposition = "attachment"
+	}
+	if options.Filename != "" {
+		disposition = mime.FormatMediaType(disposition, map[string]string{"filename": options.Filename})
+	}
+	h.Set("Content-Disposition", disposition)
+	switch {
+	case options.MaxAge <= 0:
+		h.Set("Cache-Control", "no-store")
+	case options.Public:
+		h.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(options.MaxAge/time.Second)))
+	default:
+		h.Set("Cache-Control", "private, max-age="+strconv.Itoa(int(options.MaxAge/time.Second)))
+	}
+	h.Set("X-Content-Type-Options", "nosniff")
+	_, err := w.Write(body)
+	return err
+}
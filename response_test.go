@@ -0,0 +1,47 @@
+package wkhtmltopdf
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWritePDF(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.outbuf.WriteString("%PDF-1.4")
+	rec := httptest.NewRecorder()
+	if err := WritePDF(rec, pdfg, ResponseOptions{Filename: "invoice.pdf", Attachment: true}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":        "application/pdf",
+		"Content-Length":      "8",
+		"Content-Disposition": "attachment; filename=invoice.pdf",
+		"Cache-Control":       "no-store",
+	} {
+		if have := rec.Header().Get(name); have != want {
+			t.Errorf("Want %s %s, have %s", name, want, have)
+		}
+	}
+	if rec.Body.String() != "%PDF-1.4" {
+		t.Errorf("Want %%PDF-1.4, have %s", rec.Body.String())
+	}
+}
+
+func TestWriteImage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	res := &ImageResult{Image: []byte("jpg"), format: "jpg"}
+	if err := WriteImage(rec, res, ResponseOptions{MaxAge: time.Hour, Public: true}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":        "image/jpeg",
+		"Content-Length":      "3",
+		"Content-Disposition": "inline",
+		"Cache-Control":       "public, max-age=3600",
+	} {
+		if have := rec.Header().Get(name); have != want {
+			t.Errorf("Want %s %s, have %s", name, want, have)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ResultExpirer is implemented by a ResultStore which can delete old results, such as MemoryResultStore,
+// so a Worker with a Retention deletes the results of completed jobs
+type ResultExpirer interface {
+	DeleteBefore(t time.Time)
+}
+
+// OutputDeleter is implemented by an OutputSink which can delete stored outputs, so a Worker with a Retention
+// deletes the outputs it stored
+type OutputDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// retainedOutput is an output which a Worker stored in its Sink
+type retainedOutput struct {
+	key    string
+	stored time.Time
+}
+
+// retainedOutputs holds the outputs a Worker stored in its Sink, in the order they were stored
+type retainedOutputs struct {
+	outputs []retainedOutput
+	sync.Mutex
+}
+
+// retain adds the key of an output which was stored in the Sink, when the Worker has a Retention and the Sink can delete it
+func (w *Worker) retain(key string) {
+	if _, ok := w.Sink.(OutputDeleter); !ok || w.Retention <= 0 {
+		return
+	}
+	w.retained.Lock()
+	w.retained.outputs = append(w.retained.outputs, retainedOutput{key: key, stored: time.Now()})
+	w.retained.Unlock()
+}
+
+// minGarbageInterval is the shortest interval at which collectGarbage deletes results and outputs
+const minGarbageInterval = time.Millisecond
+
+// collectGarbage deletes the results and outputs in the Sink which were stored before the Retention,
+// every tenth of the Retention but at most every minGarbageInterval until ctx is done
+func (w *Worker) collectGarbage(ctx context.Context) {
+	interval := w.Retention / 10
+	if interval < minGarbageInterval {
+		interval = minGarbageInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.deleteBefore(ctx, time.Now().Add(-w.Retention))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deleteBefore deletes the results and outputs in the Sink which were stored before t.
+// Outputs which could not be deleted are tried again the next time.
+func (w *Worker) deleteBefore(ctx context.Context, t time.Time) {
+	if e, ok := w.Results.(ResultExpirer); ok {
+		e.DeleteBefore(t)
+	}
+	deleter, ok := w.Sink.(OutputDeleter)
+	if !ok {
+		return
+	}
+	// the outputs are deleted without holding the lock, only this function removes outputs from the front
+	w.retained.Lock()
+	n := 0
+	for n < len(w.retained.outputs) && w.retained.outputs[n].stored.Before(t) {
+		n++
+	}
+	expired := append([]retainedOutput(nil), w.retained.outputs[:n]...)
+	w.retained.Unlock()
+	// an output which can not be deleted does not keep the outputs after it
+	var failed []retainedOutput
+	for _, o := range expired {
+		if deleter.Delete(ctx, o.key) != nil {
+			failed = append(failed, o)
+		}
+	}
+	w.retained.Lock()
+	w.retained.outputs = append(failed, w.retained.outputs[n:]...)
+	w.retained.Unlock()
+}
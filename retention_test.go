@@ -0,0 +1,109 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryResultStoreDeleteBefore(t *testing.T) {
+	s := NewMemoryResultStore(10)
+	s.Put("a", []byte("1"))
+	s.Put("b", []byte("2"))
+	cutoff := time.Now().Add(time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	s.Put("c", []byte("3"))
+	// a result which is stored again is kept
+	s.Put("a", []byte("4"))
+	s.DeleteBefore(cutoff)
+	if _, ok := s.Get("b"); ok {
+		t.Error("Want b deleted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := s.Get(key); !ok {
+			t.Errorf("Want %s kept", key)
+		}
+	}
+	if len(s.keys) != 2 {
+		t.Errorf("Want 2 keys, have %q", s.keys)
+	}
+}
+
+type testDeletingSink struct {
+	mu      sync.Mutex
+	deleted []string
+	fail    bool
+	failKey string
+}
+
+func (s *testDeletingSink) Put(ctx context.Context, key, contentType string, output []byte) error {
+	return nil
+}
+
+func (s *testDeletingSink) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail || key == s.failKey {
+		return errors.New("not deleted")
+	}
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestWorkerRetention(t *testing.T) {
+	sink := &testDeletingSink{fail: true}
+	results := NewMemoryResultStore(10)
+	w := &Worker{Sink: sink, Results: results, Retention: time.Hour}
+	w.retain("old.pdf")
+	results.Put("order-1", []byte("%PDF"))
+	cutoff := time.Now().Add(time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	w.retain("new.pdf")
+
+	// outputs which could not be deleted are tried again
+	w.deleteBefore(context.Background(), cutoff)
+	if len(w.retained.outputs) != 2 {
+		t.Errorf("Want 2 retained outputs, have %d", len(w.retained.outputs))
+	}
+	if _, ok := results.Get("order-1"); ok {
+		t.Error("Want result deleted")
+	}
+	sink.fail = false
+	w.deleteBefore(context.Background(), cutoff)
+	if len(sink.deleted) != 1 || sink.deleted[0] != "old.pdf" || len(w.retained.outputs) != 1 {
+		t.Errorf("Want old.pdf deleted, have %q", sink.deleted)
+	}
+
+	// an output which can not be deleted does not keep the outputs after it
+	w.retain("a.pdf")
+	w.retain("b.pdf")
+	cutoff = time.Now().Add(time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	sink.failKey = "new.pdf"
+	w.deleteBefore(context.Background(), cutoff)
+	if len(sink.deleted) != 3 || sink.deleted[2] != "b.pdf" || len(w.retained.outputs) != 1 || w.retained.outputs[0].key != "new.pdf" {
+		t.Errorf("Want a.pdf and b.pdf deleted and new.pdf retained, have %q", sink.deleted)
+	}
+	sink.failKey = ""
+	sink.deleted = nil
+
+	// Run deletes the outputs in the background
+	w = &Worker{Consumer: newTestQueue(), Sink: sink, Retention: 20 * time.Millisecond}
+	w.retain("run.pdf")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.deleted) != 1 || sink.deleted[0] != "run.pdf" {
+		t.Errorf("Want run.pdf deleted by Run, have %q", sink.deleted)
+	}
+
+	// a Retention shorter than ten nanoseconds does not make the ticker panic
+	w = &Worker{Consumer: newTestQueue(), Sink: sink, Retention: time.Nanosecond}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+}
@@ -0,0 +1,150 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var flagValues struct {
+	allowed map[string]bool
+	sync.Mutex
+}
+
+// AllowFlagValues allows values which start with "--" for the options, for example "run-script" or "title".
+// Values of options which start with "--" are an error by default, because wkhtmltopdf would read them
+// as another option, so a title set from user input could enable local file access. Inputs and outputs which start
+// with "-" are always an error, except "-" for stdin and stdout. Values with NUL bytes are always an error.
+func AllowFlagValues(options ...string) {
+	flagValues.Lock()
+	defer flagValues.Unlock()
+	if flagValues.allowed == nil {
+		flagValues.allowed = make(map[string]bool)
+	}
+	for _, o := range options {
+		flagValues.allowed[strings.TrimPrefix(o, opt)] = true
+	}
+}
+
+// checkValue returns an error if the value of option contains a NUL byte or starts with "--" and this is not allowed.
+// When option is empty the value is an input, output or format, which is an error when it starts with "-" and is not "-".
+// wkhtmltopdf always reads the word after an option as its value, so option values like "-12.5" are kept
+func checkValue(option, value string) error {
+	name := "value"
+	prefix := "-"
+	if option != "" {
+		name = "value of " + opt + option
+		prefix = opt
+	}
+	if strings.ContainsRune(value, 0) {
+		return fmt.Errorf("%s %q contains a NUL byte", name, value)
+	}
+	if !strings.HasPrefix(value, prefix) || option == "" && value == "-" {
+		return nil
+	}
+	flagValues.Lock()
+	allowed := option != "" && flagValues.allowed[option]
+	flagValues.Unlock()
+	if !allowed {
+		return fmt.Errorf("%s %q starts with %s and would be read as an option", name, value, prefix)
+	}
+	return nil
+}
+
+// checkOptions checks the values of the options in the option structs with checkValue
+func checkOptions(opts ...interface{}) error {
+	for _, o := range opts {
+		rv := reflect.ValueOf(o).Elem()
+		for i := 0; i < rv.NumField(); i++ {
+			name, values, ok := optionValues(rv.Field(i).Addr().Interface())
+			if !ok {
+				continue
+			}
+			for _, v := range values {
+				if err := checkValue(name, v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkArgs checks the values of all options, inputs and the output file of pdfg, the viewport sizes of the pages
+// and the inputs with the InputLimits
+func (pdfg *PDFGenerator) checkArgs() error {
+	opts := []interface{}{&pdfg.globalOptions, &pdfg.outlineOptions}
+	var popts []*pageOptions
+	if pdfg.Cover.Input != "" {
+		opts = append(opts, &pdfg.Cover.pageOptions)
+		popts = append(popts, &pdfg.Cover.pageOptions)
+		if err := checkValue("", pdfg.Cover.Input); err != nil {
+			return fmt.Errorf("invalid cover: %s", err)
+		}
+	}
+	if pdfg.TOC.Include {
+		opts = append(opts, &pdfg.TOC.pageOptions, &pdfg.TOC.tocOptions)
+		popts = append(popts, &pdfg.TOC.pageOptions)
+	}
+	for _, p := range pdfg.pages {
+		if err := checkValue("", p.InputFile()); err != nil {
+			return fmt.Errorf("invalid page: %s", err)
+		}
+		switch p := p.(type) {
+		case *Page:
+			opts = append(opts, &p.PageOptions.pageOptions, &p.PageOptions.headerAndFooterOptions)
+			popts = append(popts, &p.PageOptions.pageOptions)
+		case *PageReader:
+			opts = append(opts, &p.PageOptions.pageOptions, &p.PageOptions.headerAndFooterOptions)
+			popts = append(popts, &p.PageOptions.pageOptions)
+		}
+	}
+	if err := checkValue("", pdfg.OutputFile); err != nil {
+		return fmt.Errorf("invalid OutputFile: %s", err)
+	}
+	if err := pdfg.checkPageSetup(); err != nil {
+		return err
+	}
+	if err := pdfg.checkPrintOptions(); err != nil {
+		return err
+	}
+	for _, popt := range popts {
+		if err := popt.checkViewport(); err != nil {
+			return err
+		}
+		if err := popt.checkLinks(); err != nil {
+			return err
+		}
+	}
+	if err := checkOptions(opts...); err != nil {
+		return err
+	}
+	return pdfg.checkInputs()
+}
+
+// checkArgs checks the input, output, format, form control files and credentials of the image options,
+// and the input with the InputLimits
+func (options *ImageOptions) checkArgs() error {
+	for name, v := range map[string]string{"Input": options.Input, "Output": options.Output, "Format": options.Format} {
+		if err := checkValue("", v); err != nil {
+			return fmt.Errorf("invalid %s: %s", name, err)
+		}
+	}
+	for _, o := range options.FormControls.options() {
+		if err := checkValue(o.option, o.file); err != nil {
+			return err
+		}
+	}
+	for option, m := range map[string]map[string]string{"cookie": options.Credentials.Cookies, "custom-header": options.Credentials.Headers} {
+		for k, v := range m {
+			if err := checkValue(option, k); err != nil {
+				return err
+			}
+			if err := checkValue(option, v); err != nil {
+				return err
+			}
+		}
+	}
+	return options.checkInputs()
+}
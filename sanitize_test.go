@@ -0,0 +1,77 @@
+package wkhtmltopdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPDFGeneratorCheckArgs(t *testing.T) {
+	defer func(allowed map[string]bool) { flagValues.allowed = allowed }(flagValues.allowed)
+	flagValues.allowed = nil
+
+	pdfg := NewPDFPreparer()
+	pdfg.Title.Set("--enable-local-file-access")
+	pdfg.AddPage(NewPage("https://example.com"))
+	err := pdfg.checkArgs()
+	if err == nil || !strings.Contains(err.Error(), "--title") {
+		t.Errorf("Want error for the title, have %v", err)
+	}
+	if err := pdfg.Create(); err == nil {
+		t.Error("Want Create to fail")
+	}
+
+	AllowFlagValues("title")
+	if err := pdfg.checkArgs(); err != nil {
+		t.Errorf("Want no error for an allowed option, have %s", err)
+	}
+
+	page := NewPage("https://example.com")
+	page.CustomHeader.Set("X-Name", "a\x00b")
+	pdfg.AddPage(page)
+	if err := pdfg.checkArgs(); err == nil || !strings.Contains(err.Error(), "NUL") {
+		t.Errorf("Want error for a NUL byte, have %v", err)
+	}
+
+	pdfg = NewPDFPreparer()
+	pdfg.AddPage(NewPage("--allow"))
+	if err := pdfg.checkArgs(); err == nil {
+		t.Error("Want error for a page input which starts with --")
+	}
+	pdfg = NewPDFPreparer()
+	pdfg.AddPage(NewPage("-q"))
+	if err := pdfg.checkArgs(); err == nil {
+		t.Error("Want error for a page input which starts with -")
+	}
+	flagValues.allowed = nil
+	pdfg = NewPDFPreparer()
+	page = NewPage("https://example.com")
+	page.FooterCenter.Set("- [page] -")
+	page.HeaderLeft.Set("-")
+	if err := page.SetReplaceVars(struct{ Balance float64 }{-12.5}); err != nil {
+		t.Fatal(err)
+	}
+	pdfg.AddPage(page)
+	if err := pdfg.checkArgs(); err != nil {
+		t.Errorf("Want no error for option values which start with -, have %s", err)
+	}
+	if args := pdfg.ArgString(); !strings.Contains(args, "--replace balance -12.5") {
+		t.Errorf("Want the negative replace value, have %s", args)
+	}
+	pdfg = NewPDFPreparer()
+	pdfg.AddPage(NewPage("https://example.com"))
+	pdfg.OutputFile = "-"
+	if err := pdfg.checkArgs(); err != nil {
+		t.Errorf("Want no error for stdout, have %s", err)
+	}
+}
+
+func TestBuildParamsCheckArgs(t *testing.T) {
+	params := ImageOptions{Input: "--enable-local-file-access"}
+	if _, err := buildParams(&params); err == nil {
+		t.Error("Want error for an input which starts with --")
+	}
+	params = ImageOptions{Input: "http://example.com", Format: "png\x00"}
+	if _, err := buildParams(&params); err == nil {
+		t.Error("Want error for a NUL byte")
+	}
+}
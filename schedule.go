@@ -0,0 +1,143 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next time a scheduled job is rendered after t
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Every returns a Schedule which renders a job each interval, the first time one interval after the Scheduler starts.
+// Like time.NewTicker it panics when interval is not positive
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("wkhtmltopdf: non-positive interval for Every")
+	}
+	return every(interval)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// cronSchedule is a parsed cron expression, each field holds the allowed values as bits
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// anyDom and anyDow are set when the field is *, cron matches either field when both are restricted
+	anyDom, anyDow bool
+}
+
+// cronFields are the ranges of the fields of a cron expression
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron parses a cron expression with the fields minute, hour, day of month, month and day of week,
+// for example "*/15 * * * *" or "0 6 * * 1-5". Each field can be *, a number, a range, a list and have a step.
+// Day of week 0 and 7 are Sunday. The schedule uses the time zone of the times passed to Next.
+func ParseCron(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", spec)
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in cron expression %q: %s", cronFields[i].name, spec, err)
+		}
+		bits[i] = b
+	}
+	// Sunday is 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cronSchedule{
+		minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4],
+		anyDom: fields[2] == "*", anyDow: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField returns the values of a field as bits
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			var err error
+			lo, err = strconv.Atoi(part)
+			hi = lo
+			if i := strings.Index(part, "-"); i >= 0 {
+				lo, err = strconv.Atoi(part[:i])
+				if err == nil {
+					hi, err = strconv.Atoi(part[i+1:])
+				}
+			}
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			if lo < min || hi > max || lo > hi {
+				return 0, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first minute after t which matches the schedule, or the zero time if there is none within 5 years
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches returns true if the day of month and day of week of t match, like cron a day matches either field
+// when both are restricted
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDom || c.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,59 @@
+package wkhtmltopdf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	start := time.Date(2024, 3, 15, 10, 7, 30, 0, time.UTC) // Friday
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 6 * * *", time.Date(2024, 3, 16, 6, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2024, 3, 18, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, 3, 17, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week
+		{"0 0 20 * 6", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"5,10 10 * * *", time.Date(2024, 3, 15, 10, 10, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Errorf("%s: %s", tt.spec, err)
+			continue
+		}
+		if have := s.Next(start); !have.Equal(tt.want) {
+			t.Errorf("%s: want %s, have %s", tt.spec, tt.want, have)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("Want error for %q", spec)
+		}
+	}
+}
+
+func TestEvery(t *testing.T) {
+	start := time.Now()
+	if have := Every(time.Hour).Next(start); !have.Equal(start.Add(time.Hour)) {
+		t.Errorf("Want %s, have %s", start.Add(time.Hour), have)
+	}
+}
+
+func TestEveryNotPositive(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Want panic for an interval of 0")
+		}
+	}()
+	Every(0)
+}
@@ -0,0 +1,192 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ChangeFunc is called by a Scheduler with the output of a scheduled job when it changed since the last time it was stored.
+// diff is nil for the first output of a job and for PDF jobs, which are compared byte by byte.
+type ChangeFunc func(name string, output []byte, diff *RenderDiff)
+
+// Scheduler renders jobs again on a schedule, for example to keep snapshots of pages up to date,
+// stores the output in Results and calls OnChange when it changed. Images are compared with CompareRenders,
+// so only visible changes are reported. The settings should not be changed after Run is called.
+type Scheduler struct {
+	Results   ResultStore // Stores the last changed output of each job by its name, required
+	Limiter   *Limiter    // Limits the renders with the job name as tenant, optional
+	Threshold float64     // Minimum RenderDiff.Score of an image to be a change, 0 means any different pixel
+	OnChange  ChangeFunc
+	OnError   func(name string, err error) // Called when a render fails, the job is tried again at its next time
+
+	// changed is called like OnChange with the last stored output, nil for the first output, see Monitor
+	changed func(ctx context.Context, name string, before, output []byte, diff *RenderDiff)
+
+	mu   sync.Mutex
+	jobs map[string]*scheduledJob
+	wake chan struct{}
+}
+
+// scheduledJob is a job added to a Scheduler, saved as JSON because a job can only be rendered once
+type scheduledJob struct {
+	schedule Schedule
+	job      []byte
+	image    bool
+	next     time.Time
+	running  bool
+}
+
+// Add adds or replaces the job with name, it is first rendered at the next time of the schedule after it is added.
+// The job is saved with ToJSON, so changes to it after Add have no effect. A schedule which has no next time after
+// the time it is added, such as an interval which is not positive, is an error.
+func (s *Scheduler) Add(name string, schedule Schedule, job *Job) error {
+	now := time.Now()
+	next := schedule.Next(now)
+	if !next.After(now) {
+		return fmt.Errorf("schedule of job %s has no next time after %s", name, now.Format(time.RFC3339))
+	}
+	b, err := job.ToJSON()
+	if err != nil {
+		return fmt.Errorf("error saving scheduled job %s: %s", name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobs == nil {
+		s.jobs = make(map[string]*scheduledJob)
+	}
+	s.jobs[name] = &scheduledJob{schedule: schedule, job: b, image: job.Image != nil, next: next}
+	s.notify()
+	return nil
+}
+
+// Remove removes the job with name, a render of the job which is running is not canceled
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, name)
+	s.notify()
+}
+
+// notify wakes up Run to recalculate the next time, s.mu must be held
+func (s *Scheduler) notify() {
+	if s.wake == nil {
+		s.wake = make(chan struct{}, 1)
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run renders the jobs at their scheduled times until ctx is done, it returns after the running renders finished.
+// A job which is still rendering at its next time is skipped until the render after that.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.Results == nil {
+		return errors.New("Scheduler has no Results set")
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	s.mu.Lock()
+	s.notify()
+	wake := s.wake
+	s.mu.Unlock()
+	for {
+		now := time.Now()
+		var next time.Time
+		s.mu.Lock()
+		for name, sj := range s.jobs {
+			for !sj.next.IsZero() && !sj.next.After(now) {
+				if !sj.running {
+					sj.running = true
+					wg.Add(1)
+					go func(name string, sj *scheduledJob) {
+						defer wg.Done()
+						s.render(ctx, name, sj)
+					}(name, sj)
+				}
+				sj.next = sj.schedule.Next(now)
+			}
+			if !sj.next.IsZero() && (next.IsZero() || sj.next.Before(next)) {
+				next = sj.next
+			}
+		}
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(next.Sub(now))
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// render renders the scheduled job, stores the output when it changed and calls OnChange
+func (s *Scheduler) render(ctx context.Context, name string, sj *scheduledJob) {
+	defer func() {
+		s.mu.Lock()
+		sj.running = false
+		s.mu.Unlock()
+	}()
+
+	var output []byte
+	render := func(ctx context.Context) error {
+		job, err := JobFromJSON(bytes.NewReader(sj.job), false)
+		if err != nil {
+			return err
+		}
+		output, err = job.Render(ctx)
+		return err
+	}
+	var err error
+	if s.Limiter != nil {
+		err = s.Limiter.DoContext(ctx, name, render)
+	} else {
+		err = render(ctx)
+	}
+	if err != nil {
+		if s.OnError != nil && ctx.Err() == nil {
+			s.OnError(name, err)
+		}
+		return
+	}
+
+	changed, diff := true, (*RenderDiff)(nil)
+	last, ok := s.Results.Get(name)
+	if ok {
+		changed = !bytes.Equal(last, output)
+		if changed && sj.image {
+			// images which can not be decoded, such as svg, are compared byte by byte
+			if d, err := CompareRenders(last, output); err == nil {
+				diff = d
+				changed = d.Pixels > 0 && d.Score >= s.Threshold
+			}
+		}
+	}
+	if !changed {
+		return
+	}
+	s.Results.Put(name, output)
+	if s.OnChange != nil {
+		s.OnChange(name, output, diff)
+	}
+	if s.changed != nil {
+		s.changed(ctx, name, last, output, diff)
+	}
+}
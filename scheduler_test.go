@@ -0,0 +1,112 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-scheduler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	page := filepath.Join(dir, "page.png")
+	writePage := func(x int) {
+		buf := &bytes.Buffer{}
+		png.Encode(buf, testPage(64, 64, x))
+		if err := ioutil.WriteFile(page, buf.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePage(0)
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\ncat "+page+"\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type change struct {
+		output []byte
+		diff   *RenderDiff
+	}
+	changes := make(chan change, 10)
+	s := &Scheduler{
+		Results: NewMemoryResultStore(10),
+		OnChange: func(name string, output []byte, diff *RenderDiff) {
+			if name != "dashboard" {
+				t.Errorf("Want dashboard, have %s", name)
+			}
+			changes <- change{output, diff}
+		},
+		OnError: func(name string, err error) {
+			t.Errorf("Want no error, have %s", err)
+		},
+	}
+	err = s.Add("dashboard", Every(20*time.Millisecond), &Job{Image: &ImageOptions{BinaryPath: bin, Input: "http://example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	next := func() change {
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("Want change, have none")
+		}
+		return change{}
+	}
+	first := next()
+	if first.diff != nil {
+		t.Error("Want no diff for the first output")
+	}
+	// the same page is not a change
+	select {
+	case <-changes:
+		t.Error("Want no change for the same page")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	writePage(32)
+	second := next()
+	if second.diff == nil || second.diff.Pixels == 0 {
+		t.Errorf("Want diff with different pixels, have %+v", second.diff)
+	}
+	if out, _ := s.Results.Get("dashboard"); !bytes.Equal(out, second.output) {
+		t.Error("Want changed output stored in Results")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Want context.Canceled, have %v", err)
+	}
+}
+
+func TestSchedulerNoResults(t *testing.T) {
+	s := &Scheduler{}
+	if err := s.Run(context.Background()); err == nil {
+		t.Error("Want error without Results")
+	}
+}
+
+// stoppedSchedule has no next time after t
+type stoppedSchedule struct{}
+
+func (stoppedSchedule) Next(t time.Time) time.Time { return t }
+
+func TestSchedulerAddNoNextTime(t *testing.T) {
+	s := &Scheduler{}
+	if err := s.Add("stopped", stoppedSchedule{}, &Job{}); err == nil {
+		t.Error("Want error for a schedule without a next time")
+	}
+}
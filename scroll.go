@@ -0,0 +1,29 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"time"
+)
+
+// scrollTemplate scrolls through the page one window height at a time, waits until the images are loaded or 10 seconds
+// have passed, scrolls back to the top and runs the settle script in place of %s
+const scrollTemplate = `(function(){` +
+	`var y=0,step=window.innerHeight||600,checks=0;` +
+	`function done(){window.scrollTo(0,0);%s}` +
+	`function wait(){var imgs=document.images;for(var i=0;i<imgs.length&&checks<100;i++){if(!imgs[i].complete){checks++;return setTimeout(wait,100)}}done()}` +
+	`function next(){window.scrollTo(0,y);y+=step;setTimeout(y<document.body.scrollHeight?next:wait,100)}` +
+	`next()})()`
+
+// scrollScript returns a script which scrolls through the page and sets the window status to settledStatus
+// delay after the images are loaded
+func scrollScript(delay time.Duration) string {
+	return fmt.Sprintf(scrollTemplate, settleScript(delay))
+}
+
+// SetScrollToBottom scrolls through the page after it is loaded, so images which are loaded lazily when they are scrolled
+// into view are rendered instead of placeholders. wkhtmltopdf waits until the images are loaded, at most 10 seconds,
+// and then for delay. It adds a script to RunScript and replaces WindowStatus, use it instead of SetSettleDelay.
+func (popt *pageOptions) SetScrollToBottom(delay time.Duration) {
+	popt.RunScript.Set(scrollScript(delay))
+	popt.WindowStatus.Set(settledStatus)
+}
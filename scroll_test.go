@@ -0,0 +1,35 @@
+package wkhtmltopdf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScrollScript(t *testing.T) {
+	script := scrollScript(500 * time.Millisecond)
+	if !strings.Contains(script, settleScript(500*time.Millisecond)) {
+		t.Errorf("Want the settle script in %s", script)
+	}
+	for _, pair := range []string{"()", "{}", "[]"} {
+		if strings.Count(script, pair[:1]) != strings.Count(script, pair[1:]) {
+			t.Errorf("Want balanced %s in %s", pair, script)
+		}
+	}
+
+	page := NewPage("https://example.com")
+	page.SetScrollToBottom(0)
+	args := page.Args()
+	if strings.Join(args, " ") != "--run-script "+scrollScript(0)+" --window-status "+settledStatus {
+		t.Errorf("Want scroll script and window status, have %q", args)
+	}
+
+	options := ImageOptions{Input: "http://example.com", ScrollToBottom: true, SettleDelay: time.Second}
+	v, err := buildParams(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v[2] != "--run-script" || v[3] != scrollScript(time.Second) || v[5] != settledStatus {
+		t.Errorf("Want scroll script with settle delay, have %q", v[2:6])
+	}
+}
@@ -0,0 +1,60 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// settledStatus is the window status set by the settle script, wkhtmltopdf waits until the page has this status
+const settledStatus = "wkhtmltopdf-settled"
+
+// settleScript returns a script which sets the window status to settledStatus after delay
+func settleScript(delay time.Duration) string {
+	return fmt.Sprintf("setTimeout(function(){window.status=%q},%d)", settledStatus, delay/time.Millisecond)
+}
+
+// SetSettleDelay waits another delay after the page is loaded and JavascriptDelay has passed, for example to let images
+// which are loaded by scroll events or timers appear. It adds a script to RunScript and replaces WindowStatus,
+// which wkhtmltopdf uses to wait for the script.
+func (popt *pageOptions) SetSettleDelay(delay time.Duration) {
+	popt.RunScript.Set(settleScript(delay))
+	popt.WindowStatus.Set(settledStatus)
+}
+
+// settleArgs returns the wkhtmltoimage options to scroll through the page and to wait for delay after the page is loaded,
+// none when scroll is false and delay is 0
+func settleArgs(scroll bool, delay time.Duration) []string {
+	switch {
+	case scroll:
+		return []string{"--run-script", scrollScript(delay), "--window-status", settledStatus}
+	case delay > 0:
+		return []string{"--run-script", settleScript(delay), "--window-status", settledStatus}
+	}
+	return nil
+}
+
+// ResolveRedirects follows the redirects of url, at most max, and returns the URL it ends on.
+// wkhtmltopdf and wkhtmltoimage follow any number of redirects without reporting them, render the returned URL to
+// limit how far a page can redirect, for example to a login page. It is an error when url redirects more than max times.
+func ResolveRedirects(ctx context.Context, url string, max int) (string, error) {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > max {
+				return fmt.Errorf("more than %d redirects", max)
+			}
+			return nil
+		},
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Request.URL.String(), nil
+}
@@ -0,0 +1,55 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetSettleDelay(t *testing.T) {
+	page := NewPage("https://example.com")
+	page.SetSettleDelay(1500 * time.Millisecond)
+	args := strings.Join(page.Args(), " ")
+	for _, want := range []string{`--run-script setTimeout(function(){window.status="wkhtmltopdf-settled"},1500)`, "--window-status wkhtmltopdf-settled"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Want %s in %s", want, args)
+		}
+	}
+
+	options := ImageOptions{Input: "http://example.com", SettleDelay: time.Second}
+	v, err := buildParams(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--run-script", `setTimeout(function(){window.status="wkhtmltopdf-settled"},1000)`, "--window-status", settledStatus}
+	if !reflect.DeepEqual(v[2:6], want) {
+		t.Errorf("Want %q, have %q", want, v[2:6])
+	}
+}
+
+func TestResolveRedirects(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+		}
+	}))
+	defer ts.Close()
+
+	url, err := ResolveRedirects(context.Background(), ts.URL+"/a", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != ts.URL+"/c" {
+		t.Errorf("Want %s/c, have %s", ts.URL, url)
+	}
+	if _, err := ResolveRedirects(context.Background(), ts.URL+"/a", 1); err == nil || !strings.Contains(err.Error(), "more than 1 redirects") {
+		t.Errorf("Want error for too many redirects, have %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OutputSink stores the outputs of a Worker outside of the message queue, for example in S3 or Google Cloud Storage.
+// Put must be safe for concurrent use.
+type OutputSink interface {
+	Put(ctx context.Context, key, contentType string, output []byte) error
+}
+
+// URLSigner is implemented by an OutputSink which can issue time-limited download URLs for stored outputs,
+// such as pre-signed S3 URLs or signed Cloud Storage URLs
+type URLSigner interface {
+	SignURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// DefaultURLExpiry is the time the download URLs of a Worker are valid when Worker.URLExpiry is not set
+const DefaultURLExpiry = time.Hour
+
+// resultURLKey is the context key of the download URL of the output of a job
+type resultURLKey struct{}
+
+// ResultURL returns the download URL of the output of the job which is published with the context, or an empty string.
+// A Worker with a Sink which implements URLSigner publishes large outputs as a URL instead of bytes, see Worker.Sink.
+func ResultURL(ctx context.Context) string {
+	url, _ := ctx.Value(resultURLKey{}).(string)
+	return url
+}
+
+// sinkOutput stores the output of the job in the Sink of the Worker when it has at least SinkMinSize bytes, and returns
+// a context with the download URL and no output when the Sink implements URLSigner. The key is made by sinkKey.
+func (w *Worker) sinkOutput(ctx context.Context, job *Job, output []byte) (context.Context, []byte, error) {
+	if w.Sink == nil || len(output) == 0 || len(output) < w.SinkMinSize {
+		return ctx, output, nil
+	}
+	ext, contentType := job.outputType()
+	key := sinkKey(job.Profile, job.RequestID, ext)
+	if err := w.Sink.Put(ctx, key, contentType, output); err != nil {
+		return ctx, nil, err
+	}
+	w.retain(key)
+	signer, ok := w.Sink.(URLSigner)
+	if !ok {
+		return ctx, output, nil
+	}
+	expiry := w.URLExpiry
+	if expiry <= 0 {
+		expiry = DefaultURLExpiry
+	}
+	url, err := signer.SignURL(ctx, key, expiry)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return context.WithValue(ctx, resultURLKey{}, url), nil, nil
+}
+
+// sinkKey returns a new key for an output in the Sink, the request ID of the job with a random suffix and the extension
+// of the output, after the profile name when the job has a profile. The profile and request ID are client input, they are
+// escaped so they can not add components to the key or refer to a parent, and the suffix keeps jobs with the same request ID apart
+func sinkKey(profile, requestID, ext string) string {
+	key := keyComponent(requestID) + "-" + newRequestID() + "." + ext
+	if profile != "" {
+		key = keyComponent(profile) + "/" + key
+	}
+	return key
+}
+
+// keyComponent escapes s for use as one component of a key
+func keyComponent(s string) string {
+	s = url.PathEscape(s)
+	if strings.Trim(s, ".") == "" {
+		s = strings.Replace(s, ".", "%2E", -1)
+	}
+	return s
+}
+
+// outputType returns the file extension and content type of the output of the job
+func (j *Job) outputType() (ext, contentType string) {
+	if j.PDF != nil {
+		return "pdf", "application/pdf"
+	}
+	if base64Output(j.Image.Output) {
+		return "txt", "text/plain"
+	}
+	options := *j.Image
+	setImageDefaults(&options)
+	if options.Format == "" {
+		options.Format = "png"
+	}
+	return options.Format, imageContentType(options.Format)
+}
@@ -0,0 +1,118 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testSink struct {
+	mu      sync.Mutex
+	outputs map[string][]byte
+	types   map[string]string
+}
+
+func (s *testSink) Put(ctx context.Context, key, contentType string, output []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outputs[key] = output
+	s.types[key] = contentType
+	return nil
+}
+
+type testSigningSink struct {
+	*testSink
+}
+
+func (s testSigningSink) SignURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "https://storage.example.com/" + key + "?expires=" + expiry.String(), nil
+}
+
+func TestWorkerSink(t *testing.T) {
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+	defer setTestImagePath(bin.Name())()
+
+	run := func(sink OutputSink, html, requestID string) (*testQueue, *testMessage) {
+		jb, err := (&Job{Image: &ImageOptions{Input: "-", Html: html, Format: "svg"}, RequestID: requestID}).ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := &testMessage{data: jb}
+		q := newTestQueue(m)
+		w := &Worker{Consumer: q, Publisher: q, Sink: sink, SinkMinSize: 16, URLExpiry: time.Minute}
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-q.done
+			cancel()
+		}()
+		w.Run(ctx)
+		return q, m
+	}
+
+	sink := &testSink{outputs: make(map[string][]byte), types: make(map[string]string)}
+	// small outputs are published as bytes
+	q, m := run(testSigningSink{sink}, "<svg></svg>", "small")
+	if string(q.pdfs[m]) != "<svg></svg>" || q.urls[m] != "" || len(sink.outputs) != 0 {
+		t.Errorf("Want small output published, have %q and URL %q", q.pdfs[m], q.urls[m])
+	}
+
+	large := "<svg><text>large</text></svg>"
+	q, m = run(testSigningSink{sink}, large, "large")
+	key := sinkKeyWithPrefix(sink, "large-")
+	if q.pdfs[m] != nil || q.urls[m] != "https://storage.example.com/"+key+"?expires=1m0s" {
+		t.Errorf("Want URL of large output, have %q and URL %q", q.pdfs[m], q.urls[m])
+	}
+	if !strings.HasSuffix(key, ".svg") || string(sink.outputs[key]) != large || sink.types[key] != "image/svg+xml" {
+		t.Errorf("Want large output stored as image/svg+xml, have %q as %s", sink.outputs[key], sink.types[key])
+	}
+
+	// a sink which can not sign URLs only stores the output
+	q, m = run(sink, large, "unsigned")
+	if string(q.pdfs[m]) != large || q.urls[m] != "" || sinkKeyWithPrefix(sink, "unsigned-") == "" {
+		t.Errorf("Want output stored and published, have %q and URL %q", q.pdfs[m], q.urls[m])
+	}
+
+	// the same request ID is stored under a new key
+	run(sink, large, "unsigned")
+	if len(sink.outputs) != 3 {
+		t.Errorf("Want 3 outputs, have %d", len(sink.outputs))
+	}
+}
+
+// sinkKeyWithPrefix returns the key of the output in sink which starts with prefix
+func sinkKeyWithPrefix(sink *testSink, prefix string) string {
+	for key := range sink.outputs {
+		if strings.HasPrefix(key, prefix) {
+			return key
+		}
+	}
+	return ""
+}
+
+func TestSinkKey(t *testing.T) {
+	for _, c := range []struct{ profile, requestID, prefix string }{
+		{"", "order-1", "order-1-"},
+		{"acme", "order-1", "acme/order-1-"},
+		{"..", "../../etc/passwd", "%2E%2E/..%2F..%2Fetc%2Fpasswd-"},
+		{"a/b", "", "a%2Fb/-"},
+	} {
+		key := sinkKey(c.profile, c.requestID, "pdf")
+		if !strings.HasPrefix(key, c.prefix) || !strings.HasSuffix(key, ".pdf") || len(key) != len(c.prefix)+16+len(".pdf") {
+			t.Errorf("Want key %s<suffix>.pdf, have %s", c.prefix, key)
+		}
+	}
+	if sinkKey("", "a", "pdf") == sinkKey("", "a", "pdf") {
+		t.Error("Want unique keys")
+	}
+}
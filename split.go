@@ -0,0 +1,53 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SplitPDF splits a PDF document into one PDF document per page using qpdf.
+// This can be used to split a PDF created from many documents at once back into the separate documents.
+func SplitPDF(data []byte) ([][]byte, error) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.pdf")
+	err = ioutil.WriteFile(in, data, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	// qpdf writes the pages to page-01.pdf, page-02.pdf, etc.
+	// the page numbers are zero padded so the file names sort in page order
+	pagesDir := filepath.Join(dir, "pages")
+	err = os.Mkdir(pagesDir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	err = execQPDF(context.Background(), "--split-pages", in, filepath.Join(pagesDir, "page.pdf"))
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(pagesDir, "page-*.pdf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	pages := make([][]byte, 0, len(files))
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, b)
+	}
+	return pages, nil
+}
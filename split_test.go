@@ -0,0 +1,44 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSplitPDF(t *testing.T) {
+	pdfg, err := NewPDFGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdfg.AddPage(NewPageReader(strings.NewReader(`<html><body>
+<div style="page-break-after: always">Customer 1</div>
+<div style="page-break-after: always">Customer 2</div>
+<div>Customer 3</div>
+</body></html>`)))
+
+	err = pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := SplitPDF(pdfg.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 {
+		t.Fatalf("Want 3 pages, have %d", len(pages))
+	}
+	for i, p := range pages {
+		if !bytes.HasPrefix(p, []byte("%PDF")) {
+			t.Errorf("Page %d is not a PDF", i)
+		}
+	}
+}
+
+func TestSplitPDFInvalid(t *testing.T) {
+	_, err := SplitPDF([]byte("not a PDF"))
+	if err == nil {
+		t.Fatal("Want an error for an invalid PDF, have no error")
+	}
+}
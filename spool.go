@@ -0,0 +1,209 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSpoolEmpty is returned by Spool.Next when there are no pending jobs
+var ErrSpoolEmpty = errors.New("spool is empty")
+
+const (
+	spoolPending = ".json"
+	spoolRunning = ".running"
+	spoolFailed  = ".failed"
+)
+
+// Constants for job priorities in the Spool, any priority between 0 and 999 can be used
+const (
+	PriorityBatch       = 0   // Priority for bulk jobs (default)
+	PriorityInteractive = 100 // Priority for jobs a user is waiting for
+)
+
+// Spool is a disk backed queue of PDF jobs. Jobs are saved as JSON files using Job.ToJSON,
+// so pending jobs survive a crash or restart of the process that creates the PDFs.
+// A Spool is safe for concurrent use, but only one process should use a spool directory at a time.
+// Next returns jobs with the highest priority first and alternates between tenants with jobs of the same priority,
+// so one tenant adding many jobs can not starve the others.
+type Spool struct {
+	dir    string
+	seq    uint64
+	served uint64
+	// lastServed holds the value of served when a job of the tenant was last returned by Next,
+	// for the tenants with pending jobs and the tenant served last
+	lastServed map[string]uint64
+	sync.Mutex
+}
+
+// OpenSpool opens or creates the spool in dir.
+// Jobs that were taken with Next but not finished with Done, for example because the process crashed, are pending again.
+func OpenSpool(dir string) (*Spool, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	running, err := filepath.Glob(filepath.Join(dir, "*"+spoolRunning))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range running {
+		err = os.Rename(f, strings.TrimSuffix(f, spoolRunning)+spoolPending)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Spool{dir: dir, lastServed: make(map[string]uint64)}, nil
+}
+
+// Add saves the PDFGenerator as a pending job with PriorityBatch and returns the job id.
+// Pages added with a PageReader are read, so they can not be read again after adding the job.
+func (s *Spool) Add(pdfg *PDFGenerator) (string, error) {
+	return s.AddJob(pdfg, PriorityBatch, "")
+}
+
+// AddJob saves the PDFGenerator as a pending job with a priority for a tenant and returns the job id.
+// The tenant can be any string, for example a customer id, use an empty string if tenants are not used.
+func (s *Spool) AddJob(pdfg *PDFGenerator, priority int, tenant string) (string, error) {
+	if priority < 0 || priority > 999 {
+		return "", fmt.Errorf("priority %d is not between 0 and 999", priority)
+	}
+	jb, err := (&Job{PDF: pdfg}).ToJSON()
+	if err != nil {
+		return "", err
+	}
+
+	s.Lock()
+	s.seq++
+	// the inverted priority comes first so ids sort by priority and then by the time they were added,
+	// the tenant is hex encoded so it is safe to use in a file name
+	id := fmt.Sprintf("%03d-%020d-%06d-%s", 999-priority, time.Now().UnixNano(), s.seq, hex.EncodeToString([]byte(tenant)))
+	s.Unlock()
+
+	// write to a temporary file first so a crash never leaves an incomplete job
+	tmp := filepath.Join(s.dir, id+".tmp")
+	err = ioutil.WriteFile(tmp, jb, 0600)
+	if err != nil {
+		return "", err
+	}
+	return id, os.Rename(tmp, s.path(id, spoolPending))
+}
+
+// Next takes the next pending job from the spool and returns its id and PDFGenerator.
+// The job stays in the spool until Done is called and returns to pending with Release or when the spool is opened again.
+// ErrSpoolEmpty is returned if there are no pending jobs. A job which can not be read is moved to a .failed file in the
+// spool directory, so it is not returned again, and its id is returned with the error.
+func (s *Spool) Next() (string, *PDFGenerator, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	pending, err := s.ids(spoolPending)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pending) == 0 {
+		return "", nil, ErrSpoolEmpty
+	}
+	id := s.pick(pending)
+
+	err = os.Rename(s.path(id, spoolPending), s.path(id, spoolRunning))
+	if err != nil {
+		return "", nil, err
+	}
+	jb, err := ioutil.ReadFile(s.path(id, spoolRunning))
+	var job *Job
+	if err == nil {
+		job, err = JobFromJSON(bytes.NewReader(jb), false)
+	}
+	if err == nil && job.PDF == nil {
+		err = errors.New("not a PDF job")
+	}
+	if err != nil {
+		if ferr := os.Rename(s.path(id, spoolRunning), s.path(id, spoolFailed)); ferr != nil {
+			return id, nil, fmt.Errorf("error reading job %s: %s, error moving it to failed: %s", id, err, ferr)
+		}
+		return id, nil, fmt.Errorf("error reading job %s: %s", id, err)
+	}
+	return id, job.PDF, nil
+}
+
+// pick returns the oldest job of the tenant that was served least recently from the jobs with the highest priority.
+// Tenants without pending jobs are removed from lastServed, so it does not grow with every tenant which ever added
+// a job, they are served like a new tenant when they add jobs again
+func (s *Spool) pick(pending []string) string {
+	// pending is sorted, so the first job has the highest priority
+	priority := spoolJobPriority(pending[0])
+	id := ""
+	tenant := ""
+	waiting := make(map[string]bool)
+	for _, p := range pending {
+		t := spoolJobTenant(p)
+		waiting[t] = true
+		if spoolJobPriority(p) != priority {
+			continue
+		}
+		if id == "" || s.lastServed[t] < s.lastServed[tenant] {
+			id, tenant = p, t
+		}
+	}
+	for t := range s.lastServed {
+		if !waiting[t] {
+			delete(s.lastServed, t)
+		}
+	}
+	s.served++
+	s.lastServed[tenant] = s.served
+	return id
+}
+
+// spoolJobPriority returns the inverted priority from the job id
+func spoolJobPriority(id string) string {
+	return id[:strings.Index(id, "-")]
+}
+
+// spoolJobTenant returns the hex encoded tenant from the job id
+func spoolJobTenant(id string) string {
+	return id[strings.LastIndex(id, "-")+1:]
+}
+
+// Done removes a finished job from the spool
+func (s *Spool) Done(id string) error {
+	return os.Remove(s.path(id, spoolRunning))
+}
+
+// Release returns a job taken with Next to pending, for example to retry it after an error
+func (s *Spool) Release(id string) error {
+	return os.Rename(s.path(id, spoolRunning), s.path(id, spoolPending))
+}
+
+// Len returns the number of pending jobs
+func (s *Spool) Len() (int, error) {
+	pending, err := s.ids(spoolPending)
+	return len(pending), err
+}
+
+func (s *Spool) path(id, ext string) string {
+	return filepath.Join(s.dir, id+ext)
+}
+
+// ids returns the sorted ids of all jobs with extension ext, ids sort by priority and the time they were added
+func (s *Spool) ids(ext string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+ext))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(files))
+	for _, f := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(f), ext))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,210 @@
+package wkhtmltopdf
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newTestSpool(t *testing.T) (*Spool, string) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, dir
+}
+
+func TestSpool(t *testing.T) {
+	s, dir := newTestSpool(t)
+	defer os.RemoveAll(dir)
+
+	for _, html := range []string{"<html>1</html>", "<html>2</html>"} {
+		pdfg := NewPDFPreparer()
+		pdfg.AddPage(NewPageReader(strings.NewReader(html)))
+		_, err := s.Add(pdfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := s.Len()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Want 2 pending jobs, have %d", n)
+	}
+
+	// jobs are returned in the order they were added
+	id, pdfg, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadAll(pdfg.pages[0].Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "<html>1</html>" {
+		t.Errorf("Want first job, have %s", buf)
+	}
+	err = s.Done(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _, err = s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Release(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, _, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id2 != id {
+		t.Errorf("Want released job %s, have %s", id, id2)
+	}
+	err = s.Done(id2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = s.Next()
+	if err != ErrSpoolEmpty {
+		t.Errorf("Want ErrSpoolEmpty, have %v", err)
+	}
+}
+
+func TestSpoolRecovery(t *testing.T) {
+	s, dir := newTestSpool(t)
+	defer os.RemoveAll(dir)
+
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPage("https://www.google.com"))
+	id, err := s.Add(pdfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the job was not done, so it is pending again after a restart
+	s, err = OpenSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.Len()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("Want 1 pending job, have %d", n)
+	}
+	id2, pdfgFromSpool, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id2 != id {
+		t.Errorf("Want job %s, have %s", id, id2)
+	}
+	if pdfgFromSpool.ArgString() != pdfg.ArgString() {
+		t.Errorf("Want argstring:\n%s\nHave:\n%s", pdfg.ArgString(), pdfgFromSpool.ArgString())
+	}
+}
+
+func TestSpoolInvalidJob(t *testing.T) {
+	s, dir := newTestSpool(t)
+	defer os.RemoveAll(dir)
+
+	id := "999-00000000000000000001-000001-"
+	if err := ioutil.WriteFile(s.path(id, spoolPending), []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	have, _, err := s.Next()
+	if err == nil || have != id {
+		t.Fatalf("Want error with job %s, have %q, %v", id, have, err)
+	}
+	if _, err := os.Stat(s.path(id, spoolFailed)); err != nil {
+		t.Errorf("Want the job moved to failed, have %v", err)
+	}
+	if _, _, err := s.Next(); err != ErrSpoolEmpty {
+		t.Errorf("Want ErrSpoolEmpty, have %v", err)
+	}
+}
+
+func TestSpoolPriorityAndTenants(t *testing.T) {
+	s, dir := newTestSpool(t)
+	defer os.RemoveAll(dir)
+
+	add := func(priority int, tenant string) string {
+		pdfg := NewPDFPreparer()
+		pdfg.AddPage(NewPage("https://www.google.com"))
+		id, err := s.AddJob(pdfg, priority, tenant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	// a bulk export from tenant a, followed by jobs from other tenants
+	a1 := add(PriorityBatch, "a")
+	a2 := add(PriorityBatch, "a")
+	a3 := add(PriorityBatch, "a")
+	b1 := add(PriorityBatch, "b")
+	c1 := add(PriorityInteractive, "c")
+
+	want := []string{c1, a1, b1, a2, a3}
+	for i, w := range want {
+		id, _, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != w {
+			t.Errorf("Want job %d to be %s, have %s", i, w, id)
+		}
+		err = s.Done(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := s.lastServed[hex.EncodeToString([]byte("a"))]; !ok || len(s.lastServed) != 1 {
+		t.Errorf("Want only the tenant served last kept, have %v", s.lastServed)
+	}
+
+	_, err := s.AddJob(NewPDFPreparer(), 1000, "")
+	if err == nil {
+		t.Error("Want an error for priority 1000, have no error")
+	}
+}
+
+func TestSpoolPostProcessOptions(t *testing.T) {
+	s, dir := newTestSpool(t)
+	defer os.RemoveAll(dir)
+
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>1</html>")))
+	pdfg.Linearize = true
+	pdfg.Watermark.Text = "DRAFT"
+	_, err := s.Add(pdfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, next, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.Linearize || next.Watermark.Text != "DRAFT" {
+		t.Errorf("Want post process options restored, have Linearize %v Watermark %q", next.Linearize, next.Watermark.Text)
+	}
+}
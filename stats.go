@@ -0,0 +1,157 @@
+package wkhtmltopdf
+
+import (
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// statsDurations is the number of process durations which are kept for the averages and percentiles of Stats
+const statsDurations = 1000
+
+// Constants for the types of failures in Stats.Failures
+const (
+	FailureStart  = "start"  // The binary could not be started
+	FailureExit   = "exit"   // The binary exited with an error
+	FailureCrash  = "crash"  // The binary was killed by a signal other than SIGKILL and SIGTERM, see CrashError
+	FailureKilled = "killed" // The binary was killed with SIGKILL or SIGTERM, because the context was done or by the OOM killer, see KilledError
+)
+
+// Stats are counters of the processes which were run since the program started, see GetStats
+type Stats struct {
+	Renders  uint64            `json:"renders"`   // Processes which were started
+	ByBinary map[string]uint64 `json:"by_binary"` // Processes which were started by binary, e.g. wkhtmltopdf or qpdf
+	Failures map[string]uint64 `json:"failures"`  // Processes which failed by type, FailureStart, FailureExit, FailureCrash or FailureKilled
+	Active   int64             `json:"active"`    // Processes which are running
+	Waiting  int64             `json:"waiting"`   // Renders which are waiting for a Limiter
+
+	WarmProcesses int64  `json:"warm_processes"` // Started processes of WarmPools, they are not counted in Renders and Active
+	WarmIdle      int64  `json:"warm_idle"`      // Processes of WarmPools which wait for a PDF
+	WarmRecycled  uint64 `json:"warm_recycled"`  // Processes of WarmPools which were replaced after MaxJobsPerProcess, MaxProcessAge or a failure
+
+	// Durations of the last 1000 processes
+	AverageDuration Duration `json:"average_duration"`
+	P50Duration     Duration `json:"p50_duration"`
+	P95Duration     Duration `json:"p95_duration"`
+	P99Duration     Duration `json:"p99_duration"`
+}
+
+var stats struct {
+	active       int64 // the counters up to warmRecycled are used with atomic and first for their alignment on 32 bit systems
+	waiting      int64
+	warm         int64
+	warmIdle     int64
+	warmRecycled uint64
+	renders      uint64
+	byBinary     map[string]uint64
+	failures     map[string]uint64
+	durations    []time.Duration // ring buffer of the last durations
+	next         int
+	sync.Mutex
+}
+
+// GetStats returns the statistics of the processes which were run since the program started
+func GetStats() Stats {
+	stats.Lock()
+	s := Stats{
+		Renders:  stats.renders,
+		ByBinary: make(map[string]uint64, len(stats.byBinary)),
+		Failures: make(map[string]uint64, len(stats.failures)),
+	}
+	for k, v := range stats.byBinary {
+		s.ByBinary[k] = v
+	}
+	for k, v := range stats.failures {
+		s.Failures[k] = v
+	}
+	durations := append([]time.Duration(nil), stats.durations...)
+	stats.Unlock()
+	s.Active = atomic.LoadInt64(&stats.active)
+	s.Waiting = atomic.LoadInt64(&stats.waiting)
+	s.WarmProcesses = atomic.LoadInt64(&stats.warm)
+	s.WarmIdle = atomic.LoadInt64(&stats.warmIdle)
+	s.WarmRecycled = atomic.LoadUint64(&stats.warmRecycled)
+
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		var total time.Duration
+		for _, d := range durations {
+			total += d
+		}
+		s.AverageDuration = Duration(total / time.Duration(len(durations)))
+		percentile := func(p int) Duration {
+			return Duration(durations[(len(durations)-1)*p/100])
+		}
+		s.P50Duration = percentile(50)
+		s.P95Duration = percentile(95)
+		s.P99Duration = percentile(99)
+	}
+	return s
+}
+
+// StatsHandler returns an http.Handler which writes GetStats as JSON, for a debug endpoint such as /debug/wkhtmltopdf.
+// To publish the stats with expvar instead use
+//
+//	expvar.Publish("wkhtmltopdf", expvar.Func(func() interface{} { return wkhtmltopdf.GetStats() }))
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GetStats())
+	})
+}
+
+// recordStart counts a process of cmd which is started
+func recordStart(cmd *exec.Cmd) {
+	binary := strings.TrimSuffix(filepath.Base(cmd.Path), ".exe")
+	stats.Lock()
+	stats.renders++
+	if stats.byBinary == nil {
+		stats.byBinary = make(map[string]uint64)
+	}
+	stats.byBinary[binary]++
+	stats.Unlock()
+}
+
+// recordEnd counts the result of a process which ran for d, or which did not start
+func recordEnd(d time.Duration, err error, started bool) {
+	stats.Lock()
+	defer stats.Unlock()
+	if started {
+		if len(stats.durations) < statsDurations {
+			stats.durations = append(stats.durations, d)
+		} else {
+			stats.durations[stats.next] = d
+			stats.next = (stats.next + 1) % statsDurations
+		}
+	}
+	if err == nil {
+		return
+	}
+	if stats.failures == nil {
+		stats.failures = make(map[string]uint64)
+	}
+	stats.failures[failureType(err, started)]++
+}
+
+// failureType returns the type of failure of a process which returned err
+func failureType(err error, started bool) string {
+	if !started {
+		return FailureStart
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			if status.Signal() == syscall.SIGKILL || status.Signal() == syscall.SIGTERM {
+				return FailureKilled
+			}
+			return FailureCrash
+		}
+	}
+	return FailureExit
+}
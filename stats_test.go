@@ -0,0 +1,71 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	before := GetStats()
+	runCommand(context.Background(), exec.Command("/bin/true"), nil)
+	runCommand(context.Background(), exec.Command("/bin/false"), nil)
+	runCommand(context.Background(), exec.Command("/bin/sh", "-c", "kill -KILL $$"), nil)
+	runCommand(context.Background(), exec.Command("/bin/sh", "-c", "kill -SEGV $$"), nil)
+	runCommand(context.Background(), exec.Command("/no/such/wkhtmltopdf"), nil)
+
+	s := GetStats()
+	if s.Renders-before.Renders != 4 {
+		t.Errorf("Want 4 renders, have %d", s.Renders-before.Renders)
+	}
+	if s.ByBinary["sh"]-before.ByBinary["sh"] != 2 || s.ByBinary["false"]-before.ByBinary["false"] != 1 {
+		t.Errorf("Want renders by binary, have %v", s.ByBinary)
+	}
+	for _, f := range []string{FailureStart, FailureExit, FailureKilled, FailureCrash} {
+		if s.Failures[f]-before.Failures[f] != 1 {
+			t.Errorf("Want 1 failure of type %s, have %d", f, s.Failures[f]-before.Failures[f])
+		}
+	}
+	if s.Active != 0 {
+		t.Errorf("Want no active processes, have %d", s.Active)
+	}
+	if s.AverageDuration <= 0 || s.P50Duration > s.P95Duration || s.P95Duration > s.P99Duration {
+		t.Errorf("Want durations, have %v %v %v %v", s.AverageDuration, s.P50Duration, s.P95Duration, s.P99Duration)
+	}
+}
+
+func TestStatsDurations(t *testing.T) {
+	stats.Lock()
+	saved, next := stats.durations, stats.next
+	stats.durations, stats.next = nil, 0
+	stats.Unlock()
+	defer func() {
+		stats.Lock()
+		stats.durations, stats.next = saved, next
+		stats.Unlock()
+	}()
+
+	for i := 1; i <= statsDurations+100; i++ {
+		recordEnd(time.Duration(i)*time.Millisecond, nil, true)
+	}
+	s := GetStats()
+	// the first 100 durations are replaced
+	if time.Duration(s.P50Duration) != 600*time.Millisecond || time.Duration(s.P99Duration) != 1090*time.Millisecond {
+		t.Errorf("Want p50 600ms and p99 1.09s, have %s and %s", time.Duration(s.P50Duration), time.Duration(s.P99Duration))
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	StatsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/wkhtmltopdf", nil))
+	var s map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s["renders"]; !ok {
+		t.Errorf("Want renders in stats, have %s", rec.Body.String())
+	}
+}
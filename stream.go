@@ -0,0 +1,140 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// loadingBanner starts the progress text which some builds of wkhtmltoimage write to stdout, also in quiet mode
+const loadingBanner = "Loading page"
+
+// ErrContaminatedOutput is returned when wkhtmltoimage wrote text into the image on stdout and StdoutStrategy is StdoutPipe
+var ErrContaminatedOutput = errors.New("wkhtmltoimage wrote text into the image on stdout")
+
+// imageMagic holds the bytes every image of a format starts with
+var imageMagic = map[string][]byte{
+	"png": []byte(pngMagic),
+	"jpg": {0xff, 0xd8, 0xff},
+}
+
+// imageWriter is an io.Writer which writes the output of wkhtmltoimage to w, starting at the magic bytes of the image format.
+// Some versions of wkhtmltoimage write text to stdout before the image, which is dropped without buffering the image.
+// Formats without magic bytes can not be separated from the text, nothing is written to w when they start with loadingBanner.
+type imageWriter struct {
+	w      io.Writer
+	magic  []byte // nil when the start of the image is found or the format has no magic bytes
+	banner []byte // loadingBanner for formats without magic bytes, nil when the start of the output is checked
+	buf    []byte // the output before the magic bytes, only the last bytes which can be the start of the magic bytes are kept
+	n      int64  // number of bytes written to the imageWriter
+
+	contaminated bool // the image could not be separated from the text, see flush
+}
+
+// newImageWriter returns an imageWriter which writes images of format to w
+func newImageWriter(w io.Writer, format string) *imageWriter {
+	iw := &imageWriter{w: w, magic: imageMagic[format]}
+	if iw.magic == nil {
+		iw.banner = []byte(loadingBanner)
+	}
+	return iw
+}
+
+func (iw *imageWriter) Write(p []byte) (int, error) {
+	iw.n += int64(len(p))
+	if iw.contaminated {
+		return len(p), nil
+	}
+	if iw.banner != nil {
+		iw.buf = append(iw.buf, p...)
+		n := len(iw.buf)
+		if n > len(iw.banner) {
+			n = len(iw.banner)
+		}
+		if bytes.Equal(iw.buf[:n], iw.banner[:n]) {
+			if n == len(iw.banner) {
+				iw.contaminated, iw.buf = true, nil
+			}
+			return len(p), nil
+		}
+		return iw.writeBuffered(len(p))
+	}
+	if iw.magic == nil {
+		return iw.w.Write(p)
+	}
+	iw.buf = append(iw.buf, p...)
+	i := bytes.Index(iw.buf, iw.magic)
+	if i < 0 {
+		if keep := len(iw.magic) - 1; len(iw.buf) > keep {
+			iw.buf = iw.buf[:copy(iw.buf, iw.buf[len(iw.buf)-keep:])]
+		}
+		return len(p), nil
+	}
+	iw.buf = iw.buf[i:]
+	iw.magic = nil
+	return iw.writeBuffered(len(p))
+}
+
+// writeBuffered writes the buffered output to w and returns n
+func (iw *imageWriter) writeBuffered(n int) (int, error) {
+	_, err := iw.w.Write(iw.buf)
+	iw.banner, iw.buf = nil, nil
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// flush writes output which is shorter than loadingBanner, and marks the output as contaminated when
+// something was written but the magic bytes were not found. It is called after wkhtmltoimage exits.
+func (iw *imageWriter) flush() error {
+	if iw.magic != nil && iw.n > 0 {
+		iw.contaminated = true
+	}
+	if iw.contaminated || iw.banner == nil {
+		return nil
+	}
+	_, err := iw.writeBuffered(0)
+	return err
+}
+
+// renderTempOutput is like runImage but wkhtmltoimage writes the image to a temporary file instead of stdout, which is read back.
+// args must end with the output "-".
+func renderTempOutput(ctx context.Context, options *ImageOptions, args []string) (*ImageResult, error) {
+	format := options.Format
+	if format == "" {
+		format = "png"
+	}
+	f, err := ioutil.TempFile(options.WorkDir, tempPrefix(ctx, "wkhtmltoimage")+"*."+format)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	// the path must not be relative to WorkDir, which is the working directory of wkhtmltoimage
+	output, err := filepath.Abs(f.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	tempArgs := append([]string(nil), args...)
+	tempArgs[len(tempArgs)-1] = output
+	res, err := runImage(ctx, options, tempArgs)
+	if err != nil {
+		return res, err
+	}
+	image, err := ioutil.ReadFile(output)
+	if err != nil {
+		return res, err
+	}
+	if options.OutputWriter != nil {
+		_, err = options.OutputWriter.Write(image)
+		return res, err
+	}
+	res.Image = image
+	return res, nil
+}
@@ -0,0 +1,200 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImageWriter(t *testing.T) {
+	magic := "\x89PNG\r\n\x1a\n"
+	buf := &bytes.Buffer{}
+	iw := newImageWriter(buf, "png")
+	// the magic bytes are split over two writes
+	for _, p := range []string{"Loading page (1/2)\n\x89PN", "G\r\n\x1a\nimage", "data"} {
+		n, err := iw.Write([]byte(p))
+		if err != nil || n != len(p) {
+			t.Fatalf("Want %d bytes written, have %d, %v", len(p), n, err)
+		}
+	}
+	if buf.String() != magic+"imagedata" {
+		t.Errorf("Want PNG without prefix, have %q", buf.String())
+	}
+
+	buf.Reset()
+	iw = newImageWriter(buf, "svg")
+	iw.Write([]byte("<svg></svg>"))
+	if buf.String() != "<svg></svg>" {
+		t.Errorf("Want <svg></svg>, have %q", buf.String())
+	}
+}
+
+func TestImageWriterContaminated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	iw := newImageWriter(buf, "svg")
+	iw.Write([]byte("Loading "))
+	iw.Write([]byte("page (1/2)\n<svg></svg>"))
+	if err := iw.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !iw.contaminated || buf.Len() != 0 {
+		t.Errorf("Want contaminated output without writes, have %t %q", iw.contaminated, buf.String())
+	}
+
+	// the magic bytes are not found
+	iw = newImageWriter(buf, "png")
+	iw.Write([]byte("Loading page (1/2)\n\x89PN_broken"))
+	iw.flush()
+	if !iw.contaminated || buf.Len() != 0 {
+		t.Errorf("Want contaminated output without writes, have %t %q", iw.contaminated, buf.String())
+	}
+
+	// short output is written when wkhtmltoimage exits
+	iw = newImageWriter(buf, "svg")
+	iw.Write([]byte("Load"))
+	iw.flush()
+	if iw.contaminated || buf.String() != "Load" {
+		t.Errorf("Want Load, have %t %q", iw.contaminated, buf.String())
+	}
+}
+
+func TestRenderImageContaminatedStdout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the binary writes the banner into stdout, but not into an output file
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	script := "#!/bin/bash\nargs=(\"$@\")\nout=\"${args[-1]}\"\n" +
+		"if [ \"$out\" = - ]; then echo 'Loading page (1/2)'; echo '<svg></svg>'; else echo '<svg></svg>' > \"$out\"; fi\n"
+	err = ioutil.WriteFile(bin, []byte(script), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, strategy := range []string{"", StdoutTempFile} {
+		options := &ImageOptions{BinaryPath: bin, Input: "http://example.com", Format: "svg", WorkDir: dir, StdoutStrategy: strategy}
+		res, err := RenderImage(context.Background(), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res.Image) != "<svg></svg>\n" {
+			t.Errorf("Want <svg></svg> with strategy %q, have %q", strategy, res.Image)
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.svg"))
+	if len(files) != 0 {
+		t.Errorf("Want temporary file removed, have %v", files)
+	}
+
+	options := &ImageOptions{BinaryPath: bin, Input: "http://example.com", Format: "svg", StdoutStrategy: StdoutPipe}
+	_, err = RenderImage(context.Background(), options)
+	if err != ErrContaminatedOutput {
+		t.Errorf("Want ErrContaminatedOutput, have %v", err)
+	}
+}
+
+func TestRenderImageFileOutputStdout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the binary always writes the banner to stdout and counts its runs
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	script := "#!/bin/bash\nargs=(\"$@\")\necho run >> runs\necho 'Loading page (1/2)'\necho '<svg></svg>' > \"${args[-1]}\"\n"
+	err = ioutil.WriteFile(bin, []byte(script), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, strategy := range []string{"", StdoutPipe} {
+		os.Remove(filepath.Join(dir, "runs"))
+		options := &ImageOptions{BinaryPath: bin, Input: "http://example.com", Output: "out.svg", WorkDir: dir, StdoutStrategy: strategy}
+		res, err := RenderImage(context.Background(), options)
+		if err != nil {
+			t.Fatalf("Want no error with strategy %q, have %v", strategy, err)
+		}
+		if len(res.Image) != 0 {
+			t.Errorf("Want no image with an output file, have %q", res.Image)
+		}
+		if runs, _ := ioutil.ReadFile(filepath.Join(dir, "runs")); string(runs) != "run\n" {
+			t.Errorf("Want one run with strategy %q, have %q", strategy, runs)
+		}
+	}
+}
+
+// benchmarkImage returns a large PNG screenshot with text before it, like some versions of wkhtmltoimage write it
+func benchmarkImage(b *testing.B) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 1920, 4000))
+	for y := 0; y < 4000; y++ {
+		for x := 0; x < 1920; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+	buf := bytes.NewBufferString("Loading page (1/2)\n")
+	err := png.Encode(buf, img)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// decodeOutput is the previous way of removing the text, which buffers, decodes and encodes the whole image
+func decodeOutput(img []byte, format string) []byte {
+	buf := new(bytes.Buffer)
+	switch format {
+	case "png":
+		decoded, err := png.Decode(bytes.NewReader(img))
+		for err != nil && len(img) > 1 {
+			img = img[1:]
+			decoded, err = png.Decode(bytes.NewReader(img))
+		}
+		png.Encode(buf, decoded)
+	case "jpg":
+		decoded, err := jpeg.Decode(bytes.NewReader(img))
+		for err != nil && len(img) > 1 {
+			img = img[1:]
+			decoded, err = jpeg.Decode(bytes.NewReader(img))
+		}
+		jpeg.Encode(buf, decoded, nil)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkImageOutputDecode(b *testing.B) {
+	output := benchmarkImage(b)
+	b.SetBytes(int64(len(output)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ioutil.Discard.Write(decodeOutput(output, "png"))
+	}
+}
+
+func BenchmarkImageOutputStream(b *testing.B) {
+	output := benchmarkImage(b)
+	b.SetBytes(int64(len(output)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iw := newImageWriter(ioutil.Discard, "png")
+		// exec copies stdout in chunks of 32KB
+		for p := output; len(p) > 0; {
+			n := 32 * 1024
+			if n > len(p) {
+				n = len(p)
+			}
+			iw.Write(p[:n])
+			p = p[n:]
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// TemplateStore parses the html/template files in a directory once and renders them to PDFs and images,
+// so services which render the same invoice template many times do not parse it for each render.
+// Each template is parsed together with the layouts and partials matched by Shared, and is executed by its file name,
+// so a template can define blocks and call a layout with {{template "layout.html" .}}.
+// A TemplateStore is safe for concurrent use, the fields should not be changed after the first render.
+type TemplateStore struct {
+	Dir    string           // Directory of the templates, also the working directory of the renders when it is not set
+	Shared []string         // Glob patterns of layouts and partials relative to Dir, e.g. "layouts/*.html", "partials/*.html"
+	Funcs  template.FuncMap // Functions which can be used in the templates
+
+	shared    *template.Template
+	templates map[string]*template.Template
+	mu        sync.Mutex
+}
+
+// NewTemplateStore returns a TemplateStore for the templates in dir with the layouts and partials matched by the shared patterns
+func NewTemplateStore(dir string, shared ...string) *TemplateStore {
+	return &TemplateStore{Dir: dir, Shared: shared}
+}
+
+// Template returns the parsed template of the file name relative to Dir, which is parsed when it is used the first time
+func (s *TemplateStore) Template(name string) (*template.Template, error) {
+	name = path.Clean("/" + name)[1:]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.templates[name]; ok {
+		return t, nil
+	}
+	if s.shared == nil {
+		shared := template.New("").Funcs(s.Funcs)
+		for _, pattern := range s.Shared {
+			files, err := filepath.Glob(filepath.Join(s.Dir, filepath.FromSlash(pattern)))
+			if err != nil {
+				return nil, err
+			}
+			if len(files) == 0 {
+				continue
+			}
+			if _, err := shared.ParseFiles(files...); err != nil {
+				return nil, fmt.Errorf("error parsing templates %s: %s", pattern, err)
+			}
+		}
+		s.shared = shared
+	}
+	t, err := s.shared.Clone()
+	if err != nil {
+		return nil, err
+	}
+	t, err = t.ParseFiles(filepath.Join(s.Dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %s", name, err)
+	}
+	t = t.Lookup(path.Base(name))
+	if s.templates == nil {
+		s.templates = make(map[string]*template.Template)
+	}
+	s.templates[name] = t
+	return t, nil
+}
+
+// Reset removes the parsed templates, so they are parsed again from the files when they are used
+func (s *TemplateStore) Reset() {
+	s.mu.Lock()
+	s.shared = nil
+	s.templates = nil
+	s.mu.Unlock()
+}
+
+// Execute returns the HTML of the template with data
+func (s *TemplateStore) Execute(name string, data interface{}) ([]byte, error) {
+	t, err := s.Template(name)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("error executing template %s: %s", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPDF adds the HTML of the template with data as a PageReader page to pdfg and creates the PDF with CreateContext.
+// The other options and pages of pdfg are used as they are set.
+func (s *TemplateStore) RenderPDF(ctx context.Context, pdfg *PDFGenerator, name string, data interface{}) error {
+	html, err := s.Execute(name, data)
+	if err != nil {
+		return err
+	}
+	if pdfg.workDir == "" {
+		pdfg.SetWorkDir(s.Dir)
+	}
+	pdfg.AddPage(NewPageReader(bytes.NewReader(html)))
+	return pdfg.CreateContext(ctx)
+}
+
+// RenderImage renders the HTML of the template with data with RenderImage, Input and Html of options are set
+func (s *TemplateStore) RenderImage(ctx context.Context, options *ImageOptions, name string, data interface{}) (*ImageResult, error) {
+	html, err := s.Execute(name, data)
+	if err != nil {
+		return nil, err
+	}
+	options.Input = "-"
+	options.Html = string(html)
+	if options.WorkDir == "" {
+		options.WorkDir = s.Dir
+	}
+	return RenderImage(ctx, options)
+}
@@ -0,0 +1,95 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "layouts"), 0700)
+	os.Mkdir(filepath.Join(dir, "partials"), 0700)
+	files := map[string]string{
+		"layouts/base.html":    `<html><body>{{block "content" .}}{{end}}{{template "footer.html" .}}</body></html>`,
+		"partials/footer.html": `<p>{{.Company | upper}}</p>`,
+		"invoice.html":         `{{define "content"}}<h1>Invoice {{.Number}}</h1>{{end}}{{template "base.html" .}}`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewTemplateStore(dir, "layouts/*.html", "partials/*.html", "missing/*.html")
+	s.Funcs = template.FuncMap{"upper": strings.ToUpper}
+	data := map[string]interface{}{"Number": 42, "Company": "Acme & Co"}
+
+	html, err := s.Execute("invoice.html", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<html><body><h1>Invoice 42</h1><p>ACME &amp; CO</p></body></html>"
+	if string(html) != want {
+		t.Errorf("Execute = %q, want %q", html, want)
+	}
+
+	// the parsed template is cached until Reset
+	ioutil.WriteFile(filepath.Join(dir, "invoice.html"), []byte(`changed`), 0600)
+	if html, _ := s.Execute("invoice.html", data); string(html) != want {
+		t.Errorf("Execute after change = %q, want cached %q", html, want)
+	}
+	s.Reset()
+	if html, _ := s.Execute("invoice.html", data); string(html) != "changed" {
+		t.Errorf("Execute after Reset = %q, want changed", html)
+	}
+
+	// names can not escape the directory
+	if _, err := s.Execute("../invoice.html", data); err != nil {
+		t.Errorf("Execute ../invoice.html: %s", err)
+	}
+	if _, err := s.Execute("missing.html", data); err == nil {
+		t.Error("Execute of a missing template did not return an error")
+	}
+}
+
+func TestTemplateStoreRenderImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "card.html"), []byte(`<svg>{{.}}</svg>`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// the binary prints its working directory and stdin
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/bash\npwd\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	s := NewTemplateStore(dir)
+	result, err := s.RenderImage(context.Background(), &ImageOptions{BinaryPath: bin.Name()}, "card.html", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(result.Image)
+	if !strings.Contains(got, "<svg>hello</svg>") {
+		t.Errorf("image = %q, want the HTML of the template", got)
+	}
+	real, _ := filepath.EvalSymlinks(dir)
+	if !strings.HasPrefix(got, real+"\n") && !strings.HasPrefix(got, dir+"\n") {
+		t.Errorf("image = %q, want the working directory %s", got, dir)
+	}
+}
@@ -0,0 +1,68 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Timing is the time a render spent in each stage, see ImageResult.Timing, PDFGenerator.Timing and WithTiming
+type Timing struct {
+	Queue       time.Duration // Waiting for the rate and tenant limits of a Limiter
+	Exec        time.Duration // Running wkhtmltopdf or wkhtmltoimage
+	Decode      time.Duration // Decoding the image for post processing and the blank check
+	PostProcess time.Duration // Post processing such as converting images, PDF/A, watermarks and signatures, without Decode
+	Upload      time.Duration // Publishing the output of a Worker job
+}
+
+// Total returns the time of all stages
+func (t Timing) Total() time.Duration {
+	return t.Queue + t.Exec + t.Decode + t.PostProcess + t.Upload
+}
+
+// add adds the time of all stages of o to t
+func (t *Timing) add(o Timing) {
+	t.Queue += o.Queue
+	t.Exec += o.Exec
+	t.Decode += o.Decode
+	t.PostProcess += o.PostProcess
+	t.Upload += o.Upload
+}
+
+// timingKey is the context key of the timing recorder
+type timingKey struct{}
+
+// timingRecorder holds the timing of the renders using a context
+type timingRecorder struct {
+	t Timing
+	sync.Mutex
+}
+
+// WithTiming returns a context which records the time of each stage of the renders and Limiter calls using it,
+// read with ContextTiming. The times of renders which use the context at the same time are added up.
+// A Worker sets a timing on the context of each job, see Worker.OnTiming.
+func WithTiming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timingKey{}, &timingRecorder{})
+}
+
+// ContextTiming returns the timing recorded in a context created with WithTiming, or an empty Timing
+func ContextTiming(ctx context.Context) Timing {
+	r, ok := ctx.Value(timingKey{}).(*timingRecorder)
+	if !ok {
+		return Timing{}
+	}
+	r.Lock()
+	defer r.Unlock()
+	return r.t
+}
+
+// addTiming adds t to the timing of ctx when it was created with WithTiming
+func addTiming(ctx context.Context, t Timing) {
+	r, ok := ctx.Value(timingKey{}).(*timingRecorder)
+	if !ok {
+		return
+	}
+	r.Lock()
+	r.t.add(t)
+	r.Unlock()
+}
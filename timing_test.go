@@ -0,0 +1,67 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTiming(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "page.png"), encodeTestPage(t, testPage(40, 30, 10)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\nsleep 0.05\ncat page.png\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithTiming(context.Background())
+	var res *ImageResult
+	err = (&Limiter{}).DoContext(ctx, "", func(ctx context.Context) error {
+		var err error
+		res, err = RenderImage(ctx, &ImageOptions{BinaryPath: bin, Input: "http://example.com", WorkDir: dir, BlankCheck: &BlankCheck{}})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Timing.Exec < 50*time.Millisecond || res.Timing.Decode == 0 || res.Timing.PostProcess == 0 {
+		t.Errorf("Want exec, decode and post process time, have %+v", res.Timing)
+	}
+	timing := ContextTiming(ctx)
+	if timing.Exec != res.Timing.Exec || timing.Decode != res.Timing.Decode || timing.Queue == 0 {
+		t.Errorf("Want timing of the render and the queue in the context, have %+v", timing)
+	}
+	if timing.Total() != timing.Queue+timing.Exec+timing.Decode+timing.PostProcess {
+		t.Errorf("Want total of the stages, have %s", timing.Total())
+	}
+	if (ContextTiming(context.Background()) != Timing{}) {
+		t.Error("Want no timing without WithTiming")
+	}
+}
+
+func TestPDFGeneratorTiming(t *testing.T) {
+	bin := testCapabilitiesBinary(t)
+	defer os.Remove(bin)
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin
+	pdfg.AddPage(NewPage("https://example.com"))
+	pdfg.SetErrorOutput(ioutil.Discard)
+	if err := pdfg.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if pdfg.Timing().Exec == 0 || pdfg.Timing().PostProcess != 0 {
+		t.Errorf("Want exec time without post processing, have %+v", pdfg.Timing())
+	}
+}
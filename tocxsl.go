@@ -0,0 +1,59 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// DumpDefaultTOCXSL writes the XSL style sheet which wkhtmltopdf uses for the table of contents to w. The wkhtmltopdf
+// binary is found like in NewPDFGenerator. Change the style sheet and pass it back with SetTOCStyleSheet
+func DumpDefaultTOCXSL(ctx context.Context, w io.Writer) error {
+	pdfg := NewPDFPreparer()
+	if err := pdfg.findPath(); err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pdfg.binPath, "--dump-default-toc-xsl")
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return canceled(ctx)
+		}
+		return fmt.Errorf("error dumping the TOC style sheet of %s: %s %s", pdfg.binPath, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// SetTOCStyleSheet sets the XSL style sheet for the table of contents, for example a changed copy of the style sheet
+// from DumpDefaultTOCXSL. It is written to a temporary file when the PDF is created, which replaces TOC.XslStyleSheet.
+// Set it to nil to use TOC.XslStyleSheet again
+func (pdfg *PDFGenerator) SetTOCStyleSheet(xsl []byte) {
+	pdfg.tocXSL = xsl
+}
+
+// useTOCStyleSheet writes the style sheet of SetTOCStyleSheet to a temporary file and sets TOC.XslStyleSheet to it.
+// The returned function removes the file and restores TOC.XslStyleSheet, call it when wkhtmltopdf is done
+func (pdfg *PDFGenerator) useTOCStyleSheet(ctx context.Context) (func(), error) {
+	if pdfg.tocXSL == nil || !pdfg.TOC.Include {
+		return func() {}, nil
+	}
+	if len(bytes.TrimSpace(pdfg.tocXSL)) == 0 {
+		return nil, errors.New("the TOC style sheet is empty")
+	}
+	name, err := writeTempFile(ctx, "", bytes.NewReader(pdfg.tocXSL), "*.xsl")
+	if err != nil {
+		return nil, err
+	}
+	xsl := pdfg.TOC.XslStyleSheet
+	pdfg.TOC.XslStyleSheet.Set(name)
+	return func() {
+		pdfg.TOC.XslStyleSheet = xsl
+		os.Remove(name)
+	}, nil
+}
@@ -0,0 +1,67 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testTOCXSL = `<?xml version="1.0" encoding="UTF-8"?><xsl:stylesheet version="2.0"/>`
+
+// testTOCBinary writes a binary which dumps testTOCXSL and prints the style sheet it renders the TOC with
+func testTOCBinary(t *testing.T) string {
+	f, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("#!/bin/sh\n[ \"$1\" = --dump-default-toc-xsl ] && { printf '%s' '" + testTOCXSL + "'; exit; }\n" +
+		"cat > /dev/null\nwhile [ $# -gt 0 ]; do [ \"$1\" = --xsl-style-sheet ] && cat \"$2\"; shift; done\n")
+	f.Close()
+	os.Chmod(f.Name(), 0700)
+	return f.Name()
+}
+
+func TestDumpDefaultTOCXSL(t *testing.T) {
+	bin := testTOCBinary(t)
+	defer os.Remove(bin)
+	defer SetPath(GetWKHTMLToPDFPath())
+	SetPath(bin)
+
+	var buf bytes.Buffer
+	if err := DumpDefaultTOCXSL(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != testTOCXSL {
+		t.Errorf("Want the default style sheet, have %q", buf.String())
+	}
+}
+
+func TestSetTOCStyleSheet(t *testing.T) {
+	bin := testTOCBinary(t)
+	defer os.Remove(bin)
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin
+	pdfg.TOC.Include = true
+	pdfg.TOC.XslStyleSheet.Set("toc.xsl")
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html><h1>Hi</h1></html>")))
+	xsl := strings.Replace(testTOCXSL, "2.0", "1.0", 1)
+	pdfg.SetTOCStyleSheet([]byte(xsl))
+	if err := pdfg.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if pdfg.Buffer().String() != xsl {
+		t.Errorf("Want the style sheet of SetTOCStyleSheet, have %q", pdfg.Buffer().String())
+	}
+	if pdfg.TOC.XslStyleSheet.value != "toc.xsl" {
+		t.Errorf("Want TOC.XslStyleSheet restored, have %q", pdfg.TOC.XslStyleSheet.value)
+	}
+
+	pdfg.SetTOCStyleSheet([]byte(" \n"))
+	if err := pdfg.Create(); err == nil {
+		t.Error("Want error for an empty style sheet")
+	}
+}
@@ -0,0 +1,109 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// Transformer changes the HTML or the output of jobs which have its name in Job.Transforms,
+// for example to inline stylesheets before rendering or to convert images after rendering. Either function can be nil.
+type Transformer struct {
+	// HTML changes the HTML of the pages of a PDF which are read from a PageReader,
+	// or of an image which is read from ImageOptions.Html
+	HTML func(ctx context.Context, html []byte) ([]byte, error)
+	// Output changes the PDF or image
+	Output func(ctx context.Context, output []byte) ([]byte, error)
+}
+
+var transformers struct {
+	m map[string]Transformer
+	sync.Mutex
+}
+
+// RegisterTransformer registers a transformer with a name, for example "inline-assets", which can be used in
+// Job.Transforms and in the "transforms" of the JSON job format. Transformers should be registered when the program
+// starts, registering a name again replaces the transformer.
+func RegisterTransformer(name string, t Transformer) {
+	transformers.Lock()
+	defer transformers.Unlock()
+	if transformers.m == nil {
+		transformers.m = make(map[string]Transformer)
+	}
+	transformers.m[name] = t
+}
+
+// lookupTransformers returns the transformers with names in order
+func lookupTransformers(names []string) ([]Transformer, error) {
+	transformers.Lock()
+	defer transformers.Unlock()
+	list := make([]Transformer, 0, len(names))
+	for _, name := range names {
+		t, ok := transformers.m[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown transformer %q", name)
+		}
+		list = append(list, t)
+	}
+	return list, nil
+}
+
+// transformHTML applies the HTML functions of the transformers to the HTML input of the job
+func (j *Job) transformHTML(ctx context.Context, list []Transformer) error {
+	transform := func(html []byte) ([]byte, error) {
+		for _, t := range list {
+			if t.HTML == nil {
+				continue
+			}
+			var err error
+			html, err = t.HTML(ctx, html)
+			if err != nil {
+				return nil, fmt.Errorf("error transforming HTML: %s", err)
+			}
+		}
+		return html, nil
+	}
+
+	switch {
+	case j.PDF != nil:
+		for _, p := range j.PDF.pages {
+			pr, ok := p.(*PageReader)
+			if !ok || pr.Input == nil {
+				continue
+			}
+			html, err := ioutil.ReadAll(pr.Input)
+			if err != nil {
+				return err
+			}
+			html, err = transform(html)
+			if err != nil {
+				return err
+			}
+			pr.Input = bytes.NewReader(html)
+		}
+	case j.Image != nil && j.Image.Html != "":
+		html, err := transform([]byte(j.Image.Html))
+		if err != nil {
+			return err
+		}
+		j.Image.Html = string(html)
+	}
+	return nil
+}
+
+// transformOutput applies the Output functions of the transformers to output
+func transformOutput(ctx context.Context, list []Transformer, output []byte) ([]byte, error) {
+	for _, t := range list {
+		if t.Output == nil {
+			continue
+		}
+		var err error
+		output, err = t.Output(ctx, output)
+		if err != nil {
+			return nil, fmt.Errorf("error transforming output: %s", err)
+		}
+	}
+	return output, nil
+}
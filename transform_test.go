@@ -0,0 +1,94 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestJobTransforms(t *testing.T) {
+	defer func(m map[string]Transformer) { transformers.m = m }(transformers.m)
+	transformers.m = nil
+	RegisterTransformer("upper", Transformer{
+		HTML: func(ctx context.Context, html []byte) ([]byte, error) {
+			return bytes.ToUpper(html), nil
+		},
+	})
+	RegisterTransformer("frame", Transformer{
+		HTML: func(ctx context.Context, html []byte) ([]byte, error) {
+			return append([]byte("<svg>"), append(html, "</svg>"...)...), nil
+		},
+		Output: func(ctx context.Context, output []byte) ([]byte, error) {
+			return append(output, "<!-- framed -->"...), nil
+		},
+	})
+
+	// the binary prints its input
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	job := &Job{
+		Image:      &ImageOptions{BinaryPath: bin.Name(), Input: "-", Html: "hi", Format: "svg"},
+		Transforms: []string{"upper", "frame"},
+	}
+	output, err := job.Render(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<svg>HI</svg><!-- framed -->"; string(output) != want {
+		t.Errorf("Want %s, have %s", want, output)
+	}
+	if job.Image.Html != "hi" {
+		t.Errorf("Want the HTML of the job unchanged, have %s", job.Image.Html)
+	}
+
+	job.Transforms = []string{"webp"}
+	_, err = job.Render(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `unknown transformer "webp"`) {
+		t.Errorf("Want unknown transformer error, have %v", err)
+	}
+}
+
+func TestTransformPDFPages(t *testing.T) {
+	defer func(m map[string]Transformer) { transformers.m = m }(transformers.m)
+	transformers.m = nil
+	RegisterTransformer("fail", Transformer{
+		HTML: func(ctx context.Context, html []byte) ([]byte, error) {
+			if string(html) == "<html>bad</html>" {
+				return nil, errors.New("bad page")
+			}
+			return bytes.Replace(html, []byte("Hi"), []byte("Hello"), 1), nil
+		},
+	})
+	list, err := lookupTransformers([]string{"fail"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	pdfg.AddPage(NewPage("http://example.com"))
+	job := &Job{PDF: pdfg}
+	if err := job.transformHTML(context.Background(), list); err != nil {
+		t.Fatal(err)
+	}
+	html, _ := ioutil.ReadAll(pdfg.pages[0].Reader())
+	if string(html) != "<html>Hello</html>" {
+		t.Errorf("Want <html>Hello</html>, have %s", html)
+	}
+
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>bad</html>")))
+	if err := job.transformHTML(context.Background(), list); err == nil {
+		t.Error("Want error from transformer")
+	}
+}
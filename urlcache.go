@@ -0,0 +1,122 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// URLCache caches the output of renders of URL inputs for TTL, so a page which is requested often is not rendered each time.
+// Use one URLCache for each set of render options, the output is only stored by URL.
+type URLCache struct {
+	TTL time.Duration
+	// Revalidate sends a HEAD request with If-None-Match and If-Modified-Since when the output of a page has expired, if the ETag
+	// or Last-Modified header of the page did not change the output is kept for another TTL instead of rendering the page again
+	Revalidate bool
+	// Client is used for the HEAD requests, default http.DefaultClient
+	Client *http.Client
+
+	entries map[string]*urlCacheEntry
+	mu      sync.Mutex
+}
+
+// urlCacheEntry is the output of a URL with the validators of the page when it was rendered
+type urlCacheEntry struct {
+	output       []byte
+	expires      time.Time
+	etag         string
+	lastModified string
+}
+
+// NewURLCache returns a URLCache which keeps the output for ttl
+func NewURLCache(ttl time.Duration) *URLCache {
+	return &URLCache{TTL: ttl, entries: make(map[string]*urlCacheEntry)}
+}
+
+// Render returns the cached output of url, or calls render and caches its output if there is none or it has expired.
+// An error of render is returned and not cached.
+func (c *URLCache) Render(ctx context.Context, url string, render func() ([]byte, error)) ([]byte, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e := c.entries[url]
+	c.mu.Unlock()
+	if e != nil && now.Before(e.expires) {
+		return e.output, nil
+	}
+
+	var etag, lastModified string
+	if c.Revalidate {
+		var notModified bool
+		notModified, etag, lastModified = c.revalidate(ctx, url, e)
+		if notModified {
+			c.put(url, &urlCacheEntry{output: e.output, expires: now.Add(c.TTL), etag: etag, lastModified: lastModified})
+			return e.output, nil
+		}
+	}
+
+	output, err := render()
+	if err != nil {
+		return nil, err
+	}
+	c.put(url, &urlCacheEntry{output: output, expires: now.Add(c.TTL), etag: etag, lastModified: lastModified})
+	return output, nil
+}
+
+// revalidate sends a HEAD request for url and returns the ETag and Last-Modified headers of the page, notModified is true
+// when e is not nil and the page did not change since e was rendered. A failed request is the same as a changed page.
+func (c *URLCache) revalidate(ctx context.Context, url string, e *urlCacheEntry) (notModified bool, etag, lastModified string) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return false, "", ""
+	}
+	if e != nil && e.etag != "" {
+		req.Header.Set("If-None-Match", e.etag)
+	}
+	if e != nil && e.lastModified != "" {
+		req.Header.Set("If-Modified-Since", e.lastModified)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, "", ""
+	}
+	resp.Body.Close()
+	if e != nil && resp.StatusCode == http.StatusNotModified {
+		return true, e.etag, e.lastModified
+	}
+	etag, lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if e != nil && (etag != "" && etag == e.etag || etag == "" && lastModified != "" && lastModified == e.lastModified) {
+		return true, etag, lastModified
+	}
+	return false, etag, lastModified
+}
+
+// Remove removes the output of url, so it is rendered again
+func (c *URLCache) Remove(url string) {
+	c.mu.Lock()
+	delete(c.entries, url)
+	c.mu.Unlock()
+}
+
+// put stores e and removes the expired entries, with Revalidate they are kept for another TTL so they can be revalidated
+func (c *URLCache) put(url string, e *urlCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*urlCacheEntry)
+	}
+	cutoff := time.Now()
+	if c.Revalidate {
+		cutoff = cutoff.Add(-c.TTL)
+	}
+	for k, old := range c.entries {
+		if !cutoff.Before(old.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[url] = e
+}
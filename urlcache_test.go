@@ -0,0 +1,86 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestURLCache(t *testing.T) {
+	renders := 0
+	render := func() ([]byte, error) {
+		renders++
+		return []byte("output"), nil
+	}
+	c := NewURLCache(time.Hour)
+	for i := 0; i < 2; i++ {
+		output, err := c.Render(context.Background(), "https://example.com", render)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(output) != "output" {
+			t.Errorf("Want output, have %s", output)
+		}
+	}
+	if renders != 1 {
+		t.Errorf("Want 1 render, have %d", renders)
+	}
+
+	c.entries["https://example.com"].expires = time.Now()
+	c.Render(context.Background(), "https://example.com", render)
+	if renders != 2 {
+		t.Errorf("Want 2 renders after the output expired, have %d", renders)
+	}
+
+	c.Remove("https://example.com")
+	_, err := c.Render(context.Background(), "https://example.com", func() ([]byte, error) { return nil, errors.New("failed") })
+	if err == nil {
+		t.Error("Want error of render")
+	}
+	if _, ok := c.entries["https://example.com"]; ok {
+		t.Error("Want failed render not cached")
+	}
+}
+
+func TestURLCacheRevalidate(t *testing.T) {
+	etag := `"v1"`
+	heads := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads++
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+	}))
+	defer ts.Close()
+
+	renders := 0
+	render := func() ([]byte, error) {
+		renders++
+		return []byte(etag), nil
+	}
+	c := NewURLCache(time.Hour)
+	c.Revalidate = true
+	c.Render(context.Background(), ts.URL, render)
+	c.entries[ts.URL].expires = time.Now()
+	output, _ := c.Render(context.Background(), ts.URL, render)
+	if renders != 1 || string(output) != `"v1"` {
+		t.Errorf("Want 1 render for an unchanged page, have %d with %s", renders, output)
+	}
+
+	etag = `"v2"`
+	c.entries[ts.URL].expires = time.Now()
+	output, _ = c.Render(context.Background(), ts.URL, render)
+	if renders != 2 || string(output) != `"v2"` {
+		t.Errorf("Want 2 renders for a changed page, have %d with %s", renders, output)
+	}
+	if heads != 3 {
+		t.Errorf("Want 3 HEAD requests, have %d", heads)
+	}
+}
@@ -0,0 +1,45 @@
+package wkhtmltopdf
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// viewportRe matches the viewport sizes wkhtmltopdf accepts, width x height in pixels
+var viewportRe = regexp.MustCompile(`^(\d+)x(\d+)$`)
+
+// SetViewport sets ViewportSize to width x height pixels, for example 1280 x 1024 to render a responsive layout
+// at its desktop breakpoint. The viewport is the window size of the page, it does not change the paper size.
+func (popt *pageOptions) SetViewport(width, height uint) {
+	popt.ViewportSize.Set(fmt.Sprintf("%dx%d", width, height))
+}
+
+// Viewport returns the width and height of ViewportSize in pixels, ok is false when it is not set or invalid
+func (popt *pageOptions) Viewport() (width, height uint, ok bool) {
+	m := viewportRe.FindStringSubmatch(popt.ViewportSize.value)
+	if m == nil {
+		return 0, 0, false
+	}
+	w, err := strconv.ParseUint(m[1], 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	h, err := strconv.ParseUint(m[2], 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint(w), uint(h), true
+}
+
+// checkViewport returns an error if ViewportSize is set and is not width x height, wkhtmltopdf ignores an invalid size
+// and renders at its default window size, which uses another breakpoint of a responsive layout without an error
+func (popt *pageOptions) checkViewport() error {
+	if popt.ViewportSize.value == "" {
+		return nil
+	}
+	if w, h, ok := popt.Viewport(); !ok || w == 0 || h == 0 {
+		return fmt.Errorf("invalid value of --viewport-size %q, want width x height in pixels, for example 1280x1024", popt.ViewportSize.value)
+	}
+	return nil
+}
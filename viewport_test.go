@@ -0,0 +1,37 @@
+package wkhtmltopdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestViewport(t *testing.T) {
+	page := NewPage("https://example.com")
+	page.SetViewport(1280, 1024)
+	if page.ViewportSize.value != "1280x1024" {
+		t.Errorf("Want 1280x1024, have %s", page.ViewportSize.value)
+	}
+	w, h, ok := page.Viewport()
+	if !ok || w != 1280 || h != 1024 {
+		t.Errorf("Want 1280 1024 true, have %d %d %t", w, h, ok)
+	}
+	if !strings.Contains(strings.Join(page.Args(), " "), "--viewport-size 1280x1024") {
+		t.Errorf("Want --viewport-size 1280x1024 in %v", page.Args())
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(page)
+	if err := pdfg.checkArgs(); err != nil {
+		t.Errorf("Want no error, have %s", err)
+	}
+	for _, size := range []string{"1280", "1280x", "0x1024", "1280 x 1024", "wide"} {
+		page.ViewportSize.Set(size)
+		if err := pdfg.checkArgs(); err == nil {
+			t.Errorf("Want error for viewport size %q", size)
+		}
+	}
+	page.ViewportSize.Unset()
+	if _, _, ok := page.Viewport(); ok {
+		t.Error("Want no viewport when ViewportSize is not set")
+	}
+}
@@ -0,0 +1,468 @@
+package wkhtmltopdf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxArgsLine is the longest line of arguments wkhtmltopdf reads from stdin with --read-args-from-stdin
+const maxArgsLine = 20398
+
+// ErrWarmPoolClosed is returned by WarmPool.Create after Close is called
+var ErrWarmPoolClosed = errors.New("warm pool is closed")
+
+// ErrWarmPoolNotStarted is returned by WarmPool.Create before Start is called
+var ErrWarmPoolNotStarted = errors.New("warm pool is not started")
+
+// WarmPool keeps wkhtmltopdf processes started with --read-args-from-stdin and hands PDFs to idle processes,
+// so latency sensitive services do not wait for wkhtmltopdf and Qt to start for each PDF.
+// Qt leaks memory in long running processes, set MaxJobsPerProcess or MaxProcessAge to replace processes before they grow too large.
+// The settings should not be changed after Start. wkhtmltoimage has no --read-args-from-stdin and can not be pooled.
+type WarmPool struct {
+	BinaryPath        string        // Path of wkhtmltopdf, default found like NewPDFGenerator
+	WorkDir           string        // Working directory of the processes, relative paths of pages and OutputFile are resolved from it
+	Size              int           // Number of processes which are kept started (default 1)
+	MaxJobsPerProcess int           // Number of PDFs after which a process is replaced, 0 means no limit
+	MaxProcessAge     time.Duration // Time after which a process is replaced, idle processes are replaced in the background. 0 means no limit
+
+	// ConfigureCmd is called with the command of each process right before it is started, see PDFGenerator.ConfigureCmd
+	ConfigureCmd func(*exec.Cmd)
+
+	slots     chan *warmProcess // the idle processes, nil for a process which has to be started again
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Start starts the processes of the pool
+func (p *WarmPool) Start() error {
+	if p.BinaryPath == "" {
+		pdfg := &PDFGenerator{}
+		if err := pdfg.findPath(); err != nil {
+			return err
+		}
+		p.BinaryPath = pdfg.binPath
+	}
+	if p.Size < 1 {
+		p.Size = 1
+	}
+	p.slots = make(chan *warmProcess, p.Size)
+	p.done = make(chan struct{})
+	for i := 0; i < p.Size; i++ {
+		wp, err := p.start()
+		if err != nil {
+			for ; i < p.Size; i++ {
+				p.slots <- nil
+			}
+			p.Close()
+			return err
+		}
+		p.put(wp)
+	}
+	if p.MaxProcessAge > 0 {
+		go p.recycleIdle()
+	}
+	return nil
+}
+
+// Close stops the processes of the pool after the PDFs in progress are created
+func (p *WarmPool) Close() error {
+	if p.done == nil {
+		return nil
+	}
+	p.closeOnce.Do(func() {
+		close(p.done)
+		for i := 0; i < cap(p.slots); i++ {
+			if wp := <-p.slots; wp != nil {
+				atomic.AddInt64(&stats.warmIdle, -1)
+				wp.stop(false)
+			}
+		}
+	})
+	return nil
+}
+
+// Create creates the PDF of pdfg with an idle process of the pool, like pdfg.CreateContext.
+// The work directory of pdfg must be empty or the WorkDir of the pool. SetOutlineOutput is not supported,
+// and Quiet is not used because the progress output shows when a PDF is done.
+func (p *WarmPool) Create(ctx context.Context, pdfg *PDFGenerator) error {
+	if p.done == nil {
+		return ErrWarmPoolNotStarted
+	}
+	pdfg.timing = Timing{}
+	if pdfg.outlineWriter != nil {
+		return errors.New("SetOutlineOutput can not be used with a WarmPool")
+	}
+	if pdfg.workDir != "" && pdfg.workDir != p.WorkDir {
+		return fmt.Errorf("work dir %s is not the WorkDir of the pool %s", pdfg.workDir, p.WorkDir)
+	}
+	if err := pdfg.checkArgs(); err != nil {
+		return err
+	}
+	if err := checkOutputFile(pdfg.OutputFile, p.WorkDir, pdfg.MinFreeSpace); err != nil {
+		return err
+	}
+
+	restoreTOC, err := pdfg.useTOCStyleSheet(ctx)
+	if err != nil {
+		return err
+	}
+	defer restoreTOC()
+	supported, unsupported, caps, err := pdfg.supportedArgs(ctx, p.BinaryPath, pdfg.args())
+	if err != nil {
+		return err
+	}
+	var args []string
+	for _, arg := range supported {
+		if arg != "--quiet" {
+			args = append(args, arg)
+		}
+	}
+	secrets := secretValues(args)
+	// the HTML of a page reader is passed in a file, stdin has the arguments
+	for _, page := range pdfg.pages {
+		if page.Reader() == nil {
+			continue
+		}
+		input, err := writeTempFile(ctx, "", page.Reader(), "*.html")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(input)
+		for i := 0; i < len(args)-1; i++ {
+			if args[i] == "page" && args[i+1] == "-" {
+				args[i+1] = input
+			}
+		}
+	}
+	output := ""
+	if pdfg.OutputFile == "" {
+		var err error
+		output, err = writeTempFile(ctx, "", strings.NewReader(""), "*.pdf")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(output)
+		args[len(args)-1] = output
+	}
+	line, err := argsLine(args)
+	if err != nil {
+		return err
+	}
+
+	wp, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	created := newOutputFile(pdfg.OutputFile, p.WorkDir)
+	start := time.Now()
+	stderr, failed, err := wp.render(ctx, line)
+	pdfg.timing.Exec = time.Since(start)
+	addTiming(ctx, pdfg.timing)
+	p.release(wp, err)
+
+	errOutput := redactString(stderr, secrets)
+	if pdfg.errWriter != nil {
+		io.WriteString(pdfg.errWriter, errOutput)
+	}
+	pdfg.warnings = parseWarnings(errOutput)
+	if pdfg.OnWarning != nil {
+		for _, w := range pdfg.warnings {
+			pdfg.OnWarning(w)
+		}
+	}
+	pdfg.addUnsupportedWarnings(caps, unsupported)
+	pdfg.failed = parseFailedRequests(errOutput)
+	if ctx.Err() != nil {
+		removeOutputFile(created)
+		return canceled(ctx)
+	}
+	if err != nil {
+		return requestError(ctx, err)
+	}
+	if failed {
+		return requestError(ctx, errors.New(strings.TrimSpace(errOutput)))
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if output != "" {
+		f, err := os.Open(output)
+		if err != nil {
+			return err
+		}
+		_, err = buf.ReadFrom(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if pdfg.postProcessing() {
+		start = time.Now()
+		err = pdfg.postProcess(ctx, buf)
+		pdfg.timing.PostProcess = time.Since(start)
+		addTiming(ctx, Timing{PostProcess: pdfg.timing.PostProcess})
+		if err != nil && ctx.Err() != nil {
+			removeOutputFile(created)
+			return canceled(ctx)
+		}
+		return requestError(ctx, err)
+	}
+	if output == "" {
+		return nil
+	}
+	if pdfg.outWriter != nil {
+		_, err = pdfg.outWriter.Write(buf.Bytes())
+		return err
+	}
+	_, err = pdfg.outbuf.Write(buf.Bytes())
+	return err
+}
+
+// get returns an idle process, starting it when it was stopped
+func (p *WarmPool) get(ctx context.Context) (*warmProcess, error) {
+	select {
+	case <-p.done:
+		return nil, ErrWarmPoolClosed
+	default:
+	}
+	select {
+	case wp := <-p.slots:
+		if wp != nil {
+			atomic.AddInt64(&stats.warmIdle, -1)
+			if !p.expired(wp) {
+				return wp, nil
+			}
+			wp.stop(false)
+			atomic.AddUint64(&stats.warmRecycled, 1)
+		}
+		wp, err := p.start()
+		if err != nil {
+			p.slots <- nil
+			return nil, err
+		}
+		return wp, nil
+	case <-p.done:
+		return nil, ErrWarmPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release returns wp to the pool after a PDF, a process which failed or expired is replaced
+func (p *WarmPool) release(wp *warmProcess, err error) {
+	wp.jobs++
+	if err == nil && !p.expired(wp) {
+		p.put(wp)
+		return
+	}
+	p.replace(wp, err != nil)
+}
+
+// expired returns true if wp reached MaxJobsPerProcess or MaxProcessAge
+func (p *WarmPool) expired(wp *warmProcess) bool {
+	return p.MaxJobsPerProcess > 0 && wp.jobs >= p.MaxJobsPerProcess ||
+		p.MaxProcessAge > 0 && time.Since(wp.started) >= p.MaxProcessAge
+}
+
+// recycleIdle replaces the idle processes which are older than MaxProcessAge until the pool is closed,
+// so a PDF does not wait for a process to start
+func (p *WarmPool) recycleIdle() {
+	interval := p.MaxProcessAge / 4
+	if interval <= 0 {
+		interval = p.MaxProcessAge
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+		for i := len(p.slots); i > 0; i-- {
+			select {
+			case wp := <-p.slots:
+				if wp == nil || !p.expired(wp) {
+					p.slots <- wp
+					continue
+				}
+				atomic.AddInt64(&stats.warmIdle, -1)
+				p.replace(wp, false)
+			default:
+			}
+		}
+	}
+}
+
+// replace stops wp and starts a new process in the background
+func (p *WarmPool) replace(wp *warmProcess, kill bool) {
+	wp.stop(kill)
+	atomic.AddUint64(&stats.warmRecycled, 1)
+	go func() {
+		select {
+		case <-p.done:
+			p.slots <- nil
+			return
+		default:
+		}
+		wp, err := p.start()
+		if err != nil {
+			p.slots <- nil
+			return
+		}
+		p.put(wp)
+	}()
+}
+
+// put adds an idle process to the pool
+func (p *WarmPool) put(wp *warmProcess) {
+	atomic.AddInt64(&stats.warmIdle, 1)
+	p.slots <- wp
+}
+
+// start starts a process of the pool
+func (p *WarmPool) start() (*warmProcess, error) {
+	cmd := exec.Command(p.BinaryPath, "--read-args-from-stdin")
+	cmd.Dir = p.WorkDir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	killOnParentDeath(cmd)
+	if p.ConfigureCmd != nil {
+		p.ConfigureCmd(cmd)
+	}
+	joinCgroup, err := startInCgroup(cmd)
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		joinCgroup()
+		return nil, err
+	}
+	if err := joinCgroup(); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	assignProcess(cmd)
+	atomic.AddInt64(&stats.warm, 1)
+	return &warmProcess{cmd: cmd, stdin: stdin, stderr: bufio.NewReader(stderr), started: time.Now()}, nil
+}
+
+// warmProcess is a started wkhtmltopdf process of a WarmPool
+type warmProcess struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  *bufio.Reader
+	started time.Time
+	jobs    int
+	once    sync.Once
+}
+
+// render writes the line of arguments to the process and returns its stderr output until the PDF is done.
+// failed is true when wkhtmltopdf could not create the PDF, err is set when the process can not be used anymore.
+func (wp *warmProcess) render(ctx context.Context, line string) (stderr string, failed bool, err error) {
+	type result struct {
+		stderr string
+		failed bool
+		err    error
+	}
+	c := make(chan result, 1)
+	go func() {
+		var r result
+		out := &bytes.Buffer{}
+		if _, r.err = io.WriteString(wp.stdin, line+"\n"); r.err != nil {
+			c <- r
+			return
+		}
+		for {
+			l, err := wp.stderr.ReadString('\n')
+			out.WriteString(l)
+			// progress bars are redrawn with carriage returns on the same line
+			status := strings.TrimSpace(l[strings.LastIndex(l, "\r")+1:])
+			switch {
+			case status == "Done":
+			case strings.HasPrefix(status, "Exit with code"):
+				r.failed = true
+			case err != nil:
+				r.err = fmt.Errorf("wkhtmltopdf exited: %s", err)
+			default:
+				continue
+			}
+			r.stderr = out.String()
+			c <- r
+			return
+		}
+	}()
+	select {
+	case r := <-c:
+		return r.stderr, r.failed, r.err
+	case <-ctx.Done():
+		wp.stop(true)
+		<-c
+		return "", false, ctx.Err()
+	}
+}
+
+// stop closes stdin so the process exits, or kills it
+func (wp *warmProcess) stop(kill bool) {
+	wp.once.Do(func() {
+		if kill {
+			wp.cmd.Process.Kill()
+		}
+		wp.stdin.Close()
+		wp.cmd.Wait()
+		atomic.AddInt64(&stats.warm, -1)
+	})
+}
+
+// argsLine returns the arguments as a line for --read-args-from-stdin, which splits arguments at spaces
+// outside of double quotes and unescapes backslashes
+func argsLine(args []string) (string, error) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, "\r\n") {
+			return "", fmt.Errorf("argument %q contains a line break", arg)
+		}
+		arg = strings.Replace(arg, `\`, `\\`, -1)
+		quoted[i] = `"` + strings.Replace(arg, `"`, `\"`, -1) + `"`
+	}
+	line := strings.Join(quoted, " ")
+	if len(line) > maxArgsLine {
+		return "", fmt.Errorf("arguments are %d bytes, wkhtmltopdf reads at most %d from stdin", len(line), maxArgsLine)
+	}
+	return line, nil
+}
+
+// writeTempFile writes r to a temporary file with the pattern in dir and returns its absolute path
+func writeTempFile(ctx context.Context, dir string, r io.Reader, pattern string) (string, error) {
+	f, err := ioutil.TempFile(dir, tempPrefix(ctx, "wkhtmltopdf")+pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return filepath.Abs(f.Name())
+}
@@ -0,0 +1,173 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testWarmBinary is a wkhtmltopdf which reads arguments from stdin and writes its pid and the page to the output file
+const testWarmBinary = `#!/bin/bash
+[ "$1" = --read-args-from-stdin ] || exit 2
+while IFS= read -r line; do
+  eval "args=($line)"
+  out="${args[-1]}"
+  echo 'Loading pages (1/6)' >&2
+  if [[ "$line" == *fail.example.com* || "$line" == *--quiet* ]]; then
+    echo 'Exit with code 1 due to network error: HostNotFoundError' >&2
+    continue
+  fi
+  for ((i = 0; i < ${#args[@]}; i++)); do [ "${args[i]}" = page ] && in="${args[i+1]}"; done
+  { echo -n "$$ "; cat "$in"; } > "$out"
+  printf '[====] 100%%\rDone\n' >&2
+done
+`
+
+func TestArgsLine(t *testing.T) {
+	line, err := argsLine([]string{"--title", `Say "hi" C:\tmp`, "page", "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"--title" "Say \"hi\" C:\\tmp" "page" "-"`; line != want {
+		t.Errorf("Want %s, have %s", want, line)
+	}
+	if _, err := argsLine([]string{"--title", "a\nb"}); err == nil {
+		t.Error("Want error for a line break")
+	}
+	if _, err := argsLine([]string{strings.Repeat("a", maxArgsLine)}); err == nil {
+		t.Error("Want error for a long line")
+	}
+}
+
+func TestWarmPool(t *testing.T) {
+	bin, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString(testWarmBinary)
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	before := GetStats()
+	pool := &WarmPool{BinaryPath: bin.Name(), MaxJobsPerProcess: 2}
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if s := GetStats(); s.WarmProcesses != before.WarmProcesses+1 || s.WarmIdle != before.WarmIdle+1 {
+		t.Errorf("Want one idle warm process, have %d, %d idle", s.WarmProcesses-before.WarmProcesses, s.WarmIdle-before.WarmIdle)
+	}
+
+	create := func(html string) (string, string) {
+		pdfg := NewPDFPreparer()
+		pdfg.Quiet.Set(true)
+		pdfg.AddPage(NewPageReader(strings.NewReader(html)))
+		if err := pool.Create(context.Background(), pdfg); err != nil {
+			t.Fatal(err)
+		}
+		fields := strings.SplitN(pdfg.Buffer().String(), " ", 2)
+		return fields[0], fields[1]
+	}
+	pid1, page := create("<html>One</html>")
+	if page != "<html>One</html>" {
+		t.Errorf("Want <html>One</html>, have %s", page)
+	}
+	pid2, _ := create("<html>Two</html>")
+	// the process is replaced after MaxJobsPerProcess
+	pid3, page := create("<html>Three</html>")
+	if pid1 != pid2 || pid2 == pid3 || page != "<html>Three</html>" {
+		t.Errorf("Want the second PDF from the same process and the third from a new one, have %s %s %s", pid1, pid2, pid3)
+	}
+	if s := GetStats(); s.WarmRecycled != before.WarmRecycled+1 {
+		t.Errorf("Want one recycled process, have %d", s.WarmRecycled-before.WarmRecycled)
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPage("https://fail.example.com"))
+	if err := (&WarmPool{}).Create(context.Background(), pdfg); err != ErrWarmPoolNotStarted {
+		t.Errorf("Want ErrWarmPoolNotStarted, have %v", err)
+	}
+	err = pool.Create(context.Background(), pdfg)
+	if err == nil || !strings.Contains(err.Error(), "HostNotFoundError") {
+		t.Errorf("Want network error, have %v", err)
+	}
+
+	pool.Close()
+	if s := GetStats(); s.WarmProcesses != before.WarmProcesses || s.WarmIdle != before.WarmIdle {
+		t.Errorf("Want no warm processes after Close, have %d, %d idle", s.WarmProcesses-before.WarmProcesses, s.WarmIdle-before.WarmIdle)
+	}
+	if err := pool.Create(context.Background(), pdfg); err != ErrWarmPoolClosed {
+		t.Errorf("Want ErrWarmPoolClosed, have %v", err)
+	}
+}
+
+func TestWarmPoolMaxProcessAge(t *testing.T) {
+	bin, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString(testWarmBinary)
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	pool := &WarmPool{BinaryPath: bin.Name(), MaxProcessAge: 40 * time.Millisecond}
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	before := GetStats()
+	// the idle process is replaced in the background
+	time.Sleep(100 * time.Millisecond)
+	if s := GetStats(); s.WarmRecycled <= before.WarmRecycled {
+		t.Error("Want idle process replaced after MaxProcessAge")
+	}
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	if err := pool.Create(context.Background(), pdfg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(pdfg.Buffer().String(), "<html>Hi</html>") {
+		t.Errorf("Want page from a new process, have %s", pdfg.Buffer().String())
+	}
+}
+
+func TestWarmPoolCapabilities(t *testing.T) {
+	bin, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString(testWarmBinary)
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	pool := &WarmPool{BinaryPath: bin.Name()}
+	if err := pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	pdfg := NewPDFPreparer()
+	pdfg.SetCapabilities(&CapabilityReport{Version: "wkhtmltopdf 0.12.4", Options: map[string]bool{"title": true}})
+	pdfg.Title.Set("Report")
+	page := NewPageReader(strings.NewReader("<html>Hi</html>"))
+	page.PrintMediaType.Set(true)
+	pdfg.AddPage(page)
+	if err := pool.Create(context.Background(), pdfg); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--print-media-type is not supported by wkhtmltopdf 0.12.4 and was not used"}
+	if !reflect.DeepEqual(pdfg.Warnings(), want) {
+		t.Errorf("Want %q, have %q", want, pdfg.Warnings())
+	}
+
+	pdfg.StrictOptions = true
+	if _, ok := pool.Create(context.Background(), pdfg).(*UnsupportedOptionsError); !ok {
+		t.Error("Want *UnsupportedOptionsError with StrictOptions")
+	}
+}
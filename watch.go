@@ -0,0 +1,100 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// watchInterval is the time between checks for changed files in Watch
+var watchInterval = 250 * time.Millisecond
+
+// Watch renders job when Watch is called and again each time a file in dir or its subdirectories is added, changed
+// or removed, for example to see the result while working on a template and its images and stylesheets, until ctx is done.
+// onResult is called with the output or the error of each render. The job is saved with ToJSON, so changes to it
+// after Watch is called have no effect.
+//
+// Files are checked for changes by their modification time and size every 250ms, changes during a render
+// start a new render after it finished.
+func Watch(ctx context.Context, dir string, job *Job, onResult func(output []byte, err error)) error {
+	b, err := job.ToJSON()
+	if err != nil {
+		return fmt.Errorf("error saving watched job: %s", err)
+	}
+	last, err := snapshotDir(dir)
+	if err != nil {
+		return err
+	}
+	render := func() {
+		job, err := JobFromJSON(bytes.NewReader(b), false)
+		if err != nil {
+			onResult(nil, err)
+			return
+		}
+		output, err := job.Render(ctx)
+		if ctx.Err() == nil {
+			onResult(output, err)
+		}
+	}
+	render()
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		files, err := snapshotDir(dir)
+		if err != nil {
+			// files can be removed while the directory is read, try again at the next check
+			continue
+		}
+		if !sameFiles(last, files) {
+			last = files
+			render()
+		}
+	}
+}
+
+// fileState is the state of a file used to check if it changed
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshotDir returns the state of the files in dir and its subdirectories by path
+func snapshotDir(dir string) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading watched directory: %s", err)
+	}
+	return files, nil
+}
+
+// sameFiles returns true if a and b have the same files in the same state
+func sameFiles(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		other, ok := b[path]
+		if !ok || !other.modTime.Equal(state.modTime) || other.size != state.size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	template := filepath.Join(dir, "templates")
+	if err := os.Mkdir(template, 0700); err != nil {
+		t.Fatal(err)
+	}
+	page := filepath.Join(template, "page.svg")
+	if err := ioutil.WriteFile(page, []byte("<svg>1</svg>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// the binary prints its input file
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/bash\nargs=(\"$@\")\ncat \"${args[-2]}\"\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(interval time.Duration) { watchInterval = interval }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+	results := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, template, &Job{Image: &ImageOptions{BinaryPath: bin, Input: page, Format: "svg"}}, func(output []byte, err error) {
+			if err != nil {
+				t.Errorf("Want no error, have %s", err)
+			}
+			results <- string(output)
+		})
+	}()
+
+	next := func() string {
+		select {
+		case r := <-results:
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatal("Want render, have none")
+		}
+		return ""
+	}
+	if have := next(); have != "<svg>1</svg>" {
+		t.Errorf("Want <svg>1</svg>, have %s", have)
+	}
+	if err := ioutil.WriteFile(page, []byte("<svg>22</svg>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if have := next(); have != "<svg>22</svg>" {
+		t.Errorf("Want <svg>22</svg>, have %s", have)
+	}
+	// a new asset renders again
+	if err := ioutil.WriteFile(filepath.Join(template, "style.css"), []byte("svg {}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if have := next(); have != "<svg>22</svg>" {
+		t.Errorf("Want <svg>22</svg>, have %s", have)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Want context.Canceled, have %v", err)
+	}
+}
+
+func TestWatchMissingDir(t *testing.T) {
+	err := Watch(context.Background(), "testfiles/nosuchdir", &Job{Image: &ImageOptions{Input: "page.html"}}, func([]byte, error) {})
+	if err == nil {
+		t.Error("Want error for a missing directory")
+	}
+}
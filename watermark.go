@@ -0,0 +1,140 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Constants for watermark positions
+const (
+	WatermarkCenter      = "center"       // Center of the page (default)
+	WatermarkTop         = "top"          // Top center of the page
+	WatermarkBottom      = "bottom"       // Bottom center of the page
+	WatermarkTopLeft     = "top-left"     // Top left corner of the page
+	WatermarkTopRight    = "top-right"    // Top right corner of the page
+	WatermarkBottomLeft  = "bottom-left"  // Bottom left corner of the page
+	WatermarkBottomRight = "bottom-right" // Bottom right corner of the page
+)
+
+// Watermark is a text or image which is stamped on every page of the generated PDF using qpdf.
+// The watermark is rendered by wkhtmltopdf as a separate PDF on the same page size and overlaid on each page.
+type Watermark struct {
+	Text     string  // Text to stamp on each page, e.g. CONFIDENTIAL
+	Image    string  // Path or URL of an image to stamp on each page, used instead of Text
+	Position string  // Position on the page, one of the Watermark position constants (default center)
+	Opacity  float64 // Opacity between 0 and 1 (default 0.3)
+	Rotation int     // Rotation in degrees clockwise
+	FontSize uint    // Font size of the text in pt (default 72)
+	Color    string  // CSS color of the text (default gray)
+}
+
+// enabled returns true if there is something to stamp
+func (wm *Watermark) enabled() bool {
+	return wm.Text != "" || wm.Image != ""
+}
+
+// html returns the HTML document for the watermark page of width x height mm
+func (wm *Watermark) html(width, height float64) string {
+	opacity := wm.Opacity
+	if opacity <= 0 || opacity > 1 {
+		opacity = 0.3
+	}
+	fontSize := wm.FontSize
+	if fontSize == 0 {
+		fontSize = 72
+	}
+	color := wm.Color
+	if color == "" {
+		color = "gray"
+	}
+
+	valign, halign := "middle", "center"
+	position := strings.Split(wm.Position, "-")
+	for _, p := range position {
+		switch p {
+		case "top", "bottom":
+			valign = p
+		case "left", "right":
+			halign = p
+		}
+	}
+
+	content := html.EscapeString(wm.Text)
+	if wm.Image != "" {
+		src := wm.Image
+		if !strings.Contains(src, "://") {
+			src = "file://" + filepath.ToSlash(src)
+		}
+		content = `<img src="` + html.EscapeString(src) + `">`
+	}
+
+	// a table is used for positioning because flexbox is not supported by the wkhtmltopdf WebKit version
+	return fmt.Sprintf(`<!doctype html><html><head><style>
+html, body { margin: 0; padding: 0; background: transparent; }
+table { position: absolute; top: 0; left: 0; width: %.1fmm; height: %.1fmm; border-collapse: collapse; }
+td { padding: 10mm; text-align: %s; vertical-align: %s; }
+div { display: inline-block; opacity: %.2f; -webkit-transform: rotate(%ddeg); color: %s; font-size: %dpt; font-family: Arial, sans-serif; white-space: nowrap; }
+</style></head><body><table><tr><td><div>%s</div></td></tr></table></body></html>`,
+		width, height, halign, valign, opacity, wm.Rotation, html.EscapeString(color), fontSize, content)
+}
+
+// stamp renders the watermark using wkhtmltopdf and overlays it on every page of the PDF
+func (pdfg *PDFGenerator) stamp(ctx context.Context, pdf []byte) ([]byte, error) {
+	wmg := pdfg.watermarkGenerator()
+
+	// the request ID is added once to the error of the PDF
+	err := wmg.run(WithRequestID(ctx, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error creating watermark: %s", err)
+	}
+
+	f, err := ioutil.TempFile("", tempPrefix(ctx, "wkhtmltopdf-watermark"))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(wmg.Bytes())
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// overlay page 1 of the watermark on all pages
+	return runQPDF(ctx, pdf, "--overlay", f.Name(), "--from=", "--repeat=1", "--")
+}
+
+// watermarkGenerator returns the generator of the watermark PDF with the page size of pdfg.
+// It does not have the default options, a default Watermark would stamp the watermark itself
+// and post processing such as Linearize, PDFA and Sign is not needed for the overlay.
+func (pdfg *PDFGenerator) watermarkGenerator() *PDFGenerator {
+	wmg := newPDFGenerator()
+	wmg.binPath = pdfg.binPath
+	wmg.PageSize = pdfg.PageSize
+	wmg.PageWidth = pdfg.PageWidth
+	wmg.PageHeight = pdfg.PageHeight
+	wmg.Orientation = pdfg.Orientation
+	wmg.MarginTop.Set(0)
+	wmg.MarginBottom.Set(0)
+	wmg.MarginLeft.Set(0)
+	wmg.MarginRight.Set(0)
+
+	width, height := pdfg.paperSize()
+
+	// the table is a little smaller than the page so it never flows onto a second page
+	page := &PageReader{
+		Input:       strings.NewReader(pdfg.Watermark.html(width*0.98, height*0.98)),
+		PageOptions: PageOptions{pageOptions: newPageOptions(), headerAndFooterOptions: newHeaderAndFooterOptions()},
+	}
+	page.NoBackground.Set(true)
+	page.DisableSmartShrinking.Set(true)
+	if pdfg.Watermark.Image != "" && !strings.Contains(pdfg.Watermark.Image, "://") {
+		page.Allow.Set(filepath.Dir(pdfg.Watermark.Image))
+	}
+	wmg.AddPage(page)
+	return wmg
+}
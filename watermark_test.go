@@ -0,0 +1,97 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWatermark(t *testing.T) {
+	pdfg := newTestPostProcessPDFGenerator(t)
+	pdfg.Watermark.Text = "CONFIDENTIAL"
+	pdfg.Watermark.Rotation = -45
+
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pdfg.WriteFile("./testfiles/TestWatermark.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("PDF size %vkB", len(pdfg.Bytes())/1024)
+}
+
+func TestWatermarkHTML(t *testing.T) {
+	wm := Watermark{
+		Text:     "<Customer & Co>",
+		Position: WatermarkBottomRight,
+		Opacity:  0.5,
+	}
+	h := wm.html(210, 297)
+	for _, want := range []string{
+		"width: 210.0mm; height: 297.0mm",
+		"text-align: right; vertical-align: bottom",
+		"opacity: 0.50",
+		"font-size: 72pt",
+		"&lt;Customer &amp; Co&gt;",
+	} {
+		if !strings.Contains(h, want) {
+			t.Errorf("Want %q in watermark HTML:\n%s", want, h)
+		}
+	}
+
+	wm = Watermark{Image: "/tmp/logo.png"}
+	h = wm.html(210, 297)
+	if !strings.Contains(h, `<img src="file:///tmp/logo.png">`) {
+		t.Errorf("Want image in watermark HTML:\n%s", h)
+	}
+	if !strings.Contains(h, "text-align: center; vertical-align: middle") {
+		t.Errorf("Want centered watermark HTML:\n%s", h)
+	}
+}
+
+func TestWatermarkDefault(t *testing.T) {
+	SetDefaultPDFOptions(PDFOptions{
+		Document: func(pdfg *PDFGenerator) {
+			pdfg.Watermark.Text = "DRAFT"
+			pdfg.Linearize = true
+		},
+		Page: func(po *PageOptions) { po.HeaderCenter.Set("Report") },
+	})
+	defer SetDefaultPDFOptions(PDFOptions{})
+
+	// the binary logs each run
+	dir, err := ioutil.TempDir("", "wkhtmltopdf-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log := filepath.Join(dir, "runs")
+	bin := filepath.Join(dir, "wkhtmltopdf")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\necho run >> "+log+"\nprintf '%%PDF-1.4\\n%%%%EOF\\n'\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	wmg := pdfg.watermarkGenerator()
+	if wmg.Watermark.Text != "" || wmg.Linearize || strings.Contains(wmg.ArgString(), "Report") {
+		t.Errorf("Want watermark generator without defaults, have %s", wmg.ArgString())
+	}
+
+	// the error of qpdf with the stub PDFs does not matter, the watermark must be rendered once
+	pdfg.CreateContext(context.Background())
+	runs, err := ioutil.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(runs), "run"); n != 2 {
+		t.Errorf("Want the PDF and the watermark rendered, have %d runs", n)
+	}
+}
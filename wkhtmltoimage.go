@@ -3,18 +3,20 @@ package wkhtmltopdf
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"image/jpeg"
-	"image/png"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // ImageOptions represent the options to generate the image.
@@ -42,6 +44,8 @@ type ImageOptions struct {
 	// Quality determines the final image quality.
 	//
 	// Values supported between 1 and 100. Default is 94
+	//
+	// The image written by wkhtmltoimage is not encoded again, Quality is also used for jpg images in OutputFormats
 	Quality int
 	// Html is a string of html to render into and image.
 	//
@@ -50,11 +54,276 @@ type ImageOptions struct {
 	// Output controls how to save or return the image.
 	//
 	// Leave nil to return a []byte of the image. Set to a path (/tmp/example.png) to save as a file.
+	// Set to OutputBase64 or OutputDataURI to return the image base64 encoded.
 	Output string
+	// DebugJavascript collects the console messages and errors of the page in ImageResult.Console.
+	//
+	// Quiet mode is not used when this is set, unless Quiet is set, because it hides the messages.
+	DebugJavascript bool
+	// StopSlowScripts sets if wkhtmltoimage stops scripts which run too long, such as heavy chart libraries.
+	//
+	// Default nil, the wkhtmltoimage default which stops them. A stopped script is reported in ImageResult.SlowScriptStopped
+	StopSlowScripts *bool
+	// SettleDelay waits another delay after the page is loaded, for example for images which are loaded by scroll events or timers.
+	//
+	// It uses a script and the window status of the page. Default 0, no delay
+	SettleDelay time.Duration
+	// ScrollToBottom scrolls through the page before the image is rendered, so images which are loaded lazily when they are
+	// scrolled into view are not placeholders, and waits until the images are loaded. SettleDelay is waited for afterwards.
+	//
+	// Default false
+	ScrollToBottom bool
+	// Credentials are cookies and HTTP headers which are sent for the page and its resources, such as images behind authentication.
+	//
+	// The values are replaced with **** in errors, the ErrorWriter and the audit log
+	Credentials Credentials
+	// Capabilities of the wkhtmltoimage binary, see ImageCapabilities. Options which the binary does not support are not
+	// passed to it and are reported in ImageResult.Warnings. It is not saved in jobs.
+	Capabilities *CapabilityReport `json:"-"`
+	// StrictOptions returns an UnsupportedOptionsError when options are set which wkhtmltoimage does not support, instead of
+	// leaving them out. The binary is probed with ProbeCapabilities when Capabilities is not set. It is not saved in jobs.
+	StrictOptions bool `json:"-"`
+	// Quiet sets if wkhtmltoimage runs in quiet mode, which hides the warnings in ImageResult.
+	//
+	// Default true, or false when DebugJavascript is set
+	Quiet *bool
+	// DisablePlugins sets if plugins such as flash are disabled.
+	//
+	// Default true
+	DisablePlugins *bool
+	// SmartWidth sets if wkhtmltoimage makes the image wider than Width when the content does not fit.
+	//
+	// Default nil, the wkhtmltoimage default which is enabled. Set to false for fixed-width screenshots such as emails
+	SmartWidth *bool
+	// FormControls sets the SVG files used to draw checkboxes and radio buttons.
+	//
+	// Relative paths are resolved from WorkDir. Default the WebKit form controls
+	FormControls FormControls
+	// ErrorWriter receives the stderr output of wkhtmltoimage while it runs, for example to write it to a log.
+	//
+	// The output is also parsed for ImageResult. It is not saved in jobs.
+	ErrorWriter io.Writer `json:"-"`
+	// OutputWriter receives the image while wkhtmltoimage writes it, instead of returning it in memory.
+	//
+	// Only used when Output is not set. It is not saved in jobs.
+	OutputWriter io.Writer `json:"-"`
+	// RetryOnCrash renders the image again once when wkhtmltoimage crashes, see CrashError.
+	//
+	// There is no retry when OutputWriter is set, because part of the image can already be written.
+	RetryOnCrash bool
+	// WorkDir is the working directory of wkhtmltoimage.
+	//
+	// Relative paths of Input, Output and resources in local HTML files are resolved from this directory. Default the working directory of the program
+	WorkDir string
+	// StdinStrategy sets how Html is passed to wkhtmltoimage when Input is "-".
+	//
+	// StdinPipe (default) or StdinTempFile. Use StdinTempFile for very large HTML, which can stall some versions of wkhtmltoimage
+	// when it is written to stdin. The temporary file is created in WorkDir, or in the temporary directory when WorkDir is not set,
+	// relative paths in the HTML are resolved from that directory.
+	StdinStrategy string
+	// StdoutStrategy sets how the image is read from wkhtmltoimage when Output is not set.
+	//
+	// Default the image is read from stdout, and rendered again into a temporary file when some builds of wkhtmltoimage wrote
+	// text such as "Loading page" into it which can not be removed. StdoutPipe returns ErrContaminatedOutput instead,
+	// StdoutTempFile always renders into a temporary file, which is created like the one of StdinTempFile.
+	StdoutStrategy string
+	// OutputFormats converts the image to more formats after it is rendered, returned in ImageResult.Images.
+	//
+	// png, jpg and gif are supported. When Output is set the images are also saved with the extension of the format,
+	// for example /tmp/example.jpg next to /tmp/example.png. Can not be used with OutputWriter
+	OutputFormats []string
+	// DPI sets the pixel density in png and jpg images, used when the image is printed or embedded in a print document.
+	//
+	// Default the density written by wkhtmltoimage. Can not be used with OutputWriter
+	DPI int
+	// OptimizePNG makes png images smaller after they are rendered, see PNGOptimization.
+	//
+	// Default nil, not optimized. Can not be used with OutputWriter
+	OptimizePNG *PNGOptimization
+	// StripMetadata removes text chunks, EXIF data and comments from png and jpg images, for example before user facing
+	// screenshots are stored.
+	//
+	// Color profiles and the DPI are kept. Can not be used with OutputWriter
+	StripMetadata bool
+	// ColorProfile embeds an ICC color profile in png and jpg images, so colors stay the same when the image is printed.
+	//
+	// ColorProfileSRGB or the path to an RGB ICC profile, relative to WorkDir. Can not be used with OutputWriter
+	ColorProfile string
+	// Hash sets ImageResult.Hash to a perceptual hash of the image, to detect if a page changed since an earlier screenshot.
+	//
+	// See ImageHash and HashDistance. Can not be used with OutputWriter
+	Hash bool
+	// BlankCheck detects images with one color, reported in ImageResult.Blank with a warning or a BlankImageError.
+	//
+	// Default nil, not checked. Can not be used with OutputWriter
+	BlankCheck *BlankCheck
+	// MinFreeSpace checks that the directory of Output exists, is writable and has at least this many bytes of free space
+	// before wkhtmltoimage is started, see ErrInsufficientSpace. Default 0, not checked
+	MinFreeSpace uint64
+	// ConfigureCmd is called with the wkhtmltoimage command right before it is started, for example to set SysProcAttr,
+	// Env or ExtraFiles. The process is killed when the Go process dies through SysProcAttr on Linux, keep Pdeathsig set.
+	// It is not saved in jobs.
+	ConfigureCmd func(*exec.Cmd) `json:"-"`
+}
+
+// Constants for StdinStrategy
+const (
+	StdinPipe     = "pipe"     // Html is written to stdin of wkhtmltoimage
+	StdinTempFile = "tempfile" // Html is written to a temporary file which is used as input and removed afterwards
+)
+
+// Constants for StdoutStrategy
+const (
+	StdoutPipe     = "pipe"     // The image is read from stdout of wkhtmltoimage
+	StdoutTempFile = "tempfile" // The image is written to a temporary file which is read and removed afterwards
+)
+
+// Clone returns a copy of the options which does not share Quiet, DisablePlugins, SmartWidth, StopSlowScripts and Credentials with options
+func (options ImageOptions) Clone() ImageOptions {
+	options.Quiet = cloneBool(options.Quiet)
+	options.DisablePlugins = cloneBool(options.DisablePlugins)
+	options.SmartWidth = cloneBool(options.SmartWidth)
+	options.StopSlowScripts = cloneBool(options.StopSlowScripts)
+	options.Credentials = Credentials{Cookies: mergeMap(nil, options.Credentials.Cookies), Headers: mergeMap(nil, options.Credentials.Headers)}
+	options.OutputFormats = append([]string(nil), options.OutputFormats...)
+	if options.OptimizePNG != nil {
+		po := *options.OptimizePNG
+		options.OptimizePNG = &po
+	}
+	if options.BlankCheck != nil {
+		bc := *options.BlankCheck
+		options.BlankCheck = &bc
+	}
+	return options
+}
+
+func cloneBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	v := *b
+	return &v
+}
+
+// Merge sets all options which are set in overrides on options, options which are empty or 0 in overrides are not changed
+func (options *ImageOptions) Merge(overrides ImageOptions) {
+	if overrides.BinaryPath != "" {
+		options.BinaryPath = overrides.BinaryPath
+	}
+	if overrides.Input != "" {
+		options.Input = overrides.Input
+	}
+	if overrides.Format != "" {
+		options.Format = overrides.Format
+	}
+	if overrides.Height != 0 {
+		options.Height = overrides.Height
+	}
+	if overrides.Width != 0 {
+		options.Width = overrides.Width
+	}
+	if overrides.Quality != 0 {
+		options.Quality = overrides.Quality
+	}
+	if overrides.Html != "" {
+		options.Html = overrides.Html
+	}
+	if overrides.Output != "" {
+		options.Output = overrides.Output
+	}
+	if overrides.DebugJavascript {
+		options.DebugJavascript = true
+	}
+	if overrides.Quiet != nil {
+		options.Quiet = cloneBool(overrides.Quiet)
+	}
+	if overrides.DisablePlugins != nil {
+		options.DisablePlugins = cloneBool(overrides.DisablePlugins)
+	}
+	if overrides.SmartWidth != nil {
+		options.SmartWidth = cloneBool(overrides.SmartWidth)
+	}
+	if overrides.StopSlowScripts != nil {
+		options.StopSlowScripts = cloneBool(overrides.StopSlowScripts)
+	}
+	if overrides.SettleDelay != 0 {
+		options.SettleDelay = overrides.SettleDelay
+	}
+	if overrides.ScrollToBottom {
+		options.ScrollToBottom = true
+	}
+	options.Credentials.merge(overrides.Credentials)
+	if overrides.Capabilities != nil {
+		options.Capabilities = overrides.Capabilities
+	}
+	if overrides.StrictOptions {
+		options.StrictOptions = true
+	}
+	options.FormControls.merge(overrides.FormControls)
+	if overrides.ErrorWriter != nil {
+		options.ErrorWriter = overrides.ErrorWriter
+	}
+	if overrides.OutputWriter != nil {
+		options.OutputWriter = overrides.OutputWriter
+	}
+	if overrides.RetryOnCrash {
+		options.RetryOnCrash = true
+	}
+	if overrides.WorkDir != "" {
+		options.WorkDir = overrides.WorkDir
+	}
+	if overrides.StdinStrategy != "" {
+		options.StdinStrategy = overrides.StdinStrategy
+	}
+	if overrides.StdoutStrategy != "" {
+		options.StdoutStrategy = overrides.StdoutStrategy
+	}
+	if len(overrides.OutputFormats) > 0 {
+		options.OutputFormats = append([]string(nil), overrides.OutputFormats...)
+	}
+	if overrides.DPI != 0 {
+		options.DPI = overrides.DPI
+	}
+	if overrides.OptimizePNG != nil {
+		po := *overrides.OptimizePNG
+		options.OptimizePNG = &po
+	}
+	if overrides.BlankCheck != nil {
+		bc := *overrides.BlankCheck
+		options.BlankCheck = &bc
+	}
+	if overrides.StripMetadata {
+		options.StripMetadata = true
+	}
+	if overrides.ColorProfile != "" {
+		options.ColorProfile = overrides.ColorProfile
+	}
+	if overrides.Hash {
+		options.Hash = true
+	}
+	if overrides.MinFreeSpace != 0 {
+		options.MinFreeSpace = overrides.MinFreeSpace
+	}
+	if overrides.ConfigureCmd != nil {
+		options.ConfigureCmd = overrides.ConfigureCmd
+	}
 }
 
 var binImagePath stringStore
 
+// imageDefaults holds the options used by GenerateImage when they are not set in ImageOptions, see SetDefaultImageOptions
+var imageDefaults struct {
+	options ImageOptions
+	sync.Mutex
+}
+
+// setImageDefaults sets the options which are not set in options to the defaults
+func setImageDefaults(options *ImageOptions) {
+	imageDefaults.Lock()
+	defer imageDefaults.Unlock()
+	mergeImageOptions(options, imageDefaults.options)
+}
+
 // GetWKHTMLToPDFPath gets the path to wkhtmltopdf
 func GetWKHTMLToImagePath() string {
 	return binImagePath.Get()
@@ -97,49 +366,287 @@ func ImageFromJSON(jsonReader io.Reader) ([]byte, error) {
 // GenerateImage creates an image from an input.
 // It returns the image ([]byte) and any error encountered.
 func GenerateImage(options *ImageOptions) ([]byte, error) {
-	arr, err := buildParams(options)
-	if err != nil {
+	return GenerateImageContext(context.Background(), options)
+}
+
+// GenerateImageContext is like GenerateImage but kills the wkhtmltoimage process and returns ErrCanceled
+// when the context is canceled or times out before the image is created
+func GenerateImageContext(ctx context.Context, options *ImageOptions) ([]byte, error) {
+	res, err := RenderImage(ctx, options)
+	if res == nil {
 		return []byte{}, err
 	}
+	return res.Image, err
+}
+
+// ImageResult is the result of RenderImage
+type ImageResult struct {
+	Image             []byte            // The image, empty when Output or OutputWriter is set
+	Warnings          []string          // Warnings written by wkhtmltoimage, such as resources which failed to load, not reported in quiet mode
+	Console           []ConsoleMessage  // Console messages and errors of the page when DebugJavascript is set
+	Failed            []FailedRequest   // Pages and resources which failed to load, not reported in quiet mode
+	Images            map[string][]byte // The image in each of ImageOptions.OutputFormats by format
+	Width             int               // Width of the image in pixels, 0 when Output or OutputWriter is set without post processing
+	Height            int               // Height of the image in pixels, 0 when Output or OutputWriter is set without post processing
+	Hash              uint64            // Perceptual hash of the image when ImageOptions.Hash is set, see HashDistance
+	SlowScriptStopped bool              // A slow script was stopped, see ImageOptions.StopSlowScripts, not reported in quiet mode
+	Blank             bool              // The image has one color, see ImageOptions.BlankCheck
+	Timing            Timing            // Time of the stages of the render, Queue and Upload are not set
+	Resources         ResourceUsage     // Memory and CPU time of wkhtmltoimage
+
+	format       string
+	contaminated bool // wkhtmltoimage wrote text into the image on stdout, see StdoutStrategy
+}
+
+// RenderImage is like GenerateImageContext but also returns diagnostics of the render in the result.
+// The result is nil if wkhtmltoimage could not be started.
+func RenderImage(ctx context.Context, options *ImageOptions) (*ImageResult, error) {
+	if base64Output(options.Output) {
+		return renderBase64(ctx, options)
+	}
+	// the defaults and the format of the extension of Output are set in a copy, the Format of the caller is kept
+	copied := *options
+	res, err := renderImage(ctx, &copied)
+	options.BinaryPath = copied.BinaryPath
+	return res, err
+}
+
+// renderImage renders the image of RenderImage, options are changed
+func renderImage(ctx context.Context, options *ImageOptions) (*ImageResult, error) {
+	setImageDefaults(options)
+	if options.postProcessing() && options.OutputWriter != nil {
+		return nil, errors.New("OutputFormats, DPI, OptimizePNG, StripMetadata, ColorProfile and Hash can not be used with OutputWriter")
+	}
+	if options.BlankCheck != nil && options.OutputWriter != nil {
+		return nil, errors.New("BlankCheck can not be used with OutputWriter")
+	}
+	args := getArgs()
+	defer putArgs(args)
+	arr, err := appendParams(*args, options)
+	if err != nil {
+		return nil, err
+	}
+	*args = arr
 
 	findPath()
 
 	if options.BinaryPath == "" {
 		options.BinaryPath = GetWKHTMLToImagePath()
 		if options.BinaryPath == "" {
-			return []byte{}, errors.New("BinaryPath not set")
+			return nil, errors.New("BinaryPath not set")
 		}
 	}
 
-	cmd := exec.Command(options.BinaryPath, arr...)
+	if err := checkOutputFile(options.Output, options.WorkDir, options.MinFreeSpace); err != nil {
+		return nil, err
+	}
+
+	run := options
+	if options.Input == "-" && options.StdinStrategy == StdinTempFile {
+		input, err := writeHTMLFile(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(input)
+		copied := *options
+		copied.Input = input
+		copied.Html = ""
+		run = &copied
+		arr, err = appendParams((*args)[:0], run)
+		if err != nil {
+			return nil, err
+		}
+		*args = arr
+	}
+
+	render := runImage
+	if run.Output == "" && run.StdoutStrategy == StdoutTempFile {
+		render = renderTempOutput
+	}
+	res, err := render(ctx, run, arr)
+	// a crash is tried again once, unless part of the image was already written to the output writer
+	if isCrash(err) && options.RetryOnCrash && options.OutputWriter == nil {
+		res, err = render(ctx, run, arr)
+	}
+	// nothing was written to the output writer when the output is contaminated
+	if err == nil && res.contaminated {
+		if run.StdoutStrategy == StdoutPipe {
+			return res, ErrContaminatedOutput
+		}
+		res, err = renderTempOutput(ctx, run, arr)
+	}
+	start := time.Now()
+	if err == nil && run.postProcessing() {
+		err = postProcessImage(res, run)
+	} else if res != nil {
+		res.setSize(res.Image)
+	}
+	if err == nil && run.BlankCheck != nil {
+		err = checkBlank(res, run)
+	}
+	if res != nil && (run.postProcessing() || run.BlankCheck != nil) {
+		res.Timing.PostProcess = time.Since(start) - res.Timing.Decode
+		addTiming(ctx, Timing{Decode: res.Timing.Decode, PostProcess: res.Timing.PostProcess})
+	}
+	return res, err
+}
+
+// writeHTMLFile writes the Html of options to a temporary file and returns its path
+func writeHTMLFile(ctx context.Context, options *ImageOptions) (string, error) {
+	f, err := ioutil.TempFile(options.WorkDir, tempPrefix(ctx, "wkhtmltoimage")+"*.html")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(options.Html)
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	// the path must not be relative to WorkDir, which is the working directory of wkhtmltoimage
+	return filepath.Abs(f.Name())
+}
+
+// runImage runs wkhtmltoimage with args
+func runImage(ctx context.Context, options *ImageOptions, args []string) (*ImageResult, error) {
+	caps := options.Capabilities
+	if caps == nil && options.StrictOptions {
+		var err error
+		caps, err = ProbeCapabilities(ctx, options.BinaryPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var unsupported []string
+	if caps != nil {
+		args, unsupported = caps.filterArgs(args)
+		if options.StrictOptions && len(unsupported) > 0 {
+			return nil, &UnsupportedOptionsError{Binary: caps.Binary, Version: caps.Version, Options: unsupported}
+		}
+	}
+	cmd := exec.CommandContext(ctx, options.BinaryPath, args...)
+	cmd.Dir = options.WorkDir
 
 	if options.Html != "" {
 		cmd.Stdin = strings.NewReader(options.Html)
 	}
 
-	output, err := cmd.CombinedOutput()
+	// the image is streamed to the output writer, or to a buffer, without the output that comes before it.
+	// When the output is a file, stdout only has the text of wkhtmltoimage, which is dropped
+	stdout := getBuffer()
+	defer putBuffer(stdout)
+	stderr := getBuffer()
+	defer putBuffer(stderr)
+	var iw *imageWriter
+	if args[len(args)-1] == "-" {
+		var out io.Writer = stdout
+		if options.OutputWriter != nil {
+			out = options.OutputWriter
+		}
+		iw = newImageWriter(out, options.Format)
+		cmd.Stdout = iw
+	}
+	cmd.Stderr = stderr
+	secrets := secretValues(args)
+	var rw *secretWriter
+	if options.ErrorWriter != nil {
+		rw = redactWriter(options.ErrorWriter, secrets)
+		cmd.Stderr = io.MultiWriter(stderr, rw)
+	}
+	created := newOutputFile(options.Output, options.WorkDir)
+	start := time.Now()
+	err := runCommand(ctx, cmd, options.ConfigureCmd)
+	elapsed := time.Since(start)
+	if rw != nil {
+		rw.flush()
+	}
+	addTiming(ctx, Timing{Exec: elapsed})
+	if ctx.Err() != nil {
+		removeOutputFile(created)
+		return nil, canceled(ctx)
+	}
+	if iw != nil {
+		if ferr := iw.flush(); err == nil {
+			err = ferr
+		}
+	}
+	errOutput := redactString(stderr.String(), secrets)
+	if se := signalError(options.BinaryPath, err, errOutput); se != nil {
+		err = se
+	}
 	if err != nil {
 		fmt.Println(err.Error())
 	}
 
-	trimmed := cleanupOutput(output, options.Format)
-
-	return trimmed, err
+	res := &ImageResult{
+		Image:     append([]byte(nil), stdout.Bytes()...),
+		Warnings:  parseWarnings(errOutput),
+		format:    options.Format,
+		Timing:    Timing{Exec: elapsed},
+		Resources: processUsage(cmd.ProcessState),
+	}
+	if iw != nil {
+		res.contaminated = iw.contaminated
+	}
+	for _, option := range unsupported {
+		res.Warnings = append(res.Warnings, caps.unsupportedWarning(option))
+	}
+	res.Console = parseConsole(res.Warnings)
+	res.SlowScriptStopped = slowScriptStopped(res.Warnings)
+	res.Failed = parseFailedRequests(errOutput)
+	return res, requestError(ctx, err)
 }
 
 // buildParams takes the image options set by the user and turns them into command flags for wkhtmltoimage
 // It returns an array of command flags.
 func buildParams(options *ImageOptions) ([]string, error) {
-	a := []string{}
+	return appendParams([]string{}, options)
+}
 
+// appendParams is like buildParams but appends the command flags to a
+func appendParams(a []string, options *ImageOptions) ([]string, error) {
 	if options.Input == "" {
 		return []string{}, errors.New("Must provide input")
 	}
+	if err := options.checkArgs(); err != nil {
+		return []string{}, err
+	}
+
+	// the format is inferred from the extension of the output file, so a .jpg file does not contain a png
+	if ext := outputFormat(options.Output); ext != "" {
+		if options.Format == "" {
+			options.Format = ext
+		} else if outputFormat("."+options.Format) != ext {
+			return []string{}, fmt.Errorf("Format %s does not match Output %s", options.Format, options.Output)
+		}
+	}
 
 	// silence extra wkhtmltoimage output
 	// might want to add --javascript-delay too?
-	a = append(a, "-q")
-	a = append(a, "--disable-plugins")
+	if options.DebugJavascript {
+		a = append(a, "--debug-javascript")
+	}
+	quiet := !options.DebugJavascript
+	if options.Quiet != nil {
+		quiet = *options.Quiet
+	}
+	if quiet {
+		a = append(a, "-q")
+	}
+	if options.DisablePlugins == nil || *options.DisablePlugins {
+		a = append(a, "--disable-plugins")
+	} else {
+		a = append(a, "--enable-plugins")
+	}
+	if options.StopSlowScripts != nil {
+		if *options.StopSlowScripts {
+			a = append(a, "--stop-slow-scripts")
+		} else {
+			a = append(a, "--no-stop-slow-scripts")
+		}
+	}
+	a = append(a, settleArgs(options.ScrollToBottom, options.SettleDelay)...)
+	a = append(a, options.Credentials.args()...)
 
 	a = append(a, "--format")
 	if options.Format != "" {
@@ -157,7 +664,18 @@ func buildParams(options *ImageOptions) ([]string, error) {
 		a = append(a, "--width")
 		a = append(a, strconv.Itoa(options.Width))
 	}
+	if options.SmartWidth != nil {
+		if *options.SmartWidth {
+			a = append(a, "--enable-smart-width")
+		} else {
+			a = append(a, "--disable-smart-width")
+		}
+	}
+	a = append(a, options.FormControls.args()...)
 
+	if options.Quality < 0 || options.Quality > 100 {
+		return []string{}, fmt.Errorf("Quality must be between 1 and 100, got %d", options.Quality)
+	}
 	if options.Quality != 0 {
 		a = append(a, "--quality")
 		a = append(a, strconv.Itoa(options.Quality))
@@ -180,35 +698,19 @@ func buildParams(options *ImageOptions) ([]string, error) {
 	return a, nil
 }
 
-func cleanupOutput(img []byte, format string) []byte {
-	buf := new(bytes.Buffer)
-	switch {
-	case format == "png":
-		decoded, err := png.Decode(bytes.NewReader(img))
-		for err != nil {
-			img = img[1:]
-			if len(img) == 0 {
-				break
-			}
-			decoded, err = png.Decode(bytes.NewReader(img))
-		}
-		png.Encode(buf, decoded)
-		return buf.Bytes()
-	case format == "jpg":
-		decoded, err := jpeg.Decode(bytes.NewReader(img))
-		for err != nil {
-			img = img[1:]
-			if len(img) == 0 {
-				break
-			}
-			decoded, err = jpeg.Decode(bytes.NewReader(img))
-		}
-		jpeg.Encode(buf, decoded, nil)
-		return buf.Bytes()
-		// case format == "svg":
-		// 	return img
+// outputFormat returns the image format for the extension of the file, or an empty string if it is not an image format
+func outputFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg":
+		return "jpg"
+	case ".png":
+		return "png"
+	case ".svg":
+		return "svg"
+	case ".bmp":
+		return "bmp"
 	}
-	return img
+	return ""
 }
 
 func findPath() error {
@@ -242,3 +744,53 @@ func findPath() error {
 	}
 	return fmt.Errorf("%s not found", exe)
 }
+
+// PDFThumbnail creates a preview image of the first page of a PDF document using wkhtmltoimage.
+// The first page is the cover page if one is set, otherwise the first page added with AddPage.
+// If options.Height is not set, the image is cut to the page size of the PDFGenerator so only the first page is rendered.
+// Input and Html in options are ignored, the Format defaults to png.
+func PDFThumbnail(pdfg *PDFGenerator, options ImageOptions) ([]byte, error) {
+	err := thumbnailOptions(pdfg, &options)
+	if err != nil {
+		return nil, err
+	}
+	return GenerateImage(&options)
+}
+
+// thumbnailOptions sets the input, format and height in options for the first page of pdfg
+func thumbnailOptions(pdfg *PDFGenerator, options *ImageOptions) error {
+	options.Html = ""
+	switch {
+	case pdfg.Cover.Input != "":
+		options.Input = pdfg.Cover.Input
+	case len(pdfg.pages) > 0:
+		p := pdfg.pages[0]
+		options.Input = p.InputFile()
+		if p.Reader() != nil {
+			buf, err := ioutil.ReadAll(p.Reader())
+			if err != nil {
+				return fmt.Errorf("error reading input on page 0: %s", err)
+			}
+			// restore the reader so the PDF can still be created after the thumbnail
+			if pr, ok := p.(*PageReader); ok {
+				pr.Input = bytes.NewReader(buf)
+			}
+			options.Html = string(buf)
+		}
+	default:
+		return errors.New("PDFGenerator has no pages")
+	}
+
+	if options.Format == "" {
+		options.Format = "png"
+	}
+	if options.Height == 0 {
+		width := options.Width
+		if width == 0 {
+			width = 1024
+		}
+		w, h := pdfg.paperSize()
+		options.Height = int(float64(width) * h / w)
+	}
+	return nil
+}
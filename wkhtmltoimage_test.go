@@ -1,6 +1,12 @@
 package wkhtmltopdf
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -114,6 +120,281 @@ func TestBuildParamsSetsAllParams(t *testing.T) {
 	}
 }
 
+func TestThumbnailOptionsUsesFirstPage(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	pdfg.AddPage(NewPage("http://example.com"))
+
+	options := ImageOptions{Input: "http://ignored.com", Width: 800}
+	err := thumbnailOptions(pdfg, &options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.Input != "-" {
+		t.Error("Expected -, got ", options.Input)
+	}
+	if options.Html != "<html>Hi</html>" {
+		t.Error("Expected <html>Hi</html>, got ", options.Html)
+	}
+	if options.Format != "png" {
+		t.Error("Expected png, got ", options.Format)
+	}
+	// A4 portrait
+	if options.Height != 1131 {
+		t.Error("Expected 1131, got ", options.Height)
+	}
+
+	// the page reader must still be readable to create the PDF
+	buf, err := ioutil.ReadAll(pdfg.pages[0].Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "<html>Hi</html>" {
+		t.Error("Expected <html>Hi</html>, got ", string(buf))
+	}
+}
+
+func TestThumbnailOptionsUsesCover(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPage("http://example.com"))
+	pdfg.Cover.Input = "http://example.com/cover"
+	pdfg.Orientation.Set(OrientationLandscape)
+
+	options := ImageOptions{Format: "jpg"}
+	err := thumbnailOptions(pdfg, &options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.Input != "http://example.com/cover" {
+		t.Error("Expected http://example.com/cover, got ", options.Input)
+	}
+	if options.Format != "jpg" {
+		t.Error("Expected jpg, got ", options.Format)
+	}
+	// A4 landscape
+	if options.Height != 724 {
+		t.Error("Expected 724, got ", options.Height)
+	}
+}
+
+func TestThumbnailOptionsReturnsErrorIfNoPages(t *testing.T) {
+	options := ImageOptions{}
+	err := thumbnailOptions(NewPDFPreparer(), &options)
+	if err == nil {
+		t.Error("Expected err to not be nil, got nil")
+	}
+}
+
+func TestBuildParamsDebugJavascript(t *testing.T) {
+	params := ImageOptions{Input: "http://example.com", DebugJavascript: true}
+
+	v, err := buildParams(&params)
+	if err != nil {
+		t.Error("Expected err to be nil, got ", err)
+	}
+	// quiet mode hides the console messages
+	if v[0] != "--debug-javascript" {
+		t.Error("Expected --debug-javascript, got ", v[0])
+	}
+	for _, p := range v {
+		if p == "-q" {
+			t.Error("Expected no -q with DebugJavascript")
+		}
+	}
+}
+
+func TestBuildParamsQuietAndPlugins(t *testing.T) {
+	no := false
+	yes := true
+	params := ImageOptions{Input: "http://example.com", Quiet: &no, DisablePlugins: &no}
+
+	v, err := buildParams(&params)
+	if err != nil {
+		t.Error("Expected err to be nil, got ", err)
+	}
+	if v[0] != "--enable-plugins" {
+		t.Error("Expected --enable-plugins, got ", v[0])
+	}
+
+	params = ImageOptions{Input: "http://example.com", DebugJavascript: true, Quiet: &yes}
+	v, err = buildParams(&params)
+	if err != nil {
+		t.Error("Expected err to be nil, got ", err)
+	}
+	if v[0] != "--debug-javascript" || v[1] != "-q" || v[2] != "--disable-plugins" {
+		t.Error("Expected --debug-javascript -q --disable-plugins, got ", v[:3])
+	}
+}
+
+func TestBuildParamsSmartWidth(t *testing.T) {
+	no := false
+	params := ImageOptions{Input: "http://example.com", Width: 600, SmartWidth: &no}
+
+	v, err := buildParams(&params)
+	if err != nil {
+		t.Error("Expected err to be nil, got ", err)
+	}
+	if v[6] != "--disable-smart-width" {
+		t.Error("Expected --disable-smart-width, got ", v[6])
+	}
+
+	yes := true
+	params = ImageOptions{Input: "http://example.com", SmartWidth: &yes}
+	v, err = buildParams(&params)
+	if err != nil {
+		t.Error("Expected err to be nil, got ", err)
+	}
+	if v[4] != "--enable-smart-width" {
+		t.Error("Expected --enable-smart-width, got ", v[4])
+	}
+
+	params = ImageOptions{Input: "http://example.com"}
+	v, _ = buildParams(&params)
+	for _, p := range v {
+		if strings.Contains(p, "smart-width") {
+			t.Error("Expected no smart width option by default, got ", p)
+		}
+	}
+}
+
+func TestBuildParamsStopSlowScripts(t *testing.T) {
+	no := false
+	params := ImageOptions{Input: "http://example.com", StopSlowScripts: &no}
+
+	v, err := buildParams(&params)
+	if err != nil {
+		t.Error("Expected err to be nil, got ", err)
+	}
+	if v[2] != "--no-stop-slow-scripts" {
+		t.Error("Expected --no-stop-slow-scripts, got ", v[2])
+	}
+
+	merged := ImageOptions{}
+	merged.Merge(params)
+	if merged.StopSlowScripts == nil || *merged.StopSlowScripts {
+		t.Error("Expected StopSlowScripts false after Merge, got ", merged.StopSlowScripts)
+	}
+}
+
+func TestImageOptionsMerge(t *testing.T) {
+	shared := ImageOptions{Format: "png", Width: 1024, Quality: 80}
+	options := shared.Clone()
+	options.Merge(ImageOptions{Input: "-", Html: "<html>Hi</html>", Width: 600})
+	want := ImageOptions{Input: "-", Html: "<html>Hi</html>", Format: "png", Width: 600, Quality: 80}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("Want %+v, have %+v", want, options)
+	}
+	if shared.Width != 1024 {
+		t.Errorf("Want shared width 1024, have %d", shared.Width)
+	}
+
+	quiet := true
+	shared.Quiet = &quiet
+	options = shared.Clone()
+	*options.Quiet = false
+	if !*shared.Quiet {
+		t.Error("Want Quiet of shared options unchanged")
+	}
+}
+
+func TestImageWorkDir(t *testing.T) {
+	options := &ImageOptions{Input: "htmlsimple.html", WorkDir: "./testfiles"}
+	merged := ImageOptions{}
+	merged.Merge(*options)
+	if merged.WorkDir != "./testfiles" {
+		t.Error("Expected ./testfiles, got ", merged.WorkDir)
+	}
+}
+
+func TestImageStdinTempFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the binary prints its input file, which is the second last argument
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/bash\nargs=(\"$@\")\ncat \"${args[-2]}\"\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := &ImageOptions{BinaryPath: bin, Input: "-", Html: "<svg></svg>", Format: "svg", WorkDir: dir, StdinStrategy: StdinTempFile}
+	res, err := RenderImage(context.Background(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Image) != "<svg></svg>" {
+		t.Error("Expected <svg></svg>, got ", string(res.Image))
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.html"))
+	if len(files) != 0 {
+		t.Error("Expected temporary file to be removed, got ", files)
+	}
+}
+
+func TestBuildParamsInfersFormatFromOutput(t *testing.T) {
+	params := ImageOptions{Input: "http://example.com", Output: "/tmp/example.JPEG"}
+	v, err := buildParams(&params)
+	if err != nil {
+		t.Error("Expected err to be nil, got ", err)
+	}
+	if v[2] != "--format" || v[3] != "jpg" {
+		t.Error("Expected --format jpg, got ", v[2:4])
+	}
+
+	params = ImageOptions{Input: "http://example.com", Format: "png", Output: "/tmp/example.jpg"}
+	_, err = buildParams(&params)
+	if err == nil {
+		t.Error("Expected err to not be nil, got nil")
+	}
+
+	params = ImageOptions{Input: "http://example.com", Format: "jpeg", Output: "/tmp/example.jpg"}
+	_, err = buildParams(&params)
+	if err != nil {
+		t.Error("Expected err to be nil, got ", err)
+	}
+}
+
+func TestRenderImageInfersFormatBeforeDefaults(t *testing.T) {
+	SetDefaultImageOptions(ImageOptions{Format: "png"})
+	defer SetDefaultImageOptions(ImageOptions{})
+	dir, err := ioutil.TempDir("", "wkhtmltoimage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "wkhtmltoimage")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\necho \"$@\" > args\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := &ImageOptions{BinaryPath: bin, Input: "http://example.com", Output: "example.jpg", WorkDir: dir}
+	if _, err := RenderImage(context.Background(), options); err != nil {
+		t.Fatal(err)
+	}
+	if args, _ := ioutil.ReadFile(filepath.Join(dir, "args")); !strings.Contains(string(args), "--format jpg") {
+		t.Errorf("Want --format jpg, have %s", args)
+	}
+	if options.Format != "" {
+		t.Errorf("Want the Format of the options kept, have %s", options.Format)
+	}
+
+	options = &ImageOptions{BinaryPath: bin, Input: "http://example.com", Format: "png", Output: "example.jpg", WorkDir: dir}
+	if _, err := RenderImage(context.Background(), options); err == nil {
+		t.Error("Want an error for an explicit Format which does not match Output")
+	}
+}
+
+func TestBuildParamsQualityRange(t *testing.T) {
+	params := ImageOptions{Input: "http://example.com", Quality: 101}
+	_, err := buildParams(&params)
+	if err == nil {
+		t.Error("Expected err to not be nil, got nil")
+	}
+}
+
 // this test has to be last cause it kills the env var - pretty hacky
 func TestGetImageReturnsErrorIfNoBinaryPath(t *testing.T) {
 	c := ImageOptions{Input: "http://example.com"}
@@ -3,6 +3,7 @@ package wkhtmltopdf
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 //the cached mutexed path as used by findPath()
@@ -122,10 +124,27 @@ func (po *PageOptions) Args() []string {
 
 // NewPageOptions returns a new PageOptions struct with all options
 func NewPageOptions() PageOptions {
-	return PageOptions{
+	po := PageOptions{
 		pageOptions:            newPageOptions(),
 		headerAndFooterOptions: newHeaderAndFooterOptions(),
 	}
+	setPageDefaults(&po)
+	return po
+}
+
+// Clone returns a copy of the page options which does not share slices or maps with po,
+// so it can be changed without changing po, for example to change shared default options for one page
+func (po PageOptions) Clone() PageOptions {
+	cloneOptions(&po.pageOptions)
+	cloneOptions(&po.headerAndFooterOptions)
+	return po
+}
+
+// Merge sets all options which are set in overrides on po, options which are not set in overrides are not changed.
+// Map options such as CustomHeader are merged by key, slice options such as Allow are replaced.
+func (po *PageOptions) Merge(overrides PageOptions) {
+	mergeOptions(&po.pageOptions, &overrides.pageOptions)
+	mergeOptions(&po.headerAndFooterOptions, &overrides.headerAndFooterOptions)
 }
 
 // cover page
@@ -152,16 +171,54 @@ type PDFGenerator struct {
 
 	Cover      cover
 	TOC        toc
-	OutputFile string //filename to write to, default empty (writes to internal buffer)
-
-	binPath   string
-	outbuf    bytes.Buffer
-	outWriter io.Writer
-	pages     []page
+	OutputFile string      //filename to write to, default empty (writes to internal buffer)
+	Linearize  bool        //linearize the PDF for fast web view using qpdf, see SetQPDFPath
+	PDFA       PDFAOptions //convert the PDF to PDF/A using Ghostscript, see SetGhostscriptPath
+	Watermark  Watermark   //stamp a text or image on every page using qpdf, see SetQPDFPath
+	Sign       SignFunc    //sign the finished PDF, called after all other post processing
+	OnWarning  WarningFunc //called for each warning while the PDF is created, see Warnings
+
+	// Accessibility adds the document language and title for screen readers after the PDF is created, see AccessibilityOptions
+	Accessibility AccessibilityOptions
+	// ColorProfile adds an output intent with an ICC color profile to the PDF, so print workflows know the colors are sRGB.
+	// ColorProfileSRGB or the path to an RGB ICC profile. A PDF/A already has an output intent and is not changed
+	ColorProfile string
+	// MinFreeSpace checks that the directory of OutputFile exists, is writable and has at least this many bytes of free space
+	// before wkhtmltopdf is started, see ErrInsufficientSpace. Default 0, not checked
+	MinFreeSpace uint64
+	// StrictOptions returns an UnsupportedOptionsError when options are set which the wkhtmltopdf binary does not support,
+	// instead of leaving them out, see SetCapabilities. The binary is probed with ProbeCapabilities when SetCapabilities was not called
+	StrictOptions bool
+	// ConfigureCmd is called with the wkhtmltopdf command right before it is started, for example to set SysProcAttr,
+	// Env or ExtraFiles. The process is killed when the Go process dies through SysProcAttr on Linux, keep Pdeathsig set
+	ConfigureCmd func(*exec.Cmd)
+
+	binPath       string
+	outbuf        bytes.Buffer
+	outWriter     io.Writer
+	caps          *CapabilityReport
+	outlineWriter io.Writer
+	tocXSL        []byte
+	errWriter     io.Writer
+	workDir       string
+	lang          string
+	pages         []page
+	attachments   []Attachment
+	pdfaReport    []string
+	warnings      []string
+	failed        []FailedRequest
+	timing        Timing
+	usage         ResourceUsage
+}
+
+//Args returns the commandline arguments as a string slice.
+// The values of cookies, passwords and custom headers are replaced with ****, so the arguments can be logged.
+func (pdfg *PDFGenerator) Args() []string {
+	return redactArgs(pdfg.args())
 }
 
-//Args returns the commandline arguments as a string slice
-func (pdfg *PDFGenerator) Args() []string {
+// args returns the commandline arguments including secrets
+func (pdfg *PDFGenerator) args() []string {
 	args := append([]string{}, pdfg.globalOptions.Args()...)
 	args = append(args, pdfg.outlineOptions.Args()...)
 	if pdfg.Cover.Input != "" {
@@ -226,6 +283,81 @@ func (pdfg *PDFGenerator) SetOutput(w io.Writer) {
 	pdfg.outWriter = w
 }
 
+// SetOutputDescriptor sets a file, pipe or unix socket to write the PDF to, which is passed to wkhtmltopdf as stdout,
+// so a very large PDF is handed to another process without a temporary file or copying it through Go.
+// Use (*net.UnixConn).File or os.Pipe to get the *os.File of a socket or pipe, f is not closed.
+// A PDF which is post processed is written to f afterwards. The internal buffer is not used, so the Bytes(), Buffer()
+// and WriteFile() methods will not work.
+func (pdfg *PDFGenerator) SetOutputDescriptor(f *os.File) {
+	pdfg.outWriter = f
+}
+
+// SetCapabilities sets the capabilities of the wkhtmltopdf binary, see Capabilities. Options which the binary does not
+// support are not passed to it, so old 0.12.x builds do not fail with "Unknown long argument", and are reported in Warnings.
+func (pdfg *PDFGenerator) SetCapabilities(c *CapabilityReport) {
+	pdfg.caps = c
+}
+
+// Warnings returns the warnings of the last Create, for example pages, images or other resources which failed to load
+// when LoadErrorHandling or LoadMediaErrorHandling is set to ignore. There are no warnings when Quiet is set.
+func (pdfg *PDFGenerator) Warnings() []string {
+	return pdfg.warnings
+}
+
+// JavascriptConsole returns the javascript console messages and errors of the last Create,
+// these are only written by wkhtmltopdf for pages which have DebugJavascript set.
+func (pdfg *PDFGenerator) JavascriptConsole() []ConsoleMessage {
+	return parseConsole(pdfg.warnings)
+}
+
+// SlowScriptStopped returns true if wkhtmltopdf stopped a slow script during the last Create, for example a chart library
+// which had not finished drawing. Set NoStopSlowScripts on the page to let the script finish. It is false when Quiet is set.
+func (pdfg *PDFGenerator) SlowScriptStopped() bool {
+	return slowScriptStopped(pdfg.warnings)
+}
+
+// FailedRequests returns the pages and resources which failed to load during the last Create, with their status codes,
+// also when Create returned an error because of them. There are none when Quiet is set.
+func (pdfg *PDFGenerator) FailedRequests() []FailedRequest {
+	return pdfg.failed
+}
+
+// Timing returns the time wkhtmltopdf ran and the time of the post processing steps during the last Create
+func (pdfg *PDFGenerator) Timing() Timing {
+	return pdfg.timing
+}
+
+// ResourceUsage returns the memory and CPU time wkhtmltopdf used during the last Create
+func (pdfg *PDFGenerator) ResourceUsage() ResourceUsage {
+	return pdfg.usage
+}
+
+// SetErrorOutput sets the writer which receives the stderr output of wkhtmltopdf while it runs, for example to write it to a log.
+// The output is also used for the error returned by Create and for Warnings.
+func (pdfg *PDFGenerator) SetErrorOutput(w io.Writer) {
+	pdfg.errWriter = w
+}
+
+// SetWorkDir sets the working directory of wkhtmltopdf, relative paths of pages, resources in local HTML files
+// and OutputFile are resolved from this directory instead of from the working directory of the program
+func (pdfg *PDFGenerator) SetWorkDir(dir string) {
+	pdfg.workDir = dir
+}
+
+// outputFile returns the path of OutputFile from the working directory of the program
+func (pdfg *PDFGenerator) outputFile() string {
+	if pdfg.workDir == "" || filepath.IsAbs(pdfg.OutputFile) {
+		return pdfg.OutputFile
+	}
+	return filepath.Join(pdfg.workDir, pdfg.OutputFile)
+}
+
+// SetOutlineOutput sets the writer to write the outline XML of the PDF to when the PDF is created.
+// This replaces any file set with the DumpOutline option.
+func (pdfg *PDFGenerator) SetOutlineOutput(w io.Writer) {
+	pdfg.outlineWriter = w
+}
+
 // WriteFile writes the contents of the output buffer to a file
 func (pdfg *PDFGenerator) WriteFile(filename string) error {
 	return ioutil.WriteFile(filename, pdfg.Bytes(), 0666)
@@ -275,20 +407,85 @@ func (pdfg *PDFGenerator) findPath() error {
 
 // Create creates the PDF document and stores it in the internal buffer if no error is returned
 func (pdfg *PDFGenerator) Create() error {
-	return pdfg.run()
+	return pdfg.run(context.Background())
 }
 
-func (pdfg *PDFGenerator) run() error {
+// CreateContext is like Create but kills the wkhtmltopdf process and returns ErrCanceled
+// when the context is canceled or times out before the PDF is created
+func (pdfg *PDFGenerator) CreateContext(ctx context.Context) error {
+	return pdfg.run(ctx)
+}
 
-	errbuf := &bytes.Buffer{}
+func (pdfg *PDFGenerator) run(ctx context.Context) error {
+	pdfg.timing = Timing{}
+	pdfg.usage = ResourceUsage{}
 
-	cmd := exec.Command(pdfg.binPath, pdfg.Args()...)
-	cmd.Stderr = errbuf
+	if err := pdfg.checkArgs(); err != nil {
+		return err
+	}
+	if pdfg.Accessibility.Enable {
+		lang, err := pdfg.documentLang()
+		if err != nil {
+			return err
+		}
+		pdfg.lang = lang
+	}
+	if err := checkOutputFile(pdfg.OutputFile, pdfg.workDir, pdfg.MinFreeSpace); err != nil {
+		return err
+	}
+	errbuf := getBuffer()
+	defer putBuffer(errbuf)
 
-	// set output to the desired writer or the internal buffer
-	if pdfg.outWriter != nil {
+	restoreTOC, err := pdfg.useTOCStyleSheet(ctx)
+	if err != nil {
+		return err
+	}
+	defer restoreTOC()
+	args, unsupported, caps, err := pdfg.supportedArgs(ctx, pdfg.binPath, pdfg.args())
+	if err != nil {
+		return err
+	}
+	secrets := secretValues(args)
+
+	// wkhtmltopdf can only dump the outline to a file, so use a temporary file for the outline writer
+	outlineFile := ""
+	if pdfg.outlineWriter != nil {
+		f, err := ioutil.TempFile("", tempPrefix(ctx, "wkhtmltopdf-outline"))
+		if err != nil {
+			return err
+		}
+		f.Close()
+		outlineFile = f.Name()
+		defer os.Remove(outlineFile)
+		args = append([]string{opt + pdfg.DumpOutline.option, outlineFile}, args...)
+	}
+
+	cmd := exec.CommandContext(ctx, pdfg.binPath, args...)
+	cmd.Dir = pdfg.workDir
+	stderr := []io.Writer{errbuf}
+	if pdfg.OnWarning != nil {
+		stderr = append(stderr, warningWriter(func(warning string) {
+			pdfg.OnWarning(redactString(warning, secrets))
+		}))
+	}
+	var rw *secretWriter
+	if pdfg.errWriter != nil {
+		rw = redactWriter(pdfg.errWriter, secrets)
+		stderr = append(stderr, rw)
+	}
+	cmd.Stderr = io.MultiWriter(stderr...)
+
+	// set output to the desired writer or the internal buffer,
+	// when post processing the output is written to a temporary buffer first
+	postbuf := getBuffer()
+	defer putBuffer(postbuf)
+	switch {
+	case pdfg.postProcessing():
+		cmd.Stdout = postbuf
+	case pdfg.outWriter != nil:
+		// an *os.File is passed to wkhtmltopdf as stdout without copying
 		cmd.Stdout = pdfg.outWriter
-	} else {
+	default:
 		cmd.Stdout = &pdfg.outbuf
 	}
 
@@ -300,33 +497,69 @@ func (pdfg *PDFGenerator) run() error {
 		}
 	}
 
-	err := cmd.Run()
+	created := newOutputFile(pdfg.OutputFile, pdfg.workDir)
+	start := time.Now()
+	err = runCommand(ctx, cmd, pdfg.ConfigureCmd)
+	pdfg.timing = Timing{Exec: time.Since(start)}
+	if rw != nil {
+		rw.flush()
+	}
+	pdfg.usage = processUsage(cmd.ProcessState)
+	addTiming(ctx, pdfg.timing)
+	errOutput := redactString(errbuf.String(), secrets)
+	pdfg.warnings = parseWarnings(errOutput)
+	pdfg.addUnsupportedWarnings(caps, unsupported)
+	pdfg.failed = parseFailedRequests(errOutput)
+	if ctx.Err() != nil {
+		removeOutputFile(created)
+		return canceled(ctx)
+	}
+	if se := signalError(pdfg.binPath, err, errOutput); se != nil {
+		return requestError(ctx, se)
+	}
 	if err != nil {
-		errStr := errbuf.String()
+		errStr := errOutput
 		if strings.TrimSpace(errStr) == "" {
 			errStr = err.Error()
 		}
-		return errors.New(errStr)
+		return requestError(ctx, errors.New(errStr))
+	}
+	if outlineFile != "" {
+		err = pdfg.writeOutline(outlineFile)
+		if err != nil {
+			return err
+		}
+	}
+	if pdfg.postProcessing() {
+		start = time.Now()
+		err = pdfg.postProcess(ctx, postbuf)
+		pdfg.timing.PostProcess = time.Since(start)
+		addTiming(ctx, Timing{PostProcess: pdfg.timing.PostProcess})
+		if err != nil && ctx.Err() != nil {
+			removeOutputFile(created)
+			return canceled(ctx)
+		}
+		return requestError(ctx, err)
 	}
 	return nil
 }
 
+// writeOutline copies the dumped outline file to the outline writer
+func (pdfg *PDFGenerator) writeOutline(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(pdfg.outlineWriter, f)
+	return err
+}
+
 // NewPDFGenerator returns a new PDFGenerator struct with all options created and
 // checks if wkhtmltopdf can be found on the system
 func NewPDFGenerator() (*PDFGenerator, error) {
-	pdfg := &PDFGenerator{
-		globalOptions:  newGlobalOptions(),
-		outlineOptions: newOutlineOptions(),
-		Cover: cover{
-			pageOptions: newPageOptions(),
-		},
-		TOC: toc{
-			allTocOptions: allTocOptions{
-				tocOptions:  newTocOptions(),
-				pageOptions: newPageOptions(),
-			},
-		},
-	}
+	pdfg := newPDFGenerator()
+	setPDFDefaults(pdfg)
 	err := pdfg.findPath()
 	return pdfg, err
 }
@@ -335,6 +568,13 @@ func NewPDFGenerator() (*PDFGenerator, error) {
 // This is useful to prepare a PDF file that is generated elsewhere and you just want to save the config as JSON.
 // Note that Create() can not be called on this object unless you call SetPath yourself.
 func NewPDFPreparer() *PDFGenerator {
+	pdfg := newPDFGenerator()
+	setPDFDefaults(pdfg)
+	return pdfg
+}
+
+// newPDFGenerator returns a PDFGenerator with all options created, without the default options of SetDefaultPDFOptions
+func newPDFGenerator() *PDFGenerator {
 	return &PDFGenerator{
 		globalOptions:  newGlobalOptions(),
 		outlineOptions: newOutlineOptions(),
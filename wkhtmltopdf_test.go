@@ -2,8 +2,11 @@ package wkhtmltopdf
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"reflect"
 	"strings"
 	"testing"
@@ -43,7 +46,7 @@ func newTestPDFGenerator(tb testing.TB) *PDFGenerator {
 }
 
 func wantArgString() string {
-	return "--dpi 600 --margin-bottom 40 --margin-left 0 --page-size A4 cover https://wkhtmltopdf.org/index.html --zoom 0.750 toc --disable-dotted-lines page https://www.google.com --allow /usr/local/html --allow /usr/local/images --custom-header X-AppKey abcdef --disable-smart-shrinking --viewport-size 3840x2160 --header-spacing 10.010 -"
+	return "--dpi 600 --margin-bottom 40 --margin-left 0 --page-size A4 cover https://wkhtmltopdf.org/index.html --zoom 0.750 toc --disable-dotted-lines page https://www.google.com --allow /usr/local/html --allow /usr/local/images --custom-header X-AppKey **** --disable-smart-shrinking --viewport-size 3840x2160 --header-spacing 10.010 -"
 }
 
 func TestArgString(t *testing.T) {
@@ -227,6 +230,18 @@ func TestSetFooter(t *testing.T) {
 	t.Logf("PDF size %vkB", len(pdfg.Bytes())/1024)
 }
 
+func TestCreateContextCanceled(t *testing.T) {
+	pdfg := newTestPDFGenerator(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := pdfg.CreateContext(ctx)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Want ErrCanceled with %v, have %v", context.Canceled, err)
+	}
+}
+
 func TestPath(t *testing.T) {
 	path := "/usr/wkhtmltopdf/wkhtmltopdf"
 	SetPath(path)
@@ -270,6 +285,73 @@ func TestSetOutput(t *testing.T) {
 	}
 }
 
+func TestQualityOptions(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.Grayscale.Set(true)
+	pdfg.Lowquality.Set(true)
+	pdfg.NoPdfCompression.Set(true)
+	pdfg.ImageDpi.Set(150)
+	pdfg.ImageQuality.Set(60)
+	pdfg.AddPage(NewPage("https://www.google.com"))
+
+	want := "--grayscale --image-dpi 150 --image-quality 60 --lowquality --no-pdf-compression page https://www.google.com -"
+	if pdfg.ArgString() != want {
+		t.Errorf("Want argstring:\n%s\nHave:\n%s", want, pdfg.ArgString())
+	}
+}
+
+func TestOutlineOptions(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.Outline.Set(true)
+	pdfg.OutlineDepth.Set(2)
+	pdfg.AddPage(NewPage("https://www.google.com"))
+
+	want := "--outline --outline-depth 2 page https://www.google.com -"
+	if pdfg.ArgString() != want {
+		t.Errorf("Want argstring:\n%s\nHave:\n%s", want, pdfg.ArgString())
+	}
+}
+
+func TestSetOutlineOutput(t *testing.T) {
+	pdfg, err := NewPDFGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	htmlfile, err := ioutil.ReadFile("./testfiles/html5.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdfg.AddPage(NewPageReader(bytes.NewReader(htmlfile)))
+
+	outline := new(bytes.Buffer)
+	pdfg.SetOutlineOutput(outline)
+
+	err = pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(outline.String(), "<outline") {
+		t.Errorf("expected outline XML, have %q", outline.String())
+	}
+	if pdfg.Buffer().Len() == 0 {
+		t.Error("expected PDF in internal buffer")
+	}
+}
+
+func TestFormAndLinkOptions(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	page := NewPage("https://www.google.com")
+	page.EnableForms.Set(true)
+	page.EnableInternalLinks.Set(true)
+	page.EnableExternalLinks.Set(true)
+	pdfg.AddPage(page)
+
+	want := "page https://www.google.com --enable-external-links --enable-forms --enable-internal-links -"
+	if pdfg.ArgString() != want {
+		t.Errorf("Want argstring:\n%s\nHave:\n%s", want, pdfg.ArgString())
+	}
+}
+
 func TestStringOption(t *testing.T) {
 	opt := stringOption{
 		option: "stringopt",
@@ -388,6 +470,36 @@ func TestBoolOption(t *testing.T) {
 	}
 }
 
+func TestPageOptionsCloneMerge(t *testing.T) {
+	shared := NewPageOptions()
+	shared.Allow.Set("/tmp")
+	shared.CustomHeader.Set("X-Tenant", "a")
+	shared.Zoom.Set(0.9)
+
+	po := shared.Clone()
+	po.Allow.Set("/var")
+	po.CustomHeader.Set("X-Tenant", "b")
+	if len(shared.Allow.value) != 1 || shared.CustomHeader.value["X-Tenant"] != "a" {
+		t.Errorf("Want shared options unchanged, have allow %v and custom header %v", shared.Allow.value, shared.CustomHeader.value)
+	}
+
+	overrides := NewPageOptions()
+	overrides.CustomHeader.Set("X-Request", "1")
+	overrides.FooterRight.Set("[page]")
+	merged := shared.Clone()
+	merged.Merge(overrides)
+
+	if len(merged.Allow.value) != 1 || merged.Zoom.value != 0.9 || merged.FooterRight.value != "[page]" {
+		t.Errorf("Want options from shared and overrides, have %v", merged.Args())
+	}
+	if merged.CustomHeader.value["X-Tenant"] != "a" || merged.CustomHeader.value["X-Request"] != "1" {
+		t.Errorf("Want custom headers merged, have %v", merged.CustomHeader.value)
+	}
+	if len(shared.CustomHeader.value) != 1 || shared.FooterRight.value != "" {
+		t.Errorf("Want shared options unchanged after merge, have %v", shared.Args())
+	}
+}
+
 func BenchmarkArgs(b *testing.B) {
 	pdfg := newTestPDFGenerator(b)
 	b.ResetTimer()
@@ -395,3 +507,108 @@ func BenchmarkArgs(b *testing.B) {
 		pdfg.Args()
 	}
 }
+
+func TestSetErrorOutput(t *testing.T) {
+	pdfg := newTestPDFGenerator(t)
+	htmlfile, err := ioutil.ReadFile("./testfiles/htmlsimple.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdfg.ResetPages()
+	pdfg.AddPage(NewPageReader(bytes.NewReader(htmlfile)))
+
+	errbuf := new(bytes.Buffer)
+	pdfg.SetErrorOutput(errbuf)
+	err = pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(errbuf.String(), "Loading pages") {
+		t.Errorf("Want the progress of wkhtmltopdf in the error output, have %q", errbuf.String())
+	}
+}
+
+func TestSetWorkDir(t *testing.T) {
+	pdfg := newTestPDFGenerator(t)
+	pdfg.ResetPages()
+	// the page is only found from the testfiles directory
+	pdfg.AddPage(NewPage("htmlsimple.html"))
+	pdfg.SetWorkDir("./testfiles")
+	err := pdfg.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdfg.OutputFile = "out.pdf"
+	if pdfg.outputFile() != "testfiles/out.pdf" {
+		t.Errorf("Want testfiles/out.pdf, have %s", pdfg.outputFile())
+	}
+	pdfg.OutputFile = "/tmp/out.pdf"
+	if pdfg.outputFile() != "/tmp/out.pdf" {
+		t.Errorf("Want /tmp/out.pdf, have %s", pdfg.outputFile())
+	}
+}
+
+func TestSetOutputDescriptor(t *testing.T) {
+	// the binary writes the PDF to stdout when the output is -
+	bin := outputDescriptorBinary(t)
+	defer os.Remove(bin)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = bin
+	pdfg.AddPage(NewPage("https://example.com"))
+	pdfg.SetOutputDescriptor(w)
+	err = pdfg.Create()
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pdf) != "%PDF-1.4" {
+		t.Errorf("Want %%PDF-1.4, have %q", pdf)
+	}
+	if pdfg.Buffer().Len() != 0 {
+		t.Errorf("Want empty buffer, have %q", pdfg.Bytes())
+	}
+	if args := pdfg.Args(); args[len(args)-1] != "-" {
+		t.Errorf("Want - as output in Args, have %s", args[len(args)-1])
+	}
+}
+
+// outputDescriptorBinary writes a script which writes a PDF to stdout when its last argument is -
+func outputDescriptorBinary(t *testing.T) string {
+	bin, err := ioutil.TempFile("", "wkhtmltopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin.WriteString("#!/bin/sh\nfor last; do :; done\n[ \"$last\" = - ] && printf %%PDF-1.4\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+	return bin.Name()
+}
+
+func TestConfigureCmd(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.binPath = "/bin/sh"
+	pdfg.AddPage(NewPage("https://example.com"))
+	// the command is replaced by a script which writes the environment variable set by ConfigureCmd
+	pdfg.ConfigureCmd = func(cmd *exec.Cmd) {
+		cmd.Args = []string{"/bin/sh", "-c", `printf %s "$TEST_CONFIGURE_CMD"`}
+		cmd.Env = []string{"TEST_CONFIGURE_CMD=configured"}
+	}
+	if err := pdfg.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if have := pdfg.Buffer().String(); have != "configured" {
+		t.Errorf("Want configured, have %q", have)
+	}
+}
@@ -0,0 +1,193 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message is a render job received from a message queue, the data is the JSON created with Job.ToJSON or PDFGenerator.ToJSON
+type Message interface {
+	Data() []byte
+	Ack() error  // Ack marks the job as done
+	Nack() error // Nack returns the job to the queue so it can be tried again later
+}
+
+// Consumer receives render jobs from a message queue such as NATS, Kafka or SQS.
+// Receive blocks until a message is available or ctx is done.
+type Consumer interface {
+	Receive(ctx context.Context) (Message, error)
+}
+
+// Publisher publishes the result of a render job, output is the PDF or image and is nil when err is not nil,
+// or when it was stored in a Worker.Sink which issues download URLs, see ResultURL
+type Publisher interface {
+	Publish(ctx context.Context, job Message, output []byte, err error) error
+}
+
+// Worker creates the PDF documents or images for the jobs received from a Consumer and publishes the results with a Publisher.
+// The BinaryPath of image jobs is ignored, the path of the preset, the profile or GetWKHTMLToImagePath is used
+type Worker struct {
+	Consumer    Consumer
+	Publisher   Publisher
+	Limiter     *Limiter    // Optional Limiter to run the renders through
+	Concurrency int         // Number of jobs that are rendered at the same time (default 1)
+	Results     ResultStore // Optional store for the output of jobs with an IdempotencyKey, see ResultStore
+	RetryCrash  bool        // Render a job again once when wkhtmltopdf or wkhtmltoimage crashes, see CrashError
+	// Profiles are the settings of tenants by name, used by jobs which set Job.Profile.
+	// Jobs with a profile which is not in Profiles fail.
+	Profiles map[string]Profile
+	// OnTiming is called with the time each job spent waiting for the Limiter, rendering, post processing and publishing,
+	// after it is published. The context has the request ID of the job
+	OnTiming func(ctx context.Context, msg Message, timing Timing)
+	// OnComplete is called with each job, its output and error after the result is published, so upstream systems can be
+	// notified instead of polling, for example with Webhook.OnComplete. The output is nil when it was stored in a Sink which
+	// issues download URLs, ResultURL of ctx returns the URL. job is nil when the message is not a valid job.
+	// Jobs which are returned to the queue are not complete
+	OnComplete func(ctx context.Context, job *Job, output []byte, err error)
+	// Sink stores outputs of at least SinkMinSize bytes before they are published. When it implements URLSigner the output
+	// is published as nil and ResultURL returns a download URL which is valid for URLExpiry (default DefaultURLExpiry),
+	// so large PDFs do not pass through the message queue and the servers which receive the results
+	Sink        OutputSink
+	SinkMinSize int
+	URLExpiry   time.Duration
+	// Retention is the time the results of completed jobs are kept. Run deletes older results from Results when it
+	// implements ResultExpirer and outputs it stored in Sink when it implements OutputDeleter. Default 0, kept
+	Retention time.Duration
+
+	calls    idempotentCalls
+	retained retainedOutputs
+}
+
+// Run receives and renders jobs until ctx is done or the Consumer or Publisher returns an error.
+// Jobs which fail to render are published with their error and acknowledged, jobs which are not rendered because
+// ctx is done or the circuit breaker of the Limiter is open are returned to the queue with Nack.
+func (w *Worker) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n := w.Concurrency
+	if n < 1 {
+		n = 1
+	}
+
+	var once sync.Once
+	var runErr error
+	wg := sync.WaitGroup{}
+	if w.Retention > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.collectGarbage(ctx)
+		}()
+	}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := w.work(ctx)
+			if err != nil {
+				once.Do(func() {
+					runErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if runErr != nil && runErr != context.Canceled {
+		return runErr
+	}
+	return ctx.Err()
+}
+
+// work handles jobs until ctx is done or an error occurs
+func (w *Worker) work(ctx context.Context) error {
+	for {
+		msg, err := w.Consumer.Receive(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		err = w.handle(ctx, msg)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle renders and publishes one job, the context passed to the Limiter and Publisher has the request ID of the job
+func (w *Worker) handle(ctx context.Context, msg Message) error {
+	job, err := jobFromJSON(msg.Data(), false, w.Profiles, true)
+	publisher := w.Publisher
+	var output []byte
+	if err == nil {
+		if p := w.Profiles[job.Profile]; p.Publisher != nil {
+			publisher = p.Publisher
+		}
+		if job.RequestID == "" {
+			job.RequestID = newRequestID()
+		}
+		ctx = WithTiming(WithRequestID(ctx, job.RequestID))
+		output, err = w.render(ctx, job, msg.Data())
+	}
+	if ctx.Err() != nil || err == ErrCircuitOpen || err == ErrLimiterClosed {
+		return msg.Nack()
+	}
+	start := time.Now()
+	if err == nil {
+		ctx, output, err = w.sinkOutput(ctx, job, output)
+	}
+	pubErr := publisher.Publish(ctx, msg, output, err)
+	addTiming(ctx, Timing{Upload: time.Since(start)})
+	if w.OnTiming != nil {
+		w.OnTiming(ctx, msg, ContextTiming(ctx))
+	}
+	if pubErr != nil {
+		msg.Nack()
+		return pubErr
+	}
+	if w.OnComplete != nil {
+		w.OnComplete(ctx, job, output, err)
+	}
+	return msg.Ack()
+}
+
+// render creates the PDF or image for the job, which is created from the JSON in jb again when it is retried after a crash
+func (w *Worker) render(ctx context.Context, job *Job, jb []byte) ([]byte, error) {
+	return idempotent(ctx, w.Results, &w.calls, resultKey(job.Profile, job.IdempotencyKey), func() ([]byte, error) {
+		output, err := w.renderJob(ctx, job)
+		if !w.RetryCrash || !isCrash(err) {
+			return output, err
+		}
+		// the pages of a job can only be read once
+		retry, err := jobFromJSON(jb, false, w.Profiles, true)
+		if err != nil {
+			return nil, err
+		}
+		retry.RequestID = job.RequestID
+		return w.renderJob(ctx, retry)
+	})
+}
+
+// renderJob renders the job, through the Limiter of its profile or the Worker when it is set.
+// The profile is the tenant of the Limiter.
+func (w *Worker) renderJob(ctx context.Context, job *Job) ([]byte, error) {
+	limiter := w.Limiter
+	if p := w.Profiles[job.Profile]; p.Limiter != nil {
+		limiter = p.Limiter
+	}
+	if limiter == nil {
+		return job.Render(ctx)
+	}
+	var output []byte
+	err := limiter.DoContext(ctx, job.Profile, func(ctx context.Context) error {
+		var err error
+		output, err = job.Render(ctx)
+		return err
+	})
+	return output, err
+}
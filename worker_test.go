@@ -0,0 +1,282 @@
+package wkhtmltopdf
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testMessage struct {
+	data  []byte
+	acked bool
+	nack  bool
+}
+
+func (m *testMessage) Data() []byte { return m.data }
+func (m *testMessage) Ack() error   { m.acked = true; return nil }
+func (m *testMessage) Nack() error  { m.nack = true; return nil }
+
+type testQueue struct {
+	messages chan Message
+	mu       sync.Mutex
+	results  map[Message]error
+	pdfs     map[Message][]byte
+	requests map[Message]string
+	urls     map[Message]string
+	done     chan struct{}
+	want     int
+}
+
+func newTestQueue(messages ...*testMessage) *testQueue {
+	q := &testQueue{
+		messages: make(chan Message, len(messages)),
+		results:  make(map[Message]error),
+		pdfs:     make(map[Message][]byte),
+		requests: make(map[Message]string),
+		urls:     make(map[Message]string),
+		done:     make(chan struct{}),
+		want:     len(messages),
+	}
+	for _, m := range messages {
+		q.messages <- m
+	}
+	return q
+}
+
+func (q *testQueue) Receive(ctx context.Context) (Message, error) {
+	select {
+	case m := <-q.messages:
+		return m, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (q *testQueue) Publish(ctx context.Context, job Message, pdf []byte, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.results[job] = err
+	q.pdfs[job] = pdf
+	q.requests[job] = RequestID(ctx)
+	q.urls[job] = ResultURL(ctx)
+	if len(q.results) == q.want {
+		close(q.done)
+	}
+	return nil
+}
+
+// setTestImagePath sets the wkhtmltoimage which the Worker uses for image jobs, it returns a function which restores it
+func setTestImagePath(bin string) func() {
+	old := binImagePath.Get()
+	binImagePath.Set(bin)
+	return func() { binImagePath.Set(old) }
+}
+
+func TestWorker(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	jb, err := pdfg.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := &testMessage{data: jb}
+	bad := &testMessage{data: []byte("{not json")}
+	q := newTestQueue(good, bad)
+
+	w := &Worker{Consumer: q, Publisher: q, Concurrency: 2}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-q.done
+		cancel()
+	}()
+	err = w.Run(ctx)
+	if err != context.Canceled {
+		t.Errorf("Want context canceled, have %v", err)
+	}
+
+	if q.results[good] != nil {
+		t.Errorf("Want no error for good job, have %v", q.results[good])
+	}
+	if len(q.pdfs[good]) == 0 {
+		t.Errorf("Want PDF for good job")
+	}
+	if q.results[bad] == nil {
+		t.Errorf("Want error for bad job")
+	}
+	if q.requests[good] == "" {
+		t.Errorf("Want request ID for good job")
+	}
+	if !good.acked || !bad.acked {
+		t.Errorf("Want all jobs to be acknowledged")
+	}
+}
+
+func TestWorkerIdempotencyKey(t *testing.T) {
+	pdfg := NewPDFPreparer()
+	pdfg.AddPage(NewPageReader(strings.NewReader("<html>Hi</html>")))
+	jb, err := (&Job{PDF: pdfg, IdempotencyKey: "order-1"}).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &testMessage{data: jb}
+	q := newTestQueue(m)
+
+	results := NewMemoryResultStore(10)
+	results.Put(`""/order-1`, []byte("stored"))
+	w := &Worker{Consumer: q, Publisher: q, Results: results}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-q.done
+		cancel()
+	}()
+	w.Run(ctx)
+
+	if string(q.pdfs[m]) != "stored" {
+		t.Errorf("Want stored output, have %q", q.pdfs[m])
+	}
+}
+
+func TestWorkerIdempotencyKeyProfiles(t *testing.T) {
+	// the binary prints its input
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	// both tenants use the same idempotency key
+	var messages []*testMessage
+	for _, profile := range []string{"acme", "globex"} {
+		job := &Job{Image: &ImageOptions{Input: "-", Html: "<svg>" + profile + "</svg>", Format: "svg"}, Profile: profile, IdempotencyKey: "order-1"}
+		jb, err := job.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, &testMessage{data: jb})
+	}
+	q := newTestQueue(messages...)
+	results := NewMemoryResultStore(10)
+	w := &Worker{
+		Consumer:  q,
+		Publisher: q,
+		Results:   results,
+		Profiles: map[string]Profile{
+			"acme":   {WKHTMLToImagePath: bin.Name()},
+			"globex": {WKHTMLToImagePath: bin.Name()},
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-q.done
+		cancel()
+	}()
+	w.Run(ctx)
+
+	for i, want := range []string{"<svg>acme</svg>", "<svg>globex</svg>"} {
+		if have := string(q.pdfs[messages[i]]); have != want {
+			t.Errorf("Want %s, have %q", want, have)
+		}
+	}
+	if output, ok := results.Get(`"globex"/order-1`); !ok || string(output) != "<svg>globex</svg>" {
+		t.Errorf("Want output stored with the profile, have %q", output)
+	}
+}
+
+func TestWorkerProfiles(t *testing.T) {
+	// the binary prints its input
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	// the BinaryPath of the job is ignored, the binary of the profile is used
+	job := &Job{Image: &ImageOptions{BinaryPath: "/bin/false", Input: "-", Html: "<svg></svg>", Format: "svg"}, Profile: "acme"}
+	jb, err := job.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme := &testMessage{data: jb}
+	job.Profile = "unknown"
+	jb, err = job.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := &testMessage{data: jb}
+	q := newTestQueue(acme, unknown)
+	q.want = 1
+	acmeResults := newTestQueue()
+	acmeResults.want = 1
+
+	w := &Worker{
+		Consumer:  q,
+		Publisher: q,
+		Profiles: map[string]Profile{
+			"acme": {WKHTMLToImagePath: bin.Name(), Limiter: &Limiter{MaxPerTenant: 1}, Publisher: acmeResults},
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-q.done
+		<-acmeResults.done
+		cancel()
+	}()
+	w.Run(ctx)
+
+	if string(acmeResults.pdfs[acme]) != "<svg></svg>" || acmeResults.results[acme] != nil {
+		t.Errorf("Want svg published by the profile, have %q, %v", acmeResults.pdfs[acme], acmeResults.results[acme])
+	}
+	if err := q.results[unknown]; err == nil || !strings.Contains(err.Error(), `unknown profile "unknown"`) {
+		t.Errorf("Want unknown profile error, have %v", err)
+	}
+}
+
+func TestWorkerOnTiming(t *testing.T) {
+	bin, err := ioutil.TempFile("", "wkhtmltoimage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bin.Name())
+	bin.WriteString("#!/bin/sh\nsleep 0.01\ncat\n")
+	bin.Close()
+	os.Chmod(bin.Name(), 0700)
+
+	defer setTestImagePath(bin.Name())()
+
+	jb, err := (&Job{Image: &ImageOptions{Input: "-", Html: "<svg></svg>", Format: "svg"}}).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &testMessage{data: jb}
+	q := newTestQueue(m)
+
+	var mu sync.Mutex
+	var timing Timing
+	w := &Worker{Consumer: q, Publisher: q, Limiter: &Limiter{}, OnTiming: func(ctx context.Context, msg Message, t Timing) {
+		mu.Lock()
+		timing = t
+		mu.Unlock()
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-q.done
+		cancel()
+	}()
+	w.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if timing.Exec < 10*time.Millisecond || timing.Queue == 0 || timing.Upload == 0 {
+		t.Errorf("Want exec, queue and upload time, have %+v", timing)
+	}
+}